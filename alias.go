@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// aliasTarget matches the named type at the start of the right-hand side of a
+// type alias: an optional package qualifier and the type's name
+var aliasTarget = regexp.MustCompile(`^(?:(\w+)\.)?(\w+)`)
+
+// followAlias returns doc, the text documentation of the type req targets,
+// followed by the documentation of the type it aliases, and the aliased type,
+// qualified by its import path when declared in another package. Targets that
+// aren't aliases of a named type return doc unchanged and an empty aliasOf.
+func (s *GodocServer) followAlias(ctx context.Context, req docRequest, doc cachedDoc) (cachedDoc, string) {
+	if req.target == "" || strings.Contains(req.target, ".") || len(req.targets) > 0 || req.format != formatText || req.source != "" {
+		return doc, ""
+	}
+	rhs := aliasedType(doc.lines, req.target)
+	m := aliasTarget.FindStringSubmatch(rhs)
+	if m == nil {
+		return doc, ""
+	}
+	qualifier, name := m[1], m[2]
+	pkgPath := req.path
+	if qualifier != "" {
+		if pkgPath = s.aliasImport(ctx, req, qualifier); pkgPath == "" {
+			return doc, ""
+		}
+	}
+	args := slices.Concat(req.cmdFlags, []string{pkgPath, name})
+	underlying, err := s.runGoDoc(ctx, req.cacheScope, req.workingDir, formatText, args...)
+	if err != nil {
+		ctxLogger(ctx, s.logger).WithError(err).Debug("Cannot document aliased type")
+		return doc, ""
+	}
+	aliasOf := name
+	if qualifier != "" {
+		aliasOf = pkgPath + "." + name
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(strings.Join(doc.lines, "\n"), "\n"))
+	sb.WriteString("\n\nALIASED TYPE " + aliasOf + "\n\n")
+	sb.WriteString(strings.Join(underlying.lines, "\n"))
+	combined := newCachedDoc(sb.String())
+	combined.stderr = doc.stderr
+	return combined, aliasOf
+}
+
+// aliasedType returns the right-hand side of the alias declaration of name
+// in lines, or "" if name isn't declared as an alias
+func aliasedType(lines []string, name string) string {
+	prefix := "type " + name
+	for _, line := range lines {
+		rest, ok := strings.CutPrefix(line, prefix)
+		if !ok {
+			continue
+		}
+		// Generic aliases declare type parameters before the =
+		if strings.HasPrefix(rest, "[") {
+			if i := strings.Index(rest, "] = "); i >= 0 {
+				rest = rest[i+1:]
+			}
+		}
+		if rhs, ok := strings.CutPrefix(rest, " = "); ok {
+			return strings.TrimSpace(rhs)
+		}
+		return ""
+	}
+	return ""
+}
+
+// aliasImport returns the import path the source file declaring req's target
+// imports as qualifier, or "" if it can't be found
+func (s *GodocServer) aliasImport(ctx context.Context, req docRequest, qualifier string) string {
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return ""
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles,
+		Dir:     req.workingDir,
+		Env:     buildEnviron(ctx),
+	}, req.path)
+	release()
+	if err != nil || len(pkgs) != 1 {
+		return ""
+	}
+	file, _ := declPosition(pkgs[0].GoFiles, req.target)
+	if file == "" {
+		return ""
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+	if err != nil {
+		return ""
+	}
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := assumedPackageName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == qualifier {
+			return importPath
+		}
+	}
+	return ""
+}
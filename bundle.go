@@ -0,0 +1,262 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// Debug bundles hold the most recent log lines and failed tool calls
+const (
+	bundleLogLines = 1000
+	bundleFailures = 50
+)
+
+const debugBundleDescription = `Write a debug bundle for bug reports: a gzipped tarball of this session's recent
+server logs and failed tool calls, the configuration and go env output with credentials redacted,
+and server statistics. Returns the path of the tarball on the server host, with the tarball
+attached as an embedded resource.`
+
+// ring keeps the last size items added to it
+type ring[T any] struct {
+	mu    sync.Mutex
+	items []T
+	next  int
+	size  int
+}
+
+func newRing[T any](size int) *ring[T] {
+	return &ring[T]{size: size}
+}
+
+func (r *ring[T]) add(item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.items) < r.size {
+		r.items = append(r.items, item)
+		return
+	}
+	r.items[r.next] = item
+	r.next = (r.next + 1) % r.size
+}
+
+// list returns the items, oldest first
+func (r *ring[T]) list() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]T, 0, len(r.items))
+	return append(append(out, r.items[r.next:]...), r.items[:r.next]...)
+}
+
+// logRing is a logrus hook recording recent log lines for debug bundles
+type logRing struct {
+	lines     *ring[logLine]
+	formatter logrus.Formatter
+}
+
+// logLine is a recorded log line and the session it was logged for. Lines
+// logged outside any request are the server's own.
+type logLine struct {
+	session string
+	request bool
+	line    []byte
+}
+
+func newLogRing() *logRing {
+	return &logRing{
+		lines:     newRing[logLine](bundleLogLines),
+		formatter: &logrus.TextFormatter{DisableColors: true, FullTimestamp: true},
+	}
+}
+
+func (h *logRing) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (h *logRing) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	l := logLine{line: line}
+	if entry.Context != nil {
+		l.session = sessionID(entry.Context)
+		l.request = l.session != "" || requestIDFromContext(entry.Context) != "" || callIDFromContext(entry.Context) != ""
+	}
+	h.lines.add(l)
+	return nil
+}
+
+// sessionLines returns the recorded lines logged for session or by the
+// server itself, oldest first, leaving out those of other clients
+func (h *logRing) sessionLines(session string) []byte {
+	var out bytes.Buffer
+	for _, l := range h.lines.list() {
+		if session != "" && l.session == session || l.session == "" && !l.request {
+			out.Write(l.line)
+		}
+	}
+	return out.Bytes()
+}
+
+// failedCall records a failed tool call for debug bundles
+type failedCall struct {
+	Session   string         `json:"-"`
+	Time      time.Time      `json:"time"`
+	CallID    string         `json:"call_id,omitempty"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Code      string         `json:"code"`
+	Message   string         `json:"message"`
+	Duration  string         `json:"duration"`
+}
+
+// recordFailure remembers a tool call that failed
+func (s *GodocServer) recordFailure(ctx context.Context, request mcp.CallToolRequest, d time.Duration, result *mcp.CallToolResult, err error) {
+	code := errorCategory(result, err)
+	if code == "" {
+		return
+	}
+	var msg string
+	if err != nil {
+		msg = err.Error()
+	} else {
+		for _, c := range result.Content {
+			if tc, ok := c.(mcp.TextContent); ok {
+				msg = tc.Text
+				break
+			}
+		}
+	}
+	s.failures.add(failedCall{
+		Session:   sessionID(ctx),
+		Time:      time.Now().UTC(),
+		CallID:    callIDFromContext(ctx),
+		Tool:      request.Params.Name,
+		Arguments: request.GetArguments(),
+		Code:      code,
+		Message:   msg,
+		Duration:  d.String(),
+	})
+}
+
+// credentialsPattern matches the userinfo of URLs, such as credentials in GOPROXY
+var credentialsPattern = regexp.MustCompile(`://[^/@\s"]+@`)
+
+// secretPattern matches a setting, environment variable, or header whose name
+// suggests a secret, such as GITHUB_TOKEN='...' in go env output, "Password":
+// "..." in JSON, or Authorization:[...] in a logged header map, capturing the
+// name and separator and then the value
+var secretPattern = regexp.MustCompile(`(?i)("?[\w.-]*(?:token|secret|passw(?:or)?d|api[_-]?key|auth|credential|cookie|private[_-]?key)[\w.-]*"?\s*[:=]\s*)("(?:[^"\\]|\\.)*"|'[^']*'|\[[^\]]*\]|[^\s,}\]]+)`)
+
+// bearerPattern matches credentials in Authorization header values
+var bearerPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[\w.~+/=-]+`)
+
+// redact removes credentials embedded in URLs, the values of secret-looking
+// settings, environment variables, and headers, and authorization credentials
+func redact(b []byte) []byte {
+	b = credentialsPattern.ReplaceAll(b, []byte("://REDACTED@"))
+	b = bearerPattern.ReplaceAll(b, []byte("$1 REDACTED"))
+	return secretPattern.ReplaceAllFunc(b, func(m []byte) []byte {
+		sub := secretPattern.FindSubmatch(m)
+		value := string(sub[2])
+		switch strings.Trim(value, `"'`) {
+		case "", "true", "false", "null", "[]":
+			// Unset values and switches give nothing away
+			return m
+		}
+		return append(sub[1], "REDACTED"...)
+	})
+}
+
+// handleDebugBundle implements the debug_bundle tool
+func (s *GodocServer) handleDebugBundle(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	data, err := s.debugBundle(ctx)
+	if err != nil {
+		return errorResultFromErr("failed to build debug bundle", err), nil
+	}
+	name := fmt.Sprintf("godoc-mcp-debug-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	path := filepath.Join(os.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return errorResultFromErr("failed to write debug bundle", err), nil
+	}
+	ctxLogger(ctx, s.logger).WithField("path", path).Info("Wrote debug bundle")
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewTextContent(fmt.Sprintf("Debug bundle written to %s (%d bytes). Attach it to your bug report.", path, len(data))),
+			mcp.NewEmbeddedResource(mcp.BlobResourceContents{
+				URI:      "file://" + filepath.ToSlash(path),
+				MIMEType: "application/gzip",
+				Blob:     base64.StdEncoding.EncodeToString(data),
+			}),
+		},
+	}, nil
+}
+
+// debugBundle builds the gzipped tarball of diagnostics for the session in ctx.
+// Other sessions' log lines and failed calls are left out.
+func (s *GodocServer) debugBundle(ctx context.Context) ([]byte, error) {
+	session := sessionID(ctx)
+	config, err := json.MarshalIndent(s.config.Load(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	goEnvOut, err := exec.CommandContext(ctx, "go", "env").CombinedOutput()
+	if err != nil {
+		goEnvOut = append(goEnvOut, fmt.Sprintf("\ngo env failed: %v\n", err)...)
+	}
+	own := []failedCall{}
+	for _, f := range s.failures.list() {
+		if f.Session == session {
+			own = append(own, f)
+		}
+	}
+	failures, err := json.MarshalIndent(own, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{"stats.txt", []byte(s.statsReport())},
+		{"config.json", config},
+		{"go-env.txt", goEnvOut},
+		{"failures.json", failures},
+		{"logs.txt", s.recentLogs.sessionLines(session)},
+	} {
+		data := redact(f.data)
+		hdr := &tar.Header{Name: "godoc-mcp-debug/" + f.name, Mode: 0o600, Size: int64(len(data)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
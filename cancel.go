@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+// rpcIDHeader carries the JSON-RPC ID of a tool call from the before hook to the
+// tool handler, which mcp-go does not otherwise expose
+const rpcIDHeader = "X-Godoc-Mcp-Rpc-Id"
+
+// rpcKey identifies a request by its session and JSON-RPC ID. IDs are compared
+// in their JSON encoding, so a numeric ID matches however it was decoded.
+func rpcKey(session string, id any) string {
+	data, err := json.Marshal(id)
+	if err != nil {
+		return ""
+	}
+	return session + "|" + string(data)
+}
+
+// onBeforeCallTool records the JSON-RPC ID of a tool call on the request
+func (s *GodocServer) onBeforeCallTool(_ context.Context, id any, request *mcp.CallToolRequest) {
+	header := request.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	data, err := json.Marshal(id)
+	if err != nil {
+		return
+	}
+	header.Set(rpcIDHeader, string(data))
+	request.Header = header
+}
+
+// withCancellation makes each tool call cancellable by a notifications/cancelled
+// from its client. Cancelling the context kills the call's go subprocesses.
+func (s *GodocServer) withCancellation(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id := request.Header.Get(rpcIDHeader)
+		if id == "" {
+			return next(ctx, request)
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		key := sessionID(ctx) + "|" + id
+		s.cancels.Store(key, cancel)
+		defer s.cancels.Delete(key)
+		return next(ctx, request)
+	}
+}
+
+// onCancelled cancels the tool call named by a notifications/cancelled
+func (s *GodocServer) onCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	id := notification.Params.AdditionalFields["requestId"]
+	log := ctxLogger(ctx, s.logger).WithFields(logrus.Fields{
+		"rpc_id": id,
+		"reason": notification.Params.AdditionalFields["reason"],
+	})
+	cancel, ok := s.cancels.LoadAndDelete(rpcKey(sessionID(ctx), id))
+	if !ok {
+		log.Debug("Cancellation for a request not in progress")
+		return
+	}
+	log.Info("Cancelling tool call at client request")
+	cancel.(context.CancelFunc)()
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+const checkCodeDescription = `Type-check Go code against the exact API of a package before suggesting it to the user. Given code,
+the snippet is compiled in a temporary package that can import the package, at version when one is given,
+and compile errors are reported with the snippet's line numbers. The snippet may be a whole file, top-level
+declarations, or the statements of a function body; imports of the package and of standard library
+packages it uses but doesn't import are added. Without code, the package's own testable examples are
+type-checked instead, reporting the examples that no longer compile.`
+
+// checkDirName is the directory the snippet is overlaid in, inside the module
+// it is checked in. Nothing is written there.
+const checkDirName = "godoc_mcp_check"
+
+// checkCodeSchema is the check_code input schema
+var checkCodeSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Package to check against, as for get_doc (e.g., 'strings', 'github.com/google/uuid', or './pkg').",
+		},
+		"code": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go code to type-check: a file with a package clause, top-level declarations, or statements. Omit it to check the package's examples.",
+		},
+		"version": map[string]any{
+			"type":        "string",
+			"description": "Optional: Module version to check against (e.g., 'v1.6.0'), fetched into a temporary module. Defaults to the version the working module requires, then to the latest. Not valid for the standard library or local packages.",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory for relative paths and the module's own packages. Defaults to the session working directory. Ignored with version.",
+		},
+	},
+	Required: []string{"path"},
+}
+
+// codeErrorSchema describes codeError in output schemas
+var codeErrorSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"file":    map[string]any{"type": "string", "description": "File name, snippet.go for the snippet"},
+		"line":    map[string]any{"type": "integer"},
+		"column":  map[string]any{"type": "integer"},
+		"message": map[string]any{"type": "string"},
+	},
+	"required": []string{"message"},
+}
+
+// checkCodeOutputSchema is the outputSchema of check_code, describing checkCodeOutput
+var checkCodeOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"package": map[string]any{"type": "string", "description": "Import path checked against"},
+		"version": map[string]any{"type": "string", "description": "Go toolchain version for the standard library, or the version of the module providing the package"},
+		"ok":      map[string]any{"type": "boolean", "description": "Whether the snippet or every example type-checked"},
+		"errors": map[string]any{
+			"type":        "array",
+			"items":       codeErrorSchema,
+			"description": "Errors in the snippet, or in the package's test files outside its examples",
+		},
+		"imports_added": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Imports added to the snippet"},
+		"examples": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name":   map[string]any{"type": "string", "description": "Example function, such as ExampleBuilder_Grow"},
+					"file":   map[string]any{"type": "string"},
+					"line":   map[string]any{"type": "integer"},
+					"ok":     map[string]any{"type": "boolean"},
+					"errors": map[string]any{"type": "array", "items": codeErrorSchema},
+				},
+				"required": []string{"name", "file", "line", "ok"},
+			},
+			"description": "The package's examples, when no code was given",
+		},
+	},
+	Required: []string{"package", "ok"},
+}
+
+// checkCodeOutput is the structured content of a check_code result
+type checkCodeOutput struct {
+	Package  string         `json:"package"`
+	Version  string         `json:"version,omitempty"`
+	OK       bool           `json:"ok"`
+	Errors   []codeError    `json:"errors,omitempty"`
+	Imports  []string       `json:"imports_added,omitempty"`
+	Examples []exampleCheck `json:"examples,omitempty"`
+}
+
+// codeError is a compile error at a position in a file
+type codeError struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// String formats the error as the compiler does
+func (e codeError) String() string {
+	switch {
+	case e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+	return e.Message
+}
+
+// exampleCheck is the outcome of type-checking one example
+type exampleCheck struct {
+	Name   string      `json:"name"`
+	File   string      `json:"file"`
+	Line   int         `json:"line"`
+	OK     bool        `json:"ok"`
+	Errors []codeError `json:"errors,omitempty"`
+}
+
+// handleCheckCode implements the check_code tool
+func (s *GodocServer) handleCheckCode(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path := request.GetString("path", "")
+	if path == "" || strings.Contains(path, "@") {
+		return errorResult(codeInvalidArgument, "invalid or missing path parameter; pass a version separately"), nil
+	}
+	code := request.GetString("code", "")
+	version := request.GetString("version", "")
+	if version != "" {
+		switch {
+		case strings.HasPrefix(path, "."), filepath.IsAbs(path):
+			return errorResult(codeInvalidArgument, "version applies to module packages, not local ones"), nil
+		case isStdLib(path):
+			return errorResult(codeInvalidArgument, "the standard library's version is the Go toolchain's, "+goToolchainVersion()), nil
+		}
+	}
+	if err := s.runtime.Load().toolchainErr; err != nil {
+		return errorResultFromErr("cannot load packages", err), nil
+	}
+
+	var dir string
+	var err error
+	if version != "" {
+		dir, err = s.projectManager.GetOrCreateProject(ctx, path+"@"+version)
+	} else {
+		dir, err = s.loadDir(ctx, request, path)
+	}
+	if err != nil {
+		return errorResultFromErr("failed to find the source of "+path, err), nil
+	}
+	if code != "" {
+		// Snippets are overlaid in a module that imports the package, which
+		// the module cache and no module at all can't be
+		root := modCacheDir()
+		switch {
+		case dir == "":
+			dir, err = s.projectManager.GetOrCreateProject(ctx, path)
+		case root != "" && strings.HasPrefix(dir, root+string(filepath.Separator)):
+			dir, err = s.projectManager.GetOrCreateProject(ctx, path+"@"+resolvedVersion(path, dir))
+		}
+		if err != nil {
+			return errorResultFromErr("failed to create a module to check the code in", err), nil
+		}
+	}
+
+	var out *checkCodeOutput
+	if code != "" {
+		out, err = s.checkSnippet(ctx, dir, path, code)
+	} else {
+		out, err = s.checkExamples(ctx, dir, path)
+	}
+	if err != nil {
+		return errorResultFromErr("failed to check "+path, err), nil
+	}
+	out.Version = resolvedVersion(out.Package, dir)
+
+	against := out.Package
+	if out.Version != "" {
+		against += " " + out.Version
+	}
+	var sb strings.Builder
+	if code != "" {
+		if out.OK {
+			fmt.Fprintf(&sb, "The code type-checks against %s", against)
+		} else {
+			fmt.Fprintf(&sb, "The code does not type-check against %s:", against)
+			for _, e := range out.Errors {
+				sb.WriteString("\n" + e.String())
+			}
+		}
+		if len(out.Imports) > 0 {
+			fmt.Fprintf(&sb, "\n\nImports added: %s", strings.Join(out.Imports, ", "))
+		}
+	} else {
+		failed := 0
+		for _, ex := range out.Examples {
+			if !ex.OK {
+				failed++
+			}
+		}
+		switch {
+		case len(out.Examples) == 0:
+			fmt.Fprintf(&sb, "%s has no examples to check", against)
+		case failed == 0:
+			fmt.Fprintf(&sb, "All %d examples of %s type-check", len(out.Examples), against)
+		default:
+			fmt.Fprintf(&sb, "%d of %d examples of %s do not type-check:", failed, len(out.Examples), against)
+			for _, ex := range out.Examples {
+				if ex.OK {
+					continue
+				}
+				fmt.Fprintf(&sb, "\n\n%s (%s:%d)", ex.Name, ex.File, ex.Line)
+				for _, e := range ex.Errors {
+					sb.WriteString("\n  " + e.String())
+				}
+			}
+		}
+		if len(out.Errors) > 0 {
+			sb.WriteString("\n\nErrors in the test files outside the examples:")
+			for _, e := range out.Errors {
+				sb.WriteString("\n" + e.String())
+			}
+		}
+	}
+	result := mcp.NewToolResultText(sb.String())
+	result.StructuredContent = out
+	return result, nil
+}
+
+// loadChecked loads patterns in dir with their types and syntax
+func (s *GodocServer) loadChecked(ctx context.Context, dir string, cfg packages.Config, patterns ...string) ([]*packages.Package, error) {
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	cfg.Context = ctx
+	cfg.Mode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps | packages.NeedTypes
+	cfg.Dir = dir
+	cfg.Env = buildEnviron(ctx)
+	return packages.Load(&cfg, patterns...)
+}
+
+// loadedTarget returns the package named by path among pkgs, or an error
+// result if it didn't load
+func loadedTarget(pkgs []*packages.Package, path string) (*packages.Package, error) {
+	i := slices.IndexFunc(pkgs, func(p *packages.Package) bool {
+		return !strings.HasSuffix(p.ID, ".test") && !strings.Contains(p.ID, " [")
+	})
+	if i < 0 {
+		return nil, withCode(codePkgNotFound, fmt.Errorf("no package %s", path))
+	}
+	pkg := pkgs[i]
+	if pkg.Types == nil || len(pkg.GoFiles) == 0 {
+		msg := "no Go files in " + path
+		if len(pkg.Errors) > 0 {
+			msg = pkg.Errors[0].Msg
+		}
+		return nil, withCode(cmp.Or(classifyOutput(msg), codePkgNotFound), fmt.Errorf("%s", msg))
+	}
+	return pkg, nil
+}
+
+// snippetSource returns a file holding code with the given imports, with
+// positions mapped back to the snippet. Code without a package clause is put
+// in package main, and statements in its main function.
+func snippetSource(code string, imports []string) (string, error) {
+	var imps strings.Builder
+	for _, imp := range imports {
+		fmt.Fprintf(&imps, "; import %q", imp)
+	}
+	fset := token.NewFileSet()
+	if f, err := parser.ParseFile(fset, "snippet.go", code, parser.PackageClauseOnly); err == nil {
+		// Imports follow the package clause on its line, keeping line numbers
+		end := fset.Position(f.Name.End()).Offset
+		src := code[:end] + imps.String() + code[end:]
+		_, err := parser.ParseFile(fset, "snippet.go", src, 0)
+		return src, err
+	}
+	const directive = "\n//line snippet.go:1:1\n"
+	decls := "package main" + imps.String() + directive + code
+	_, declErr := parser.ParseFile(fset, "", decls, 0)
+	if declErr == nil {
+		return decls, nil
+	}
+	stmts := "package main" + imps.String() + "\n\nfunc main() {" + directive + code + "\n}\n"
+	_, stmtErr := parser.ParseFile(fset, "", stmts, 0)
+	if stmtErr == nil {
+		return stmts, nil
+	}
+	// Report the errors of the form the snippet most likely takes
+	for _, keyword := range []string{"func", "type", "import"} {
+		if strings.HasPrefix(strings.TrimSpace(code), keyword) {
+			return "", declErr
+		}
+	}
+	return "", stmtErr
+}
+
+// checkSnippet type-checks code against the package path in the module at
+// dir, adding missing imports of it and of the standard library
+func (s *GodocServer) checkSnippet(ctx context.Context, dir, path, code string) (*checkCodeOutput, error) {
+	file := filepath.Join(dir, checkDirName, "snippet.go")
+	var imports []string
+	for {
+		src, err := snippetSource(code, imports)
+		if err != nil {
+			list, ok := err.(scanner.ErrorList)
+			if !ok {
+				return nil, withCode(codeInvalidArgument, err)
+			}
+			out := &checkCodeOutput{Package: path}
+			for _, e := range list {
+				out.Errors = append(out.Errors, codeError{File: "snippet.go", Line: e.Pos.Line, Column: e.Pos.Column, Message: e.Msg})
+			}
+			return out, nil
+		}
+		pkgs, err := s.loadChecked(ctx, dir, packages.Config{Overlay: map[string][]byte{file: []byte(src)}}, path, "./"+checkDirName)
+		if err != nil {
+			return nil, err
+		}
+		var snippet *packages.Package
+		pkgs = slices.DeleteFunc(pkgs, func(p *packages.Package) bool {
+			if slices.Contains(p.GoFiles, file) {
+				snippet = p
+				return true
+			}
+			return false
+		})
+		target, err := loadedTarget(pkgs, path)
+		if err != nil {
+			return nil, err
+		}
+		if target.Name == "main" {
+			return nil, withCode(codeInvalidArgument, fmt.Errorf("%s is a command, which can't be imported", path))
+		}
+		if snippet == nil {
+			return nil, fmt.Errorf("the snippet's package didn't load")
+		}
+
+		out := &checkCodeOutput{Package: target.PkgPath, OK: true, Imports: imports}
+		var missing []string
+		for _, e := range snippet.TypeErrors {
+			out.OK = false
+			pos := e.Fset.Position(e.Pos)
+			out.Errors = append(out.Errors, codeError{File: filepath.Base(pos.Filename), Line: pos.Line, Column: pos.Column, Message: e.Msg})
+			if name, ok := strings.CutPrefix(e.Msg, "undefined: "); ok && token.IsIdentifier(name) {
+				imp := target.PkgPath
+				if name != target.Name {
+					if matches := s.stdlib.resolve(name); len(matches) == 1 {
+						imp = matches[0]
+					} else {
+						continue
+					}
+				}
+				if !slices.Contains(imports, imp) && !slices.Contains(missing, imp) {
+					missing = append(missing, imp)
+				}
+			}
+		}
+		for _, e := range snippet.Errors {
+			if e.Kind != packages.TypeError {
+				out.OK = false
+				out.Errors = append(out.Errors, codeError{Message: e.Msg})
+			}
+		}
+		// Imports are added once, so errors after adding them are the snippet's
+		if len(missing) == 0 || imports != nil {
+			return out, nil
+		}
+		imports = missing
+	}
+}
+
+// checkExamples type-checks the testable examples of the package path in dir
+func (s *GodocServer) checkExamples(ctx context.Context, dir, path string) (*checkCodeOutput, error) {
+	pkgs, err := s.loadChecked(ctx, dir, packages.Config{Tests: true}, path)
+	if err != nil {
+		return nil, err
+	}
+	target, err := loadedTarget(pkgs, path)
+	if err != nil {
+		return nil, err
+	}
+	out := &checkCodeOutput{Package: target.PkgPath, OK: true}
+
+	// The test variants hold the examples: the package with its internal
+	// tests, and the external test package
+	type span struct {
+		check    *exampleCheck
+		file     string
+		pos, end token.Pos
+	}
+	for _, pkg := range pkgs {
+		if !strings.Contains(pkg.ID, " [") {
+			continue
+		}
+		var spans []span
+		for _, f := range pkg.Syntax {
+			filename := pkg.Fset.Position(f.Pos()).Filename
+			if !strings.HasSuffix(filename, "_test.go") {
+				continue
+			}
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Example") {
+					continue
+				}
+				check := &exampleCheck{Name: fn.Name.Name, File: filepath.Base(filename), Line: pkg.Fset.Position(fn.Pos()).Line, OK: true}
+				spans = append(spans, span{check, filename, fn.Pos(), fn.End()})
+			}
+		}
+		for _, e := range pkg.TypeErrors {
+			pos := e.Fset.Position(e.Pos)
+			if !strings.HasSuffix(pos.Filename, "_test.go") {
+				continue
+			}
+			ce := codeError{File: filepath.Base(pos.Filename), Line: pos.Line, Column: pos.Column, Message: e.Msg}
+			i := slices.IndexFunc(spans, func(sp span) bool { return sp.file == pos.Filename && sp.pos <= e.Pos && e.Pos < sp.end })
+			if i < 0 {
+				out.Errors = append(out.Errors, ce)
+				continue
+			}
+			spans[i].check.OK = false
+			spans[i].check.Errors = append(spans[i].check.Errors, ce)
+		}
+		for _, sp := range spans {
+			out.OK = out.OK && sp.check.OK
+			out.Examples = append(out.Examples, *sp.check)
+		}
+	}
+	slices.SortFunc(out.Examples, func(a, b exampleCheck) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line))
+	})
+	return out, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckCode(t *testing.T) {
+	s := newTestServer(t)
+	// Standard library imports are added by the package names the index knows
+	s.stdlib.build(context.Background(), s.logger)
+	dir := writeModule(t, map[string]string{
+		"go.mod":           "module example.com/mod\n\ngo 1.21\n",
+		"greet/greet.go":   "package greet\n\n// Hello greets name\nfunc Hello(name string) string { return \"Hello, \" + name }\n",
+		"cmd/tool/main.go": "package main\n\nfunc main() {}\n",
+		"greet/example_test.go": `package greet_test
+
+import (
+	"fmt"
+
+	"example.com/mod/greet"
+)
+
+func ExampleHello() {
+	fmt.Println(greet.Hello("gopher"))
+	// Output: Hello, gopher
+}
+
+func ExampleGoodbye() {
+	fmt.Println(greet.Goodbye("gopher"))
+}
+
+func helper() int { return "not an int" }
+`,
+	})
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		ok       bool
+		imports  []string
+		// errors are the errors expected, as file:line: and the start of their message
+		errors   []string
+		examples []string
+	}{
+		{"no path", map[string]any{"code": "x := 1"}, codeInvalidArgument, false, nil, nil, nil},
+		{"version in path", map[string]any{"path": "example.com/mod@v1.0.0"}, codeInvalidArgument, false, nil, nil, nil},
+		{"version of local package", map[string]any{"path": "./greet", "version": "v1.0.0", "working_dir": dir}, codeInvalidArgument, false, nil, nil, nil},
+		{"version of standard library", map[string]any{"path": "strings", "version": "v1.0.0"}, codeInvalidArgument, false, nil, nil, nil},
+		{"command", map[string]any{"path": "./cmd/tool", "code": "x := 1", "working_dir": dir}, codeInvalidArgument, false, nil, nil, nil},
+		{"statements", map[string]any{"path": "strings", "code": `fmt.Println(strings.ToUpper("a"))`}, "", true, []string{"fmt", "strings"}, nil, nil},
+		{"declarations", map[string]any{"path": "strings", "code": "func lower() string {\n\treturn strings.ToLower(\"A\")\n}"}, "", true, []string{"strings"}, nil, nil},
+		{"file", map[string]any{"path": "strings", "code": "package p\n\nimport \"strings\"\n\nvar V = strings.ToUpper(\"a\")\n"}, "", true, nil, nil, nil},
+		{"type error", map[string]any{"path": "strings", "code": "s := \"a\"\n_ = strings.NoSuch(s)"}, "", false, []string{"strings"}, []string{"snippet.go:2: undefined: strings.NoSuch"}, nil},
+		{"syntax error", map[string]any{"path": "strings", "code": "func ("}, "", false, nil, []string{"snippet.go:1: "}, nil},
+		{"local package", map[string]any{"path": "./greet", "code": `_ = greet.Hello("a")`, "working_dir": dir}, "", true, []string{"example.com/mod/greet"}, nil, nil},
+		{"examples", map[string]any{"path": "./greet", "working_dir": dir}, "", false, nil,
+			[]string{"example_test.go:18: cannot use \"not an int\""}, []string{"ExampleHello ok", "ExampleGoodbye example_test.go:15: undefined: greet.Goodbye"}},
+		{"no examples", map[string]any{"path": "./cmd/tool", "working_dir": dir}, "", true, nil, nil, nil},
+	}
+	errorLines := func(errs []codeError) []string {
+		var lines []string
+		for _, e := range errs {
+			lines = append(lines, fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message))
+		}
+		return lines
+	}
+	// matches reports whether each of got starts with the respective want
+	matches := func(got, want []string) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range want {
+			if !strings.HasPrefix(got[i], want[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleCheckCode, "check_code", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*checkCodeOutput)
+		if out.OK != tt.ok || !slices.Equal(out.Imports, tt.imports) {
+			t.Errorf("%s: ok %v, imports added %q; want %v, %q", tt.name, out.OK, out.Imports, tt.ok, tt.imports)
+		}
+		if got := errorLines(out.Errors); !matches(got, tt.errors) {
+			t.Errorf("%s: errors %q, want %q", tt.name, got, tt.errors)
+		}
+		var examples []string
+		for _, ex := range out.Examples {
+			if ex.OK {
+				examples = append(examples, ex.Name+" ok")
+			} else {
+				examples = append(examples, ex.Name+" "+strings.Join(errorLines(ex.Errors), "; "))
+			}
+		}
+		if !matches(examples, tt.examples) {
+			t.Errorf("%s: examples %q, want %q", tt.name, examples, tt.examples)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+// maxCompletions is the most values a completion response may carry
+const maxCompletions = 100
+
+// workspacePackagesTTL is how long the package list of a workspace is reused
+const workspacePackagesTTL = time.Minute
+
+// CompletePromptArgument completes the path, symbol, and version arguments of
+// prompts
+func (s *GodocServer) CompletePromptArgument(ctx context.Context, _ string, argument mcp.CompleteArgument, resolved mcp.CompleteContext) (*mcp.Completion, error) {
+	return s.complete(ctx, argument, resolved.Arguments["path"]), nil
+}
+
+// CompleteResourceArgument completes the importPath and symbol variables of the
+// godoc:// resource templates
+func (s *GodocServer) CompleteResourceArgument(ctx context.Context, _ string, argument mcp.CompleteArgument, resolved mcp.CompleteContext) (*mcp.Completion, error) {
+	return s.complete(ctx, argument, resolved.Arguments["importPath"]), nil
+}
+
+// complete returns the completions of an argument naming a package or a symbol
+// of pkgPath
+func (s *GodocServer) complete(ctx context.Context, argument mcp.CompleteArgument, pkgPath string) *mcp.Completion {
+	var candidates []string
+	switch argument.Name {
+	case "path", "importPath":
+		candidates = s.knownPackages(ctx)
+	case "symbol":
+		candidates = s.packageSymbols(ctx, pkgPath)
+	case "old", "new":
+		candidates = s.releases.moduleVersions(pkgPath)
+	}
+	var values []string
+	for _, c := range candidates {
+		if matchesCompletion(c, argument.Value) {
+			values = append(values, c)
+		}
+	}
+	completion := &mcp.Completion{Values: values, Total: len(values)}
+	if len(values) > maxCompletions {
+		completion.Values = values[:maxCompletions]
+		completion.HasMore = true
+	}
+	if completion.Values == nil {
+		completion.Values = []string{}
+	}
+	return completion
+}
+
+// matchesCompletion reports whether candidate completes value, matching a prefix
+// of either the whole candidate or its last path or selector element
+func matchesCompletion(candidate, value string) bool {
+	value = strings.ToLower(value)
+	candidate = strings.ToLower(candidate)
+	if strings.HasPrefix(candidate, value) {
+		return true
+	}
+	if i := strings.LastIndexAny(candidate, "/."); i >= 0 {
+		return strings.HasPrefix(candidate[i+1:], value)
+	}
+	return false
+}
+
+// knownPackages lists the public standard library packages followed by the
+// packages of the session's working directory, the client's roots, and the gopls
+// workspaces
+func (s *GodocServer) knownPackages(ctx context.Context) []string {
+	known := s.stdlib.publicPackages()
+	dirs := slices.Concat(s.clientRoots(ctx), s.config.Load().GoplsWorkspaces)
+	if dir := s.sessions.get(ctx).workingDir; dir != "" {
+		dirs = append([]string{dir}, dirs...)
+	}
+	for _, dir := range dirs {
+		for _, pkg := range s.workspacePackages(ctx, dir) {
+			if !slices.Contains(known, pkg) {
+				known = append(known, pkg)
+			}
+		}
+	}
+	return known
+}
+
+// workspacePackages returns the import paths of the packages in the module at
+// dir. Completion never waits for a subprocess slot, so a busy server completes
+// from what is already known.
+func (s *GodocServer) workspacePackages(ctx context.Context, dir string) []string {
+	if item := s.workspacePkgs.Get(dir); item != nil {
+		return item.Value()
+	}
+	release, ok := s.limiter.tryAcquire()
+	if !ok {
+		return nil
+	}
+	defer release()
+	pkgs, err := packages.Load(&packages.Config{Context: ctx, Mode: packages.NeedName, Dir: dir}, "./...")
+	if err != nil {
+		ctxLogger(ctx, s.logger).WithError(err).WithField("dir", dir).Debug("Failed to list workspace packages for completion")
+		return nil
+	}
+	var paths []string
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) == 0 {
+			paths = append(paths, pkg.PkgPath)
+		}
+	}
+	slices.Sort(paths)
+	s.workspacePkgs.Set(dir, paths, workspacePackagesTTL)
+	return paths
+}
+
+// packageSymbols lists the exported symbols of pkgPath, including methods as
+// Type.Method. Packages outside the standard library are only read from the
+// session's working directory or the module cache, never downloaded.
+func (s *GodocServer) packageSymbols(ctx context.Context, pkgPath string) []string {
+	if pkgPath == "" {
+		return nil
+	}
+	if isStdLib(pkgPath) {
+		sp, ok := s.stdlib.lookup(pkgPath)
+		if !ok {
+			return nil
+		}
+		symbols := make([]string, 0, len(sp.symbols))
+		for name := range sp.symbols {
+			symbols = append(symbols, name)
+		}
+		slices.Sort(symbols)
+		return symbols
+	}
+
+	var scope, workingDir string
+	if dir := s.sessions.get(ctx).workingDir; dir != "" {
+		scope, workingDir = sessionID(ctx), dir
+	} else if m, ok := findCachedModule(pkgPath, ""); ok {
+		workingDir = m.dir
+	} else {
+		return nil
+	}
+	return s.docSymbols(ctx, scope, workingDir, pkgPath)
+}
+
+// docSymbols lists the exported symbols of pkgPath as documented in workingDir
+func (s *GodocServer) docSymbols(ctx context.Context, scope, workingDir, pkgPath string) []string {
+	doc, err := s.runGoDoc(ctx, scope, workingDir, formatJSON, pkgPath)
+	if err != nil {
+		ctxLogger(ctx, s.logger).WithError(err).WithField("package", pkgPath).Debug("Failed to load package symbols")
+		return nil
+	}
+	var pd packageDoc
+	if err := json.Unmarshal([]byte(strings.Join(doc.lines, "\n")), &pd); err != nil {
+		return nil
+	}
+	return pd.symbols()
+}
+
+// symbols returns the sorted names declared in pd
+func (pd *packageDoc) symbols() []string {
+	var names []string
+	addValues := func(vals []valueDoc) {
+		for _, v := range vals {
+			names = append(names, v.Names...)
+		}
+	}
+	addFuncs := func(prefix string, fns []funcDoc) {
+		for _, fn := range fns {
+			names = append(names, prefix+fn.Name)
+		}
+	}
+	addValues(pd.Consts)
+	addValues(pd.Vars)
+	addFuncs("", pd.Funcs)
+	for _, t := range pd.Types {
+		names = append(names, t.Name)
+		addValues(t.Consts)
+		addValues(t.Vars)
+		addFuncs("", t.Funcs)
+		addFuncs(t.Name+".", t.Methods)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// newWorkspacePackagesCache creates the cache of workspace package lists
+func newWorkspacePackagesCache() *ttlcache.Cache[string, []string] {
+	cache := ttlcache.New(ttlcache.WithTTL[string, []string](workspacePackagesTTL))
+	go cache.Start()
+	return cache
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// writeModule writes a module of the given files, keyed by slash-separated
+// path, to a temporary directory and returns the directory
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCompletePromptArgument(t *testing.T) {
+	s := newTestServer(t)
+	s.stdlib.build(context.Background(), s.logger)
+	dir := writeModule(t, map[string]string{
+		"go.mod":        "module example.com/mod\n\ngo 1.21\n",
+		"greet/hi.go":   "// Package greet says hello\npackage greet\n\n// Hello greets\nfunc Hello() string { return \"hi\" }\n",
+		"internal/x.go": "package internal\n",
+	})
+	a, b := sessionContext("a"), sessionContext("b")
+	callTool(t, a, s.handleSetSessionDefaults, "set_session_defaults", map[string]any{"working_dir": dir})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		argument string
+		value    string
+		path     string
+		want     []string
+		notWant  []string
+		hasMore  bool
+	}{
+		{"everything", b, "path", "", "", nil, nil, true},
+		{"path prefix", b, "path", "net/ht", "", []string{"net/http", "net/http/httptest"}, []string{"net/url"}, false},
+		{"last element", b, "path", "HTTPT", "", []string{"net/http/httptest"}, []string{"net/http"}, false},
+		{"internal", b, "path", "internal/", "", nil, []string{"internal/abi"}, false},
+		{"workspace", a, "path", "example.com/", "", []string{"example.com/mod/greet"}, nil, false},
+		{"other session", b, "path", "example.com/", "", nil, []string{"example.com/mod/greet"}, false},
+		{"std symbol", b, "symbol", "Buffer.Wr", "bytes", []string{"Buffer.Write", "Buffer.WriteString"}, []string{"Buffer.Read"}, false},
+		{"selector", b, "symbol", "readall", "io", []string{"ReadAll"}, []string{"Reader"}, false},
+		{"workspace symbol", a, "symbol", "He", "example.com/mod/greet", []string{"Hello"}, nil, false},
+		{"no path", b, "symbol", "R", "", nil, []string{"Reader"}, false},
+		{"uncached module", b, "symbol", "He", "example.com/mod/greet", nil, []string{"Hello"}, false},
+	}
+	for _, tt := range tests {
+		completion, err := s.CompletePromptArgument(tt.ctx, "use_symbol",
+			mcp.CompleteArgument{Name: tt.argument, Value: tt.value},
+			mcp.CompleteContext{Arguments: map[string]string{"path": tt.path}})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if completion.Values == nil || completion.HasMore != tt.hasMore || len(completion.Values) > maxCompletions {
+			t.Errorf("%s: %d values of %d, has more %v", tt.name, len(completion.Values), completion.Total, completion.HasMore)
+		}
+		for _, want := range tt.want {
+			if !slices.Contains(completion.Values, want) {
+				t.Errorf("%s: completions %q lack %q", tt.name, completion.Values, want)
+			}
+		}
+		for _, notWant := range tt.notWant {
+			if slices.Contains(completion.Values, notWant) {
+				t.Errorf("%s: completions %q include %q", tt.name, completion.Values, notWant)
+			}
+		}
+	}
+}
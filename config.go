@@ -0,0 +1,435 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HTTP transports that can be served in HTTP mode
+const (
+	transportStreamable = "streamable"
+	transportSSE        = "sse"
+	transportBoth       = "both"
+)
+
+// Config holds the server settings. Settings that do not affect listeners are
+// reloaded from the config file on SIGHUP.
+type Config struct {
+	ConfigFile     string
+	ShowVersion    bool
+	Doctor         bool
+	Query          *QueryConfig
+	LogLevel       string
+	LogFormat      string
+	HTTPAddr       string
+	HTTPTransport  string
+	BasePath       string
+	AdvertiseURL   string
+	TrustForwarded bool
+	DrainTimeout   time.Duration
+	CORS           CORSConfig
+	// WebUI serves the browsable documentation site in HTTP mode
+	WebUI bool
+
+	PprofAddr       string
+	ProfileDir      string
+	ProfileInterval time.Duration
+	MetricsInterval time.Duration
+	SlowRequest     time.Duration
+
+	DocBackend string
+
+	GoplsPath       string
+	GoplsWorkspaces []string
+	IndexWorkspaces []string
+	PkgsiteURL      string
+	PkgsiteFallback bool
+	PlaygroundURL   string
+	// ModuleIndex configures the catalog of recent releases read from the
+	// module index
+	ModuleIndex ModuleIndexConfig
+	DepsDevURL  string
+	CacheTTL    time.Duration
+	// CacheMaxBytes bounds the documentation cache; zero means unbounded
+	CacheMaxBytes int64
+	ProjectTTL    time.Duration
+	Pagination    PaginationConfig
+
+	StreamThreshold int
+	PrefetchImports int
+
+	EnableTools  []string
+	DisableTools []string
+	// Instructions replaces the usage guide sent to clients on initialize
+	Instructions string
+	// Embeddings enables the semantic_search tool when a backend is set
+	Embeddings EmbeddingsConfig
+	// CodeSearch enables the usage_examples tool when a backend is set
+	CodeSearch CodeSearchConfig
+
+	MaxWorkers      int
+	MaxSubprocesses int
+	MaxQueued       int
+	QueueTimeout    time.Duration
+}
+
+// CORSConfig controls the CORS headers sent by the HTTP transport
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowCredentials bool
+}
+
+// EmbeddingsConfig selects the embedding backend of the semantic_search tool.
+// The API key is read from the environment variable APIKeyEnv, so it never
+// appears in config files or debug bundles.
+type EmbeddingsConfig struct {
+	Backend   string
+	URL       string
+	Model     string
+	APIKeyEnv string
+}
+
+// CodeSearchConfig selects the code search backend of the usage_examples tool.
+// The access token is read from the environment variable TokenEnv, so it never
+// appears in config files or debug bundles.
+type CodeSearchConfig struct {
+	Backend  string
+	URL      string
+	TokenEnv string
+}
+
+// ModuleIndexConfig configures the poller keeping a catalog of the module
+// versions recently published to the module index
+type ModuleIndexConfig struct {
+	// Poll is the interval between reads of the index; zero disables them
+	Poll   time.Duration
+	URL    string
+	Window time.Duration
+}
+
+// envPrefix is prepended to a flag's name to form its environment variable
+const envPrefix = "GODOC_MCP_"
+
+// listFlag is a flag holding a comma-separated list
+type listFlag struct{ list *[]string }
+
+func (f listFlag) String() string {
+	if f.list == nil {
+		return ""
+	}
+	return strings.Join(*f.list, ",")
+}
+
+func (f listFlag) Set(s string) error {
+	*f.list = splitList(s)
+	return nil
+}
+
+// newFlagSet defines every configuration flag on a new flag set bound to cfg
+func newFlagSet(cfg *Config) *flag.FlagSet {
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	cfg.CORS.AllowedMethods = []string{"GET", "POST", "DELETE", "OPTIONS"}
+	fs.StringVar(&cfg.ConfigFile, "config", "", "path to a JSON config file mapping flag names to values; reloaded on SIGHUP")
+	fs.BoolVar(&cfg.ShowVersion, "version", false, "print version information and exit")
+	fs.BoolVar(&cfg.Doctor, "doctor", false, "check the go toolchain, module cache, and proxy, print diagnostics, and exit")
+	fs.StringVar(&cfg.LogLevel, "log-level", "info", "server log level: trace, debug, info, warn, error, fatal, or panic")
+	fs.StringVar(&cfg.LogFormat, "log-format", "text", "server log format: text or json")
+	fs.StringVar(&cfg.HTTPAddr, "http", "", "serve as http on a TCP address (host:port), unix socket (unix:///path/to/sock), or systemd-activated socket (systemd)")
+	fs.StringVar(&cfg.HTTPTransport, "http-transport", transportStreamable, "http transport to serve: streamable, sse (legacy), or both")
+	fs.StringVar(&cfg.BasePath, "base-path", "", "URL path prefix all http endpoints are served under (e.g. /mcp/godoc)")
+	fs.StringVar(&cfg.AdvertiseURL, "advertise-url", "", "externally reachable base URL advertised to clients when it differs from the listen address (e.g. https://tools.example.com)")
+	fs.BoolVar(&cfg.TrustForwarded, "trust-forwarded", false, "trust X-Forwarded-For/-Proto/-Host headers from a reverse proxy")
+	fs.DurationVar(&cfg.DrainTimeout, "drain-timeout", 30*time.Second, "maximum time to wait for in-flight requests on http shutdown")
+	fs.BoolVar(&cfg.WebUI, "web-ui", false, "in http mode, also serve a browsable documentation site for web browsers under <base-path>/ui/")
+	fs.Var(listFlag{&cfg.CORS.AllowedOrigins}, "cors-origins", "comma-separated list of origins allowed to make CORS requests in http mode ('*' for any)")
+	fs.Var(listFlag{&cfg.CORS.AllowedMethods}, "cors-methods", "comma-separated list of methods allowed for CORS requests")
+	fs.BoolVar(&cfg.CORS.AllowCredentials, "cors-credentials", false, "allow credentialed CORS requests from the listed origins; cannot be combined with '*'")
+	fs.StringVar(&cfg.DocBackend, "doc-backend", backendGoDoc, "how text documentation is generated: go-doc (run go doc) or native (in process, falling back to go doc; its layout differs slightly from go doc's)")
+	fs.StringVar(&cfg.GoplsPath, "gopls", "gopls", "path to the gopls binary used for -gopls-workspaces and the find_definition, hover_symbol, and signature_help tools")
+	fs.Var(listFlag{&cfg.GoplsWorkspaces}, "gopls-workspaces", "comma-separated module directories to keep a warm gopls instance for; symbol lookups in them are answered by gopls")
+	fs.Var(listFlag{&cfg.IndexWorkspaces}, "index-workspaces", "comma-separated module directories whose symbols workspace_symbols indexes at startup and keeps up to date; other workspaces are indexed on their first query")
+	fs.StringVar(&cfg.PkgsiteURL, "pkgsite-url", "https://pkg.go.dev", "base URL of the pkgsite instance find_packages searches")
+	fs.BoolVar(&cfg.PkgsiteFallback, "pkgsite-fallback", false, "when a third-party package can't be downloaded or documented locally, serve get_doc the documentation rendered by -pkgsite-url instead")
+	fs.StringVar(&cfg.PlaygroundURL, "playground-url", "https://play.golang.org", "base URL of the Go Playground share_playground shares code on")
+	fs.DurationVar(&cfg.ModuleIndex.Poll, "module-index-poll", 0, "how often to read new module versions from -module-index-url into the catalog recent_releases answers from; 0 disables the catalog and the tool")
+	fs.StringVar(&cfg.ModuleIndex.URL, "module-index-url", "https://index.golang.org", "base URL of the module index the release catalog is read from")
+	fs.DurationVar(&cfg.ModuleIndex.Window, "module-index-window", 24*time.Hour, "how far back the release catalog reaches")
+	fs.StringVar(&cfg.DepsDevURL, "deps-dev-url", "https://api.deps.dev", "base URL of the deps.dev API that package signals are fetched from")
+	fs.StringVar(&cfg.PprofAddr, "pprof", "", "serve net/http/pprof endpoints on a separate address (host:port or unix:///path/to/sock); disabled when empty")
+	fs.StringVar(&cfg.ProfileDir, "profile-dir", "", "directory to continuously write CPU, heap, and goroutine profiles to; disabled when empty")
+	fs.DurationVar(&cfg.ProfileInterval, "profile-interval", time.Minute, "length of each CPU profile written to -profile-dir")
+	fs.DurationVar(&cfg.MetricsInterval, "metrics-interval", time.Minute, "how often to send metrics as MCP logging notifications (logger godoc-mcp.metrics, level info) to clients; 0 disables them")
+	fs.DurationVar(&cfg.SlowRequest, "slow-request", 10*time.Second, "log a warning with the arguments and a timing breakdown of tool calls taking at least this long; 0 disables")
+	fs.DurationVar(&cfg.CacheTTL, "cache-ttl", 5*time.Minute, "how long generated documentation is cached")
+	fs.Int64Var(&cfg.CacheMaxBytes, "cache-max-bytes", 256<<20, "maximum total bytes of cached documentation before the least recently used entries are evicted; 0 for no limit")
+	fs.DurationVar(&cfg.ProjectTTL, "project-ttl", 30*time.Minute, "how long temporary projects for external packages are kept")
+	fs.IntVar(&cfg.Pagination.DefaultPageSize, "default-page-size", 1000, "default number of lines per get_doc page")
+	fs.IntVar(&cfg.Pagination.MaxPageSize, "max-page-size", 5000, "maximum number of lines per get_doc page clients may request")
+	fs.IntVar(&cfg.Pagination.MaxResponseBytes, "max-response-bytes", 0, "maximum bytes of documentation in a single response; 0 for no limit")
+	fs.StringVar(&cfg.Pagination.Oversize, "oversize", oversizeOutline, "what get_doc returns for documentation longer than a page when no page is requested: outline (overview and declaration outline) or page (page 1)")
+	fs.StringVar(&cfg.Pagination.Tokenizer, "tokenizer", "bytes", "token estimate for page_size_tokens: bytes (four bytes per token) or words (per word and symbol)")
+	fs.IntVar(&cfg.StreamThreshold, "stream-threshold", 64<<10, "documents of at least this many bytes are streamed in chunks as progress notifications to get_doc calls that set stream; 0 disables streaming")
+	fs.IntVar(&cfg.PrefetchImports, "prefetch-imports", 0, "after serving a package's documentation, prefetch up to this many of its direct imports into the cache while the server is idle; 0 disables prefetching")
+	fs.Var(listFlag{&cfg.EnableTools}, "enable-tools", "comma-separated tool names or tags (exec, network, debug, test) to offer; all but the debug and test tools when empty; the debug and test tags opt in to their tools without restricting the others")
+	fs.Var(listFlag{&cfg.DisableTools}, "disable-tools", "comma-separated tool names or tags (exec, network, debug, test) to withhold from clients")
+	fs.StringVar(&cfg.Instructions, "instructions", "", "server instructions sent to clients on initialize, replacing the built-in usage guide; @path reads them from a file")
+	fs.StringVar(&cfg.Embeddings.Backend, "embeddings", "", "embedding backend enabling the semantic_search tool: ollama (a local Ollama server) or openai (an OpenAI-compatible embeddings API); disabled when empty")
+	fs.StringVar(&cfg.Embeddings.URL, "embeddings-url", "", "base URL of the embedding backend; defaults to "+defaultOllamaURL+" for ollama and "+defaultOpenAIURL+" for openai")
+	fs.StringVar(&cfg.Embeddings.Model, "embeddings-model", "", "embedding model; defaults to "+defaultOllamaModel+" for ollama and "+defaultOpenAIModel+" for openai")
+	fs.StringVar(&cfg.Embeddings.APIKeyEnv, "embeddings-api-key-env", "OPENAI_API_KEY", "environment variable holding the API key sent to the openai embedding backend")
+	fs.StringVar(&cfg.CodeSearch.Backend, "code-search", "", "code search backend enabling the usage_examples tool: sourcegraph (a Sourcegraph instance) or grepapp (grep.app public code search); disabled when empty")
+	fs.StringVar(&cfg.CodeSearch.URL, "code-search-url", "", "base URL of the code search backend; defaults to "+defaultSourcegraphURL+" for sourcegraph and "+defaultGrepAppURL+" for grepapp")
+	fs.StringVar(&cfg.CodeSearch.TokenEnv, "code-search-token-env", "SRC_ACCESS_TOKEN", "environment variable holding the access token sent to the sourcegraph code search backend")
+	fs.IntVar(&cfg.MaxWorkers, "max-workers", 2*runtime.NumCPU(), "maximum number of tool calls processed concurrently")
+	fs.IntVar(&cfg.MaxSubprocesses, "max-subprocesses", runtime.NumCPU(), "maximum number of concurrent go subprocesses")
+	fs.IntVar(&cfg.MaxQueued, "max-queued", 64, "maximum number of requests waiting for a subprocess slot before rejecting as busy")
+	fs.DurationVar(&cfg.QueueTimeout, "queue-timeout", 30*time.Second, "maximum time a request waits for a subprocess slot")
+	return fs
+}
+
+// loadConfig builds the server configuration from command line args, GODOC_MCP_*
+// environment variables, and the config file, in that order of precedence
+func loadConfig(args []string) (*Config, error) {
+	cfg := &Config{}
+	fs := newFlagSet(cfg)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Lower precedence sources only fill in flags not already set
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if err := applyEnv(fs, set); err != nil {
+		return nil, err
+	}
+	if cfg.ConfigFile != "" {
+		if err := applyConfigFile(fs, cfg.ConfigFile, set); err != nil {
+			return nil, err
+		}
+	}
+
+	switch fs.Arg(0) {
+	case "":
+	case "query":
+		q, err := parseQueryArgs(fs.Args()[1:])
+		if err != nil {
+			return nil, err
+		}
+		cfg.Query = q
+	default:
+		return nil, fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+
+	if file, ok := strings.CutPrefix(cfg.Instructions, "@"); ok {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read instructions: %v", err)
+		}
+		cfg.Instructions = strings.TrimSpace(string(data))
+	}
+
+	cfg.BasePath = strings.TrimSuffix(cfg.BasePath, "/")
+	cfg.AdvertiseURL = strings.TrimSuffix(cfg.AdvertiseURL, "/")
+	cfg.PkgsiteURL = strings.TrimSuffix(cfg.PkgsiteURL, "/")
+	cfg.PlaygroundURL = strings.TrimSuffix(cfg.PlaygroundURL, "/")
+	cfg.ModuleIndex.URL = strings.TrimSuffix(cfg.ModuleIndex.URL, "/")
+	cfg.DepsDevURL = strings.TrimSuffix(cfg.DepsDevURL, "/")
+	return cfg, nil
+}
+
+// envName returns the environment variable mirroring the named flag
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets every flag in fs not already in set that has a matching
+// environment variable, e.g. GODOC_MCP_MAX_QUEUED for -max-queued
+func applyEnv(fs *flag.FlagSet, set map[string]bool) error {
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		v, ok := os.LookupEnv(name)
+		if !ok || set[f.Name] {
+			return
+		}
+		if err := fs.Set(f.Name, v); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q for %s: %v", v, name, err))
+			return
+		}
+		set[f.Name] = true
+	})
+	return errors.Join(errs...)
+}
+
+// applyConfigFile sets every flag in fs not already in set from a JSON object
+// mapping flag names to values, e.g. {"log-level": "debug", "disable-tools": ["network"]}
+func applyConfigFile(fs *flag.FlagSet, path string, set map[string]bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	var errs []error
+	for name, v := range values {
+		if fs.Lookup(name) == nil {
+			errs = append(errs, fmt.Errorf("unknown setting %q in %s", name, path))
+			continue
+		}
+		if set[name] {
+			continue
+		}
+		var s string
+		switch v := v.(type) {
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			s = strings.Join(items, ",")
+		default:
+			s = fmt.Sprint(v)
+		}
+		if err := fs.Set(name, s); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q for %s in %s: %v", s, name, path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validate reports configuration values that cannot be served
+func (c *Config) validate() error {
+	if _, err := logrus.ParseLevel(c.LogLevel); err != nil {
+		return fmt.Errorf("invalid log level: %v", err)
+	}
+	if _, err := logFormatter(c.LogFormat); err != nil {
+		return err
+	}
+	switch c.HTTPTransport {
+	case transportStreamable, transportSSE, transportBoth:
+	default:
+		return fmt.Errorf("invalid http transport %q: must be one of %s, %s, %s",
+			c.HTTPTransport, transportStreamable, transportSSE, transportBoth)
+	}
+	// Reflecting any origin with credentials would let every site act as the user
+	if c.CORS.AllowCredentials && slices.Contains(c.CORS.AllowedOrigins, "*") {
+		return fmt.Errorf("invalid cors origins: '*' cannot be combined with cors credentials; list the allowed origins instead")
+	}
+	switch c.DocBackend {
+	case backendNative, backendGoDoc:
+	default:
+		return fmt.Errorf("invalid doc backend %q: must be %s or %s", c.DocBackend, backendNative, backendGoDoc)
+	}
+	switch c.Embeddings.Backend {
+	case "", embeddingsOllama, embeddingsOpenAI:
+	default:
+		return fmt.Errorf("invalid embeddings backend %q: must be %s or %s", c.Embeddings.Backend, embeddingsOllama, embeddingsOpenAI)
+	}
+	switch c.CodeSearch.Backend {
+	case "", codeSearchSourcegraph, codeSearchGrepApp:
+	default:
+		return fmt.Errorf("invalid code search backend %q: must be %s or %s", c.CodeSearch.Backend, codeSearchSourcegraph, codeSearchGrepApp)
+	}
+	if u, err := url.Parse(c.PkgsiteURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid pkgsite url %q: must be an absolute http(s) URL", c.PkgsiteURL)
+	}
+	if u, err := url.Parse(c.PlaygroundURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid playground url %q: must be an absolute http(s) URL", c.PlaygroundURL)
+	}
+	if c.ModuleIndex.Poll < 0 {
+		return fmt.Errorf("invalid module index poll interval %v: must not be negative", c.ModuleIndex.Poll)
+	}
+	if c.ModuleIndex.Window <= 0 {
+		return fmt.Errorf("invalid module index window %v: must be positive", c.ModuleIndex.Window)
+	}
+	if u, err := url.Parse(c.ModuleIndex.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid module index url %q: must be an absolute http(s) URL", c.ModuleIndex.URL)
+	}
+	if u, err := url.Parse(c.DepsDevURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid deps.dev url %q: must be an absolute http(s) URL", c.DepsDevURL)
+	}
+	if c.Embeddings.URL != "" {
+		u, err := url.Parse(c.Embeddings.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid embeddings url %q: must be an absolute http(s) URL", c.Embeddings.URL)
+		}
+	}
+	if c.CodeSearch.URL != "" {
+		u, err := url.Parse(c.CodeSearch.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid code search url %q: must be an absolute http(s) URL", c.CodeSearch.URL)
+		}
+	}
+	if err := c.Pagination.validate(); err != nil {
+		return err
+	}
+	if c.StreamThreshold < 0 {
+		return fmt.Errorf("invalid stream threshold %d: must not be negative", c.StreamThreshold)
+	}
+	if c.PrefetchImports < 0 {
+		return fmt.Errorf("invalid prefetch imports %d: must not be negative", c.PrefetchImports)
+	}
+	if c.SlowRequest < 0 {
+		return fmt.Errorf("invalid slow request threshold %s: must not be negative", c.SlowRequest)
+	}
+	if c.MetricsInterval < 0 {
+		return fmt.Errorf("invalid metrics interval %s: must not be negative", c.MetricsInterval)
+	}
+	if c.ProfileDir != "" && c.ProfileInterval <= 0 {
+		return fmt.Errorf("invalid profile interval %s: must be positive", c.ProfileInterval)
+	}
+	if c.CacheMaxBytes < 0 {
+		return fmt.Errorf("invalid cache max bytes %d: must not be negative", c.CacheMaxBytes)
+	}
+	if c.CacheTTL <= 0 || c.ProjectTTL <= 0 {
+		return fmt.Errorf("invalid cache ttl: must be positive")
+	}
+	if c.MaxWorkers < 1 {
+		return fmt.Errorf("invalid max workers %d: must be at least 1", c.MaxWorkers)
+	}
+	if c.MaxSubprocesses < 1 {
+		return fmt.Errorf("invalid max subprocesses %d: must be at least 1", c.MaxSubprocesses)
+	}
+	if c.MaxQueued < 0 {
+		return fmt.Errorf("invalid max queued %d: must not be negative", c.MaxQueued)
+	}
+	if c.BasePath != "" && !strings.HasPrefix(c.BasePath, "/") {
+		return fmt.Errorf("invalid base path %q: must start with '/'", c.BasePath)
+	}
+	if c.AdvertiseURL != "" {
+		u, err := url.Parse(c.AdvertiseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid advertise url %q: must be an absolute http(s) URL", c.AdvertiseURL)
+		}
+		if u.Path != "" {
+			return fmt.Errorf("invalid advertise url %q: use -base-path for the path prefix", c.AdvertiseURL)
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// correctSymbol returns the symbol among symbols that a target naming none of
+// them most likely meant: the only one equal to it ignoring case, or else the
+// only closest match. It reports false when no symbol stands out.
+func correctSymbol(target string, symbols []string) (string, bool) {
+	var folded []string
+	for _, name := range symbols {
+		if strings.EqualFold(name, target) {
+			folded = append(folded, name)
+		}
+	}
+	switch len(folded) {
+	case 0:
+	case 1:
+		return folded[0], true
+	default:
+		return "", false
+	}
+	ranked := rankClosest(target, symbols, func(s string) string { return s })
+	if len(ranked) == 0 || (len(ranked) > 1 && ranked[1].score == ranked[0].score) {
+		return "", false
+	}
+	return ranked[0].name, true
+}
+
+// correctSymbol resolves pkgPath as checkStdLib does and returns its import
+// path with the symbol target most likely meant
+func (x *stdIndex) correctSymbol(pkgPath, target string) (string, string, bool) {
+	sp, ok := x.lookup(pkgPath)
+	if !ok {
+		matches := x.resolve(pkgPath)
+		if len(matches) != 1 {
+			return "", "", false
+		}
+		pkgPath = matches[0]
+		sp, _ = x.lookup(pkgPath)
+	}
+	symbol, ok := correctSymbol(target, sp.names())
+	return pkgPath, symbol, ok
+}
+
+// correctionNote labels documentation of symbol served for a target that named
+// no symbol
+func correctionNote(target, symbol string) string {
+	how := "closest match"
+	if strings.EqualFold(target, symbol) {
+		how = "case-insensitive match"
+	}
+	return fmt.Sprintf("Note: symbol %s not found; showing %s, the %s.\n", target, symbol, how)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithCORS(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		credentials bool
+		origin      string
+		preflight   bool
+		wantAllow   string
+		wantStatus  int
+	}{
+		{"disabled", nil, false, "https://a.example", false, "", http.StatusOK},
+		{"no origin", []string{"https://a.example"}, false, "", false, "", http.StatusOK},
+		{"listed origin", []string{"https://a.example", "https://b.example"}, false, "https://b.example", false, "https://b.example", http.StatusOK},
+		{"unlisted origin", []string{"https://a.example"}, false, "https://b.example", false, "", http.StatusOK},
+		{"origin prefix", []string{"https://a.example"}, false, "https://a.example.evil", false, "", http.StatusOK},
+		{"wildcard", []string{"*"}, false, "https://b.example", false, "*", http.StatusOK},
+		{"credentials", []string{"https://a.example"}, true, "https://a.example", false, "https://a.example", http.StatusOK},
+		{"preflight", []string{"https://a.example"}, false, "https://a.example", true, "https://a.example", http.StatusNoContent},
+		{"unlisted preflight", []string{"https://a.example"}, false, "https://b.example", true, "", http.StatusOK},
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, tt := range tests {
+		cfg := CORSConfig{AllowedOrigins: tt.origins, AllowedMethods: []string{"GET", "POST"}, AllowCredentials: tt.credentials}
+		r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if tt.preflight {
+			r = httptest.NewRequest(http.MethodOptions, "/mcp", nil)
+			r.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		w := httptest.NewRecorder()
+		withCORS(cfg, next).ServeHTTP(w, r)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", tt.name, got, tt.wantAllow)
+		}
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+		if got, want := w.Header().Get("Access-Control-Allow-Credentials") == "true", tt.credentials && tt.wantAllow != ""; got != want {
+			t.Errorf("%s: allows credentials %v, want %v", tt.name, got, want)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Methods"); tt.wantStatus == http.StatusNoContent && got != "GET, POST" {
+			t.Errorf("%s: Access-Control-Allow-Methods = %q, want %q", tt.name, got, "GET, POST")
+		}
+	}
+}
+
+func TestValidateCORS(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"-cors-origins", "*"}, false},
+		{[]string{"-cors-origins", "https://a.example", "-cors-credentials"}, false},
+		{[]string{"-cors-origins", "https://a.example,*", "-cors-credentials"}, true},
+		{[]string{"-cors-origins", "*", "-cors-credentials"}, true},
+	}
+	for _, tt := range tests {
+		cfg, err := loadConfig(tt.args)
+		if err != nil {
+			t.Fatalf("loadConfig(%q): %v", tt.args, err)
+		}
+		if err := cfg.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("validate with %q = %v, want error %v", tt.args, err, tt.wantErr)
+		}
+	}
+}
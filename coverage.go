@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/tools/cover"
+	"golang.org/x/tools/go/packages"
+)
+
+const testCoverageDescription = `Report the test coverage of each function in packages of a local Go module.
+Runs go test -cover for path (e.g., "./pkg" or "./...") in working_dir, or reads an existing coverage
+profile, and lists functions from least to most covered, marking exported functions without a doc comment.
+Use it to find code that is both undocumented and untested before changing it, or to see which behavior
+the tests pin down. Running the tests executes the module's test code.`
+
+// Limits on test_coverage requests
+const (
+	defaultCoverageResults = 100
+	maxCoverageResults     = 1000
+	// coverageTimeout bounds the go test run
+	coverageTimeout = 10 * time.Minute
+	// coverageOutputBytes is how much of a failed go test's output is reported
+	coverageOutputBytes = 4 << 10
+)
+
+// testCoverageSchema is the test_coverage input schema
+var testCoverageSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Package or pattern relative to working_dir to test, such as './pkg' or './...'. Default '.'.",
+			"default":     ".",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory to test in. Defaults to the session working directory, then to the first client root that is a Go module.",
+		},
+		"profile": map[string]any{
+			"type":        "string",
+			"description": "Optional: Existing coverage profile, as written by go test -coverprofile, to read instead of running the tests. A path inside working_dir, relative to it.",
+		},
+		"max_coverage": map[string]any{
+			"type":        "number",
+			"description": "Optional: Only list functions covered by at most this percentage, such as 0 for untested functions.",
+			"minimum":     0,
+			"maximum":     100,
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of functions to list.",
+			"minimum":     1,
+			"maximum":     maxCoverageResults,
+			"default":     defaultCoverageResults,
+		},
+	},
+}
+
+// coverageOutputSchema is the outputSchema of test_coverage, describing coverageOutput
+var coverageOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path":     map[string]any{"type": "string"},
+		"profile":  map[string]any{"type": "string", "description": "The coverage profile read, when one was given"},
+		"total":    map[string]any{"type": "number", "description": "Percentage of the statements of all functions covered"},
+		"untested": map[string]any{"type": "integer", "description": "Number of functions the tests never ran"},
+		"undocumented_untested": map[string]any{
+			"type":        "integer",
+			"description": "Number of exported functions the tests never ran that have no doc comment",
+		},
+		"functions": map[string]any{
+			"type":        "array",
+			"description": "Functions from least to most covered",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"file":       map[string]any{"type": "string", "description": "File relative to working_dir"},
+					"line":       map[string]any{"type": "integer"},
+					"name":       map[string]any{"type": "string", "description": "Function name, with its receiver type for methods (Type.Method)"},
+					"coverage":   map[string]any{"type": "number", "description": "Percentage of the function's statements covered"},
+					"exported":   map[string]any{"type": "boolean"},
+					"documented": map[string]any{"type": "boolean", "description": "Whether the function has a doc comment"},
+				},
+				"required": []string{"file", "line", "name", "coverage", "exported", "documented"},
+			},
+		},
+		"test_failed": map[string]any{"type": "boolean", "description": "Set when go test failed; coverage is of the tests that ran"},
+		"test_output": map[string]any{"type": "string", "description": "The end of go test's output, when it failed"},
+	},
+	Required: []string{"path", "total", "functions"},
+}
+
+// coverageOutput is the structured content of a test_coverage result
+type coverageOutput struct {
+	Path                 string         `json:"path"`
+	Profile              string         `json:"profile,omitempty"`
+	Total                float64        `json:"total"`
+	Untested             int            `json:"untested"`
+	UndocumentedUntested int            `json:"undocumented_untested"`
+	Functions            []funcCoverage `json:"functions"`
+	TestFailed           bool           `json:"test_failed,omitempty"`
+	TestOutput           string         `json:"test_output,omitempty"`
+}
+
+// funcCoverage is the test coverage of one function
+type funcCoverage struct {
+	File       string  `json:"file"`
+	Line       int     `json:"line"`
+	Name       string  `json:"name"`
+	Coverage   float64 `json:"coverage"`
+	Exported   bool    `json:"exported"`
+	Documented bool    `json:"documented"`
+	// statements and covered are the counts Coverage is computed from, and
+	// executed whether any of the function ran, for functions without statements
+	statements, covered int
+	executed            bool
+}
+
+// handleTestCoverage implements the test_coverage tool
+func (s *GodocServer) handleTestCoverage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pattern := request.GetString("path", ".")
+	if pattern != "." && !strings.HasPrefix(pattern, "./") {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("path %q must be relative to working_dir, such as './pkg' or './...'", pattern)), nil
+	}
+	limit := request.GetInt("limit", defaultCoverageResults)
+	if limit < 1 || limit > maxCoverageResults {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("limit must be between 1 and %d, got %d", maxCoverageResults, limit)), nil
+	}
+	maxCoverage := request.GetFloat("max_coverage", 100)
+	workingDir := request.GetString("working_dir", s.sessions.get(ctx).workingDir)
+	if workingDir == "" {
+		workingDir = s.rootWorkingDir(ctx, pattern)
+	}
+	if workingDir == "" {
+		return errorResult(codeInvalidArgument, "no module to test: pass working_dir or set it with set_session_defaults"), nil
+	}
+	if err := checkWorkingDir(workingDir); err != nil {
+		return errorResultFromErr("invalid working directory", err), nil
+	}
+	if err := s.runtime.Load().toolchainErr; err != nil {
+		return errorResultFromErr("cannot run go test", err), nil
+	}
+
+	log := ctxLogger(ctx, s.logger).WithFields(logrus.Fields{"path": pattern, "working_dir": workingDir})
+	out := &coverageOutput{Path: pattern, Functions: []funcCoverage{}}
+	profile := request.GetString("profile", "")
+	if profile != "" {
+		out.Profile = profile
+		var err error
+		if profile, err = profilePath(workingDir, profile); err != nil {
+			return errorResultFromErr("invalid profile", err), nil
+		}
+	} else {
+		dir, err := os.MkdirTemp("", "godoc-cover-")
+		if err != nil {
+			return errorResultFromErr("cannot create the coverage profile", err), nil
+		}
+		defer os.RemoveAll(dir)
+		profile = filepath.Join(dir, "cover.out")
+		output, err := s.runCoverage(ctx, log, workingDir, pattern, profile)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errServerBusy) {
+				return errorResultFromErr("go test did not finish", err), nil
+			}
+			out.TestFailed, out.TestOutput = true, lastBytes(output, coverageOutputBytes)
+			if info, statErr := os.Stat(profile); statErr != nil || info.Size() == 0 {
+				return errorResult(cmp.Or(classifyOutput(output), codeInvalidArgument), "go test failed without writing coverage: "+out.TestOutput), nil
+			}
+		}
+	}
+
+	profiles, err := cover.ParseProfiles(profile)
+	if err != nil {
+		return errorResultFromErr("cannot read the coverage profile", withCode(codeInvalidArgument, err)), nil
+	}
+	// A build or setup failure leaves a profile of only its mode line
+	if len(profiles) == 0 && out.TestFailed {
+		return errorResult(cmp.Or(classifyOutput(out.TestOutput), codeInvalidArgument), "go test failed without writing coverage: "+out.TestOutput), nil
+	}
+	funcs, err := s.profileFuncs(ctx, workingDir, profiles)
+	if err != nil {
+		return errorResultFromErr("cannot locate the covered files", err), nil
+	}
+
+	var statements, covered int
+	for _, fn := range funcs {
+		statements += fn.statements
+		covered += fn.covered
+		if !fn.executed {
+			out.Untested++
+			if fn.Exported && !fn.Documented {
+				out.UndocumentedUntested++
+			}
+		}
+	}
+	out.Total = percent(covered, statements)
+	slices.SortStableFunc(funcs, func(a, b funcCoverage) int { return cmp.Compare(a.Coverage, b.Coverage) })
+	for _, fn := range funcs {
+		if fn.Coverage <= maxCoverage && len(out.Functions) < limit {
+			out.Functions = append(out.Functions, fn)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Coverage of %s: %.1f%% of statements in %d functions; %d untested, %d of them exported without a doc comment\n",
+		cmp.Or(out.Profile, pattern), out.Total, len(funcs), out.Untested, out.UndocumentedUntested)
+	if out.TestFailed {
+		sb.WriteString("Warning: go test failed, so coverage is of the tests that ran:\n" + out.TestOutput + "\n")
+	}
+	sb.WriteString("\n")
+	for _, fn := range out.Functions {
+		fmt.Fprintf(&sb, "%5.1f%%  %s:%d  %s", fn.Coverage, fn.File, fn.Line, fn.Name)
+		if fn.Exported && !fn.Documented {
+			sb.WriteString("  (undocumented)")
+		}
+		sb.WriteString("\n")
+	}
+	if hidden := len(funcs) - len(out.Functions); hidden > 0 {
+		fmt.Fprintf(&sb, "\n%d more functions not listed", hidden)
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// profilePath returns the coverage profile named by profile, a path relative
+// to workingDir, refusing paths that lead outside workingDir, including
+// through symbolic links
+func profilePath(workingDir, profile string) (string, error) {
+	if !filepath.IsLocal(profile) {
+		return "", withCode(codeInvalidArgument, fmt.Errorf("profile %q must be a path inside working_dir, relative to it", profile))
+	}
+	root, err := filepath.EvalSymlinks(workingDir)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, profile))
+	if err != nil {
+		return "", withCode(codeInvalidArgument, err)
+	}
+	if rel, err := filepath.Rel(root, resolved); err != nil || !filepath.IsLocal(rel) {
+		return "", withCode(codeInvalidArgument, fmt.Errorf("profile %q leads outside working_dir", profile))
+	}
+	return resolved, nil
+}
+
+// runCoverage runs go test for pattern in workingDir, writing its coverage
+// profile to profile, and returns the combined output
+func (s *GodocServer) runCoverage(ctx context.Context, log *logrus.Entry, workingDir, pattern, profile string) (string, error) {
+	progressFromContext(ctx).step("Running go test -cover " + pattern)
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(ctx, coverageTimeout)
+	defer cancel()
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "test", "-cover", "-coverprofile="+profile, pattern)
+	cmd.Dir = workingDir
+	cmd.Stdout, cmd.Stderr = &output, &output
+	_, span := startSpan(ctx, "go test", attribute.String("pattern", pattern), attribute.String("working_dir", workingDir))
+	start := time.Now()
+	err = cmd.Run()
+	endSpan(span, err)
+	log.WithFields(logrus.Fields{"duration": time.Since(start), "error": err}).Debug("go test finished")
+	if err != nil && ctx.Err() != nil {
+		return output.String(), ctx.Err()
+	}
+	return output.String(), err
+}
+
+// profileFuncs returns the coverage of each function declared in the files
+// of profiles, computed as go tool cover -func does, with files relative to
+// workingDir
+func (s *GodocServer) profileFuncs(ctx context.Context, workingDir string, profiles []*cover.Profile) ([]funcCoverage, error) {
+	// Profiles name files by their package's import path
+	var importPaths []string
+	for _, p := range profiles {
+		if dir := path.Dir(p.FileName); !slices.Contains(importPaths, dir) {
+			importPaths = append(importPaths, dir)
+		}
+	}
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := packages.Load(&packages.Config{Context: ctx, Mode: packages.NeedName | packages.NeedFiles, Dir: workingDir}, importPaths...)
+	release()
+	if err != nil {
+		return nil, err
+	}
+	dirs := make(map[string]string)
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) > 0 {
+			dirs[pkg.PkgPath] = filepath.Dir(pkg.GoFiles[0])
+		}
+	}
+
+	var funcs []funcCoverage
+	fset := token.NewFileSet()
+	for _, p := range profiles {
+		dir, ok := dirs[path.Dir(p.FileName)]
+		if !ok {
+			continue
+		}
+		file := filepath.Join(dir, path.Base(p.FileName))
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(workingDir, file)
+		if err != nil {
+			rel = file
+		}
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+			fn := funcCoverage{
+				File:       filepath.ToSlash(rel),
+				Line:       fset.Position(fd.Pos()).Line,
+				Name:       fd.Name.Name,
+				Exported:   fd.Name.IsExported(),
+				Documented: fd.Doc != nil,
+			}
+			if fd.Recv != nil {
+				recv := receiverName(fd.Recv)
+				fn.Name = recv + "." + fn.Name
+				fn.Exported = fn.Exported && token.IsExported(recv)
+			}
+			start, end := fset.Position(fd.Pos()), fset.Position(fd.End())
+			for _, b := range p.Blocks {
+				if b.StartLine < start.Line || b.StartLine == start.Line && b.StartCol < start.Column ||
+					b.EndLine > end.Line || b.EndLine == end.Line && b.EndCol > end.Column {
+					continue
+				}
+				fn.statements += b.NumStmt
+				if b.Count > 0 {
+					fn.covered += b.NumStmt
+					fn.executed = true
+				}
+			}
+			fn.Coverage = percent(fn.covered, fn.statements)
+			if fn.statements == 0 && fn.executed {
+				fn.Coverage = 100
+			}
+			funcs = append(funcs, fn)
+		}
+	}
+	return funcs, nil
+}
+
+// percent returns covered as a percentage of total, rounded to a tenth
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(covered)*1000/float64(total)) / 10
+}
+
+// lastBytes returns the last n bytes of s, starting at a line
+func lastBytes(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	s = s[len(s)-n:]
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestProfilePath(t *testing.T) {
+	dir := t.TempDir()
+	module := filepath.Join(dir, "module")
+	if err := os.MkdirAll(filepath.Join(module, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"module/cover.out", "module/sub/cover.out", "secret"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("mode: set\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "secret"), filepath.Join(module, "link.out")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("cover.out", filepath.Join(module, "sub", "inside.out")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		profile string
+		wantErr bool
+	}{
+		{"cover.out", false},
+		{"sub/cover.out", false},
+		{"sub/../cover.out", false},
+		{"sub/inside.out", false},
+		{filepath.Join(module, "cover.out"), true},
+		{filepath.Join(dir, "secret"), true},
+		{"../secret", true},
+		{"sub/../../secret", true},
+		{"link.out", true},
+		{"missing.out", true},
+	}
+	for _, tt := range tests {
+		got, err := profilePath(module, tt.profile)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("profilePath(%q) = %q, %v; want error %v", tt.profile, got, err, tt.wantErr)
+		}
+	}
+}
+
+func TestToolEnabledTestOptIn(t *testing.T) {
+	tags := []string{tagExec, tagTest}
+	tests := []struct {
+		enable, disable []string
+		want            bool
+	}{
+		{nil, nil, false},
+		{[]string{tagExec}, nil, false},
+		{[]string{tagTest}, nil, true},
+		{[]string{"test_coverage"}, nil, true},
+		{[]string{tagTest}, []string{tagExec}, false},
+		{[]string{tagDebug}, nil, false},
+	}
+	for _, tt := range tests {
+		c := &Config{EnableTools: tt.enable, DisableTools: tt.disable}
+		if got := c.toolEnabled("test_coverage", tags); got != tt.want {
+			t.Errorf("toolEnabled(test_coverage) with enable %v, disable %v = %v, want %v", tt.enable, tt.disable, got, tt.want)
+		}
+	}
+	// The test tag adds its tools without restricting the others
+	c := &Config{EnableTools: []string{tagTest}}
+	if !c.toolEnabled("get_doc", []string{tagExec, tagNetwork}) {
+		t.Error("-enable-tools test withheld get_doc")
+	}
+}
+
+func TestTestCoverage(t *testing.T) {
+	s := newTestServer(t, "-enable-tools", tagTest)
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/mod\n\ngo 1.21\n",
+		"calc/calc.go": `package calc
+
+// Add adds a and b
+func Add(a, b int) int { return a + b }
+
+func Sub(a, b int) int { return a - b }
+
+func helper() {}
+`,
+		"calc/calc_test.go":       "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 2) != 3 {\n\t\tt.Fail()\n\t}\n}\n",
+		"failing/failing.go":      "package failing\n\n// Half halves n\nfunc Half(n int) int { return n / 2 }\n",
+		"failing/failing_test.go": "package failing\n\nimport \"testing\"\n\nfunc TestHalf(t *testing.T) {\n\tif Half(3) != 2 {\n\t\tt.Fatal(\"3 / 2 is not 2\")\n\t}\n}\n",
+		"broken/broken.go":        "package broken\n\nfunc Broken() int { return undefined }\n",
+		// A profile covering only Sub
+		"cover.out": "mode: set\nexample.com/mod/calc/calc.go:6.24,6.40 1 1\n",
+	})
+	ctx := context.Background()
+	tests := []struct {
+		name          string
+		args          map[string]any
+		wantCode      string
+		want          []string
+		total         float64
+		untested      int
+		undocUntested int
+		failed        bool
+	}{
+		{"absolute path", map[string]any{"path": "example.com/mod/calc", "working_dir": dir}, codeInvalidArgument, nil, 0, 0, 0, false},
+		{"bad limit", map[string]any{"path": "./calc", "limit": 0, "working_dir": dir}, codeInvalidArgument, nil, 0, 0, 0, false},
+		{"no module", map[string]any{"path": "./calc"}, codeInvalidArgument, nil, 0, 0, 0, false},
+		{"profile outside", map[string]any{"profile": "../cover.out", "working_dir": dir}, codeInvalidArgument, nil, 0, 0, 0, false},
+		{"build failure", map[string]any{"path": "./broken", "working_dir": dir}, codeInvalidArgument, nil, 0, 0, 0, false},
+		{"tests run", map[string]any{"path": "./calc", "working_dir": dir}, "", []string{"Sub", "helper", "Add"}, 50, 2, 1, false},
+		{"max coverage", map[string]any{"path": "./calc", "max_coverage": 0, "working_dir": dir}, "", []string{"Sub", "helper"}, 50, 2, 1, false},
+		{"limit", map[string]any{"path": "./calc", "limit": 1, "working_dir": dir}, "", []string{"Sub"}, 50, 2, 1, false},
+		{"tests fail", map[string]any{"path": "./failing", "working_dir": dir}, "", []string{"Half"}, 100, 0, 0, true},
+		{"profile", map[string]any{"profile": "cover.out", "working_dir": dir}, "", []string{"Add", "helper", "Sub"}, 100, 2, 0, false},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleTestCoverage, "test_coverage", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*coverageOutput)
+		var names []string
+		for _, fn := range out.Functions {
+			names = append(names, fn.Name)
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("%s: functions %q, want %q", tt.name, names, tt.want)
+		}
+		if out.Total != tt.total || out.Untested != tt.untested || out.UndocumentedUntested != tt.undocUntested || out.TestFailed != tt.failed {
+			t.Errorf("%s: total %v, %d untested, %d undocumented, failed %v; want %v, %d, %d, %v", tt.name,
+				out.Total, out.Untested, out.UndocumentedUntested, out.TestFailed, tt.total, tt.untested, tt.undocUntested, tt.failed)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// docCursor is the position a continuation cursor resumes from. Clients treat
+// the encoded form as opaque.
+type docCursor struct {
+	DocID    string `json:"d"`
+	Line     int    `json:"l"`
+	PageSize int    `json:"n"`
+	// PageTokens is the token budget of pages sized by page_size_tokens
+	PageTokens int `json:"t,omitempty"`
+}
+
+// cursorDoc is a document held for continuation cursors, with what it documents
+type cursorDoc struct {
+	doc    cachedDoc
+	pkg    string
+	symbol string
+	src    docSource
+}
+
+// encodeCursor returns the opaque form of c
+func encodeCursor(c docCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor returned by encodeCursor
+func decodeCursor(cursor string) (docCursor, error) {
+	var c docCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.DocID == "" || c.Line < 1 || c.PageSize < 1 || c.PageTokens < 0 {
+		return docCursor{}, errors.New("malformed cursor")
+	}
+	return c, nil
+}
+
+// cursorKey identifies the document a cursor continues within a session
+func cursorKey(session, docID string) string {
+	return session + "|" + docID
+}
+
+// keepForCursor holds doc so its cursors and range requests read the same content,
+// even if the documentation is regenerated or evicted from the cache in between
+func (s *GodocServer) keepForCursor(ctx context.Context, doc cachedDoc, pkg, symbol string, src docSource) {
+	if httpScoped(ctx) {
+		// No later request can read a document held for a single HTTP request
+		return
+	}
+	s.cursorDocs.Set(cursorKey(sessionID(ctx), doc.id), cursorDoc{doc: doc, pkg: pkg, symbol: symbol, src: src}, s.config.Load().CacheTTL)
+}
+
+// continueCursor returns the page of documentation a cursor points at
+func (s *GodocServer) continueCursor(ctx context.Context, log *logrus.Entry, cursor string) *mcp.CallToolResult {
+	c, err := decodeCursor(cursor)
+	if err != nil {
+		return errorResult(codeInvalidArgument, "invalid cursor; request the first page again")
+	}
+	// Reading the document extends its lifetime for a client paging through it
+	item := s.cursorDocs.Get(cursorKey(sessionID(ctx), c.DocID))
+	if item == nil {
+		return errorResult(codeInvalidArgument, "cursor expired; request the first page again")
+	}
+	held := item.Value()
+	if c.Line >= len(held.doc.lines) {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("cursor line %d is past the end of the document", c.Line+1))
+	}
+	if limit := s.config.Load().Pagination.MaxPageSize; c.PageSize > limit {
+		c.PageSize = limit
+	}
+	log.WithFields(logrus.Fields{
+		"doc_id": c.DocID,
+		"line":   c.Line,
+	}).Debug("Continuing from cursor")
+
+	sizing := s.sizing(c.PageSize, c.PageTokens)
+	result := s.pageAt(log, held.doc, c.Line, sizing)
+	if out, ok := result.StructuredContent.(*docOutput); ok {
+		out.Package, out.Symbol, out.Version = held.pkg, held.symbol, held.src.version
+		addDocLink(result, held.src, out)
+	}
+	return result
+}
+
+// newCursorCache creates the store of documents held for continuation cursors
+func newCursorCache(cfg *Config) *ttlcache.Cache[string, cursorDoc] {
+	cache := ttlcache.New(ttlcache.WithTTL[string, cursorDoc](cfg.CacheTTL))
+	go cache.Start()
+	return cache
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, c := range []docCursor{
+		{DocID: "abc123", Line: 1, PageSize: 50},
+		{DocID: "abc123", Line: 240, PageSize: 100, PageTokens: 2000},
+	} {
+		got, err := decodeCursor(encodeCursor(c))
+		if err != nil || got != c {
+			t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v, %v", c, got, err)
+		}
+	}
+}
+
+func TestDecodeCursorMalformed(t *testing.T) {
+	encode := func(json string) string { return base64.RawURLEncoding.EncodeToString([]byte(json)) }
+	for _, cursor := range []string{
+		"",
+		"not base64!",
+		encode("not json"),
+		encode(`{"l":1,"n":50}`),
+		encode(`{"d":"abc","l":0,"n":50}`),
+		encode(`{"d":"abc","l":1,"n":0}`),
+		encode(`{"d":"abc","l":1,"n":50,"t":-1}`),
+		encode(`{"d":"abc","l":"1","n":50}`),
+	} {
+		if c, err := decodeCursor(cursor); err == nil {
+			t.Errorf("decodeCursor(%q) = %+v, want an error", cursor, c)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// declLocation is where a documented symbol is declared, reported in the
+// _meta of get_doc results so clients can jump to the declaration
+type declLocation struct {
+	Symbol string `json:"symbol"`
+	// File is relative to the root of the module providing the package, or
+	// to GOROOT/src for the standard library
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Version string `json:"version,omitempty"`
+}
+
+// declLocations returns where the symbols req documents are declared, at
+// version. Package overviews and documentation rendered by pkg.go.dev have
+// none.
+func (s *GodocServer) declLocations(ctx context.Context, log *logrus.Entry, req docRequest, version string) []declLocation {
+	targets := req.matched
+	if len(targets) == 0 && req.target != "" {
+		targets = []string{req.target}
+	}
+	if len(targets) == 0 || req.source != "" {
+		return nil
+	}
+	pkg, err := s.packageFiles(ctx, req)
+	if err != nil {
+		log.WithError(err).Debug("No declaration locations")
+		return nil
+	}
+	root := filepath.Join(goRoot(), "src")
+	if pkg.Module != nil && pkg.Module.Dir != "" {
+		root = pkg.Module.Dir
+	}
+	positions := declPositions(pkg.GoFiles, targets)
+	var locations []declLocation
+	for _, target := range targets {
+		pos, ok := positions[target]
+		if !ok {
+			continue
+		}
+		rel, err := filepath.Rel(root, pos.Filename)
+		if err != nil {
+			continue
+		}
+		locations = append(locations, declLocation{Symbol: target, File: filepath.ToSlash(rel), Line: pos.Line, Version: version})
+	}
+	return locations
+}
+
+// addDeclLocations records locations in the _meta of result
+func addDeclLocations(result *mcp.CallToolResult, locations []declLocation) {
+	if len(locations) == 0 {
+		return
+	}
+	setResultMeta(result, "locations", locations)
+}
+
+// setResultMeta sets the _meta field key of result to value
+func setResultMeta(result *mcp.CallToolResult, key string, value any) {
+	if result.Meta == nil {
+		result.Meta = mcp.NewMetaFromMap(map[string]any{})
+	}
+	result.Meta.AdditionalFields[key] = value
+}
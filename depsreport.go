@@ -0,0 +1,590 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const moduleReportDescription = `Assess whether a third-party Go module is safe to adopt, from deps.dev.
+Reports the module's licenses, a breakdown of the licenses of its dependencies, its repository's
+OpenSSF Scorecard with the score and reason of each check, and the known security advisories
+affecting it or its dependencies. Takes a module or package path; the version defaults to the one
+the working module requires, then to the module's latest.`
+
+// Limits on module_report requests
+const (
+	// reportTimeout bounds the lookups of one report
+	reportTimeout = 30 * time.Second
+	// maxReportDependencies bounds the dependencies whose licenses and
+	// advisories are looked up
+	maxReportDependencies = 250
+	// maxReportLookups bounds the concurrent lookups of one report
+	maxReportLookups = 8
+)
+
+// moduleReportSchema is the module_report input schema
+var moduleReportSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Module or package path (e.g., 'github.com/google/uuid').",
+		},
+		"version": map[string]any{
+			"type":        "string",
+			"description": "Optional: Module version to assess (e.g., 'v1.6.0'). Defaults to the version the working module requires, then to the latest.",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory whose required version to assess. Defaults to the session working directory.",
+		},
+	},
+	Required: []string{"path"},
+}
+
+// advisorySchema describes an advisory in output schemas
+var advisorySchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"id":          map[string]any{"type": "string", "description": "Advisory identifier (e.g., 'GO-2023-1234' or 'GHSA-...')"},
+		"title":       map[string]any{"type": "string", "description": "Summary of the vulnerability"},
+		"url":         map[string]any{"type": "string", "description": "Advisory details"},
+		"aliases":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Other identifiers, such as CVEs"},
+		"cvss3_score": map[string]any{"type": "number", "description": "CVSS v3 base score, from 0 to 10"},
+		"module":      map[string]any{"type": "string", "description": "Affected module, for dependency advisories"},
+		"version":     map[string]any{"type": "string", "description": "Affected version, for dependency advisories"},
+	},
+	"required": []string{"id"},
+}
+
+// reportOutputSchema is the outputSchema of module_report, describing moduleReport
+var reportOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"module":     map[string]any{"type": "string", "description": "Module path"},
+		"version":    map[string]any{"type": "string", "description": "Version assessed"},
+		"published":  map[string]any{"type": "string", "description": "Date the version was published (YYYY-MM-DD)"},
+		"deprecated": map[string]any{"type": "boolean", "description": "Whether the version is deprecated"},
+		"licenses":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "SPDX licenses of the module"},
+		"repository": map[string]any{"type": "string", "description": "Source repository"},
+		"scorecard": map[string]any{
+			"type":        "object",
+			"description": "OpenSSF Scorecard of the source repository",
+			"properties": map[string]any{
+				"score": map[string]any{"type": "number", "description": "Overall score, from 0 to 10"},
+				"date":  map[string]any{"type": "string", "description": "Date of the assessment (YYYY-MM-DD)"},
+				"checks": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"name":   map[string]any{"type": "string", "description": "Check name (e.g., 'Maintained')"},
+							"score":  map[string]any{"type": "integer", "description": "Score from 0 to 10, or -1 when the check doesn't apply"},
+							"reason": map[string]any{"type": "string", "description": "Why the check scored as it did"},
+						},
+						"required": []string{"name", "score"},
+					},
+				},
+			},
+			"required": []string{"score"},
+		},
+		"advisories": map[string]any{"type": "array", "items": advisorySchema, "description": "Advisories affecting the version"},
+		"dependencies": map[string]any{
+			"type":        "object",
+			"description": "The module's resolved dependencies",
+			"properties": map[string]any{
+				"direct":   map[string]any{"type": "integer", "description": "Number of direct dependencies"},
+				"indirect": map[string]any{"type": "integer", "description": "Number of indirect dependencies"},
+				"licenses": map[string]any{
+					"type":        "array",
+					"description": "Dependencies by license, most common first",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"license": map[string]any{"type": "string", "description": "SPDX license, or 'unknown'"},
+							"modules": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Dependencies under the license, as path@version"},
+						},
+						"required": []string{"license", "modules"},
+					},
+				},
+				"advisories": map[string]any{"type": "array", "items": advisorySchema, "description": "Advisories affecting dependencies"},
+				"truncated":  map[string]any{"type": "boolean", "description": "Whether only some dependencies were looked up"},
+			},
+			"required": []string{"direct", "indirect", "licenses"},
+		},
+		"warnings": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Parts of the report that couldn't be looked up"},
+	},
+	Required: []string{"module", "version"},
+}
+
+// moduleReport is the structured content of a module_report result
+type moduleReport struct {
+	Module       string            `json:"module"`
+	Version      string            `json:"version"`
+	Published    string            `json:"published,omitempty"`
+	Deprecated   bool              `json:"deprecated,omitempty"`
+	Licenses     []string          `json:"licenses,omitempty"`
+	Repository   string            `json:"repository,omitempty"`
+	Scorecard    *scorecard        `json:"scorecard,omitempty"`
+	Advisories   []advisory        `json:"advisories,omitempty"`
+	Dependencies *dependencyReport `json:"dependencies,omitempty"`
+	Warnings     []string          `json:"warnings,omitempty"`
+}
+
+// scorecard is an OpenSSF Scorecard assessment of a repository
+type scorecard struct {
+	Score  float64          `json:"score"`
+	Date   string           `json:"date,omitempty"`
+	Checks []scorecardCheck `json:"checks,omitempty"`
+}
+
+// scorecardCheck is one check of a scorecard
+type scorecardCheck struct {
+	Name   string `json:"name"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// advisory is a security advisory affecting a module version
+type advisory struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+	CVSS3   float64  `json:"cvss3_score,omitempty"`
+	Module  string   `json:"module,omitempty"`
+	Version string   `json:"version,omitempty"`
+}
+
+// dependencyReport summarizes the licenses and advisories of a module's
+// resolved dependencies
+type dependencyReport struct {
+	Direct     int            `json:"direct"`
+	Indirect   int            `json:"indirect"`
+	Licenses   []licenseGroup `json:"licenses"`
+	Advisories []advisory     `json:"advisories,omitempty"`
+	Truncated  bool           `json:"truncated,omitempty"`
+}
+
+// licenseGroup lists the dependencies under one license
+type licenseGroup struct {
+	License string   `json:"license"`
+	Modules []string `json:"modules"`
+}
+
+// depsVersion is the part of a deps.dev version record reports use
+type depsVersion struct {
+	PublishedAt  time.Time `json:"publishedAt"`
+	IsDeprecated bool      `json:"isDeprecated"`
+	Licenses     []string  `json:"licenses"`
+	AdvisoryKeys []struct {
+		ID string `json:"id"`
+	} `json:"advisoryKeys"`
+	RelatedProjects []struct {
+		ProjectKey   struct{ ID string } `json:"projectKey"`
+		RelationType string              `json:"relationType"`
+	} `json:"relatedProjects"`
+}
+
+// newReportCache creates the cache of module reports by deps.dev endpoint and
+// module version
+func newReportCache() *ttlcache.Cache[string, *moduleReport] {
+	cache := ttlcache.New[string, *moduleReport](
+		ttlcache.WithTTL[string, *moduleReport](signalsCacheTTL),
+		ttlcache.WithDisableTouchOnHit[string, *moduleReport](),
+	)
+	go cache.Start()
+	return cache
+}
+
+// handleModuleReport implements the module_report tool
+func (s *GodocServer) handleModuleReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pkgPath := strings.TrimSpace(request.GetString("path", ""))
+	if pkgPath == "" {
+		return errorResult(codeInvalidArgument, "path must not be empty"), nil
+	}
+	if isStdLib(pkgPath) {
+		return errorResult(codeUnsupported, fmt.Sprintf("%s is in the standard library, which ships with Go and isn't a module dependency", pkgPath)), nil
+	}
+	version := request.GetString("version", "")
+	workingDir := request.GetString("working_dir", s.sessions.get(ctx).workingDir)
+	if workingDir != "" {
+		if err := checkWorkingDir(workingDir); err != nil {
+			return errorResultFromErr("invalid working_dir", err), nil
+		}
+	}
+	base := s.config.Load().DepsDevURL
+	log := ctxLogger(ctx, s.logger).WithFields(logrus.Fields{"package": pkgPath, "deps_dev": base})
+
+	ctx, cancel := context.WithTimeout(ctx, reportTimeout)
+	defer cancel()
+	modPath, required := requiredModule(pkgPath, workingDir)
+	if version == "" {
+		version = required
+	}
+	if modPath == "" || version == "" {
+		ps, err := s.packageSignalsFor(ctx, pkgPath)
+		if err != nil {
+			return reportError(log, base, pkgPath, err), nil
+		}
+		modPath = ps.Module
+		if version == "" {
+			version = ps.LatestVersion
+		}
+		if version == "" {
+			return errorResult(codePkgNotFound, fmt.Sprintf("deps.dev knows no versions of %s", modPath)), nil
+		}
+	}
+
+	key := base + "|" + modPath + "@" + version
+	var report *moduleReport
+	if item := s.reports.Get(key); item != nil {
+		report = item.Value()
+	} else {
+		var err error
+		progressFromContext(ctx).step("Looking up " + modPath + "@" + version + " on deps.dev")
+		if report, err = fetchModuleReport(ctx, base, modPath, version); err != nil {
+			return reportError(log, base, modPath+"@"+version, err), nil
+		}
+		// Reports missing parts are retried on the next request
+		if len(report.Warnings) == 0 {
+			s.reports.Set(key, report, ttlcache.DefaultTTL)
+		}
+	}
+	log.WithFields(logrus.Fields{"module": modPath, "version": version}).Debug("Assessed module")
+
+	result := mcp.NewToolResultText(report.String())
+	result.StructuredContent = report
+	return result, nil
+}
+
+// reportError converts a failed deps.dev lookup of what into a tool error
+func reportError(log *logrus.Entry, base, what string, err error) *mcp.CallToolResult {
+	log.WithError(err).Debug("deps.dev lookup failed")
+	switch {
+	case errors.Is(err, errModuleUnknown):
+		return errorResult(codePkgNotFound, fmt.Sprintf("%s is not known to %s", what, base))
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return errorResultFromErr("failed to look up "+what, err)
+	}
+	return errorResult(codeNetworkFetch, fmt.Sprintf("failed to look up %s on %s: %v", what, base, err))
+}
+
+// fetchModuleReport looks modPath at version up in the deps.dev API at base.
+// Only the version record must be found; the scorecard, advisory details, and
+// dependencies are reported as warnings when they can't be looked up.
+func fetchModuleReport(ctx context.Context, base, modPath, version string) (*moduleReport, error) {
+	versionURL := base + "/v3/systems/go/packages/" + url.PathEscape(modPath) + "/versions/" + url.PathEscape(version)
+	var v depsVersion
+	if err := getJSON(ctx, versionURL, &v); err != nil {
+		return nil, err
+	}
+	report := &moduleReport{Module: modPath, Version: version, Deprecated: v.IsDeprecated, Licenses: v.Licenses}
+	if !v.PublishedAt.IsZero() {
+		report.Published = v.PublishedAt.Format(time.DateOnly)
+	}
+	for _, p := range v.RelatedProjects {
+		if p.RelationType == "SOURCE_REPO" {
+			report.Repository = p.ProjectKey.ID
+		}
+	}
+
+	var mu sync.Mutex
+	warn := func(format string, args ...any) {
+		mu.Lock()
+		report.Warnings = append(report.Warnings, fmt.Sprintf(format, args...))
+		mu.Unlock()
+	}
+	var g errgroup.Group
+	if report.Repository != "" {
+		g.Go(func() error {
+			sc, err := fetchScorecard(ctx, base, report.Repository)
+			if err != nil {
+				warn("scorecard unavailable: %v", err)
+				return nil
+			}
+			mu.Lock()
+			report.Scorecard = sc
+			mu.Unlock()
+			return nil
+		})
+	}
+	g.Go(func() error {
+		advisories := make([]advisory, len(v.AdvisoryKeys))
+		for i, key := range v.AdvisoryKeys {
+			advisories[i] = advisory{ID: key.ID}
+		}
+		if err := fetchAdvisories(ctx, base, advisories); err != nil {
+			warn("advisory details unavailable: %v", err)
+		}
+		mu.Lock()
+		report.Advisories = advisories
+		mu.Unlock()
+		return nil
+	})
+	g.Go(func() error {
+		deps, err := fetchDependencyReport(ctx, base, versionURL)
+		if err != nil {
+			warn("dependencies unavailable: %v", err)
+			return nil
+		}
+		mu.Lock()
+		report.Dependencies = deps
+		mu.Unlock()
+		return nil
+	})
+	g.Wait()
+	slices.Sort(report.Warnings)
+	return report, nil
+}
+
+// fetchScorecard returns the OpenSSF Scorecard of the project repo, or nil
+// when it has none
+func fetchScorecard(ctx context.Context, base, repo string) (*scorecard, error) {
+	var project struct {
+		Scorecard *struct {
+			Date         time.Time `json:"date"`
+			OverallScore float64   `json:"overallScore"`
+			Checks       []struct {
+				Name   string `json:"name"`
+				Score  int    `json:"score"`
+				Reason string `json:"reason"`
+			} `json:"checks"`
+		} `json:"scorecard"`
+	}
+	if err := getJSON(ctx, base+"/v3/projects/"+url.PathEscape(repo), &project); err != nil {
+		return nil, err
+	}
+	if project.Scorecard == nil {
+		return nil, nil
+	}
+	sc := &scorecard{Score: project.Scorecard.OverallScore}
+	if !project.Scorecard.Date.IsZero() {
+		sc.Date = project.Scorecard.Date.Format(time.DateOnly)
+	}
+	for _, c := range project.Scorecard.Checks {
+		sc.Checks = append(sc.Checks, scorecardCheck{Name: c.Name, Score: c.Score, Reason: c.Reason})
+	}
+	// Weakest checks first, as those are what an adopter needs to weigh, and
+	// those that don't apply last
+	rank := func(c scorecardCheck) int {
+		if c.Score < 0 {
+			return 11
+		}
+		return c.Score
+	}
+	slices.SortStableFunc(sc.Checks, func(a, b scorecardCheck) int { return cmp.Compare(rank(a), rank(b)) })
+	return sc, nil
+}
+
+// fetchAdvisories fills in the details of each advisory by its ID. Advisories
+// whose details can't be looked up keep only their ID, and the first error is
+// returned.
+func fetchAdvisories(ctx context.Context, base string, advisories []advisory) error {
+	var g errgroup.Group
+	g.SetLimit(maxReportLookups)
+	for i := range advisories {
+		g.Go(func() error {
+			var details struct {
+				URL        string   `json:"url"`
+				Title      string   `json:"title"`
+				Aliases    []string `json:"aliases"`
+				CVSS3Score float64  `json:"cvss3Score"`
+			}
+			if err := getJSON(ctx, base+"/v3/advisories/"+url.PathEscape(advisories[i].ID), &details); err != nil {
+				return err
+			}
+			a := &advisories[i]
+			a.Title, a.URL, a.Aliases, a.CVSS3 = details.Title, details.URL, details.Aliases, details.CVSS3Score
+			return nil
+		})
+	}
+	err := g.Wait()
+	// Most severe first
+	slices.SortStableFunc(advisories, func(a, b advisory) int { return cmp.Compare(b.CVSS3, a.CVSS3) })
+	return err
+}
+
+// fetchDependencyReport looks up the resolved dependencies of the version at
+// versionURL, then the licenses and advisories of each
+func fetchDependencyReport(ctx context.Context, base, versionURL string) (*dependencyReport, error) {
+	var graph struct {
+		Nodes []struct {
+			VersionKey struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"versionKey"`
+			Relation string `json:"relation"`
+		} `json:"nodes"`
+		Error string `json:"error"`
+	}
+	if err := getJSON(ctx, versionURL+":dependencies", &graph); err != nil {
+		return nil, err
+	}
+	if graph.Error != "" && len(graph.Nodes) == 0 {
+		return nil, errors.New(graph.Error)
+	}
+
+	type dependency struct{ path, version string }
+	var deps []dependency
+	report := &dependencyReport{Licenses: make([]licenseGroup, 0)}
+	for _, n := range graph.Nodes {
+		switch n.Relation {
+		case "DIRECT":
+			report.Direct++
+		case "INDIRECT":
+			report.Indirect++
+		default:
+			continue
+		}
+		deps = append(deps, dependency{n.VersionKey.Name, n.VersionKey.Version})
+	}
+	if len(deps) > maxReportDependencies {
+		deps, report.Truncated = deps[:maxReportDependencies], true
+	}
+
+	var mu sync.Mutex
+	byLicense := make(map[string][]string)
+	var g errgroup.Group
+	g.SetLimit(maxReportLookups)
+	for _, dep := range deps {
+		g.Go(func() error {
+			var v depsVersion
+			err := getJSON(ctx, base+"/v3/systems/go/packages/"+url.PathEscape(dep.path)+"/versions/"+url.PathEscape(dep.version), &v)
+			if err != nil && ctx.Err() != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			id := dep.path + "@" + dep.version
+			if len(v.Licenses) == 0 {
+				byLicense["unknown"] = append(byLicense["unknown"], id)
+			}
+			for _, license := range v.Licenses {
+				byLicense[license] = append(byLicense[license], id)
+			}
+			for _, key := range v.AdvisoryKeys {
+				report.Advisories = append(report.Advisories, advisory{ID: key.ID, Module: dep.path, Version: dep.version})
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	for license, modules := range byLicense {
+		slices.Sort(modules)
+		report.Licenses = append(report.Licenses, licenseGroup{License: license, Modules: modules})
+	}
+	slices.SortFunc(report.Licenses, func(a, b licenseGroup) int {
+		if c := cmp.Compare(len(b.Modules), len(a.Modules)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.License, b.License)
+	})
+	// Advisory details are best effort; the IDs alone are worth reporting
+	fetchAdvisories(ctx, base, report.Advisories)
+	return report, nil
+}
+
+// String formats the report for reading
+func (r *moduleReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s", r.Module, r.Version)
+	if r.Published != "" {
+		fmt.Fprintf(&sb, " (published %s)", r.Published)
+	}
+	if r.Deprecated {
+		sb.WriteString(" DEPRECATED")
+	}
+	sb.WriteString("\n")
+	licenses := "unknown"
+	if len(r.Licenses) > 0 {
+		licenses = strings.Join(r.Licenses, ", ")
+	}
+	fmt.Fprintf(&sb, "License: %s\n", licenses)
+	if r.Repository != "" {
+		fmt.Fprintf(&sb, "Repository: %s\n", r.Repository)
+	}
+
+	if sc := r.Scorecard; sc != nil {
+		fmt.Fprintf(&sb, "\nOpenSSF Scorecard: %.1f/10", sc.Score)
+		if sc.Date != "" {
+			fmt.Fprintf(&sb, " (as of %s)", sc.Date)
+		}
+		sb.WriteString("\n")
+		for _, c := range sc.Checks {
+			score := "n/a"
+			if c.Score >= 0 {
+				score = fmt.Sprintf("%d/10", c.Score)
+			}
+			fmt.Fprintf(&sb, "  %-24s %5s", c.Name, score)
+			if c.Reason != "" {
+				fmt.Fprintf(&sb, "  %s", c.Reason)
+			}
+			sb.WriteString("\n")
+		}
+	} else if r.Repository != "" {
+		sb.WriteString("\nOpenSSF Scorecard: none\n")
+	}
+
+	sb.WriteString("\n")
+	writeAdvisories(&sb, "Advisories", r.Advisories)
+
+	if d := r.Dependencies; d != nil {
+		fmt.Fprintf(&sb, "\nDependencies: %d direct, %d indirect", d.Direct, d.Indirect)
+		if d.Truncated {
+			fmt.Fprintf(&sb, " (the first %d looked up)", maxReportDependencies)
+		}
+		sb.WriteString("\n")
+		for _, g := range d.Licenses {
+			fmt.Fprintf(&sb, "  %s: %d (%s)\n", g.License, len(g.Modules), strings.Join(g.Modules, ", "))
+		}
+		writeAdvisories(&sb, "Dependency advisories", d.Advisories)
+	}
+
+	for _, w := range r.Warnings {
+		fmt.Fprintf(&sb, "\nWarning: %s", w)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// writeAdvisories writes a list of advisories under title
+func writeAdvisories(sb *strings.Builder, title string, advisories []advisory) {
+	if len(advisories) == 0 {
+		fmt.Fprintf(sb, "%s: none known\n", title)
+		return
+	}
+	fmt.Fprintf(sb, "%s: %d\n", title, len(advisories))
+	for _, a := range advisories {
+		fmt.Fprintf(sb, "  %s", a.ID)
+		if a.Module != "" {
+			fmt.Fprintf(sb, " in %s@%s", a.Module, a.Version)
+		}
+		if a.CVSS3 > 0 {
+			fmt.Fprintf(sb, " (CVSS %.1f)", a.CVSS3)
+		}
+		if a.Title != "" {
+			fmt.Fprintf(sb, ": %s", a.Title)
+		}
+		if len(a.Aliases) > 0 {
+			fmt.Fprintf(sb, " [%s]", strings.Join(a.Aliases, ", "))
+		}
+		if a.URL != "" {
+			fmt.Fprintf(sb, " %s", a.URL)
+		}
+		sb.WriteString("\n")
+	}
+}
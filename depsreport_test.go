@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// depsDevResponses are the deps.dev API responses of the test server by
+// escaped request path; other paths are not found
+var depsDevResponses = map[string]string{
+	"/v3/systems/go/packages/example.com%2Flib": `{"versions":[{"versionKey":{"version":"v1.0.0"}},{"versionKey":{"version":"v1.2.0"},"isDefault":true,"publishedAt":"2024-03-01T00:00:00Z"}]}`,
+	"/v3/systems/go/packages/example.com%2Flib/versions/v1.2.0": `{"publishedAt":"2024-03-01T00:00:00Z","licenses":["MIT"],"advisoryKeys":[{"id":"GHSA-low"},{"id":"GHSA-high"}],
+		"relatedProjects":[{"projectKey":{"id":"github.com/example/lib"},"relationType":"SOURCE_REPO"}]}`,
+	"/v3/systems/go/packages/example.com%2Flib/versions/v1.2.0:dependencies": `{"nodes":[
+		{"versionKey":{"name":"example.com/lib","version":"v1.2.0"},"relation":"SELF"},
+		{"versionKey":{"name":"example.com/dep","version":"v0.1.0"},"relation":"DIRECT"},
+		{"versionKey":{"name":"example.com/unknown","version":"v0.2.0"},"relation":"INDIRECT"}]}`,
+	"/v3/systems/go/packages/example.com%2Flib/versions/v1.0.0":              `{"licenses":["MIT"],"isDeprecated":true}`,
+	"/v3/systems/go/packages/example.com%2Flib/versions/v1.0.0:dependencies": `{"nodes":[]}`,
+	"/v3/systems/go/packages/example.com%2Fdep/versions/v0.1.0":              `{"licenses":["Apache-2.0"],"advisoryKeys":[{"id":"GHSA-dep"}]}`,
+	"/v3/projects/github.com%2Fexample%2Flib": `{"scorecard":{"date":"2024-03-04T00:00:00Z","overallScore":6.5,"checks":[
+		{"name":"Maintained","score":10},{"name":"Fuzzing","score":0,"reason":"no fuzzing"},{"name":"Packaging","score":-1}]}}`,
+	"/v3/advisories/GHSA-low":  `{"title":"Minor leak","url":"https://example.com/GHSA-low","cvss3Score":3.1}`,
+	"/v3/advisories/GHSA-high": `{"title":"Remote code execution","aliases":["CVE-2024-1"],"cvss3Score":9.8}`,
+	"/v3/advisories/GHSA-dep":  `{"title":"Dependency flaw","cvss3Score":5}`,
+	// The dependencies of example.com/broken can't be looked up
+	"/v3/systems/go/packages/example.com%2Fbroken":                 `{"versions":[{"versionKey":{"version":"v1.0.0"},"isDefault":true}]}`,
+	"/v3/systems/go/packages/example.com%2Fbroken/versions/v1.0.0": `{"licenses":["BSD-3-Clause"]}`,
+}
+
+func TestModuleReport(t *testing.T) {
+	var lookups atomic.Int32
+	depsDev := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		if r.URL.EscapedPath() == "/v3/systems/go/packages/example.com%2Fbroken/versions/v1.0.0:dependencies" {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		body, ok := depsDevResponses[r.URL.EscapedPath()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer depsDev.Close()
+	s := newTestServer(t, "-deps-dev-url", depsDev.URL)
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n\nrequire example.com/lib v1.0.0\n",
+	})
+	latest := &moduleReport{
+		Module:     "example.com/lib",
+		Version:    "v1.2.0",
+		Published:  "2024-03-01",
+		Licenses:   []string{"MIT"},
+		Repository: "github.com/example/lib",
+		Scorecard: &scorecard{Score: 6.5, Date: "2024-03-04", Checks: []scorecardCheck{
+			{Name: "Fuzzing", Score: 0, Reason: "no fuzzing"},
+			{Name: "Maintained", Score: 10},
+			{Name: "Packaging", Score: -1},
+		}},
+		Advisories: []advisory{
+			{ID: "GHSA-high", Title: "Remote code execution", Aliases: []string{"CVE-2024-1"}, CVSS3: 9.8},
+			{ID: "GHSA-low", Title: "Minor leak", URL: "https://example.com/GHSA-low", CVSS3: 3.1},
+		},
+		Dependencies: &dependencyReport{
+			Direct:   1,
+			Indirect: 1,
+			Licenses: []licenseGroup{
+				{License: "Apache-2.0", Modules: []string{"example.com/dep@v0.1.0"}},
+				{License: "unknown", Modules: []string{"example.com/unknown@v0.2.0"}},
+			},
+			Advisories: []advisory{{ID: "GHSA-dep", Title: "Dependency flaw", CVSS3: 5, Module: "example.com/dep", Version: "v0.1.0"}},
+		},
+	}
+	required := &moduleReport{
+		Module:       "example.com/lib",
+		Version:      "v1.0.0",
+		Deprecated:   true,
+		Licenses:     []string{"MIT"},
+		Advisories:   []advisory{},
+		Dependencies: &dependencyReport{Licenses: []licenseGroup{}},
+	}
+	broken := &moduleReport{
+		Module:     "example.com/broken",
+		Version:    "v1.0.0",
+		Licenses:   []string{"BSD-3-Clause"},
+		Advisories: []advisory{},
+		Warnings:   []string{"dependencies unavailable: " + depsDev.URL + "/v3/systems/go/packages/example.com%2Fbroken/versions/v1.0.0:dependencies returned 500 Internal Server Error"},
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		want     *moduleReport
+		// cached is whether the report is answered without looking anything up
+		cached bool
+	}{
+		{"no path", nil, codeInvalidArgument, nil, true},
+		{"standard library", map[string]any{"path": "net/http"}, codeUnsupported, nil, true},
+		{"missing working dir", map[string]any{"path": "example.com/lib", "working_dir": dir + "/none"}, codeInvalidWorkingDir, nil, true},
+		{"unknown module", map[string]any{"path": "example.com/none"}, codePkgNotFound, nil, false},
+		{"unknown version", map[string]any{"path": "example.com/lib", "version": "v9.0.0"}, codePkgNotFound, nil, false},
+		{"latest of a package", map[string]any{"path": "example.com/lib/sub"}, "", latest, false},
+		{"cached", map[string]any{"path": "example.com/lib", "version": "v1.2.0"}, "", latest, true},
+		{"required version", map[string]any{"path": "example.com/lib", "working_dir": dir}, "", required, false},
+		{"partial", map[string]any{"path": "example.com/broken", "version": "v1.0.0"}, "", broken, false},
+		// Reports with warnings are looked up again
+		{"partial again", map[string]any{"path": "example.com/broken", "version": "v1.0.0"}, "", broken, false},
+	}
+	for _, tt := range tests {
+		before := lookups.Load()
+		result := callTool(t, ctx, s.handleModuleReport, "module_report", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if cached := lookups.Load() == before; cached != tt.cached {
+			t.Errorf("%s: answered from the cache %v, want %v", tt.name, cached, tt.cached)
+		}
+		if tt.want == nil {
+			continue
+		}
+		if got := result.StructuredContent.(*moduleReport); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: report\n%+v\nwant\n%+v", tt.name, got, tt.want)
+		}
+		if text := resultText(result); text != tt.want.String() {
+			t.Errorf("%s: text %q, want %q", tt.name, text, tt.want.String())
+		}
+	}
+}
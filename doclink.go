@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// docLink is a resource link to documentation, with the page it accompanies
+// described in its _meta
+type docLink struct {
+	mcp.ResourceLink
+	Meta map[string]any `json:"_meta,omitempty"`
+}
+
+// docSource identifies where a get_doc document can be read again
+type docSource struct {
+	uri     string
+	version string
+}
+
+// newDocSource returns the source of the documentation req selects: a godoc://
+// URI pinned to the resolved module version, or the module directory for
+// packages of a client's own module
+func newDocSource(req docRequest) docSource {
+	version := resolvedVersion(req.path, req.workingDir)
+	if req.ownModule {
+		return docSource{uri: fileURI(req.workingDir), version: version}
+	}
+	u := docURI{path: req.path, symbol: req.target}
+	// Lists of targets link to their package
+	if len(req.targets) > 1 {
+		u.symbol = ""
+	}
+	if !isStdLib(req.path) {
+		u.version = version
+	}
+	return docSource{uri: u.String(), version: version}
+}
+
+// resolvedVersion returns the version of the documentation of pkgPath generated
+// in workingDir: the go toolchain version for the standard library, or the
+// version of the module providing pkgPath. Packages of a working directory's
+// own module are unversioned unless it is in the module cache.
+func resolvedVersion(pkgPath, workingDir string) string {
+	if isStdLib(pkgPath) {
+		return goToolchainVersion()
+	}
+	data, err := os.ReadFile(filepath.Join(workingDir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return ""
+	}
+	if f.Module != nil && inModule(pkgPath, f.Module.Mod.Path) {
+		// Modules documented in place in the module cache are versioned by their directory
+		if root := modCacheDir(); root != "" && strings.HasPrefix(workingDir, root+string(filepath.Separator)) {
+			if i := strings.LastIndex(workingDir, "@"); i >= 0 {
+				version, err := module.UnescapeVersion(workingDir[i+1:])
+				if err == nil {
+					return version
+				}
+			}
+		}
+		return ""
+	}
+	_, version := requirement(f, pkgPath)
+	return version
+}
+
+// requirement returns the path and version of the module required by f that
+// provides pkgPath, or empty strings if none does
+func requirement(f *modfile.File, pkgPath string) (string, string) {
+	var best, version string
+	for _, r := range f.Require {
+		if inModule(pkgPath, r.Mod.Path) && len(r.Mod.Path) > len(best) {
+			best, version = r.Mod.Path, r.Mod.Version
+		}
+	}
+	return best, version
+}
+
+// requiredModule returns the path and version of the module providing pkgPath
+// among the requirements of the go.mod in workingDir
+func requiredModule(pkgPath, workingDir string) (string, string) {
+	data, err := os.ReadFile(filepath.Join(workingDir, "go.mod"))
+	if err != nil {
+		return "", ""
+	}
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return "", ""
+	}
+	return requirement(f, pkgPath)
+}
+
+// inModule reports whether pkgPath belongs to the module modPath
+func inModule(pkgPath, modPath string) bool {
+	return pkgPath == modPath || strings.HasPrefix(pkgPath, modPath+"/")
+}
+
+// estimateTokens approximates how many model tokens text takes, at the usual
+// four bytes per token of English and code
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// addDocLink appends to result, a get_doc page described by out, the link to its source
+func addDocLink(result *mcp.CallToolResult, src docSource, out *docOutput) {
+	text := mcp.GetTextFromContent(result.Content[0])
+	result.Content = append(result.Content, newDocLink(src, out, text))
+}
+
+// newDocLink links the page described by out to its source, so clients can pin
+// the documentation into persistent context. text is the page as returned.
+func newDocLink(src docSource, out *docOutput, text string) docLink {
+	name := out.Package
+	if out.Symbol != "" {
+		name += "." + out.Symbol
+	}
+	p := out.Pagination
+	meta := map[string]any{
+		"package":     out.Package,
+		"doc_id":      out.DocID,
+		"page":        p.Page,
+		"page_size":   p.PageSize,
+		"total_pages": p.TotalPages,
+		"first_line":  p.FirstLine,
+		"last_line":   p.LastLine,
+		"total_lines": p.TotalLines,
+		"has_more":    p.HasMore,
+		"tokens":      estimateTokens(text),
+	}
+	if out.Symbol != "" {
+		meta["symbol"] = out.Symbol
+	}
+	if src.version != "" {
+		meta["version"] = src.version
+	}
+	if p.PageSizeTokens != 0 {
+		meta["page_size_tokens"] = p.PageSizeTokens
+	}
+	if p.NextCursor != "" {
+		meta["next_cursor"] = p.NextCursor
+	}
+	part := fmt.Sprintf("page %d of %d", p.Page, p.TotalPages)
+	switch {
+	case out.Outline:
+		part = fmt.Sprintf("outline of %d pages", p.TotalPages)
+	case out.Range != nil:
+		part = fmt.Sprintf("%s %d-%d of %d", out.Range.Unit, out.Range.Start, out.Range.End, out.Range.Total)
+	}
+	description := fmt.Sprintf("Documentation of %s, %s", name, part)
+	if src.version != "" {
+		description = fmt.Sprintf("Documentation of %s at %s, %s", name, src.version, part)
+	}
+	return docLink{
+		ResourceLink: mcp.NewResourceLink(src.uri, name, description, "text/plain"),
+		Meta:         meta,
+	}
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/doc/comment"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/packages"
+)
+
+const docLintDescription = `Check the doc comments of a package's exported identifiers and list what to fix.
+Reports exported constants, variables, functions, types, and methods without a doc comment, packages
+without a package comment, doc comments that don't begin with the name they document (or "Package name"),
+and bracketed names such as [Client.Do] that go doc can't resolve into a link. Each issue comes with its
+file and line, so it serves as the worklist for a request to "fix the docs" of a package. path takes the
+same forms as get_doc's (e.g., "./pkg"); end it with /... to include the packages below it.`
+
+// Limits on doc_lint results
+const (
+	defaultLintIssues = 200
+	maxLintIssues     = 2000
+)
+
+// lintKinds are the kinds of issues doc_lint reports
+var lintKinds = []string{"missing", "name", "link"}
+
+// docLintSchema is the doc_lint input schema
+var docLintSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Package to check, as for get_doc (e.g., './pkg' or 'github.com/user/repo'). End it with '/...' to include the packages below it, as in './...'.",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory for relative paths and the module's own packages. Defaults to the session working directory.",
+		},
+		"kinds": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string", "enum": lintKinds},
+			"description": "Optional: Only report these issues: 'missing' doc comments, comments not starting with the documented 'name', and unresolved doc 'link's. Defaults to all of them.",
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of issues to list.",
+			"minimum":     1,
+			"maximum":     maxLintIssues,
+			"default":     defaultLintIssues,
+		},
+	},
+	Required: []string{"path"},
+}
+
+// docLintOutputSchema is the outputSchema of doc_lint, describing docLintOutput
+var docLintOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path":     map[string]any{"type": "string"},
+		"packages": map[string]any{"type": "integer", "description": "Number of packages checked"},
+		"counts": map[string]any{
+			"type":                 "object",
+			"description":          "Number of issues of each kind found, including any not listed",
+			"additionalProperties": map[string]any{"type": "integer"},
+		},
+		"truncated": map[string]any{"type": "boolean", "description": "Whether issues were left out at limit"},
+		"issues": map[string]any{
+			"type":        "array",
+			"description": "Issues in file order",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"kind":    map[string]any{"type": "string", "enum": lintKinds},
+					"file":    map[string]any{"type": "string", "description": "Absolute path of the file"},
+					"line":    map[string]any{"type": "integer"},
+					"symbol":  map[string]any{"type": "string", "description": "The identifier concerned, such as Client.Do, or 'package' for package comments"},
+					"message": map[string]any{"type": "string"},
+				},
+				"required": []string{"kind", "file", "line", "symbol", "message"},
+			},
+		},
+	},
+	Required: []string{"path", "packages", "counts", "truncated", "issues"},
+}
+
+// docLintOutput is the structured content of a doc_lint result
+type docLintOutput struct {
+	Path      string         `json:"path"`
+	Packages  int            `json:"packages"`
+	Counts    map[string]int `json:"counts"`
+	Truncated bool           `json:"truncated"`
+	Issues    []lintIssue    `json:"issues"`
+}
+
+// lintIssue is a problem with the documentation of an identifier
+type lintIssue struct {
+	Kind    string `json:"kind"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Symbol  string `json:"symbol"`
+	Message string `json:"message"`
+}
+
+// handleDocLint implements the doc_lint tool
+func (s *GodocServer) handleDocLint(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if err := s.runtime.Load().toolchainErr; err != nil {
+		return errorResultFromErr("cannot load packages", err), nil
+	}
+	path := request.GetString("path", "")
+	if path == "" {
+		return errorResult(codeInvalidArgument, "invalid or missing path parameter"), nil
+	}
+	kinds := request.GetStringSlice("kinds", lintKinds)
+	for _, kind := range kinds {
+		if !slices.Contains(lintKinds, kind) {
+			return errorResult(codeInvalidArgument, fmt.Sprintf("unknown issue kind %q; kinds are %s", kind, strings.Join(lintKinds, ", "))), nil
+		}
+	}
+	limit := request.GetInt("limit", defaultLintIssues)
+	if limit < 1 || limit > maxLintIssues {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("limit must be between 1 and %d, got %d", maxLintIssues, limit)), nil
+	}
+
+	log := ctxLogger(ctx, s.logger).WithField("path", path)
+	progress := s.newProgressReporter(ctx, request)
+	ctx = withProgress(ctx, progress)
+	dir, err := s.loadDir(ctx, request, path)
+	if err != nil {
+		return errorResultFromErr("failed to find the source of "+path, err), nil
+	}
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return errorResultFromErr("failed to load "+path, err), nil
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Dir:     dir,
+	}, path)
+	release()
+	if err != nil {
+		return errorResultFromErr("failed to load "+path, err), nil
+	}
+
+	out := &docLintOutput{Path: path, Counts: make(map[string]int), Issues: make([]lintIssue, 0)}
+	var errs []string
+	var all []lintIssue
+	for _, pkg := range pkgs {
+		if len(pkg.Syntax) == 0 {
+			for _, e := range pkg.Errors {
+				errs = append(errs, e.Msg)
+			}
+			continue
+		}
+		out.Packages++
+		all = append(all, lintPackage(pkg)...)
+	}
+	if out.Packages == 0 {
+		msg := fmt.Sprintf("no Go packages match %s", path)
+		if len(errs) > 0 {
+			msg = strings.Join(errs, "; ")
+		}
+		return errorResultFromErr("failed to load "+path, withCode(codePkgNotFound, errors.New(msg))), nil
+	}
+	slices.SortStableFunc(all, func(a, b lintIssue) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line))
+	})
+	for _, issue := range all {
+		if !slices.Contains(kinds, issue.Kind) {
+			continue
+		}
+		out.Counts[issue.Kind]++
+		if len(out.Issues) < limit {
+			out.Issues = append(out.Issues, issue)
+		} else {
+			out.Truncated = true
+		}
+	}
+	log.WithFields(logrus.Fields{"packages": out.Packages, "issues": len(out.Issues)}).Debug("Checked doc comments")
+
+	var issues []string
+	for _, kind := range lintKinds {
+		if n := out.Counts[kind]; n > 0 {
+			issues = append(issues, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	var sb strings.Builder
+	if len(issues) == 0 {
+		fmt.Fprintf(&sb, "No documentation issues in %s.", path)
+	} else {
+		fmt.Fprintf(&sb, "Documentation issues in %s: %s", path, strings.Join(issues, ", "))
+		if out.Truncated {
+			fmt.Fprintf(&sb, "; first %d listed, narrow kinds or path, or raise limit, to see more", len(out.Issues))
+		}
+		sb.WriteString(".\n\n")
+	}
+	for _, issue := range out.Issues {
+		fmt.Fprintf(&sb, "%s:%d: %s: %s\n", issue.File, issue.Line, issue.Kind, issue.Message)
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// lintPackage returns the documentation issues of pkg's exported
+// identifiers, skipping generated files
+func lintPackage(pkg *packages.Package) []lintIssue {
+	files := slices.DeleteFunc(slices.Clone(pkg.Syntax), ast.IsGenerated)
+	if len(files) == 0 {
+		return nil
+	}
+	dp, err := doc.NewFromFiles(pkg.Fset, files, pkg.PkgPath, doc.PreserveAST)
+	if err != nil {
+		return nil
+	}
+	l := &docLinter{fset: pkg.Fset, parser: dp.Parser()}
+
+	if dp.Name != "main" {
+		var pkgDoc *ast.CommentGroup
+		for _, f := range files {
+			if f.Doc != nil {
+				pkgDoc = f.Doc
+				break
+			}
+		}
+		switch {
+		case pkgDoc == nil:
+			l.add("missing", files[0].Name.Pos(), "package", "package "+dp.Name+" has no package comment")
+		case !strings.HasPrefix(pkgDoc.Text(), "Package "+dp.Name+" "):
+			l.add("name", pkgDoc.Pos(), "package", fmt.Sprintf("package comment should begin with %q", "Package "+dp.Name))
+		}
+		l.links("package", pkgDoc)
+	}
+
+	values := func(values []*doc.Value, kind string) {
+		for _, v := range values {
+			l.value(v, kind)
+		}
+	}
+	funcs := func(funcs []*doc.Func) {
+		for _, fn := range funcs {
+			l.function(fn)
+		}
+	}
+	values(dp.Consts, "constant")
+	values(dp.Vars, "variable")
+	funcs(dp.Funcs)
+	for _, t := range dp.Types {
+		l.typ(t)
+		values(t.Consts, "constant")
+		values(t.Vars, "variable")
+		funcs(t.Funcs)
+		funcs(t.Methods)
+	}
+	return l.issues
+}
+
+// docLinter collects the documentation issues of a package
+type docLinter struct {
+	fset   *token.FileSet
+	parser *comment.Parser
+	issues []lintIssue
+}
+
+// add records an issue at pos
+func (l *docLinter) add(kind string, pos token.Pos, symbol, message string) {
+	p := l.fset.Position(pos)
+	l.issues = append(l.issues, lintIssue{Kind: kind, File: p.Filename, Line: p.Line, Symbol: symbol, Message: message})
+}
+
+// function checks the doc comment of a function or method
+func (l *docLinter) function(fn *doc.Func) {
+	// Methods promoted from embedded types are checked where they're declared
+	if fn.Level > 0 {
+		return
+	}
+	name, kind := fn.Name, "function"
+	if fn.Decl.Recv != nil {
+		name, kind = receiverName(fn.Decl.Recv)+"."+fn.Name, "method"
+	}
+	l.named(fn.Decl.Doc, fn.Decl.Name.Pos(), name, kind, fn.Name, false)
+}
+
+// typ checks the doc comment of a type and those of its exported fields
+// and interface methods
+func (l *docLinter) typ(t *doc.Type) {
+	spec, ok := t.Decl.Specs[0].(*ast.TypeSpec)
+	if !ok {
+		return
+	}
+	l.named(t.Decl.Doc, spec.Name.Pos(), t.Name, "type", t.Name, true)
+	var fields *ast.FieldList
+	switch st := spec.Type.(type) {
+	case *ast.StructType:
+		fields = st.Fields
+	case *ast.InterfaceType:
+		fields = st.Methods
+	}
+	if fields == nil {
+		return
+	}
+	for _, field := range fields.List {
+		for _, n := range field.Names {
+			if n.IsExported() {
+				l.links(t.Name+"."+n.Name, field.Doc)
+			}
+		}
+	}
+}
+
+// value checks the doc comments of a const or var declaration. A group's
+// comment documents all its specs; otherwise each spec needs one, and a
+// group with none, like an undocumented iota enumeration, is one issue.
+func (l *docLinter) value(v *doc.Value, kind string) {
+	decl := v.Decl
+	if decl.Doc != nil {
+		first := v.Names[0]
+		if !decl.Lparen.IsValid() && len(v.Names) == 1 {
+			l.named(decl.Doc, decl.Specs[0].Pos(), first, kind, first, false)
+		} else {
+			l.links(first, decl.Doc)
+		}
+	}
+	if decl.Doc == nil && decl.Lparen.IsValid() && !slices.ContainsFunc(decl.Specs, func(spec ast.Spec) bool {
+		vs, ok := spec.(*ast.ValueSpec)
+		return ok && (vs.Doc != nil || vs.Comment != nil)
+	}) {
+		l.add("missing", decl.Pos(), v.Names[0], fmt.Sprintf("exported %ss %s have no doc comment, on their group or individually", kind, strings.Join(exportedNames(v.Names), ", ")))
+		return
+	}
+	for _, spec := range decl.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		var name string
+		for _, n := range vs.Names {
+			if n.IsExported() {
+				name = n.Name
+				break
+			}
+		}
+		switch {
+		case name == "":
+		case vs.Doc != nil:
+			l.links(name, vs.Doc)
+		case decl.Doc == nil && vs.Comment == nil:
+			l.add("missing", vs.Pos(), name, fmt.Sprintf("exported %s %s has no doc comment", kind, name))
+		}
+	}
+}
+
+// exportedNames returns the exported names among names
+func exportedNames(names []string) []string {
+	return slices.DeleteFunc(slices.Clone(names), func(name string) bool { return !token.IsExported(name) })
+}
+
+// named checks that the doc comment cg of the identifier name, declared at
+// pos, exists and begins with ident, optionally after an article
+func (l *docLinter) named(cg *ast.CommentGroup, pos token.Pos, name, kind, ident string, article bool) {
+	if cg == nil {
+		l.add("missing", pos, name, fmt.Sprintf("exported %s %s has no doc comment", kind, name))
+		return
+	}
+	text := cg.Text()
+	if article {
+		for _, a := range []string{"A ", "An ", "The "} {
+			if rest, ok := strings.CutPrefix(text, a); ok {
+				text = rest
+				break
+			}
+		}
+	}
+	if !startsWithWord(text, ident) && !strings.HasPrefix(text, "Deprecated:") {
+		l.add("name", cg.Pos(), name, fmt.Sprintf("comment on exported %s %s should begin with %q", kind, name, ident))
+	}
+	l.links(name, cg)
+}
+
+// startsWithWord reports whether text begins with the word w
+func startsWithWord(text, w string) bool {
+	rest, ok := strings.CutPrefix(text, w)
+	if !ok {
+		return false
+	}
+	r := []rune(rest)
+	return len(r) == 0 || !unicode.IsLetter(r[0]) && !unicode.IsDigit(r[0]) && r[0] != '_'
+}
+
+// docLinkLike matches bracketed text shaped like a doc link: a name,
+// qualified name, or import path, possibly starred or with parentheses
+var docLinkLike = regexp.MustCompile(`\[(\*?[\w/.]*\w(\(\))?)\]`)
+
+// links reports the bracketed names in the doc comment cg of symbol that
+// look like doc links but don't resolve to one
+func (l *docLinter) links(symbol string, cg *ast.CommentGroup) {
+	if cg == nil {
+		return
+	}
+	text := cg.Text()
+	var plain []string
+	var walk func(blocks []comment.Block)
+	texts := func(ts []comment.Text) {
+		for _, t := range ts {
+			if p, ok := t.(comment.Plain); ok {
+				plain = append(plain, string(p))
+			}
+		}
+	}
+	walk = func(blocks []comment.Block) {
+		for _, b := range blocks {
+			switch b := b.(type) {
+			case *comment.Paragraph:
+				texts(b.Text)
+			case *comment.Heading:
+				texts(b.Text)
+			case *comment.List:
+				for _, item := range b.Items {
+					walk(item.Content)
+				}
+			}
+		}
+	}
+	walk(l.parser.Parse(text).Content)
+
+	var reported []string
+	for _, p := range plain {
+		for _, m := range docLinkLike.FindAllStringSubmatchIndex(p, -1) {
+			link, target := p[m[0]:m[1]], p[m[2]:m[3]]
+			// Doc links stand apart from the words and brackets around them
+			if m[0] > 0 && isWordByte(p[m[0]-1]) || m[1] < len(p) && (isWordByte(p[m[1]]) || strings.ContainsRune("[(", rune(p[m[1]]))) {
+				continue
+			}
+			// Lowercase words like [i] or [sic] aren't meant as links
+			first := strings.TrimPrefix(target, "*")
+			if !strings.ContainsAny(first, "./") && !unicode.IsUpper([]rune(first)[0]) && m[4] < 0 {
+				continue
+			}
+			if slices.Contains(reported, link) {
+				continue
+			}
+			reported = append(reported, link)
+			line := l.fset.Position(cg.Pos()).Line
+			if i := strings.Index(text, link); i >= 0 {
+				line += strings.Count(text[:i], "\n")
+			}
+			p := l.fset.Position(cg.Pos())
+			message := fmt.Sprintf("%s in the comment on %s is not a doc link: no such declaration or imported package", link, symbol)
+			if m[4] >= 0 {
+				message = fmt.Sprintf("%s in the comment on %s is not a doc link: doc links name the function without parentheses, as [%s]", link, symbol, strings.TrimSuffix(target, "()"))
+			}
+			l.issues = append(l.issues, lintIssue{Kind: "link", File: p.Filename, Line: line, Symbol: symbol, Message: message})
+		}
+	}
+}
+
+// isWordByte reports whether c is part of a word, as letters, digits, and
+// underscores are
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestDocLint(t *testing.T) {
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/mod\n\ngo 1.21\n",
+		"lint/lint.go": `// Lint things.
+package lint
+
+// Client does [Client.Do] and [Missing] and [Do()].
+type Client struct {
+	// Addr is the address, see [Nowhere].
+	Addr string
+}
+
+func (c *Client) Do() {}
+
+// Makes a client.
+func NewClient() *Client { return nil }
+
+const (
+	A = 1
+	B = 2
+)
+
+var X = 1
+
+// Deprecated: use NewClient.
+func Old() {}
+`,
+		"lint/gen/gen.go":   "// Code generated by hand. DO NOT EDIT.\n\npackage gen\n\nfunc G() {}\n",
+		"lint/good/good.go": "// Package good is documented.\npackage good\n\n// Hello says hello.\nfunc Hello() {}\n",
+	})
+	all := []string{
+		"1 name package",
+		"4 link Client",
+		"4 link Client",
+		"6 link Client.Addr",
+		"10 missing Client.Do",
+		"12 name NewClient",
+		"15 missing A",
+		"20 missing X",
+	}
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		args      map[string]any
+		wantCode  string
+		want      []string
+		count     int
+		packages  int
+		truncated bool
+	}{
+		{"no path", map[string]any{"working_dir": dir}, codeInvalidArgument, nil, 0, 0, false},
+		{"unknown kind", map[string]any{"path": "./lint", "kinds": []any{"style"}, "working_dir": dir}, codeInvalidArgument, nil, 0, 0, false},
+		{"bad limit", map[string]any{"path": "./lint", "limit": 0, "working_dir": dir}, codeInvalidArgument, nil, 0, 0, false},
+		{"no package", map[string]any{"path": "./lint/none/...", "working_dir": dir}, codePkgNotFound, nil, 0, 0, false},
+		{"all kinds", map[string]any{"path": "./lint", "working_dir": dir}, "", all, 8, 1, false},
+		{"links", map[string]any{"path": "./lint", "kinds": []any{"link"}, "working_dir": dir}, "", all[1:4], 3, 1, false},
+		{"limit", map[string]any{"path": "./lint", "limit": 2, "working_dir": dir}, "", all[:2], 8, 1, true},
+		{"documented", map[string]any{"path": "./lint/good", "working_dir": dir}, "", nil, 0, 1, false},
+		// Generated files are skipped
+		{"packages below", map[string]any{"path": "./lint/...", "working_dir": dir}, "", all, 8, 3, false},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleDocLint, "doc_lint", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*docLintOutput)
+		var got []string
+		for _, issue := range out.Issues {
+			got = append(got, fmt.Sprintf("%d %s %s", issue.Line, issue.Kind, issue.Symbol))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: issues\n%q\nwant\n%q\n%s", tt.name, got, tt.want, resultText(result))
+		}
+		count := 0
+		for _, n := range out.Counts {
+			count += n
+		}
+		if count != tt.count || out.Packages != tt.packages || out.Truncated != tt.truncated {
+			t.Errorf("%s: %d issues in %d packages, truncated %v; want %d in %d, %v", tt.name, count, out.Packages, out.Truncated, tt.count, tt.packages, tt.truncated)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// Units of a docRange
+const (
+	rangeLines = "lines"
+	rangeBytes = "bytes"
+)
+
+// rangeArguments are the input schemas of the get_doc range arguments
+var rangeArguments = map[string]any{
+	"start_line": map[string]any{
+		"type":        "integer",
+		"description": "Optional: 1-based first line of an explicit range of the document to return instead of a page. With doc_id and no path, the range is read from that document as returned earlier in this session.",
+		"minimum":     1,
+	},
+	"end_line": map[string]any{
+		"type":        "integer",
+		"description": "Optional: Last line of the range started by start_line, inclusive. Defaults to a page's worth of lines.",
+		"minimum":     1,
+	},
+	"start_byte": map[string]any{
+		"type":        "integer",
+		"description": "Optional: 0-based byte offset of an explicit range of the document to return instead of a page, as for start_line.",
+		"minimum":     0,
+	},
+	"end_byte": map[string]any{
+		"type":        "integer",
+		"description": "Optional: Byte offset ending the range started by start_byte, exclusive. Defaults to the end of the document.",
+		"minimum":     1,
+	},
+}
+
+// docRange is an explicit range of a document requested instead of a page.
+// Line ranges are 1-based and inclusive; byte ranges are 0-based with an
+// exclusive end. A zero end means the default end.
+type docRange struct {
+	Unit  string `json:"unit"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	// Total is the document's length in the range's unit
+	Total int `json:"total"`
+}
+
+// requestedRange returns the range of the document a get_doc request asks for,
+// or nil if it asks for a page
+func requestedRange(request mcp.CallToolRequest) (*docRange, error) {
+	args := request.GetArguments()
+	_, startLine := args["start_line"]
+	_, endLine := args["end_line"]
+	_, startByte := args["start_byte"]
+	_, endByte := args["end_byte"]
+	switch {
+	case (startLine || endLine) && (startByte || endByte):
+		return nil, fmt.Errorf("request a line range or a byte range, not both")
+	case startLine:
+		r := &docRange{Unit: rangeLines, Start: request.GetInt("start_line", 0), End: request.GetInt("end_line", 0)}
+		if r.Start < 1 || endLine && r.End < r.Start {
+			return nil, fmt.Errorf("line range %d-%d is invalid: start_line must be at least 1 and end_line at least start_line", r.Start, r.End)
+		}
+		return r, nil
+	case startByte:
+		r := &docRange{Unit: rangeBytes, Start: request.GetInt("start_byte", 0), End: request.GetInt("end_byte", 0)}
+		if r.Start < 0 || endByte && r.End <= r.Start {
+			return nil, fmt.Errorf("byte range %d-%d is invalid: start_byte must not be negative and end_byte must be past it", r.Start, r.End)
+		}
+		return r, nil
+	case endLine:
+		return nil, fmt.Errorf("end_line requires start_line")
+	case endByte:
+		return nil, fmt.Errorf("end_byte requires start_byte")
+	}
+	return nil, nil
+}
+
+// heldRange returns a range of the document docID returned earlier in the
+// session, which is held like the documents continuation cursors resume
+func (s *GodocServer) heldRange(ctx context.Context, log *logrus.Entry, docID string, r docRange) *mcp.CallToolResult {
+	item := s.cursorDocs.Get(cursorKey(sessionID(ctx), docID))
+	if item == nil {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("document %s is no longer held; request it again with its path", docID))
+	}
+	held := item.Value()
+	result := s.rangeOf(log, held.doc, r)
+	if out, ok := result.StructuredContent.(*docOutput); ok {
+		out.Package, out.Symbol, out.Version = held.pkg, held.symbol, held.src.version
+		addDocLink(result, held.src, out)
+	}
+	return result
+}
+
+// rangeOf returns the range r of doc. Ranges are capped at the largest page
+// size in lines and the response size limit in bytes; byte ranges are
+// narrowed to whole UTF-8 characters.
+func (s *GodocServer) rangeOf(log *logrus.Entry, doc cachedDoc, r docRange) *mcp.CallToolResult {
+	limits := s.config.Load().Pagination
+	lines := doc.lines
+	var content string
+	var capped bool
+	var firstLine, lastLine int
+	switch r.Unit {
+	case rangeLines:
+		r.Total = len(lines)
+		if r.Start > r.Total {
+			return errorResult(codeInvalidArgument, fmt.Sprintf("start_line %d is past the end of the document (%d lines)", r.Start, r.Total))
+		}
+		if r.End == 0 {
+			r.End = r.Start + limits.DefaultPageSize - 1
+		}
+		r.End = min(r.End, r.Total)
+		if r.End-r.Start+1 > limits.MaxPageSize {
+			r.End, capped = r.Start+limits.MaxPageSize-1, true
+		}
+		if limit := limits.MaxResponseBytes; limit > 0 {
+			size := 0
+			for i := r.Start - 1; i < r.End; i++ {
+				size += len(lines[i]) + 1
+				if size > limit && i > r.Start-1 {
+					r.End, capped = i, true
+					break
+				}
+			}
+		}
+		content = strings.Join(lines[r.Start-1:r.End], "\n")
+		firstLine, lastLine = r.Start, r.End
+	case rangeBytes:
+		full := strings.Join(lines, "\n")
+		r.Total = len(full)
+		if r.Start >= r.Total {
+			return errorResult(codeInvalidArgument, fmt.Sprintf("start_byte %d is past the end of the document (%d bytes)", r.Start, r.Total))
+		}
+		if r.End == 0 || r.End > r.Total {
+			r.End = r.Total
+		}
+		if limit := limits.MaxResponseBytes; limit > 0 && r.End-r.Start > limit {
+			r.End, capped = r.Start+limit, true
+		}
+		for r.Start < r.End && !utf8.RuneStart(full[r.Start]) {
+			r.Start++
+		}
+		for r.End < r.Total && r.End > r.Start && !utf8.RuneStart(full[r.End]) {
+			r.End--
+		}
+		content = full[r.Start:r.End]
+		firstLine = strings.Count(full[:r.Start], "\n") + 1
+		lastLine = firstLine + strings.Count(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	metadata := fmt.Sprintf("Lines %d-%d of %d (doc_id %s)", r.Start, r.End, r.Total, doc.id)
+	if r.Unit == rangeBytes {
+		metadata = fmt.Sprintf("Bytes %d-%d of %d, lines %d-%d (doc_id %s)", r.Start, r.End, r.Total, firstLine, lastLine, doc.id)
+	}
+	if capped {
+		metadata += "\nRange shortened to the server's page size or response size limit; request the rest as a further range"
+	}
+
+	log.WithFields(logrus.Fields{
+		"doc_id": doc.id,
+		"unit":   r.Unit,
+		"start":  r.Start,
+		"end":    r.End,
+	}).Debug("Returning documentation range")
+	result := mcp.NewToolResultText(metadata + "\n\n" + content)
+	result.StructuredContent = &docOutput{
+		DocID: doc.id,
+		Range: &r,
+		Pagination: pageInfo{
+			PageSize:   limits.MaxPageSize,
+			TotalLines: len(lines),
+			FirstLine:  firstLine,
+			LastLine:   lastLine,
+			HasMore:    lastLine < len(lines),
+			Truncated:  capped,
+		},
+	}
+	if doc.stderr != "" {
+		result.Meta = mcp.NewMetaFromMap(map[string]any{"stderr": doc.stderr})
+	}
+	return result
+}
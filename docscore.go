@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/packages"
+)
+
+// docScoreArgument is the input schema of the doc_score argument
+var docScoreArgument = map[string]any{
+	"type":        "boolean",
+	"description": "Optional: Attach a documentation completeness score for the package: the share of its exported symbols with a doc comment, whether it has a package comment, and how many examples it has, combined into a score out of 100. Useful for comparing competing libraries. Loads the package and its tests, so it adds a moment to the request.",
+	"default":     false,
+}
+
+// docScoreSchema describes docScore in output schemas
+var docScoreSchema = map[string]any{
+	"type":        "object",
+	"description": "Documentation completeness of the package, when doc_score was requested",
+	"properties": map[string]any{
+		"score":       map[string]any{"type": "integer", "description": "Out of 100: 80 for the share of exported symbols documented, 10 for a package comment, and 10 for having examples"},
+		"exported":    map[string]any{"type": "integer", "description": "Exported constants, variables, functions, types, and methods"},
+		"documented":  map[string]any{"type": "integer", "description": "Exported symbols with a doc comment"},
+		"percent":     map[string]any{"type": "number", "description": "Percentage of exported symbols documented"},
+		"package_doc": map[string]any{"type": "boolean", "description": "Whether the package has a package comment"},
+		"examples":    map[string]any{"type": "integer", "description": "Testable examples of the package and its symbols"},
+	},
+	"required": []string{"score", "exported", "documented", "percent", "package_doc", "examples"},
+}
+
+// docScore measures how completely a package is documented
+type docScore struct {
+	Score      int     `json:"score"`
+	Exported   int     `json:"exported"`
+	Documented int     `json:"documented"`
+	Percent    float64 `json:"percent"`
+	PackageDoc bool    `json:"package_doc"`
+	Examples   int     `json:"examples"`
+}
+
+// String summarizes the score on one line
+func (ds *docScore) String() string {
+	parts := []string{fmt.Sprintf("%.0f%% of %d exported symbols documented", ds.Percent, ds.Exported)}
+	if ds.PackageDoc {
+		parts = append(parts, "package comment")
+	} else {
+		parts = append(parts, "no package comment")
+	}
+	parts = append(parts, fmt.Sprintf("%d examples", ds.Examples))
+	return fmt.Sprintf("%d/100 (%s)", ds.Score, strings.Join(parts, ", "))
+}
+
+// docScoreFor returns the documentation score of the package req documents,
+// or a warning explaining why there is none
+func (s *GodocServer) docScoreFor(ctx context.Context, log *logrus.Entry, req docRequest) (*docScore, string) {
+	if req.source != "" {
+		return nil, "documentation score unavailable: the package couldn't be loaded locally"
+	}
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, "documentation score unavailable: " + err.Error()
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Dir:     req.workingDir,
+		Env:     buildEnviron(ctx),
+	}, req.path)
+	release()
+	if err == nil && (len(pkgs) != 1 || len(pkgs[0].Syntax) == 0) {
+		err = fmt.Errorf("no Go files in package %s", req.path)
+	}
+	if err != nil {
+		log.WithError(err).Debug("Failed to load package for its documentation score")
+		return nil, "documentation score unavailable: " + err.Error()
+	}
+	pkg := pkgs[0]
+	files := slices.Concat(pkg.Syntax, parseTestFiles(pkg.Fset, filepath.Dir(pkg.GoFiles[0])))
+	dp, err := doc.NewFromFiles(pkg.Fset, files, pkg.PkgPath, doc.PreserveAST)
+	if err != nil {
+		return nil, "documentation score unavailable: " + err.Error()
+	}
+	return scorePackage(dp), ""
+}
+
+// scorePackage measures the documentation of the exported symbols of dp. The
+// names of a const or var group are documented by the group's comment or
+// their own.
+func scorePackage(dp *doc.Package) *docScore {
+	ds := &docScore{PackageDoc: dp.Doc != "", Examples: len(dp.Examples)}
+	count := func(documented bool) {
+		ds.Exported++
+		if documented {
+			ds.Documented++
+		}
+	}
+	values := func(values []*doc.Value) {
+		for _, v := range values {
+			for _, spec := range v.Decl.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				for _, n := range vs.Names {
+					if n.IsExported() {
+						count(v.Doc != "" || vs.Doc != nil || vs.Comment != nil)
+					}
+				}
+			}
+		}
+	}
+	funcs := func(funcs []*doc.Func) {
+		for _, fn := range funcs {
+			// Methods promoted from embedded types count where they're declared
+			if fn.Level == 0 {
+				count(fn.Doc != "")
+			}
+			ds.Examples += len(fn.Examples)
+		}
+	}
+	values(dp.Consts)
+	values(dp.Vars)
+	funcs(dp.Funcs)
+	for _, t := range dp.Types {
+		count(t.Doc != "")
+		ds.Examples += len(t.Examples)
+		values(t.Consts)
+		values(t.Vars)
+		funcs(t.Funcs)
+		funcs(t.Methods)
+	}
+
+	ds.Percent = 100
+	if ds.Exported > 0 {
+		ds.Percent = math.Round(float64(ds.Documented)*1000/float64(ds.Exported)) / 10
+	}
+	score := 0.8 * ds.Percent
+	if ds.PackageDoc {
+		score += 10
+	}
+	if ds.Examples > 0 {
+		score += 10
+	}
+	ds.Score = int(math.Round(score))
+	return ds
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const docSizeDescription = `Report how large a get_doc request's documentation is without returning it.
+Takes the same path, target, cmd_flags, working_dir, and format as get_doc, and reports the
+document's lines, bytes, estimated tokens, and how many pages it takes at the requested page_size or
+page_size_tokens. Use it before fetching -all documentation to decide whether to read it whole or
+symbol by symbol. The documentation is generated and cached, so a following get_doc call for it is
+served from the cache.`
+
+// newDocSizeSchema creates the doc_size input schema from the get_doc arguments it shares
+func newDocSizeSchema(p PaginationConfig) mcp.ToolInputSchema {
+	docSchema := newDocInputSchema(p)
+	properties := make(map[string]any)
+	for _, name := range []string{"path", "target", "cmd_flags", "working_dir", "format", "page_size", "page_size_tokens", "goos", "goarch", "tags", "cgo_enabled", "env"} {
+		properties[name] = docSchema.Properties[name]
+	}
+	return mcp.ToolInputSchema{
+		Type:       "object",
+		Properties: properties,
+		Required:   []string{"path"},
+	}
+}
+
+// docSizeOutputSchema is the outputSchema of doc_size, describing docSizeOutput
+var docSizeOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"package":          map[string]any{"type": "string", "description": "Import path of the documented package"},
+		"symbol":           map[string]any{"type": "string", "description": "The documented target symbol, if any"},
+		"doc_id":           map[string]any{"type": "string", "description": "Identifier of the document's content, as reported by get_doc"},
+		"lines":            map[string]any{"type": "integer", "description": "Number of lines in the document"},
+		"bytes":            map[string]any{"type": "integer", "description": "Size of the document in bytes"},
+		"tokens":           map[string]any{"type": "integer", "description": "Estimated size of the document in model tokens, by the server's tokenizer"},
+		"pages":            map[string]any{"type": "integer", "description": "Number of get_doc pages the document takes"},
+		"page_size":        map[string]any{"type": "integer", "description": "Maximum number of lines per page the page count assumes"},
+		"page_size_tokens": map[string]any{"type": "integer", "description": "Token budget per page the page count assumes, when page_size_tokens was requested"},
+	},
+	Required: []string{"package", "doc_id", "lines", "bytes", "tokens", "pages", "page_size"},
+}
+
+// docSizeOutput is the structured content of a doc_size result
+type docSizeOutput struct {
+	Package        string `json:"package"`
+	Symbol         string `json:"symbol,omitempty"`
+	DocID          string `json:"doc_id"`
+	Lines          int    `json:"lines"`
+	Bytes          int    `json:"bytes"`
+	Tokens         int    `json:"tokens"`
+	Pages          int    `json:"pages"`
+	PageSize       int    `json:"page_size"`
+	PageSizeTokens int    `json:"page_size_tokens,omitempty"`
+}
+
+// handleDocSize implements the doc_size tool
+func (s *GodocServer) handleDocSize(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sizing := s.requestSizing(ctx, request)
+	limits := s.config.Load().Pagination
+	if err := sizing.validate(limits); err != nil {
+		return errorResult(codeInvalidArgument, err.Error()), nil
+	}
+	env, err := requestBuildEnv(request)
+	if err != nil {
+		return errorResult(codeInvalidArgument, err.Error()), nil
+	}
+	req, doc, failed := s.loadDoc(withBuildEnv(ctx, env), request)
+	if failed != nil {
+		return failed, nil
+	}
+
+	count := tokenizers[limits.Tokenizer]
+	tokens := 0
+	for _, line := range doc.lines {
+		tokens += count(line)
+	}
+	out := &docSizeOutput{
+		Package:        req.path,
+		Symbol:         req.target,
+		DocID:          doc.id,
+		Lines:          len(doc.lines),
+		Bytes:          doc.byteSize,
+		Tokens:         tokens,
+		Pages:          len(sizing.pageStarts(doc.lines)),
+		PageSize:       sizing.lines,
+		PageSizeTokens: sizing.tokens,
+	}
+
+	var sb strings.Builder
+	name := out.Package
+	if out.Symbol != "" {
+		name += "." + out.Symbol
+	}
+	if len(req.cmdFlags) > 0 {
+		name += " " + strings.Join(req.cmdFlags, " ")
+	}
+	fmt.Fprintf(&sb, "%s: %d lines, %d bytes, ~%d tokens (doc_id %s)\n", name, out.Lines, out.Bytes, out.Tokens, out.DocID)
+	if sizing.tokens > 0 {
+		fmt.Fprintf(&sb, "Pages: %d of up to ~%d tokens\n", out.Pages, sizing.tokens)
+	} else {
+		fmt.Fprintf(&sb, "Pages: %d of up to %d lines\n", out.Pages, sizing.lines)
+	}
+	if out.Pages > 1 && out.Symbol == "" {
+		sb.WriteString("Reading it whole takes several get_doc calls; to read less, get the package overview without -all and look up the symbols you need with target.\n")
+	}
+	for _, w := range req.warnings {
+		sb.WriteString("Warning: " + w + "\n")
+	}
+	result := mcp.NewToolResultText(sb.String())
+	result.StructuredContent = out
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDocSize(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		want     string
+	}{
+		{"package", map[string]any{"path": "io"}, "", "io: "},
+		{"all by lines", map[string]any{"path": "io", "cmd_flags": []any{"-all"}, "page_size": 100}, "", "Reading it whole takes several get_doc calls"},
+		{"all by tokens", map[string]any{"path": "io", "cmd_flags": []any{"-all"}, "page_size_tokens": 500}, "", "of up to ~500 tokens"},
+		{"symbol", map[string]any{"path": "io", "target": "Reader"}, "", "io.Reader: "},
+		{"platform", map[string]any{"path": "os", "target": "ProcAttr", "goos": "windows"}, "", "os.ProcAttr: "},
+		{"no path", map[string]any{}, codeInvalidArgument, ""},
+		{"page size too small", map[string]any{"path": "io", "page_size": 1}, codeInvalidArgument, ""},
+		{"unknown goos", map[string]any{"path": "io", "goos": "plan10"}, codeInvalidArgument, ""},
+		{"unknown symbol", map[string]any{"path": "io", "target": "NoSuchSymbol"}, codeSymbolNotFound, ""},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleDocSize, "doc_size", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		if text := resultText(result); !strings.Contains(text, tt.want) {
+			t.Errorf("%s: result %q lacks %q", tt.name, text, tt.want)
+		}
+		size := result.StructuredContent.(*docSizeOutput)
+
+		// The size is that of the document get_doc pages through
+		args := map[string]any{"oversize": "page"}
+		for k, v := range tt.args {
+			args[k] = v
+		}
+		result = callTool(t, ctx, s.handleToolCall, "get_doc", args)
+		doc, ok := result.StructuredContent.(*docOutput)
+		if !ok {
+			t.Fatalf("%s: get_doc: %s", tt.name, resultText(result))
+		}
+		if size.DocID != doc.DocID || size.Lines != doc.Pagination.TotalLines || size.Pages != doc.Pagination.TotalPages {
+			t.Errorf("%s: doc_size reports %s of %d lines in %d pages, get_doc %s of %d lines in %d pages", tt.name,
+				size.DocID, size.Lines, size.Pages, doc.DocID, doc.Pagination.TotalLines, doc.Pagination.TotalPages)
+		}
+		if size.Bytes < size.Lines || size.Tokens <= 0 {
+			t.Errorf("%s: %d bytes and %d tokens for %d lines", tt.name, size.Bytes, size.Tokens, size.Lines)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	goversion "go/version"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// minGoVersion is the oldest toolchain whose go doc output the server supports
+const minGoVersion = "go1.21"
+
+// doctorCheck is a single environment diagnostic run by -doctor
+type doctorCheck struct {
+	name string
+	// run returns a short description of what was found, or an error
+	run func(ctx context.Context, env map[string]string) (string, error)
+	// fix describes how to resolve a failure
+	fix string
+}
+
+var doctorChecks = []doctorCheck{
+	{
+		name: "Go toolchain",
+		run:  checkGoVersion,
+		fix:  "install Go " + strings.TrimPrefix(minGoVersion, "go") + " or newer from https://go.dev/dl and make sure `go` is on the PATH of the MCP server process",
+	},
+	{
+		name: "Module cache",
+		run:  checkModCacheWritable,
+		fix:  "set GOMODCACHE (or GOPATH) in the MCP server environment to a directory the server user can write",
+	},
+	{
+		name: "Temp directory",
+		run: func(context.Context, map[string]string) (string, error) {
+			return os.TempDir(), checkTempDirWritable()
+		},
+		fix: "set TMPDIR to a writable directory; temporary projects are created there",
+	},
+	{
+		name: "go doc",
+		run:  checkGoDocWorks,
+		fix:  "run `go doc fmt.Println` as the server user and resolve any errors it prints",
+	},
+	{
+		name: "Module proxy",
+		run:  checkProxyReachable,
+		fix:  "check network access to GOPROXY, or set GOPROXY/GOPRIVATE for your environment; only external packages need the proxy",
+	},
+}
+
+// runDoctor runs every diagnostic, writes a report to w, and reports whether all passed
+func runDoctor(ctx context.Context, w io.Writer) bool {
+	env, err := goEnv(ctx, "GOVERSION", "GOROOT", "GOMODCACHE", "GOPROXY", "GOFLAGS")
+	if err != nil {
+		env = map[string]string{}
+	}
+
+	ok := true
+	for _, c := range doctorChecks {
+		found, err := c.run(ctx, env)
+		if err != nil {
+			ok = false
+			fmt.Fprintf(w, "[FAIL] %s: %v\n", c.name, err)
+			fmt.Fprintf(w, "       fix: %s\n", c.fix)
+			continue
+		}
+		fmt.Fprintf(w, "[ ok ] %s: %s\n", c.name, found)
+	}
+	if ok {
+		fmt.Fprintln(w, "\nAll checks passed.")
+	} else {
+		fmt.Fprintln(w, "\nSome checks failed; documentation requests may not work until they are fixed.")
+	}
+	return ok
+}
+
+// goEnv returns the values of the given go env variables
+func goEnv(ctx context.Context, keys ...string) (map[string]string, error) {
+	out, err := exec.CommandContext(ctx, "go", append([]string{"env", "-json"}, keys...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("go env failed: %v", err)
+	}
+	env := make(map[string]string)
+	if err := json.Unmarshal(out, &env); err != nil {
+		return nil, fmt.Errorf("failed to parse go env output: %v", err)
+	}
+	return env, nil
+}
+
+// checkGoVersion verifies the toolchain is installed and recent enough
+func checkGoVersion(_ context.Context, env map[string]string) (string, error) {
+	if err := checkGoToolchain(); err != nil {
+		return "", err
+	}
+	v := env["GOVERSION"]
+	if v == "" {
+		return "", fmt.Errorf("could not determine go version")
+	}
+	if goversion.Compare(goversion.Lang(v), minGoVersion) < 0 {
+		return "", fmt.Errorf("%s is older than the minimum supported %s", v, minGoVersion)
+	}
+	return fmt.Sprintf("%s (GOROOT %s)", v, env["GOROOT"]), nil
+}
+
+// checkModCacheWritable verifies modules can be downloaded into GOMODCACHE
+func checkModCacheWritable(_ context.Context, env map[string]string) (string, error) {
+	dir := env["GOMODCACHE"]
+	if dir == "" {
+		return "", fmt.Errorf("GOMODCACHE is not set")
+	}
+	// The cache may not exist yet; it is created on first download
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("cannot create %s: %v", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".godoc-mcp-doctor-*")
+	if err != nil {
+		return "", fmt.Errorf("%s is not writable: %v", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return dir, nil
+}
+
+// checkGoDocWorks runs a trivial go doc lookup
+func checkGoDocWorks(ctx context.Context, _ map[string]string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "go", "doc", "fmt.Println")
+	cmd.Dir = os.TempDir()
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("go doc fmt.Println failed: %v\n       output: %s", err, strings.TrimSpace(string(out)))
+	}
+	return "go doc fmt.Println succeeded", nil
+}
+
+// checkProxyReachable verifies the first configured module proxy answers HTTP requests
+func checkProxyReachable(ctx context.Context, env map[string]string) (string, error) {
+	var proxy string
+	for _, p := range strings.FieldsFunc(env["GOPROXY"], func(r rune) bool { return r == ',' || r == '|' }) {
+		if p == "off" {
+			return "GOPROXY=off, external packages must already be in the module cache", nil
+		}
+		if p != "direct" {
+			proxy = p
+			break
+		}
+	}
+	if proxy == "" {
+		return "GOPROXY=direct, modules are fetched from their origin repositories", nil
+	}
+	if strings.HasPrefix(proxy, "file://") {
+		dir := filepath.FromSlash(strings.TrimPrefix(proxy, "file://"))
+		if _, err := os.Stat(dir); err != nil {
+			return "", fmt.Errorf("file proxy %s is not accessible: %v", proxy, err)
+		}
+		return proxy, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, proxy, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid proxy URL %q: %v", proxy, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s is unreachable: %v", proxy, err)
+	}
+	resp.Body.Close()
+	return fmt.Sprintf("%s (HTTP %d)", proxy, resp.StatusCode), nil
+}
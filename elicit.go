@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// elicitTimeout bounds how long a tool call waits for the user to answer
+const elicitTimeout = 2 * time.Minute
+
+// elicitString asks the user of the client in ctx for the string field, limited
+// to choices when there are any. It reports false when the client does not
+// support elicitation or the user declined, cancelled, or did not answer.
+func (s *GodocServer) elicitString(ctx context.Context, message, field, description string, choices []string) (string, bool) {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	mcpServer := server.ServerFromContext(ctx)
+	if !ok || mcpServer == nil || session.GetClientCapabilities().Elicitation == nil {
+		return "", false
+	}
+
+	property := map[string]any{
+		"type":        "string",
+		"description": description,
+	}
+	if len(choices) > 0 {
+		property["enum"] = choices
+	}
+	log := ctxLogger(ctx, s.logger).WithField("field", field)
+	ctx, cancel := context.WithTimeout(ctx, elicitTimeout)
+	defer cancel()
+	result, err := mcpServer.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message: message,
+			RequestedSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{field: property},
+				"required":   []string{field},
+			},
+		},
+	})
+	if err != nil {
+		log.WithError(err).Warn("Failed to elicit a value from the client")
+		return "", false
+	}
+	log = log.WithField("action", result.Action)
+	if result.Action != mcp.ElicitationResponseActionAccept {
+		log.Debug("Elicitation not accepted")
+		return "", false
+	}
+	content, _ := result.Content.(map[string]any)
+	value, _ := content[field].(string)
+	if value == "" || (len(choices) > 0 && !slices.Contains(choices, value)) {
+		log.WithField("value", value).Warn("Elicitation answered with an invalid value")
+		return "", false
+	}
+	log.WithField("value", value).Debug("Elicited value")
+	return value, true
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Error codes classify failed tool calls so clients can branch on the kind of
+// failure instead of matching the human-readable message
+const (
+	codePkgNotFound       = "PKG_NOT_FOUND"
+	codeSymbolNotFound    = "SYMBOL_NOT_FOUND"
+	codeNetworkFetch      = "NETWORK_FETCH_FAILED"
+	codeBuildConstraints  = "BUILD_CONSTRAINTS"
+	codeTimeout           = "TIMEOUT"
+	codeCancelled         = "CANCELLED"
+	codeInvalidWorkingDir = "INVALID_WORKING_DIR"
+	codeInvalidArgument   = "INVALID_ARGUMENT"
+	codeDocChanged        = "DOC_CHANGED"
+	codeServerBusy        = "SERVER_BUSY"
+	codeDocFailed         = "DOC_FAILED"
+	codeUnsupported       = "UNSUPPORTED"
+	codeGoToolchain       = "GO_TOOLCHAIN"
+	codeEmbeddingFailed   = "EMBEDDING_FAILED"
+	codeInternal          = "INTERNAL"
+)
+
+// codedError attaches an error code to an error
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+
+func (e *codedError) Unwrap() error { return e.err }
+
+// withCode returns err classified under code
+func withCode(code string, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// errorCode returns the code err is classified under, defaulting to DOC_FAILED
+func errorCode(err error) string {
+	var ce *codedError
+	switch {
+	case errors.As(err, &ce):
+		return ce.code
+	case errors.Is(err, exec.ErrNotFound):
+		return codeGoToolchain
+	case errors.Is(err, errServerBusy):
+		return codeServerBusy
+	case errors.Is(err, context.DeadlineExceeded):
+		return codeTimeout
+	case errors.Is(err, context.Canceled):
+		return codeCancelled
+	default:
+		return codeDocFailed
+	}
+}
+
+// classifyOutput returns the error code matching the output of a failed go
+// command, or "" when it isn't recognized
+func classifyOutput(out string) string {
+	switch {
+	case strings.Contains(out, "build constraints exclude all Go files"):
+		return codeBuildConstraints
+	case strings.Contains(out, "unknown GOEXPERIMENT"):
+		return codeInvalidArgument
+	case strings.Contains(out, "no symbol"), strings.Contains(out, "no such symbol"),
+		strings.Contains(out, "no method or field"):
+		return codeSymbolNotFound
+	case strings.Contains(out, "no such package"), strings.Contains(out, "is not in std"),
+		strings.Contains(out, "cannot find package"), strings.Contains(out, "cannot find module"),
+		strings.Contains(out, "no required module provides"), strings.Contains(out, "does not contain package"),
+		strings.Contains(out, "no matching versions"), strings.Contains(out, "unrecognized import path"),
+		strings.Contains(out, "400 Bad Request"), strings.Contains(out, "404 Not Found"), strings.Contains(out, "410 Gone"):
+		return codePkgNotFound
+	case strings.Contains(out, "i/o timeout"), strings.Contains(out, "TLS handshake timeout"),
+		strings.Contains(out, "dial tcp"), strings.Contains(out, "connection refused"),
+		strings.Contains(out, "no such host"), strings.Contains(out, "proxyconnect"):
+		return codeNetworkFetch
+	}
+	return ""
+}
+
+// transientFetch reports whether the output of a failed go command describes a
+// network failure that may pass on retry: a timeout, a dropped connection, or
+// a module proxy that is overloaded or briefly down
+func transientFetch(out string) bool {
+	if classifyOutput(out) == codePkgNotFound {
+		return false
+	}
+	for _, s := range []string{
+		"i/o timeout", "TLS handshake timeout", "connection refused", "connection reset by peer",
+		"no such host", "proxyconnect", "unexpected EOF", "dial tcp",
+		"429 Too Many Requests", "500 Internal Server Error", "502 Bad Gateway",
+		"503 Service Unavailable", "504 Gateway Timeout",
+	} {
+		if strings.Contains(out, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWorkingDir verifies dir is an existing directory
+func checkWorkingDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return withCode(codeInvalidWorkingDir, err)
+	}
+	if !info.IsDir() {
+		return withCode(codeInvalidWorkingDir, fmt.Errorf("%s is not a directory", dir))
+	}
+	return nil
+}
+
+// errorResult returns a tool error tagged with code, both at the start of the
+// message and as error_code in the result's _meta
+func errorResult(code, msg string) *mcp.CallToolResult {
+	result := mcp.NewToolResultError("[" + code + "] " + msg)
+	result.Meta = mcp.NewMetaFromMap(map[string]any{"error_code": code})
+	return result
+}
+
+// errorResultFromErr returns a tool error for err, prefixed with msg and tagged
+// with the error's code
+func errorResultFromErr(msg string, err error) *mcp.CallToolResult {
+	return errorResult(errorCode(err), msg+": "+err.Error())
+}
+
+// resultErrorCode returns the error code of a failed tool result, if it has one
+func resultErrorCode(result *mcp.CallToolResult) string {
+	if result == nil || result.Meta == nil {
+		return ""
+	}
+	code, _ := result.Meta.AdditionalFields["error_code"].(string)
+	return code
+}
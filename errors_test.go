@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestClassifyOutput(t *testing.T) {
+	tests := []struct {
+		out, want string
+	}{
+		{"package example.com/tagged: build constraints exclude all Go files in /src/tagged", codeBuildConstraints},
+		{"go: unknown GOEXPERIMENT nosuch", codeInvalidArgument},
+		{"doc: no symbol Nope in package strings", codeSymbolNotFound},
+		{"doc: no method or field Builder.Nope", codeSymbolNotFound},
+		{"doc: no such package example.com/none", codePkgNotFound},
+		{`package nosuch is not in std (/usr/local/go/src/nosuch)`, codePkgNotFound},
+		{"no required module provides package example.com/none; to add it:", codePkgNotFound},
+		{"go: example.com/none@latest: reading https://proxy.golang.org/example.com/none/@v/list: 404 Not Found", codePkgNotFound},
+		{"go: module example.com/none: no matching versions for query \"latest\"", codePkgNotFound},
+		{"go: example.com/gone@v1.0.0: reading https://proxy.golang.org/example.com/gone/@v/v1.0.0.info: 410 Gone", codePkgNotFound},
+		{"dial tcp 142.250.1.1:443: i/o timeout", codeNetworkFetch},
+		{"dial tcp: lookup proxy.golang.org: no such host", codeNetworkFetch},
+		{"proxyconnect tcp: connection refused", codeNetworkFetch},
+		{"exit status 2", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := classifyOutput(tt.out); got != tt.want {
+			t.Errorf("classifyOutput(%q) = %q, want %q", tt.out, got, tt.want)
+		}
+	}
+}
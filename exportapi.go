@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+const exportAPIDescription = `Dump the entire exported API of a package as JSON, like go doc -all as data.
+Lists every exported constant, variable, function, type, method, struct field, and interface method with its
+kind, declaration, doc comment, whether it is deprecated, and the file and line declaring it. Use it to feed a
+package's API to other tooling, or to compare two versions of a package by diffing their dumps. path takes the
+same forms as get_doc's; end it with /... to include the packages below it. For reading documentation, get_doc
+is more compact.`
+
+// exportAPISchema is the export_api input schema
+var exportAPISchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Package to dump, as for get_doc (e.g., 'net/http', './pkg', or 'github.com/user/repo'). End it with '/...' to include the packages below it.",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory for relative paths and the module's own packages. Defaults to the session working directory.",
+		},
+		"include_docs": map[string]any{
+			"type":        "boolean",
+			"description": "Include each symbol's doc comment. Turn off for a smaller dump of declarations only.",
+			"default":     true,
+		},
+	},
+	Required: []string{"path"},
+}
+
+// apiSymbolKinds are the kinds of symbols in an API dump
+var apiSymbolKinds = []string{"const", "var", "func", "type", "method", "field"}
+
+// exportAPIOutputSchema is the outputSchema of export_api, describing apiOutput
+var exportAPIOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"packages": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"import_path": map[string]any{"type": "string"},
+					"name":        map[string]any{"type": "string"},
+					"version":     map[string]any{"type": "string", "description": "Go toolchain version for the standard library, or the version of the module providing the package"},
+					"doc":         map[string]any{"type": "string", "description": "The package comment"},
+					"symbols": map[string]any{
+						"type":        "array",
+						"description": "Exported symbols in go doc order, each type followed by its fields and methods",
+						"items": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"kind":       map[string]any{"type": "string", "enum": apiSymbolKinds},
+								"name":       map[string]any{"type": "string"},
+								"recv":       map[string]any{"type": "string", "description": "Type declaring a method or field"},
+								"decl":       map[string]any{"type": "string", "description": "The declaration, without function bodies or comments; struct types list only their exported fields"},
+								"doc":        map[string]any{"type": "string"},
+								"deprecated": map[string]any{"type": "boolean", "description": "Whether the doc comment has a Deprecated: paragraph"},
+								"file":       map[string]any{"type": "string", "description": "File declaring the symbol, relative to the package directory"},
+								"line":       map[string]any{"type": "integer"},
+							},
+							"required": []string{"kind", "name", "decl", "file", "line"},
+						},
+					},
+				},
+				"required": []string{"import_path", "name", "symbols"},
+			},
+		},
+	},
+	Required: []string{"packages"},
+}
+
+// apiOutput is the structured content of an export_api result
+type apiOutput struct {
+	Packages []apiPackage `json:"packages"`
+}
+
+// apiPackage is the exported API of a package
+type apiPackage struct {
+	ImportPath string      `json:"import_path"`
+	Name       string      `json:"name"`
+	Version    string      `json:"version,omitempty"`
+	Doc        string      `json:"doc,omitempty"`
+	Symbols    []apiSymbol `json:"symbols"`
+}
+
+// apiSymbol is an exported symbol of a package
+type apiSymbol struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Recv       string `json:"recv,omitempty"`
+	Decl       string `json:"decl"`
+	Doc        string `json:"doc,omitempty"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+}
+
+// deprecatedParagraph matches the Deprecated: paragraph of a doc comment
+var deprecatedParagraph = regexp.MustCompile(`(?m)(?:\A|\n\n)Deprecated: `)
+
+// handleExportAPI implements the export_api tool
+func (s *GodocServer) handleExportAPI(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if err := s.runtime.Load().toolchainErr; err != nil {
+		return errorResultFromErr("cannot load packages", err), nil
+	}
+	path := request.GetString("path", "")
+	if path == "" {
+		return errorResult(codeInvalidArgument, "invalid or missing path parameter"), nil
+	}
+	includeDocs := request.GetBool("include_docs", true)
+
+	log := ctxLogger(ctx, s.logger).WithField("path", path)
+	progress := s.newProgressReporter(ctx, request)
+	ctx = withProgress(ctx, progress)
+	dir, err := s.loadDir(ctx, request, path)
+	if err != nil {
+		return errorResultFromErr("failed to find the source of "+path, err), nil
+	}
+	progress.step("Loading " + path)
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return errorResultFromErr("failed to load "+path, err), nil
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Dir:     dir,
+	}, path)
+	release()
+	if err != nil {
+		return errorResultFromErr("failed to load "+path, err), nil
+	}
+
+	out := &apiOutput{Packages: make([]apiPackage, 0, len(pkgs))}
+	var errs []string
+	for _, pkg := range pkgs {
+		if len(pkg.Syntax) == 0 {
+			for _, e := range pkg.Errors {
+				errs = append(errs, e.Msg)
+			}
+			continue
+		}
+		api, err := exportedAPI(pkg, includeDocs)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		api.Version = resolvedVersion(pkg.PkgPath, dir)
+		out.Packages = append(out.Packages, *api)
+	}
+	if len(out.Packages) == 0 {
+		msg := fmt.Sprintf("no Go packages match %s", path)
+		if len(errs) > 0 {
+			msg = strings.Join(errs, "; ")
+		}
+		return errorResultFromErr("failed to load "+path, withCode(codePkgNotFound, errors.New(msg))), nil
+	}
+	log.WithField("packages", len(out.Packages)).Debug("Exported package API")
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return errorResultFromErr("failed to encode the API", withCode(codeInternal, err)), nil
+	}
+	result := mcp.NewToolResultText(string(data))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// exportedAPI lists the exported symbols of pkg, with their doc comments
+// when includeDocs is set
+func exportedAPI(pkg *packages.Package, includeDocs bool) (*apiPackage, error) {
+	// Without AllDecls, go/doc also drops the unexported fields and methods
+	// of exported struct and interface types
+	dp, err := doc.NewFromFiles(pkg.Fset, pkg.Syntax, pkg.PkgPath, doc.PreserveAST)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read documentation for %s: %v", pkg.PkgPath, err)
+	}
+	api := &apiPackage{ImportPath: dp.ImportPath, Name: dp.Name, Symbols: make([]apiSymbol, 0)}
+	add := func(kind, name, recv string, node ast.Node, decl, comment string) {
+		pos := pkg.Fset.Position(node.Pos())
+		sym := apiSymbol{
+			Kind:       kind,
+			Name:       name,
+			Recv:       recv,
+			Decl:       decl,
+			Deprecated: deprecatedParagraph.MatchString(comment),
+			File:       filepath.Base(pos.Filename),
+			Line:       pos.Line,
+		}
+		if includeDocs {
+			sym.Doc = comment
+		}
+		api.Symbols = append(api.Symbols, sym)
+	}
+	if includeDocs {
+		api.Doc = dp.Doc
+	}
+
+	values := func(values []*doc.Value) {
+		for _, v := range values {
+			for _, spec := range v.Decl.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				// Each name is listed with its own spec, and the group's
+				// comment when the spec has none
+				comment := v.Doc
+				if vs.Doc != nil {
+					comment = vs.Doc.Text()
+				} else if vs.Comment != nil && comment == "" {
+					comment = vs.Comment.Text()
+				}
+				decl := &ast.GenDecl{Tok: v.Decl.Tok, TokPos: vs.Pos(), Specs: []ast.Spec{vs}}
+				for _, n := range vs.Names {
+					if n.IsExported() {
+						add(v.Decl.Tok.String(), n.Name, "", vs, printDecl(pkg.Fset, decl), comment)
+					}
+				}
+			}
+		}
+	}
+	funcs := func(funcs []*doc.Func, recv string) {
+		for _, fn := range funcs {
+			// Promoted methods are listed with the type declaring them
+			if fn.Level > 0 {
+				continue
+			}
+			kind := "func"
+			if fn.Decl.Recv != nil {
+				kind = "method"
+			}
+			add(kind, fn.Name, recv, fn.Decl, printDecl(pkg.Fset, fn.Decl), fn.Doc)
+		}
+	}
+	values(dp.Consts)
+	values(dp.Vars)
+	funcs(dp.Funcs, "")
+	for _, t := range dp.Types {
+		spec, ok := t.Decl.Specs[0].(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		add("type", t.Name, "", spec, printDecl(pkg.Fset, t.Decl), t.Doc)
+		switch st := spec.Type.(type) {
+		case *ast.StructType:
+			for _, field := range st.Fields.List {
+				for _, name := range fieldNames(field) {
+					add("field", name, t.Name, field, printDecl(pkg.Fset, field), commentText(field.Doc, field.Comment))
+				}
+			}
+		case *ast.InterfaceType:
+			// Embedded interfaces and type constraints are part of the type's declaration
+			for _, m := range st.Methods.List {
+				if len(m.Names) > 0 && m.Names[0].IsExported() {
+					name := m.Names[0].Name
+					decl := name + strings.TrimPrefix(printDecl(pkg.Fset, m.Type), "func")
+					add("method", name, t.Name, m, decl, commentText(m.Doc, m.Comment))
+				}
+			}
+		}
+		values(t.Consts)
+		values(t.Vars)
+		funcs(t.Funcs, "")
+		funcs(t.Methods, t.Name)
+	}
+	return api, nil
+}
+
+// fieldNames returns the exported names field declares, or the name of the
+// type it embeds
+func fieldNames(field *ast.Field) []string {
+	var names []string
+	for _, n := range field.Names {
+		if n.IsExported() {
+			names = append(names, n.Name)
+		}
+	}
+	if len(field.Names) == 0 {
+		typ := field.Type
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ = star.X
+		}
+		if index, ok := typ.(*ast.IndexExpr); ok {
+			typ = index.X
+		} else if index, ok := typ.(*ast.IndexListExpr); ok {
+			typ = index.X
+		}
+		if sel, ok := typ.(*ast.SelectorExpr); ok {
+			typ = sel.Sel
+		}
+		if id, ok := typ.(*ast.Ident); ok && id.IsExported() {
+			names = append(names, id.Name)
+		}
+	}
+	return names
+}
+
+// commentText returns the text of the first of groups present
+func commentText(groups ...*ast.CommentGroup) string {
+	for _, cg := range groups {
+		if cg != nil {
+			return cg.Text()
+		}
+	}
+	return ""
+}
+
+// printDecl prints the declaration node without comments or function
+// bodies. Fields print as in their struct.
+func printDecl(fset *token.FileSet, node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		cp := *n
+		cp.Doc, cp.Body = nil, nil
+		node = &cp
+	case *ast.GenDecl:
+		cp := *n
+		cp.Doc = nil
+		node = &cp
+	case *ast.Field:
+		var names []string
+		for _, name := range n.Names {
+			names = append(names, name.Name)
+		}
+		decl := strings.TrimSpace(strings.Join(names, ", ") + " " + printDecl(fset, n.Type))
+		if n.Tag != nil {
+			decl += " " + n.Tag.Value
+		}
+		return decl
+	}
+	// The printer prints the comments attached to specs and fields, so they
+	// are detached while printing
+	type attached struct {
+		doc, comment **ast.CommentGroup
+		saved        [2]*ast.CommentGroup
+	}
+	var detached []attached
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			detached = append(detached, attached{&n.Doc, &n.Comment, [2]*ast.CommentGroup{n.Doc, n.Comment}})
+		case *ast.ValueSpec:
+			detached = append(detached, attached{&n.Doc, &n.Comment, [2]*ast.CommentGroup{n.Doc, n.Comment}})
+		case *ast.TypeSpec:
+			detached = append(detached, attached{&n.Doc, &n.Comment, [2]*ast.CommentGroup{n.Doc, n.Comment}})
+		case *ast.ImportSpec:
+			detached = append(detached, attached{&n.Doc, &n.Comment, [2]*ast.CommentGroup{n.Doc, n.Comment}})
+		}
+		return true
+	})
+	for _, a := range detached {
+		*a.doc, *a.comment = nil, nil
+	}
+	defer func() {
+		for _, a := range detached {
+			*a.doc, *a.comment = a.saved[0], a.saved[1]
+		}
+	}()
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, fset, node); err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestExportAPI(t *testing.T) {
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/mod\n\ngo 1.21\n",
+		"api/api.go": `// Package api is an API.
+package api
+
+// Limits
+const (
+	// Max is the most.
+	Max = 10
+	Min = 1 // Min is the least.
+	min = 0
+)
+
+// Client is a client.
+type Client struct {
+	// Addr is the address.
+	Addr  string ` + "`json:\"addr\"`" + `
+	token string
+	Logger
+}
+
+// Logger logs.
+type Logger interface {
+	// Log logs a message.
+	Log(msg string) error
+	private()
+}
+
+// NewClient returns a client.
+//
+// Deprecated: Use Dial.
+func NewClient() *Client { return nil }
+
+// Do does.
+func (c *Client) Do() {}
+`,
+		"api/sub/sub.go": "package sub\n\n// V is a value.\nvar V = 1\n",
+	})
+	symbols := []string{
+		"const Max api.go:7 Max is the most.\n",
+		"const Min api.go:8 Limits\n",
+		"type Client api.go:13 Client is a client.\n",
+		"field Client.Addr api.go:15 Addr is the address.\n",
+		"field Client.Logger api.go:17 ",
+		"func NewClient api.go:30 deprecated NewClient returns a client.\n\nDeprecated: Use Dial.\n",
+		"method Client.Do api.go:33 Do does.\n",
+		"type Logger api.go:21 Logger logs.\n",
+		"method Logger.Log api.go:23 Log logs a message.\n",
+	}
+	decls := map[string]string{
+		"Addr":      "Addr string `json:\"addr\"`",
+		"Log":       "Log(msg string) error",
+		"NewClient": "func NewClient() *Client",
+		"Max":       "const Max = 10",
+	}
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		packages []string
+		want     []string
+	}{
+		{"no path", map[string]any{"working_dir": dir}, codeInvalidArgument, nil, nil},
+		{"no package", map[string]any{"path": "./api/none/...", "working_dir": dir}, codePkgNotFound, nil, nil},
+		{"package", map[string]any{"path": "./api", "working_dir": dir}, "", []string{"example.com/mod/api"}, symbols},
+		{"without docs", map[string]any{"path": "./api", "include_docs": false, "working_dir": dir}, "", []string{"example.com/mod/api"}, []string{
+			"const Max api.go:7 ",
+			"const Min api.go:8 ",
+			"type Client api.go:13 ",
+			"field Client.Addr api.go:15 ",
+			"field Client.Logger api.go:17 ",
+			"func NewClient api.go:30 deprecated ",
+			"method Client.Do api.go:33 ",
+			"type Logger api.go:21 ",
+			"method Logger.Log api.go:23 ",
+		}},
+		{"packages below", map[string]any{"path": "./api/...", "working_dir": dir}, "", []string{"example.com/mod/api", "example.com/mod/api/sub"}, append(slices.Clone(symbols), "var V sub.go:4 V is a value.\n")},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleExportAPI, "export_api", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*apiOutput)
+		var packages, got []string
+		for _, pkg := range out.Packages {
+			packages = append(packages, pkg.ImportPath)
+			for _, sym := range pkg.Symbols {
+				name := sym.Name
+				if sym.Recv != "" {
+					name = sym.Recv + "." + name
+				}
+				line := fmt.Sprintf("%s %s %s:%d ", sym.Kind, name, sym.File, sym.Line)
+				if sym.Deprecated {
+					line += "deprecated "
+				}
+				got = append(got, line+sym.Doc)
+				if want, ok := decls[sym.Name]; ok && sym.Decl != want {
+					t.Errorf("%s: declaration of %s is %q, want %q", tt.name, sym.Name, sym.Decl, want)
+				}
+			}
+		}
+		if !slices.Equal(packages, tt.packages) {
+			t.Errorf("%s: packages %q, want %q", tt.name, packages, tt.packages)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: symbols\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+
+	// The standard library is dumped at the toolchain's version
+	result := callTool(t, ctx, s.handleExportAPI, "export_api", map[string]any{"path": "errors"})
+	out, ok := result.StructuredContent.(*apiOutput)
+	if !ok || len(out.Packages) != 1 || out.Packages[0].Version == "" ||
+		!slices.ContainsFunc(out.Packages[0].Symbols, func(sym apiSymbol) bool { return sym.Kind == "func" && sym.Name == "New" }) {
+		t.Errorf("export_api(errors) = %s", resultText(result))
+	}
+}
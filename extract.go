@@ -0,0 +1,615 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/tools/go/packages"
+)
+
+// Documentation backends selectable with -doc-backend
+const (
+	backendNative = "native"
+	backendGoDoc  = "go-doc"
+)
+
+// Output formats accepted by the get_doc format argument
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// errUnsupportedFlag reports a go doc flag the native backend does not implement
+var errUnsupportedFlag = errors.New("flag not supported by the native backend")
+
+// docFlags are the go doc flags understood by the native backend
+type docFlags struct {
+	all        bool // -all
+	unexported bool // -u
+	src        bool // -src
+	short      bool // -short
+	matchCase  bool // -c
+}
+
+// parseDocFlags parses go doc flags, rejecting any the native backend can't honor
+func parseDocFlags(args []string) (docFlags, error) {
+	var f docFlags
+	for _, arg := range args {
+		switch strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-") {
+		case "all":
+			f.all = true
+		case "u":
+			f.unexported = true
+		case "src":
+			f.src = true
+		case "short":
+			f.short = true
+		case "c":
+			f.matchCase = true
+		default:
+			return f, withCode(codeInvalidArgument, fmt.Errorf("%w: %s", errUnsupportedFlag, arg))
+		}
+	}
+	return f, nil
+}
+
+// goDocFlags are the flags go doc accepts
+var goDocFlags = []string{"all", "c", "cmd", "short", "src", "u"}
+
+// dropInvalidFlags removes the flags go doc would reject from flags: unknown
+// flags, values that aren't booleans, and -short combined with -all. go doc's
+// flags are all booleans, so each one set is kept once as -name, and those set
+// to false are left out. It returns the flags kept and a warning for each one
+// dropped.
+func dropInvalidFlags(flags []string) ([]string, []string) {
+	var names, warnings []string
+	set := make(map[string]bool)
+	for _, flag := range flags {
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(flag, "-"), "-"), "=")
+		if !strings.HasPrefix(flag, "-") || !slices.Contains(goDocFlags, name) {
+			warnings = append(warnings, fmt.Sprintf("ignored unsupported flag %q; go doc accepts -%s", flag, strings.Join(goDocFlags, ", -")))
+			continue
+		}
+		on := true
+		if hasValue {
+			var err error
+			if on, err = strconv.ParseBool(value); err != nil {
+				warnings = append(warnings, fmt.Sprintf("ignored flag %q; -%s is a boolean flag", flag, name))
+				continue
+			}
+		}
+		// As for go doc, the last setting of a flag wins
+		if _, ok := set[name]; !ok {
+			names = append(names, name)
+		}
+		set[name] = on
+	}
+	if set["all"] && set["short"] {
+		set["short"] = false
+		warnings = append(warnings, "ignored -short, which go doc can't combine with -all")
+	}
+	var kept []string
+	for _, name := range names {
+		if set[name] {
+			kept = append(kept, "-"+name)
+		}
+	}
+	return kept, warnings
+}
+
+// packageDoc is the structured documentation of a package or one of its symbols
+type packageDoc struct {
+	ImportPath string     `json:"import_path"`
+	Name       string     `json:"name"`
+	Doc        string     `json:"doc,omitempty"`
+	Consts     []valueDoc `json:"consts,omitempty"`
+	Vars       []valueDoc `json:"vars,omitempty"`
+	Funcs      []funcDoc  `json:"funcs,omitempty"`
+	Types      []typeDoc  `json:"types,omitempty"`
+}
+
+// valueDoc documents a const or var declaration, which may declare several names
+type valueDoc struct {
+	Names []string `json:"names"`
+	Doc   string   `json:"doc,omitempty"`
+	Decl  string   `json:"decl"`
+}
+
+// funcDoc documents a function or method
+type funcDoc struct {
+	Name string `json:"name"`
+	Recv string `json:"recv,omitempty"`
+	Doc  string `json:"doc,omitempty"`
+	Decl string `json:"decl"`
+}
+
+// typeDoc documents a type along with its associated declarations
+type typeDoc struct {
+	Name    string     `json:"name"`
+	Doc     string     `json:"doc,omitempty"`
+	Decl    string     `json:"decl"`
+	Consts  []valueDoc `json:"consts,omitempty"`
+	Vars    []valueDoc `json:"vars,omitempty"`
+	Funcs   []funcDoc  `json:"funcs,omitempty"`
+	Methods []funcDoc  `json:"methods,omitempty"`
+}
+
+// nativeDoc serves the go doc arguments args with the native backend
+func (s *GodocServer) nativeDoc(ctx context.Context, workingDir, format string, args []string) (string, error) {
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		i++
+	}
+	flags, err := parseDocFlags(args[:i])
+	if err != nil {
+		return "", err
+	}
+	positional := args[i:]
+	if len(positional) == 0 || len(positional) > 2 {
+		return "", withCode(codeInvalidArgument, fmt.Errorf("native backend needs a package path and optional symbol, got %q", positional))
+	}
+	var target string
+	if len(positional) == 2 {
+		target = positional[1]
+	}
+
+	pd, err := s.extractDoc(ctx, workingDir, positional[0], target, flags)
+	if err != nil {
+		return "", err
+	}
+	var out string
+	if format == formatJSON {
+		data, err := json.MarshalIndent(pd, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		out = string(data) + "\n"
+	} else {
+		out = pd.formatText(flags, target)
+	}
+	docStreamerFromContext(ctx).Write([]byte(out))
+	return out, nil
+}
+
+// extractDoc loads pkgPath from workingDir in process and returns its
+// documentation, narrowed to target when one is given
+func (s *GodocServer) extractDoc(ctx context.Context, workingDir, pkgPath, target string, flags docFlags) (*packageDoc, error) {
+	log := ctxLogger(ctx, s.logger)
+
+	// Loading still runs go list to resolve the package, so it needs a slot
+	progress := progressFromContext(ctx)
+	progress.step("Loading package " + pkgPath)
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, span := startSpan(ctx, "go list", attribute.String("package", pkgPath), attribute.String("working_dir", workingDir))
+	start := time.Now()
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Dir:     workingDir,
+		Env:     buildEnviron(ctx),
+	}, pkgPath)
+	endSpan(span, err)
+	release()
+	log.WithFields(logrus.Fields{
+		"package":     pkgPath,
+		"working_dir": workingDir,
+		"duration":    time.Since(start),
+	}).Debug("package load finished")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load package %s: %v", pkgPath, err)
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%s matched %d packages", pkgPath, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		err := fmt.Errorf("failed to load package %s: %v", pkgPath, pkg.Errors[0])
+		if code := classifyOutput(pkg.Errors[0].Msg); code != "" {
+			return nil, withCode(code, err)
+		}
+		return nil, err
+	}
+	if len(pkg.Syntax) == 0 {
+		return nil, withCode(codePkgNotFound, fmt.Errorf("no Go files in package %s", pkgPath))
+	}
+
+	mode := doc.PreserveAST
+	if flags.unexported {
+		mode |= doc.AllDecls
+	}
+	dpkg, err := doc.NewFromFiles(pkg.Fset, pkg.Syntax, pkg.PkgPath, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read documentation for %s: %v", pkgPath, err)
+	}
+
+	if flags.all {
+		progress.step(fmt.Sprintf("Rendering documentation of %d files", len(pkg.Syntax)))
+	}
+	r := &docRenderer{pkg: dpkg, fset: pkg.Fset, files: pkg.Syntax, flags: flags}
+	pd := r.packageDoc()
+	if target == "" {
+		return pd, nil
+	}
+	return r.selectTarget(pd, target)
+}
+
+// docRenderer converts go/doc declarations into packageDoc values
+type docRenderer struct {
+	pkg   *doc.Package
+	fset  *token.FileSet
+	files []*ast.File
+	flags docFlags
+}
+
+func (r *docRenderer) packageDoc() *packageDoc {
+	pd := &packageDoc{
+		ImportPath: r.pkg.ImportPath,
+		Name:       r.pkg.Name,
+		Doc:        r.text(r.pkg.Doc),
+		Consts:     r.values(r.pkg.Consts),
+		Vars:       r.values(r.pkg.Vars),
+		Funcs:      r.funcs(r.pkg.Funcs),
+	}
+	for _, t := range r.pkg.Types {
+		pd.Types = append(pd.Types, typeDoc{
+			Name:    t.Name,
+			Doc:     r.text(t.Doc),
+			Decl:    r.node(t.Decl),
+			Consts:  r.values(t.Consts),
+			Vars:    r.values(t.Vars),
+			Funcs:   r.funcs(t.Funcs),
+			Methods: r.funcs(t.Methods),
+		})
+	}
+	return pd
+}
+
+func (r *docRenderer) values(vals []*doc.Value) []valueDoc {
+	var out []valueDoc
+	for _, v := range vals {
+		out = append(out, valueDoc{Names: v.Names, Doc: r.text(v.Doc), Decl: r.node(v.Decl)})
+	}
+	return out
+}
+
+func (r *docRenderer) funcs(fns []*doc.Func) []funcDoc {
+	var out []funcDoc
+	for _, fn := range fns {
+		out = append(out, funcDoc{Name: fn.Name, Recv: fn.Recv, Doc: r.text(fn.Doc), Decl: r.node(fn.Decl)})
+	}
+	return out
+}
+
+// text reformats a doc comment as plain text
+func (r *docRenderer) text(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	// Wrap as go doc does, leaving room for the indent added when rendering text
+	p := r.pkg.Printer()
+	p.TextWidth = 76
+	return strings.TrimRight(string(p.Text(r.pkg.Parser().Parse(comment))), "\n")
+}
+
+// node prints a declaration without its doc comment, keeping function bodies
+// only for -src
+func (r *docRenderer) node(decl ast.Decl) string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		cp := *d
+		cp.Doc = nil
+		if !r.flags.src {
+			cp.Body = nil
+		}
+		decl = &cp
+	case *ast.GenDecl:
+		cp := *d
+		cp.Doc = nil
+		decl = &cp
+	}
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, r.fset, &printer.CommentedNode{Node: decl, Comments: r.comments(decl)}); err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return buf.String()
+}
+
+// comments returns the comments to print within decl. Function bodies keep every
+// comment from their file, while other declarations keep only those attached to
+// the specs and fields that survived export filtering.
+func (r *docRenderer) comments(decl ast.Decl) []*ast.CommentGroup {
+	if _, ok := decl.(*ast.FuncDecl); ok {
+		for _, f := range r.files {
+			if f.FileStart <= decl.Pos() && decl.Pos() < f.FileEnd {
+				return f.Comments
+			}
+		}
+		return nil
+	}
+	var groups []*ast.CommentGroup
+	add := func(cgs ...*ast.CommentGroup) {
+		for _, cg := range cgs {
+			if cg != nil {
+				groups = append(groups, cg)
+			}
+		}
+	}
+	ast.Inspect(decl, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			add(n.Doc, n.Comment)
+		case *ast.ValueSpec:
+			add(n.Doc, n.Comment)
+		case *ast.TypeSpec:
+			add(n.Doc, n.Comment)
+		}
+		return true
+	})
+	return groups
+}
+
+// selectTarget narrows pd to the symbol named by target, which is either a
+// package-level name or Type.Method / Type.Field
+func (r *docRenderer) selectTarget(pd *packageDoc, target string) (*packageDoc, error) {
+	match := func(name, want string) bool {
+		// As with go doc, a lower-case target matches either case unless -c is given
+		if r.flags.matchCase || strings.ToLower(want) != want {
+			return name == want
+		}
+		return strings.EqualFold(name, want)
+	}
+	out := &packageDoc{ImportPath: pd.ImportPath, Name: pd.Name}
+
+	if typeName, member, ok := strings.Cut(target, "."); ok {
+		for _, t := range pd.Types {
+			if !match(t.Name, typeName) {
+				continue
+			}
+			for _, m := range t.Methods {
+				if match(m.Name, member) {
+					out.Funcs = append(out.Funcs, m)
+				}
+			}
+			if len(out.Funcs) == 0 {
+				if field, ok := r.field(t.Name, member, match); ok {
+					t.Doc, t.Consts, t.Vars, t.Funcs, t.Methods = "", nil, nil, nil, nil
+					t.Decl = field
+					out.Types = append(out.Types, t)
+				}
+			}
+		}
+		if len(out.Funcs) == 0 && len(out.Types) == 0 {
+			return nil, withCode(codeSymbolNotFound, fmt.Errorf("no method or field %s in package %s", target, pd.ImportPath))
+		}
+		return out, nil
+	}
+
+	values := func(vals []valueDoc) []valueDoc {
+		var found []valueDoc
+		for _, v := range vals {
+			for _, name := range v.Names {
+				if match(name, target) {
+					found = append(found, v)
+					break
+				}
+			}
+		}
+		return found
+	}
+	out.Consts = values(pd.Consts)
+	out.Vars = values(pd.Vars)
+	for _, fn := range pd.Funcs {
+		if match(fn.Name, target) {
+			out.Funcs = append(out.Funcs, fn)
+		}
+	}
+	for _, t := range pd.Types {
+		out.Consts = append(out.Consts, values(t.Consts)...)
+		out.Vars = append(out.Vars, values(t.Vars)...)
+		for _, fn := range t.Funcs {
+			if match(fn.Name, target) {
+				out.Funcs = append(out.Funcs, fn)
+			}
+		}
+		if match(t.Name, target) {
+			out.Types = append(out.Types, t)
+		}
+	}
+	if len(out.Consts)+len(out.Vars)+len(out.Funcs)+len(out.Types) == 0 {
+		return nil, withCode(codeSymbolNotFound, fmt.Errorf("no symbol %s in package %s", target, pd.ImportPath))
+	}
+	return out, nil
+}
+
+// field prints typeName's declaration reduced to the named struct field or
+// interface method
+func (r *docRenderer) field(typeName, name string, match func(name, want string) bool) (string, bool) {
+	for _, t := range r.pkg.Types {
+		if t.Name != typeName {
+			continue
+		}
+		for _, spec := range t.Decl.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok || ts.Name.Name != typeName {
+				continue
+			}
+			var fields *ast.FieldList
+			switch tt := ts.Type.(type) {
+			case *ast.StructType:
+				fields = tt.Fields
+			case *ast.InterfaceType:
+				fields = tt.Methods
+			}
+			if fields == nil {
+				return "", false
+			}
+			for _, f := range fields.List {
+				for _, n := range f.Names {
+					if !match(n.Name, name) {
+						continue
+					}
+					// Print the type with every other field filtered out
+					one := &ast.FieldList{
+						Opening: fields.Opening,
+						List:    []*ast.Field{{Doc: f.Doc, Names: []*ast.Ident{n}, Type: f.Type, Tag: f.Tag, Comment: f.Comment}},
+					}
+					spec := *ts
+					switch tt := ts.Type.(type) {
+					case *ast.StructType:
+						reduced := *tt
+						reduced.Fields = one
+						spec.Type = &reduced
+					case *ast.InterfaceType:
+						reduced := *tt
+						reduced.Methods = one
+						spec.Type = &reduced
+					}
+					return r.node(&ast.GenDecl{TokPos: t.Decl.TokPos, Tok: token.TYPE, Specs: []ast.Spec{&spec}}), true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// formatText renders pd in the layout of go doc output
+func (pd *packageDoc) formatText(flags docFlags, target string) string {
+	var b strings.Builder
+	indent := func(text string) {
+		for _, line := range strings.Split(text, "\n") {
+			if line == "" {
+				b.WriteString("\n")
+				continue
+			}
+			b.WriteString("    " + line + "\n")
+		}
+	}
+	decl := func(d, doc string) {
+		b.WriteString(d + "\n")
+		if doc != "" && !flags.short {
+			indent(doc)
+		}
+		b.WriteString("\n")
+	}
+	summary := func(d string) string {
+		// Summarize a grouped declaration by its first spec, e.g. "const SeekStart = 0 ..."
+		if kw, rest, ok := strings.Cut(d, " (\n"); ok {
+			for _, line := range strings.Split(rest, "\n") {
+				if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "//") {
+					line, _, _ = strings.Cut(line, " //")
+					return kw + " " + strings.Join(strings.Fields(line), " ") + " ..."
+				}
+			}
+		}
+		line, _, more := strings.Cut(d, "\n")
+		if more {
+			line = strings.TrimRight(line, " ({") + " ..."
+			if strings.HasPrefix(d, "type ") {
+				line = strings.TrimSuffix(line, " ...") + "{ ... }"
+			}
+		}
+		return line
+	}
+
+	if target != "" {
+		for _, v := range pd.Consts {
+			decl(v.Decl, v.Doc)
+		}
+		for _, v := range pd.Vars {
+			decl(v.Decl, v.Doc)
+		}
+		for _, fn := range pd.Funcs {
+			decl(fn.Decl, fn.Doc)
+		}
+		for _, t := range pd.Types {
+			decl(t.Decl, t.Doc)
+			for _, v := range slices.Concat(t.Consts, t.Vars) {
+				b.WriteString(summary(v.Decl) + "\n")
+			}
+			for _, fn := range slices.Concat(t.Funcs, t.Methods) {
+				b.WriteString(fn.Decl + "\n")
+			}
+		}
+		return strings.TrimRight(b.String(), "\n") + "\n"
+	}
+
+	if !flags.short {
+		fmt.Fprintf(&b, "package %s // import %q\n\n", pd.Name, pd.ImportPath)
+		if pd.Doc != "" {
+			b.WriteString(pd.Doc + "\n\n")
+		}
+	}
+	if flags.all || flags.src {
+		section := func(title string, n int) {
+			if n > 0 {
+				b.WriteString(title + "\n\n")
+			}
+		}
+		section("CONSTANTS", len(pd.Consts))
+		for _, v := range pd.Consts {
+			decl(v.Decl, v.Doc)
+		}
+		section("VARIABLES", len(pd.Vars))
+		for _, v := range pd.Vars {
+			decl(v.Decl, v.Doc)
+		}
+		section("FUNCTIONS", len(pd.Funcs))
+		for _, fn := range pd.Funcs {
+			decl(fn.Decl, fn.Doc)
+		}
+		section("TYPES", len(pd.Types))
+		for _, t := range pd.Types {
+			decl(t.Decl, t.Doc)
+			for _, v := range t.Consts {
+				decl(v.Decl, v.Doc)
+			}
+			for _, v := range t.Vars {
+				decl(v.Decl, v.Doc)
+			}
+			for _, fn := range t.Funcs {
+				decl(fn.Decl, fn.Doc)
+			}
+			for _, fn := range t.Methods {
+				decl(fn.Decl, fn.Doc)
+			}
+		}
+		return strings.TrimRight(b.String(), "\n") + "\n"
+	}
+
+	for _, v := range pd.Consts {
+		b.WriteString(summary(v.Decl) + "\n")
+	}
+	for _, v := range pd.Vars {
+		b.WriteString(summary(v.Decl) + "\n")
+	}
+	for _, fn := range pd.Funcs {
+		b.WriteString(fn.Decl + "\n")
+	}
+	for _, t := range pd.Types {
+		b.WriteString(summary(t.Decl) + "\n")
+		for _, v := range slices.Concat(t.Consts, t.Vars) {
+			b.WriteString("    " + summary(v.Decl) + "\n")
+		}
+		for _, fn := range t.Funcs {
+			b.WriteString("    " + fn.Decl + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
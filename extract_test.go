@@ -0,0 +1,35 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDropInvalidFlags(t *testing.T) {
+	tests := []struct {
+		flags    []string
+		want     []string
+		warnings int
+	}{
+		{nil, nil, 0},
+		{[]string{"-all", "-u", "--src"}, []string{"-all", "-u", "-src"}, 0},
+		{[]string{"-c=true"}, []string{"-c"}, 0},
+		{[]string{"-all", "-all"}, []string{"-all"}, 0},
+		{[]string{"-json", "-u"}, []string{"-u"}, 1},
+		{[]string{"all", "-u"}, []string{"-u"}, 1},
+		{[]string{"-all", "-short"}, []string{"-all"}, 1},
+		{[]string{"--short", "-all", "-x"}, []string{"-all"}, 2},
+		{[]string{"-short=true", "-all"}, []string{"-all"}, 1},
+		{[]string{"--short=1", "-all=T"}, []string{"-all"}, 1},
+		{[]string{"-all=false", "-short"}, []string{"-short"}, 0},
+		{[]string{"-all", "-all=0", "-short"}, []string{"-short"}, 0},
+		{[]string{"-u=false"}, nil, 0},
+		{[]string{"-u=yes", "-src="}, nil, 2},
+	}
+	for _, tt := range tests {
+		got, warnings := dropInvalidFlags(tt.flags)
+		if !slices.Equal(got, tt.want) || len(warnings) != tt.warnings {
+			t.Errorf("dropInvalidFlags(%q) = %q, warnings %q; want %q, %d warnings", tt.flags, got, warnings, tt.want, tt.warnings)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isGoFile reports whether a get_doc path names a Go source file rather than a package
+func isGoFile(path string) bool {
+	return strings.HasSuffix(path, ".go")
+}
+
+// resolveGoFile resolves the Go source file path, relative to workingDir
+// unless absolute, to the package it belongs to, as a path relative to the
+// module root returned as the working directory, and the symbols it declares.
+// Unexported symbols are included only when unexported is set.
+func resolveGoFile(path, workingDir string, unexported bool) (pkgPath, moduleDir string, symbols []string, err error) {
+	file := path
+	if !filepath.IsAbs(file) {
+		if workingDir == "" {
+			return "", "", nil, withCode(codeInvalidArgument, fmt.Errorf("working_dir is required for relative file paths"))
+		}
+		file = filepath.Join(workingDir, file)
+	}
+	if strings.HasSuffix(file, "_test.go") {
+		return "", "", nil, withCode(codeInvalidArgument, fmt.Errorf("%s is a test file; go doc documents only non-test files", path))
+	}
+	if _, err := os.Stat(file); err != nil {
+		return "", "", nil, withCode(codeInvalidArgument, fmt.Errorf("cannot read file: %v", err))
+	}
+	moduleDir = workingDir
+	if moduleDir == "" {
+		if moduleDir = moduleRoot(filepath.Dir(file)); moduleDir == "" {
+			return "", "", nil, withCode(codeInvalidArgument, fmt.Errorf("%s is not in a Go module", path))
+		}
+	}
+	rel, err := filepath.Rel(moduleDir, filepath.Dir(file))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", "", nil, withCode(codeInvalidArgument, fmt.Errorf("%s is outside the working directory %s", path, moduleDir))
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return "", "", nil, withCode(codeInvalidArgument, fmt.Errorf("cannot parse %s: %v", path, err))
+	}
+	symbols = fileSymbols(f, unexported)
+	if len(symbols) == 0 {
+		return "", "", nil, withCode(codeSymbolNotFound, fmt.Errorf("%s declares no exported symbols; add -u to cmd_flags to document unexported ones", path))
+	}
+	return "./" + filepath.ToSlash(rel), moduleDir, symbols, nil
+}
+
+// fileSymbols returns the names of the top-level declarations of f, in
+// declaration order, with methods as Type.Method
+func fileSymbols(f *ast.File, unexported bool) []string {
+	var symbols []string
+	add := func(name string) {
+		if name != "_" && (unexported || token.IsExported(name)) {
+			symbols = append(symbols, name)
+		}
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			recv := ""
+			if d.Recv != nil {
+				recv = receiverName(d.Recv)
+			}
+			if recv == "" {
+				if d.Name.Name != "init" {
+					add(d.Name.Name)
+				}
+				continue
+			}
+			if unexported || token.IsExported(recv) && token.IsExported(d.Name.Name) {
+				symbols = append(symbols, recv+"."+d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name.Name)
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						add(name.Name)
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// fileNote lists the symbols documented for a Go file
+func fileNote(file string, matched []string, total int) string {
+	if total > len(matched) {
+		return fmt.Sprintf("Note: %s declares %d symbols; showing the first %d: %s.\n", file, total, len(matched), strings.Join(matched, ", "))
+	}
+	return fmt.Sprintf("Note: %s declares %d symbols: %s.\n", file, total, strings.Join(matched, ", "))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sharedWorkTimeout bounds extractions and project setups shared by concurrent
+// requests, which don't end with the request that started them
+const sharedWorkTimeout = 5 * time.Minute
+
+// sharedGroup runs one call at a time per key for concurrent callers, like
+// singleflight.Group. The call runs on a context detached from its callers and
+// bounded by sharedWorkTimeout, so one caller's cancellation or deadline never
+// fails the others; it is cancelled only once every caller has given up.
+type sharedGroup struct {
+	mu    sync.Mutex
+	calls map[string]*sharedCall
+}
+
+// sharedCall is a call in progress and the number of callers waiting for it
+type sharedCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	val     any
+	err     error
+}
+
+// do returns the result of fn for key, joining the call in progress for key if
+// there is one. It returns ctx's error as soon as ctx ends. shared reports
+// whether another caller started the call.
+func (g *sharedGroup) do(ctx context.Context, key string, fn func(context.Context) (any, error)) (v any, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*sharedCall)
+	}
+	c, shared := g.calls[key]
+	if !shared {
+		callCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedWorkTimeout)
+		c = &sharedCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go func() {
+			defer cancel()
+			c.val, c.err = fn(callCtx)
+			g.forget(key, c)
+			close(c.done)
+		}()
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err, shared
+	case <-ctx.Done():
+	}
+	g.mu.Lock()
+	c.waiters--
+	if c.waiters == 0 {
+		// Nobody is left to use the result, and later callers start afresh. The
+		// call is forgotten before the lock is released, so no caller joins it
+		// once it is cancelled.
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		c.cancel()
+	}
+	g.mu.Unlock()
+	return nil, ctx.Err(), shared
+}
+
+// forget removes c as the call in progress for key
+func (g *sharedGroup) forget(key string, c *sharedCall) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
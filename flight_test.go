@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSharedGroupOutlivesCancelledCaller(t *testing.T) {
+	var g sharedGroup
+	started, release := make(chan struct{}), make(chan struct{})
+	fn := func(ctx context.Context) (any, error) {
+		close(started)
+		select {
+		case <-release:
+			return "doc", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err, _ := g.do(first, "key", fn)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan any, 1)
+	go func() {
+		v, err, shared := g.do(context.Background(), "key", fn)
+		if err != nil || !shared {
+			t.Errorf("second caller: got err %v, shared %v", err, shared)
+		}
+		second <- v
+	}()
+	waitForWaiters(t, &g, "key", 2)
+
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller: got %v, want context.Canceled", err)
+	}
+	close(release)
+	if v := <-second; v != "doc" {
+		t.Fatalf("remaining caller: got %v, want the shared result", v)
+	}
+}
+
+func TestSharedGroupCancelsAbandonedCall(t *testing.T) {
+	var g sharedGroup
+	started, stopped := make(chan struct{}), make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	go g.do(ctx, "key", func(ctx context.Context) (any, error) {
+		close(started)
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return nil, ctx.Err()
+	})
+	<-started
+	cancel()
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("abandoned call ended with %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("abandoned call was not cancelled")
+	}
+
+	// A later caller starts a new call rather than joining the cancelled one
+	v, err, shared := g.do(context.Background(), "key", func(context.Context) (any, error) { return "fresh", nil })
+	if v != "fresh" || err != nil || shared {
+		t.Fatalf("got %v, %v, shared %v; want a fresh call", v, err, shared)
+	}
+}
+
+// waitForWaiters waits until n callers wait for the call for key
+func waitForWaiters(t *testing.T, g *sharedGroup, key string, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		g.mu.Lock()
+		c := g.calls[key]
+		waiting := c != nil && c.waiters == n
+		g.mu.Unlock()
+		if waiting {
+			return
+		}
+	}
+	t.Fatalf("%d callers never joined the call for %s", n, key)
+}
+
+func TestSharedGroupJoinAfterAbandon(t *testing.T) {
+	// Callers arriving as another gives up either share the call, which then
+	// completes, or start a fresh one; none receives the other's cancellation
+	var g sharedGroup
+	for range 200 {
+		ctx, cancel := context.WithCancel(context.Background())
+		started, release := make(chan struct{}), make(chan struct{})
+		go g.do(ctx, "key", func(ctx context.Context) (any, error) {
+			close(started)
+			select {
+			case <-release:
+				return "shared", nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		})
+		<-started
+		done := make(chan error, 1)
+		go func() {
+			_, err, _ := g.do(context.Background(), "key", func(context.Context) (any, error) { return "fresh", nil })
+			done <- err
+		}()
+		cancel()
+		time.Sleep(time.Millisecond)
+		close(release)
+		if err := <-done; err != nil {
+			t.Fatalf("caller joining as another gave up got %v", err)
+		}
+	}
+}
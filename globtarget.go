@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxGlobSymbols bounds the symbols a target pattern documents in one call
+const maxGlobSymbols = 50
+
+// isGlobTarget reports whether target is a pattern rather than a symbol name
+func isGlobTarget(target string) bool {
+	return strings.ContainsAny(target, "*?[")
+}
+
+// matchTargets returns the symbols matching pattern, in the syntax of
+// path.Match. A pattern without a dot matches the package-level names only, so
+// "Read*" does not match every method of a type named Reader; one with a dot
+// matches methods by their Type.Method names.
+func matchTargets(pattern string, symbols []string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, withCode(codeInvalidArgument, fmt.Errorf("invalid target pattern %q: %v", pattern, err))
+	}
+	methods := strings.Contains(pattern, ".")
+	var matched []string
+	for _, name := range symbols {
+		if strings.Contains(name, ".") != methods {
+			continue
+		}
+		if ok, _ := path.Match(pattern, name); ok && !slices.Contains(matched, name) {
+			matched = append(matched, name)
+		}
+	}
+	return matched, nil
+}
+
+// runTargetsDoc documents each symbol of pkgPath that targets name, or that
+// match those of them that are patterns, and joins the results into one
+// document: the texts one after another, or the JSON documents as an array. It
+// returns the symbols documented, how many there were in all, and the targets
+// that named or matched no symbol.
+func (s *GodocServer) runTargetsDoc(ctx context.Context, scope, workingDir, format string, cmdFlags []string, pkgPath string, targets []string) (cachedDoc, []string, int, []string, error) {
+	var symbols, matched, missing []string
+	for _, target := range targets {
+		if !isGlobTarget(target) {
+			if !slices.Contains(matched, target) {
+				matched = append(matched, target)
+			}
+			continue
+		}
+		if symbols == nil {
+			symbols = s.docSymbols(ctx, scope, workingDir, pkgPath)
+		}
+		m, err := matchTargets(target, symbols)
+		if err != nil {
+			return cachedDoc{}, nil, 0, nil, err
+		}
+		if len(m) == 0 {
+			missing = append(missing, target)
+		}
+		for _, name := range m {
+			if !slices.Contains(matched, name) {
+				matched = append(matched, name)
+			}
+		}
+	}
+	total := len(matched)
+	matched = matched[:min(total, maxGlobSymbols)]
+
+	parts := make([]string, 0, len(matched))
+	documented := make([]string, 0, len(matched))
+	var stderr []string
+	for _, symbol := range matched {
+		doc, err := s.runGoDoc(ctx, scope, workingDir, format, append(slices.Clone(cmdFlags), pkgPath, symbol)...)
+		// Named symbols that don't exist are reported rather than failing the others
+		if errorCode(err) == codeSymbolNotFound && !isGlobTarget(symbol) && len(targets) > 1 {
+			missing = append(missing, symbol)
+			total--
+			continue
+		}
+		if err != nil {
+			return cachedDoc{}, nil, 0, nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		parts = append(parts, strings.TrimRight(strings.Join(doc.lines, "\n"), "\n"))
+		documented = append(documented, symbol)
+		if doc.stderr != "" && !slices.Contains(stderr, doc.stderr) {
+			stderr = append(stderr, doc.stderr)
+		}
+	}
+	if len(documented) == 0 {
+		return cachedDoc{}, nil, 0, nil, withCode(codeSymbolNotFound, fmt.Errorf("no symbols in package %s match %s\nOmit target to list the package", pkgPath, strings.Join(quoteAll(targets), ", ")))
+	}
+	content := strings.Join(parts, "\n\n") + "\n"
+	if format == formatJSON {
+		content = "[\n" + strings.Join(parts, ",\n") + "\n]\n"
+	}
+	doc := newCachedDoc(content)
+	doc.stderr = strings.Join(stderr, "")
+	return doc, documented, total, missing, nil
+}
+
+// quoteAll returns each of names quoted
+func quoteAll(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = strconv.Quote(name)
+	}
+	return quoted
+}
+
+// globNote lists the symbols a target pattern documented
+func globNote(pattern string, matched []string, total int) string {
+	if total > len(matched) {
+		return fmt.Sprintf("Note: %d symbols match %s; showing the first %d: %s. Narrow the pattern to see the rest.\n", total, pattern, len(matched), strings.Join(matched, ", "))
+	}
+	return fmt.Sprintf("Note: %d symbols match %s: %s.\n", total, pattern, strings.Join(matched, ", "))
+}
+
+// filterEntries keeps the entries declaring one of the symbols in names
+func filterEntries(entries []docEntry, names []string) []docEntry {
+	var kept []docEntry
+	for _, e := range entries {
+		name := e.Name
+		if e.Recv != "" {
+			recv, _, _ := strings.Cut(strings.TrimLeft(e.Recv, "*"), "[")
+			name = recv + "." + e.Name
+		}
+		if slices.Contains(names, name) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
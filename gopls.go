@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// goplsStartTimeout bounds how long a gopls instance may take to initialize
+const goplsStartTimeout = 30 * time.Second
+
+// maxOnDemandGopls bounds the gopls instances started for the modules of code
+// navigation queries, beyond those of the configured workspaces
+const maxOnDemandGopls = 4
+
+// goplsPool keeps one warm gopls instance per configured workspace
+type goplsPool struct {
+	mu      sync.Mutex
+	clients []*goplsClient
+	logger  *logrus.Logger
+	// gen counts starts, so instances still starting when the workspaces
+	// change are shut down instead of joining the pool
+	gen int
+	// onDemand lists the clients started by forWorkspace, oldest first
+	onDemand []*goplsClient
+	// startMu serializes on-demand starts, so a module gets one instance
+	startMu sync.Mutex
+}
+
+// start launches gopls for every workspace in the background. Workspaces whose
+// gopls fails to start are skipped and served by the other backends.
+func (p *goplsPool) start(goplsPath string, roots []string) {
+	p.mu.Lock()
+	p.gen++
+	gen := p.gen
+	p.mu.Unlock()
+	for _, root := range roots {
+		go func() {
+			log := p.logger.WithField("workspace", root)
+			c, err := startGopls(goplsPath, root, log)
+			if err != nil {
+				log.WithError(err).Warn("Failed to start gopls, workspace will use go doc")
+				return
+			}
+			p.mu.Lock()
+			current := gen == p.gen
+			if current {
+				p.clients = append(p.clients, c)
+			}
+			p.mu.Unlock()
+			if !current {
+				c.close()
+				return
+			}
+			log.Info("gopls ready")
+		}()
+	}
+}
+
+// restart replaces the running gopls instances with ones for roots
+func (p *goplsPool) restart(goplsPath string, roots []string) {
+	p.close()
+	p.start(goplsPath, roots)
+}
+
+// forDir returns the client whose workspace contains dir, if any
+func (p *goplsPool) forDir(dir string) *goplsClient {
+	if p == nil || dir == "" {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range p.clients {
+		if c.exited() {
+			continue
+		}
+		if rel, err := filepath.Rel(c.root, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			return c
+		}
+	}
+	return nil
+}
+
+// forWorkspace returns the client whose workspace contains the module at root,
+// starting gopls for it when there is none. Past maxOnDemandGopls instances
+// started this way, the oldest is shut down.
+func (p *goplsPool) forWorkspace(goplsPath, root string) (*goplsClient, error) {
+	if c := p.forDir(root); c != nil {
+		return c, nil
+	}
+	p.startMu.Lock()
+	defer p.startMu.Unlock()
+	if c := p.forDir(root); c != nil {
+		return c, nil
+	}
+	p.mu.Lock()
+	gen := p.gen
+	p.mu.Unlock()
+	log := p.logger.WithField("workspace", root)
+	c, err := startGopls(goplsPath, root, log)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	if gen != p.gen {
+		p.mu.Unlock()
+		c.close()
+		return nil, errors.New("gopls was restarted")
+	}
+	p.clients = append(p.clients, c)
+	p.onDemand = append(p.onDemand, c)
+	var evicted *goplsClient
+	if len(p.onDemand) > maxOnDemandGopls {
+		evicted = p.onDemand[0]
+		p.onDemand = p.onDemand[1:]
+		p.clients = slices.DeleteFunc(p.clients, func(c *goplsClient) bool { return c == evicted })
+	}
+	p.mu.Unlock()
+	if evicted != nil {
+		go evicted.close()
+	}
+	log.Info("gopls ready")
+	return c, nil
+}
+
+// close shuts down every gopls instance
+func (p *goplsPool) close() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gen++
+	for _, c := range p.clients {
+		c.close()
+	}
+	p.clients, p.onDemand = nil, nil
+}
+
+// goplsClient is a minimal LSP client for one gopls process
+type goplsClient struct {
+	root string
+	cmd  *exec.Cmd
+	log  *logrus.Entry
+
+	writeMu sync.Mutex
+	stdin   io.WriteCloser
+	nextID  atomic.Int64
+
+	mu      sync.Mutex
+	pending map[int64]chan lspResponse
+	done    chan struct{}
+}
+
+// lspMessage is any JSON-RPC message exchanged with gopls
+type lspMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+type lspResponse struct {
+	result json.RawMessage
+	err    error
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *lspError) Error() string {
+	return fmt.Sprintf("gopls error %d: %s", e.Code, e.Message)
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspLocation struct {
+	URI   string `json:"uri"`
+	Range struct {
+		Start lspPosition `json:"start"`
+		End   lspPosition `json:"end"`
+	} `json:"range"`
+}
+
+type lspSymbol struct {
+	Name          string      `json:"name"`
+	ContainerName string      `json:"containerName"`
+	Location      lspLocation `json:"location"`
+}
+
+// startGopls runs gopls for the workspace at root and completes the LSP handshake
+func startGopls(goplsPath, root string, log *logrus.Entry) (*goplsClient, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(goplsPath, "serve")
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	c := &goplsClient{
+		root:    root,
+		cmd:     cmd,
+		log:     log,
+		stdin:   stdin,
+		pending: make(map[int64]chan lspResponse),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop(bufio.NewReader(stdout))
+
+	ctx, cancel := context.WithTimeout(context.Background(), goplsStartTimeout)
+	defer cancel()
+	rootURI := fileURI(root)
+	err = c.call(ctx, "initialize", map[string]any{
+		"processId": nil,
+		"rootUri":   rootURI,
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"hover": map[string]any{"contentFormat": []string{"plaintext"}},
+				"signatureHelp": map[string]any{
+					"signatureInformation": map[string]any{
+						"documentationFormat":  []string{"plaintext"},
+						"parameterInformation": map[string]any{"labelOffsetSupport": true},
+					},
+				},
+			},
+		},
+		"workspaceFolders": []map[string]any{{"uri": rootURI, "name": filepath.Base(root)}},
+	}, nil)
+	if err == nil {
+		err = c.notify("initialized", map[string]any{})
+	}
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+
+	// Load the workspace now so the first real query is fast
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		var symbols []lspSymbol
+		start := time.Now()
+		if err := c.call(ctx, "workspace/symbol", map[string]any{"query": "main"}, &symbols); err != nil {
+			log.WithError(err).Debug("gopls warm-up query failed")
+			return
+		}
+		log.WithField("duration", time.Since(start)).Debug("gopls workspace loaded")
+	}()
+	return c, nil
+}
+
+// readLoop dispatches responses from gopls to their callers and answers the
+// requests gopls sends to its client
+func (c *goplsClient) readLoop(r *bufio.Reader) {
+	defer func() {
+		c.mu.Lock()
+		for id, ch := range c.pending {
+			ch <- lspResponse{err: errors.New("gopls exited")}
+			delete(c.pending, id)
+		}
+		c.mu.Unlock()
+		close(c.done)
+	}()
+	tp := textproto.NewReader(r)
+	for {
+		header, err := tp.ReadMIMEHeader()
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil {
+			return
+		}
+		body := make([]byte, n)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return
+		}
+		var msg lspMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			continue
+		}
+
+		switch {
+		case msg.ID != nil && msg.Method != "":
+			c.reply(msg)
+		case msg.ID != nil:
+			c.mu.Lock()
+			ch, ok := c.pending[*msg.ID]
+			delete(c.pending, *msg.ID)
+			c.mu.Unlock()
+			if !ok {
+				continue
+			}
+			if msg.Error != nil {
+				ch <- lspResponse{err: msg.Error}
+			} else {
+				ch <- lspResponse{result: msg.Result}
+			}
+		}
+	}
+}
+
+// reply answers a request from gopls. Configuration requests get defaults and
+// everything else, such as progress and capability registration, is accepted.
+func (c *goplsClient) reply(req lspMessage) {
+	var result any
+	if req.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(req.Params, &params)
+		result = make([]any, len(params.Items))
+	}
+	c.write(map[string]any{"jsonrpc": "2.0", "id": *req.ID, "result": result})
+}
+
+// call sends a request to gopls and decodes its result into result when non-nil
+func (c *goplsClient) call(ctx context.Context, method string, params, result any) error {
+	id := c.nextID.Add(1)
+	ch := make(chan lspResponse, 1)
+	c.mu.Lock()
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.write(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return err
+	}
+	select {
+	case resp := <-ch:
+		if resp.err != nil {
+			return resp.err
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.result, result)
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		c.notify("$/cancelRequest", map[string]any{"id": id})
+		return ctx.Err()
+	}
+}
+
+// notify sends a notification to gopls
+func (c *goplsClient) notify(method string, params any) error {
+	return c.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// write frames msg with an LSP Content-Length header
+func (c *goplsClient) write(msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.stdin, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = c.stdin.Write(body)
+	return err
+}
+
+// close asks gopls to exit, killing it if it does not
+func (c *goplsClient) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if c.call(ctx, "shutdown", nil, nil) == nil {
+		c.notify("exit", nil)
+	}
+	c.stdin.Close()
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		c.cmd.Process.Kill()
+	}
+	c.cmd.Wait()
+}
+
+// exited reports whether the gopls process has stopped
+func (c *goplsClient) exited() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// symbolDoc documents target in package pkgPath using gopls hover information,
+// followed by the location of its definition
+func (c *goplsClient) symbolDoc(ctx context.Context, pkgPath, target string) (string, error) {
+	var symbols []lspSymbol
+	if err := c.call(ctx, "workspace/symbol", map[string]any{"query": target}, &symbols); err != nil {
+		return "", err
+	}
+	var match *lspSymbol
+	for i, sym := range symbols {
+		if sym.Name == target && sym.ContainerName == pkgPath {
+			match = &symbols[i]
+			break
+		}
+	}
+	if match == nil {
+		return "", fmt.Errorf("gopls found no symbol %s in package %s", target, pkgPath)
+	}
+
+	var hover struct {
+		Contents struct {
+			Value string `json:"value"`
+		} `json:"contents"`
+	}
+	err := c.call(ctx, "textDocument/hover", map[string]any{
+		"textDocument": map[string]any{"uri": match.Location.URI},
+		"position":     match.Location.Range.Start,
+	}, &hover)
+	if err != nil {
+		return "", err
+	}
+	if hover.Contents.Value == "" {
+		return "", fmt.Errorf("gopls returned no hover information for %s", target)
+	}
+
+	start := match.Location.Range.Start
+	return fmt.Sprintf("%s\n\nDefined at %s:%d:%d\n", strings.TrimSpace(hover.Contents.Value), uriPath(match.Location.URI), start.Line+1, start.Character+1), nil
+}
+
+// uriPath returns the file path of a file:// URI, or the URI itself otherwise
+func uriPath(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		return filepath.FromSlash(u.Path)
+	}
+	return uri
+}
+
+// fileURI returns the file:// URI of an absolute path
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/packages"
+)
+
+const grepSourceDescription = `Search the Go source files of a package or module for a regular expression.
+Returns each matching line as file:line with the lines around it, up to max_matches. Use it for
+what documentation doesn't answer, such as where a constant is defined or used, how an unexported
+helper works, or which files mention an error message. path takes the same forms as get_doc's;
+end it with /... to include the packages below it (e.g., "./..." for a whole module or
+"net/http/..."). Files excluded by build constraints are searched too.`
+
+// Limits on grep_source results
+const (
+	defaultGrepMatches = 50
+	maxGrepMatches     = 500
+	defaultGrepContext = 2
+	maxGrepContext     = 10
+	// maxGrepFileBytes skips files too large to be hand-written source, such
+	// as generated tables
+	maxGrepFileBytes = 4 << 20
+)
+
+// grepSourceSchema is the grep_source input schema
+var grepSourceSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"pattern": map[string]any{
+			"type":        "string",
+			"description": "Regular expression to search for, in Go (RE2) syntax, matched against each line (e.g., 'MaxHeaderBytes\\s*=').",
+		},
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Package to search, as for get_doc (e.g., 'net/http', './pkg', or 'github.com/user/repo'). End it with '/...' to include the packages below it.",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory for relative paths and the module's own packages. Defaults to the session working directory.",
+		},
+		"ignore_case": map[string]any{
+			"type":        "boolean",
+			"description": "Match without regard to case.",
+			"default":     false,
+		},
+		"include_tests": map[string]any{
+			"type":        "boolean",
+			"description": "Search _test.go files too.",
+			"default":     false,
+		},
+		"context": map[string]any{
+			"type":        "integer",
+			"description": "Number of lines to show before and after each match.",
+			"minimum":     0,
+			"maximum":     maxGrepContext,
+			"default":     defaultGrepContext,
+		},
+		"max_matches": map[string]any{
+			"type":        "integer",
+			"description": "Stop after this many matching lines.",
+			"minimum":     1,
+			"maximum":     maxGrepMatches,
+			"default":     defaultGrepMatches,
+		},
+	},
+	Required: []string{"pattern", "path"},
+}
+
+// grepOutputSchema is the outputSchema of grep_source, describing grepOutput
+var grepOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"pattern":        map[string]any{"type": "string", "description": "The pattern searched for"},
+		"files_searched": map[string]any{"type": "integer", "description": "Number of files searched"},
+		"truncated":      map[string]any{"type": "boolean", "description": "Whether the search stopped at max_matches"},
+		"matches": map[string]any{
+			"type":        "array",
+			"description": "Matching lines in file order",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"file":   map[string]any{"type": "string", "description": "Absolute path of the file"},
+					"line":   map[string]any{"type": "integer", "description": "Line number of the match"},
+					"text":   map[string]any{"type": "string", "description": "The matching line"},
+					"before": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Lines before the match"},
+					"after":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Lines after the match"},
+				},
+				"required": []string{"file", "line", "text"},
+			},
+		},
+	},
+	Required: []string{"pattern", "files_searched", "truncated", "matches"},
+}
+
+// grepOutput is the structured content of a grep_source result
+type grepOutput struct {
+	Pattern       string      `json:"pattern"`
+	FilesSearched int         `json:"files_searched"`
+	Truncated     bool        `json:"truncated"`
+	Matches       []grepMatch `json:"matches"`
+}
+
+// grepMatch is a line matching a grep_source pattern, with its context
+type grepMatch struct {
+	File   string   `json:"file"`
+	Line   int      `json:"line"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// handleGrepSource implements the grep_source tool
+func (s *GodocServer) handleGrepSource(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if err := s.runtime.Load().toolchainErr; err != nil {
+		return errorResultFromErr("cannot load packages", err), nil
+	}
+	pattern := request.GetString("pattern", "")
+	if pattern == "" {
+		return errorResult(codeInvalidArgument, "pattern must not be empty"), nil
+	}
+	expr := pattern
+	if request.GetBool("ignore_case", false) {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("invalid pattern: %v", err)), nil
+	}
+	path := request.GetString("path", "")
+	if path == "" {
+		return errorResult(codeInvalidArgument, "invalid or missing path parameter"), nil
+	}
+	contextLines := request.GetInt("context", defaultGrepContext)
+	if contextLines < 0 || contextLines > maxGrepContext {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("context must be between 0 and %d, got %d", maxGrepContext, contextLines)), nil
+	}
+	maxMatches := request.GetInt("max_matches", defaultGrepMatches)
+	if maxMatches < 1 || maxMatches > maxGrepMatches {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("max_matches must be between 1 and %d, got %d", maxGrepMatches, maxMatches)), nil
+	}
+
+	log := ctxLogger(ctx, s.logger).WithFields(logrus.Fields{"pattern": pattern, "path": path})
+	progress := s.newProgressReporter(ctx, request)
+	ctx = withProgress(ctx, progress)
+	dirs, err := s.sourceDirs(ctx, request, path)
+	if err != nil {
+		return errorResultFromErr("failed to find the source of "+path, err), nil
+	}
+
+	out := &grepOutput{Pattern: pattern, Matches: make([]grepMatch, 0)}
+	includeTests := request.GetBool("include_tests", false)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || !strings.HasSuffix(name, ".go") || (!includeTests && strings.HasSuffix(name, "_test.go")) {
+				continue
+			}
+			file := filepath.Join(dir, name)
+			if info, err := e.Info(); err != nil || info.Size() > maxGrepFileBytes {
+				continue
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			out.FilesSearched++
+			if out.Truncated = grepFile(out, file, string(data), re, contextLines, maxMatches); out.Truncated {
+				break
+			}
+		}
+		if out.Truncated {
+			break
+		}
+	}
+	log.WithFields(logrus.Fields{
+		"files":   out.FilesSearched,
+		"matches": len(out.Matches),
+	}).Debug("Searched source")
+
+	var sb strings.Builder
+	switch {
+	case len(out.Matches) == 0:
+		fmt.Fprintf(&sb, "No lines match %q in the %d files searched.", pattern, out.FilesSearched)
+	case out.Truncated:
+		fmt.Fprintf(&sb, "First %d matches of %q; narrow the pattern or path, or raise max_matches, to see more.\n", len(out.Matches), pattern)
+	default:
+		fmt.Fprintf(&sb, "%d matches of %q in the %d files searched.\n", len(out.Matches), pattern, out.FilesSearched)
+	}
+	for _, m := range out.Matches {
+		sb.WriteString("\n")
+		for i, line := range m.Before {
+			fmt.Fprintf(&sb, "%s-%d-%s\n", m.File, m.Line-len(m.Before)+i, line)
+		}
+		fmt.Fprintf(&sb, "%s:%d:%s\n", m.File, m.Line, m.Text)
+		for i, line := range m.After {
+			fmt.Fprintf(&sb, "%s-%d-%s\n", m.File, m.Line+1+i, line)
+		}
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// grepFile appends the lines of file matching re to out, with up to
+// contextLines lines around each, and reports whether out reached maxMatches
+func grepFile(out *grepOutput, file, content string, re *regexp.Regexp, contextLines, maxMatches int) bool {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		out.Matches = append(out.Matches, grepMatch{
+			File:   file,
+			Line:   i + 1,
+			Text:   line,
+			Before: slices.Clone(lines[max(0, i-contextLines):i]),
+			After:  slices.Clone(lines[i+1 : min(len(lines), i+1+contextLines)]),
+		})
+		if len(out.Matches) == maxMatches {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceDirs returns the directories of the packages path names: a package as
+// get_doc takes it, or all the packages below one when it ends with "/...".
+// Packages outside the standard library and the working directory's module
+// are downloaded into a temporary project first.
+func (s *GodocServer) sourceDirs(ctx context.Context, request mcp.CallToolRequest, path string) ([]string, error) {
+	dir, err := s.loadDir(ctx, request, path)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	pkgs, err := packages.Load(&packages.Config{Context: ctx, Mode: packages.NeedName | packages.NeedFiles, Dir: dir}, path)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	var errs []string
+	for _, pkg := range pkgs {
+		files := slices.Concat(pkg.GoFiles, pkg.IgnoredFiles)
+		if len(files) == 0 {
+			for _, e := range pkg.Errors {
+				errs = append(errs, e.Msg)
+			}
+			continue
+		}
+		if d := filepath.Dir(files[0]); !slices.Contains(dirs, d) {
+			dirs = append(dirs, d)
+		}
+	}
+	if len(dirs) == 0 {
+		msg := fmt.Sprintf("no Go packages match %s", path)
+		if len(errs) > 0 {
+			msg = strings.Join(errs, "; ")
+		}
+		return nil, withCode(codePkgNotFound, errors.New(msg))
+	}
+	slices.Sort(dirs)
+	return dirs, nil
+}
+
+// loadDir returns the directory to load the packages path names from, as
+// sourceDirs takes it: the working directory, or a temporary project the
+// package was downloaded into
+func (s *GodocServer) loadDir(ctx context.Context, request mcp.CallToolRequest, path string) (string, error) {
+	workingDir := request.GetString("working_dir", s.sessions.get(ctx).workingDir)
+	if workingDir == "" {
+		workingDir = s.rootWorkingDir(ctx, path)
+	}
+	if workingDir != "" {
+		if err := checkWorkingDir(workingDir); err != nil {
+			return "", err
+		}
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(path, "..."), "/")
+	dir := workingDir
+	switch {
+	case base == "":
+		return "", withCode(codeInvalidArgument, fmt.Errorf("invalid path %q", path))
+	case strings.HasPrefix(base, "."):
+		if workingDir == "" {
+			return "", withCode(codeInvalidArgument, errors.New("working_dir is required for relative paths (including '.')"))
+		}
+	case filepath.IsAbs(base):
+		if dir == "" {
+			dir = base
+		}
+	case dir == "" && !isStdLib(base):
+		project, err := s.projectManager.GetOrCreateProject(ctx, base)
+		if err != nil {
+			return "", err
+		}
+		dir = project
+	}
+	return dir, nil
+}
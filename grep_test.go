@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGrepSource(t *testing.T) {
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod":         "module example.com/mod\n\ngo 1.21\n",
+		"a/a.go":         "package a\n\n// Limit bounds requests\nconst Limit = 10\n\nvar limit = Limit\n",
+		"a/a_test.go":    "package a\n\nconst testLimit = Limit\n",
+		"b/b.go":         "package b\n\nimport \"example.com/mod/a\"\n\nvar Max = a.Limit\n",
+		"b/b_windows.go": "package b\n\nvar windowsLimit = 1\n",
+		"c/c.go":         "package c\n",
+	})
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		// want is the matches as file:line, relative to the module
+		want      []string
+		truncated bool
+	}{
+		{"empty pattern", map[string]any{"path": "./...", "working_dir": dir}, codeInvalidArgument, nil, false},
+		{"bad pattern", map[string]any{"pattern": "(", "path": "./...", "working_dir": dir}, codeInvalidArgument, nil, false},
+		{"no path", map[string]any{"pattern": "Limit", "working_dir": dir}, codeInvalidArgument, nil, false},
+		{"bad context", map[string]any{"pattern": "Limit", "path": "./...", "context": maxGrepContext + 1, "working_dir": dir}, codeInvalidArgument, nil, false},
+		{"bad max matches", map[string]any{"pattern": "Limit", "path": "./...", "max_matches": 0, "working_dir": dir}, codeInvalidArgument, nil, false},
+		{"relative without working dir", map[string]any{"pattern": "Limit", "path": "./..."}, codeInvalidArgument, nil, false},
+		{"no package", map[string]any{"pattern": "Limit", "path": "./nope", "working_dir": dir}, codePkgNotFound, nil, false},
+		{"package", map[string]any{"pattern": `\bLimit\b`, "path": "./a", "working_dir": dir}, "", []string{"a/a.go:3", "a/a.go:4", "a/a.go:6"}, false},
+		{"module", map[string]any{"pattern": `\bLimit\b`, "path": "./...", "working_dir": dir}, "", []string{"a/a.go:3", "a/a.go:4", "a/a.go:6", "b/b.go:5"}, false},
+		{"constrained files", map[string]any{"pattern": "Limit = ", "path": "./b", "working_dir": dir}, "", []string{"b/b_windows.go:3"}, false},
+		{"tests", map[string]any{"pattern": "Limit = ", "path": "./a", "include_tests": true, "working_dir": dir}, "", []string{"a/a.go:4", "a/a_test.go:3"}, false},
+		{"ignore case", map[string]any{"pattern": "^VAR (LIMIT|MAX)", "path": "./...", "ignore_case": true, "working_dir": dir}, "", []string{"a/a.go:6", "b/b.go:5"}, false},
+		{"truncated", map[string]any{"pattern": "Limit", "path": "./...", "max_matches": 2, "working_dir": dir}, "", []string{"a/a.go:3", "a/a.go:4"}, true},
+		{"no matches", map[string]any{"pattern": "Unlimited", "path": "./...", "working_dir": dir}, "", nil, false},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleGrepSource, "grep_source", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*grepOutput)
+		var got []string
+		for _, m := range out.Matches {
+			rel, _ := filepath.Rel(dir, m.File)
+			got = append(got, filepath.ToSlash(rel)+":"+strconv.Itoa(m.Line))
+		}
+		if !slices.Equal(got, tt.want) || out.Truncated != tt.truncated {
+			t.Errorf("%s: matches %q, truncated %v; want %q, %v", tt.name, got, out.Truncated, tt.want, tt.truncated)
+		}
+	}
+}
+
+func TestGrepSourceContext(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		context int
+		before  int
+		after   int
+	}{
+		{0, 0, 0},
+		{2, 2, 2},
+	}
+	for _, tt := range tests {
+		result := callTool(t, context.Background(), s.handleGrepSource, "grep_source", map[string]any{"pattern": `^\tSeekStart\b`, "path": "io", "context": tt.context})
+		out, ok := result.StructuredContent.(*grepOutput)
+		if !ok || len(out.Matches) != 1 {
+			t.Fatalf("context %d: %s", tt.context, resultText(result))
+		}
+		m := out.Matches[0]
+		if len(m.Before) != tt.before || len(m.After) != tt.after || filepath.Base(m.File) != "io.go" {
+			t.Errorf("context %d: %s:%d with %d lines before and %d after, want io.go with %d and %d", tt.context, m.File, m.Line, len(m.Before), len(m.After), tt.before, tt.after)
+		}
+		if text := resultText(result); strings.Contains(text, "io.go-"+strconv.Itoa(m.Line+1)+"-") != (tt.after > 0) {
+			t.Errorf("context %d: text shows the wrong context lines:\n%s", tt.context, text)
+		}
+	}
+}
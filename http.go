@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// readinessCheck is a single named probe run by the /readyz endpoint
+type readinessCheck struct {
+	name  string
+	check func() error
+}
+
+// newHTTPHandler builds the HTTP mux serving the MCP transports alongside health probes.
+// All endpoints are served under the configured base path.
+func (s *GodocServer) newHTTPHandler(mcpServer *server.MCPServer, cfg *Config) http.Handler {
+	mux := http.NewServeMux()
+	base := cfg.BasePath
+	if cfg.HTTPTransport == transportStreamable || cfg.HTTPTransport == transportBoth {
+		sessionHeader := func(r *http.Request) string { return r.Header.Get(server.HeaderKeySessionID) }
+		mux.Handle(base+"/mcp", s.withSubscriptions(server.NewStreamableHTTPServer(mcpServer), sessionHeader, replyJSON))
+	}
+	if cfg.HTTPTransport == transportSSE || cfg.HTTPTransport == transportBoth {
+		// Legacy clients open an event stream on /sse and post requests to the
+		// /message endpoint advertised on that stream, so it must include the
+		// externally visible base path and URL
+		sse := server.NewSSEServer(mcpServer,
+			server.WithKeepAlive(true),
+			server.WithStaticBasePath(base),
+			server.WithBaseURL(cfg.AdvertiseURL),
+		)
+		mux.Handle(base+"/sse", sse.SSEHandler())
+		sessionParam := func(r *http.Request) string { return r.URL.Query().Get("sessionId") }
+		mux.Handle(base+"/message", s.withSubscriptions(sse.MessageHandler(), sessionParam, replySSE(sse)))
+	}
+	mux.HandleFunc(base+"/doc", s.handleRESTDoc)
+	if cfg.WebUI {
+		mux.Handle(base+"/ui/", s.newWebUI(base))
+	}
+	mux.HandleFunc(base+"/healthz", s.handleHealthz)
+	mux.HandleFunc(base+"/readyz", s.handleReadyz)
+
+	handler := withRequestLogging(s.logger, withTraceContext(withCORS(cfg.CORS, mux)))
+	if cfg.TrustForwarded {
+		handler = withForwardedHeaders(handler)
+	}
+	return handler
+}
+
+// withForwardedHeaders rewrites the request's client address, scheme, and host from
+// the X-Forwarded-* headers set by a trusted reverse proxy
+func withForwardedHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			// The left-most entry is the original client
+			client, _, _ := strings.Cut(fwd, ",")
+			r.RemoteAddr = strings.TrimSpace(client)
+		}
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+			r.URL.Scheme = proto
+		}
+		if host := r.Header.Get("X-Forwarded-Host"); host != "" {
+			r.Host = host
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveHTTP serves the MCP server over HTTP until ctx is cancelled, then stops
+// accepting connections and waits up to the drain timeout for in-flight tool calls
+func (s *GodocServer) serveHTTP(ctx context.Context, mcpServer *server.MCPServer, cfg *Config) error {
+	ln, err := listen(cfg.HTTPAddr)
+	if err != nil {
+		return err
+	}
+	httpSrv := &http.Server{
+		Handler: s.newHTTPHandler(mcpServer, cfg),
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- httpSrv.Serve(ln) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.logger.WithField("drain_timeout", cfg.DrainTimeout).Info("Shutting down http server...")
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+	defer cancel()
+
+	// Shutdown closes the listeners right away, but long-lived event streams keep
+	// it waiting until the deadline, so wait on the tool calls themselves instead
+	go httpSrv.Shutdown(drainCtx)
+	if !s.waitInFlight(drainCtx) {
+		s.logger.WithField("in_flight", s.inFlight.Load()).Warn("Drain timeout exceeded, abandoning in-flight tool calls")
+	}
+	if err := httpSrv.Close(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// trackInFlight counts running tool calls so shutdown can wait for them to finish
+func (s *GodocServer) trackInFlight(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		start := time.Now()
+		result, err := next(ctx, request)
+		d := time.Since(start)
+		s.metrics.observeCall(d, result, err)
+		s.recordFailure(ctx, request, d, result, err)
+		return result, err
+	}
+}
+
+// waitInFlight blocks until no tool calls are running, reporting false if ctx expires first
+func (s *GodocServer) waitInFlight(ctx context.Context) bool {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for s.inFlight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
+// handleHealthz reports liveness; the process is alive if it can answer at all
+func (s *GodocServer) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz reports readiness by running every readiness check
+func (s *GodocServer) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	status := http.StatusOK
+	results := make(map[string]string)
+	for _, rc := range s.readinessChecks() {
+		if err := rc.check(); err != nil {
+			status = http.StatusServiceUnavailable
+			results[rc.name] = err.Error()
+			s.logger.WithField("check", rc.name).WithError(err).Warn("Readiness check failed")
+			continue
+		}
+		results[rc.name] = "ok"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(results)
+}
+
+// readinessChecks lists the conditions required to serve documentation requests
+func (s *GodocServer) readinessChecks() []readinessCheck {
+	return []readinessCheck{
+		{name: "go_toolchain", check: checkGoToolchain},
+		{name: "temp_dir", check: checkTempDirWritable},
+		{name: "cache", check: s.checkCache},
+	}
+}
+
+// checkGoToolchain verifies the go binary can be found on PATH
+func checkGoToolchain() error {
+	if _, err := exec.LookPath("go"); err != nil {
+		return fmt.Errorf("go toolchain not found: %v", err)
+	}
+	return nil
+}
+
+// checkTempDirWritable verifies temporary projects can be created
+func checkTempDirWritable() error {
+	dir, err := os.MkdirTemp("", "godoc-mcp-readyz-*")
+	if err != nil {
+		return fmt.Errorf("temp dir not writable: %v", err)
+	}
+	return os.RemoveAll(dir)
+}
+
+// checkCache verifies the documentation cache has not been shut down
+func (s *GodocServer) checkCache() error {
+	if s.cache == nil || s.closed.Load() {
+		return fmt.Errorf("documentation cache is not running")
+	}
+	return nil
+}
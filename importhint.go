@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// importHint tells code using a documented symbol how to import its package
+type importHint struct {
+	ImportPath  string `json:"import_path"`
+	PackageName string `json:"package_name"`
+	// Import is the import declaration, naming the package when its name
+	// isn't the one assumed from its path
+	Import string `json:"import"`
+	// Module, Version, and GoGet identify the module providing the package,
+	// omitted for the standard library and the working directory's own module
+	Module  string `json:"module,omitempty"`
+	Version string `json:"version,omitempty"`
+	GoGet   string `json:"go_get,omitempty"`
+}
+
+// importHintSchema is the output schema of importHint
+var importHintSchema = map[string]any{
+	"type":        "object",
+	"description": "How to use the documented symbols from other code, present for symbol lookups of importable packages",
+	"properties": map[string]any{
+		"import_path":  map[string]any{"type": "string"},
+		"package_name": map[string]any{"type": "string"},
+		"import":       map[string]any{"type": "string", "description": "The import declaration, naming the package when its name differs from the one its path suggests"},
+		"module":       map[string]any{"type": "string", "description": "Module providing the package, omitted for the standard library and the working directory's own module"},
+		"version":      map[string]any{"type": "string", "description": "Version of the module the documentation was generated from"},
+		"go_get":       map[string]any{"type": "string", "description": "The go get command adding module at version to a project"},
+	},
+	"required": []string{"import_path", "package_name", "import"},
+}
+
+// importHint returns how to import the package of the symbols req documents,
+// or nil for package overviews and packages that can't be imported
+func (s *GodocServer) importHint(ctx context.Context, req docRequest, doc cachedDoc, version string) *importHint {
+	if req.target == "" {
+		return nil
+	}
+	name := assumedPackageName(req.path)
+	if pd := s.structuredDoc(ctx, req.cacheScope, req.workingDir, req.format, doc, req.cmdArgs); pd != nil && pd.Name != "" {
+		name = pd.Name
+	}
+	if name == "main" {
+		return nil
+	}
+	hint := &importHint{ImportPath: req.path, PackageName: name, Import: "import " + strconv.Quote(req.path)}
+	if name != assumedPackageName(req.path) {
+		hint.Import = fmt.Sprintf("import %s %q", name, req.path)
+	}
+	if isStdLib(req.path) {
+		return hint
+	}
+	// Packages of the working directory's own module aren't among its requirements
+	if mod, _ := requiredModule(req.path, req.workingDir); mod != "" {
+		hint.Module, hint.Version = mod, version
+		hint.GoGet = "go get " + mod
+		if version != "" {
+			hint.GoGet += "@" + version
+		}
+	}
+	return hint
+}
+
+// String returns the hint as the line following symbol documentation
+func (h *importHint) String() string {
+	line := "To use this: " + h.Import
+	if h.GoGet != "" {
+		line += "; " + h.GoGet
+	}
+	return line
+}
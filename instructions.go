@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultInstructions is the usage guide sent to clients on initialize unless
+// -instructions replaces it
+const defaultInstructions = `godoc-mcp serves Go documentation. Use get_doc before reading Go source files:
+- Start with the package overview (path only), then look up symbols with target.
+- When you don't know which package covers a topic, find it with search_docs, or among third-party packages with find_packages.
+- Standard library packages take their import path ("net/http"); other packages need their full import path ("github.com/user/repo").
+- For packages of a local module, pass working_dir, or set it once with set_session_defaults, and use relative paths such as "./pkg".
+- Large documents are paginated; continue with the next_cursor reported with each page.`
+
+// Runtime requirements a tool can depend on
+const (
+	// needGo marks tools that need a go toolchain on PATH
+	needGo = "go"
+	// needSampling marks tools that need a client that supports sampling
+	needSampling = "sampling"
+	// needGopls marks tools that need the gopls binary
+	needGopls = "gopls"
+)
+
+// runtimeCaps records what the server's environment provides
+type runtimeCaps struct {
+	goToolchain bool
+	network     bool
+	gopls       bool
+	// toolchainErr and modCacheErr explain why documentation requests, or
+	// downloads, will fail
+	toolchainErr error
+	modCacheErr  error
+}
+
+// detectRuntimeCaps probes the environment for the go toolchain, a writable
+// module cache, module proxy access, and gopls
+func detectRuntimeCaps(ctx context.Context, cfg *Config) *runtimeCaps {
+	caps := &runtimeCaps{network: true}
+	caps.goToolchain = checkGoToolchain() == nil
+	caps.toolchainErr = checkToolchain(ctx)
+	if caps.goToolchain {
+		caps.modCacheErr = checkModCache(ctx)
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		if env, err := goEnv(ctx, "GOPROXY"); err == nil {
+			caps.network = env["GOPROXY"] != "off"
+		}
+	}
+	_, err := exec.LookPath(cfg.GoplsPath)
+	caps.gopls = err == nil
+	return caps
+}
+
+// serverHas reports whether the server's environment meets a requirement.
+// Client requirements are checked per session by filterTools.
+func (s *GodocServer) serverHas(need string) bool {
+	switch need {
+	case needGo:
+		return s.runtime.Load().goToolchain
+	case needGopls:
+		return s.runtime.Load().gopls
+	}
+	return true
+}
+
+// clientHas reports whether the client in ctx meets a requirement
+func clientHas(ctx context.Context, need string) bool {
+	switch need {
+	case needSampling:
+		session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+		return ok && session.GetClientCapabilities().Sampling != nil
+	}
+	return true
+}
+
+// filterTools hides tools from clients lacking the capabilities they require
+func (s *GodocServer) filterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	defs := s.toolDefs()
+	return slices.DeleteFunc(tools, func(tool mcp.Tool) bool {
+		i := slices.IndexFunc(defs, func(def toolDef) bool { return def.tool.Name == tool.Name })
+		return i >= 0 && slices.ContainsFunc(defs[i].requires, func(need string) bool { return !clientHas(ctx, need) })
+	})
+}
+
+// instructions returns the server instructions: the configured or built-in usage
+// guide, followed by notes on the environment the server runs in
+func (s *GodocServer) instructions() string {
+	cfg := s.config.Load()
+	caps := s.runtime.Load()
+	text := defaultInstructions
+	if cfg.Instructions != "" {
+		text = cfg.Instructions
+	}
+	var notes []string
+	if !caps.goToolchain {
+		notes = append(notes, "No go toolchain is installed, so documentation tools are unavailable.")
+	} else if caps.toolchainErr != nil {
+		notes = append(notes, "The go toolchain is unusable, so documentation requests fail with GO_TOOLCHAIN: "+caps.toolchainErr.Error()+".")
+	} else if caps.modCacheErr != nil {
+		notes = append(notes, "The module cache is not writable; packages outside the standard library must already be in the module cache or a local module.")
+	} else if !caps.network {
+		notes = append(notes, "Module downloads are disabled; packages outside the standard library must already be in the module cache or a local module.")
+	}
+	if caps.gopls && len(cfg.GoplsWorkspaces) > 0 {
+		notes = append(notes, "Symbol lookups are fastest in these local workspaces: "+strings.Join(cfg.GoplsWorkspaces, ", ")+".")
+	}
+	if len(notes) > 0 {
+		text += "\n\n" + strings.Join(notes, "\n")
+	}
+	return text
+}
+
+// onAfterInitialize sends the current instructions to each connecting client
+func (s *GodocServer) onAfterInitialize(ctx context.Context, _ any, _ *mcp.InitializeRequest, result *mcp.InitializeResult) {
+	result.Instructions = s.instructions()
+	ctxLogger(ctx, s.logger).WithField("bytes", len(result.Instructions)).Debug("Sent server instructions")
+}
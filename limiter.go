@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// errServerBusy is returned when no subprocess slot frees up in time
+var errServerBusy = errors.New("server busy: too many documentation requests in progress, retry shortly")
+
+// subprocessLimiter bounds the number of go subprocesses running at once. Callers
+// beyond the limit wait in a bounded queue for at most the queue timeout. The same
+// limiter bounds the tool calls processed at once by the worker pool.
+type subprocessLimiter struct {
+	mu      sync.RWMutex
+	slots   chan struct{}
+	queue   chan struct{}
+	timeout time.Duration
+}
+
+// newSubprocessLimiter creates a limiter allowing maxRunning concurrent subprocesses
+// with up to maxQueued callers waiting
+func newSubprocessLimiter(maxRunning, maxQueued int, timeout time.Duration) *subprocessLimiter {
+	l := &subprocessLimiter{}
+	l.resize(maxRunning, maxQueued, timeout)
+	return l
+}
+
+// resize changes the limits for subsequent callers. Callers already holding or
+// waiting for a slot finish against the limits they started with.
+func (l *subprocessLimiter) resize(maxRunning, maxQueued int, timeout time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.slots != nil && cap(l.slots) == max(maxRunning, 1) && cap(l.queue) == max(maxQueued, 0) {
+		l.timeout = timeout
+		return
+	}
+	l.slots = make(chan struct{}, max(maxRunning, 1))
+	l.queue = make(chan struct{}, max(maxQueued, 0))
+	l.timeout = timeout
+}
+
+// acquire reserves a subprocess slot, returning a function that releases it.
+// It fails with errServerBusy when the queue is full or the wait times out.
+func (l *subprocessLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	l.mu.RLock()
+	slots, queue, timeout := l.slots, l.queue, l.timeout
+	l.mu.RUnlock()
+	release := func() { <-slots }
+
+	// Fast path: a slot is free
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	// Join the queue, rejecting immediately when it is full
+	select {
+	case queue <- struct{}{}:
+		defer func() { <-queue }()
+	default:
+		return nil, errServerBusy
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, errServerBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// tryAcquire reserves a slot only if one is free right now, never queueing
+// ahead of other callers
+func (l *subprocessLimiter) tryAcquire() (func(), bool) {
+	if l == nil {
+		return func() {}, true
+	}
+	l.mu.RLock()
+	slots := l.slots
+	l.mu.RUnlock()
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+		return nil, false
+	}
+}
+
+// inUse returns the number of slots currently held
+func (l *subprocessLimiter) inUse() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.slots)
+}
+
+// withWorker runs each tool call on a worker pool slot, so independent calls
+// proceed concurrently up to -max-workers while the rest queue
+func (s *GodocServer) withWorker(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		release, err := s.workers.acquire(ctx)
+		if errors.Is(err, errServerBusy) {
+			ctxLogger(ctx, s.logger).WithField("tool", request.Params.Name).Warn("Rejected tool call, worker pool busy")
+			return errorResult(codeServerBusy, err.Error()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// systemdListenAddr selects the socket passed in by systemd socket activation
+const systemdListenAddr = "systemd"
+
+// sdListenFDsStart is the first file descriptor passed by systemd (SD_LISTEN_FDS_START)
+const sdListenFDsStart = 3
+
+// listen opens the listener for an http address, which is one of:
+//
+//	host:port              a TCP address
+//	unix:///path/to/sock   a unix domain socket
+//	systemd                the first socket passed by systemd socket activation
+func listen(addr string) (net.Listener, error) {
+	switch {
+	case addr == systemdListenAddr:
+		return systemdListener()
+	case strings.HasPrefix(addr, "unix://"):
+		return unixListener(strings.TrimPrefix(addr, "unix://"))
+	default:
+		return net.Listen("tcp", addr)
+	}
+}
+
+// unixListener listens on a unix domain socket, replacing a stale socket file
+// left behind by a previous run
+func unixListener(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, fmt.Errorf("unix socket path is empty")
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("refusing to replace %s: not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %v", err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// systemdListener returns the first socket passed by systemd, following the
+// sd_listen_fds(3) protocol
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("no sockets passed by systemd: LISTEN_PID is unset or does not match this process")
+	}
+	nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || nfds < 1 {
+		return nil, errors.New("no sockets passed by systemd: LISTEN_FDS is unset or zero")
+	}
+	// Keep the variables from leaking into go subprocesses
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(sdListenFDsStart, "LISTEN_FD_3")
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid socket passed by systemd: %v", err)
+	}
+	return ln, nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIDHeader carries the correlation ID between clients, proxies, and the server
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID matches the client-supplied correlation IDs that are reused:
+// short and free of characters that could forge or break log lines
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type requestIDKey struct{}
+
+type callIDKey struct{}
+
+// withRequestID returns a context carrying the given correlation ID
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestIDFromContext returns the correlation ID stored in ctx, if any
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// callIDFromContext returns the ID of the tool call being served in ctx, if any
+func callIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(callIDKey{}).(string)
+	return id
+}
+
+// withCallID gives each tool call its own ID, so the log lines of concurrent
+// calls, including those sharing one HTTP session, can be told apart
+func (s *GodocServer) withCallID(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id := newRequestID()
+		ctx = context.WithValue(ctx, callIDKey{}, id)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("call_id", id))
+		ctxLogger(ctx, s.logger).WithFields(logrus.Fields{
+			"tool":    request.Params.Name,
+			"session": sessionID(ctx),
+		}).Debug("Tool call started")
+		return next(ctx, request)
+	}
+}
+
+type callTimingsKey struct{}
+
+// callTimings accumulates how long each phase of a tool call took
+type callTimings struct {
+	mu     sync.Mutex
+	phases logrus.Fields
+}
+
+// timePhase starts timing the named phase of the tool call in ctx, returning a
+// function that ends it. Phases are only recorded while slow call logging is on.
+func timePhase(ctx context.Context, name string) func() {
+	t, _ := ctx.Value(callTimingsKey{}).(*callTimings)
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		d, _ := t.phases[name].(time.Duration)
+		t.phases[name] = d + time.Since(start)
+	}
+}
+
+// withSlowLog warns with the call's arguments and a per-phase timing breakdown
+// whenever a tool call takes longer than -slow-request
+func (s *GodocServer) withSlowLog(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		threshold := s.config.Load().SlowRequest
+		if threshold <= 0 {
+			return next(ctx, request)
+		}
+		t := &callTimings{phases: logrus.Fields{}}
+		start := time.Now()
+		result, err := next(context.WithValue(ctx, callTimingsKey{}, t), request)
+		if d := time.Since(start); d >= threshold {
+			t.mu.Lock()
+			ctxLogger(ctx, s.logger).WithFields(t.phases).WithFields(logrus.Fields{
+				"tool":      request.Params.Name,
+				"arguments": request.GetArguments(),
+				"duration":  d,
+				"threshold": threshold,
+			}).Warn("Slow tool call")
+			t.mu.Unlock()
+		}
+		return result, err
+	}
+}
+
+// newRequestID generates a random correlation ID
+func newRequestID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ctxLogger returns a log entry carrying the correlation and tool call IDs from
+// ctx, so every line logged while serving a request can be traced back to it
+func ctxLogger(ctx context.Context, logger *logrus.Logger) *logrus.Entry {
+	// The context also lets debug bundles tell which session logged a line
+	log := logrus.NewEntry(logger).WithContext(ctx)
+	if id := requestIDFromContext(ctx); id != "" {
+		log = log.WithField("request_id", id)
+	}
+	if id := callIDFromContext(ctx); id != "" {
+		log = log.WithField("call_id", id)
+	}
+	return log
+}
+
+// logFormatter returns the logrus formatter for the named log format
+func logFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "text":
+		return &logrus.TextFormatter{}, nil
+	case "json":
+		return &logrus.JSONFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be text or json", format)
+	}
+}
+
+// applyLogConfig sets the logger's level and format from a validated configuration
+func applyLogConfig(logger *logrus.Logger, cfg *Config) {
+	level, _ := logrus.ParseLevel(cfg.LogLevel)
+	formatter, _ := logFormatter(cfg.LogFormat)
+	logger.SetLevel(level)
+	logger.SetFormatter(formatter)
+}
+
+// logrusLevel maps an MCP logging level onto the closest logrus level
+func logrusLevel(level mcp.LoggingLevel) logrus.Level {
+	switch level {
+	case mcp.LoggingLevelDebug:
+		return logrus.DebugLevel
+	case mcp.LoggingLevelInfo, mcp.LoggingLevelNotice:
+		return logrus.InfoLevel
+	case mcp.LoggingLevelWarning:
+		return logrus.WarnLevel
+	case mcp.LoggingLevelError:
+		return logrus.ErrorLevel
+	default:
+		// critical, alert, and emergency
+		return logrus.FatalLevel
+	}
+}
+
+// mcpLevel maps a logrus level onto the MCP logging level of the same severity
+func mcpLevel(level logrus.Level) mcp.LoggingLevel {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return mcp.LoggingLevelDebug
+	case logrus.InfoLevel:
+		return mcp.LoggingLevelInfo
+	case logrus.WarnLevel:
+		return mcp.LoggingLevelWarning
+	case logrus.ErrorLevel:
+		return mcp.LoggingLevelError
+	case logrus.FatalLevel:
+		return mcp.LoggingLevelCritical
+	default:
+		return mcp.LoggingLevelEmergency
+	}
+}
+
+// serverLogger names the MCP logger that carries the server's own log lines
+const serverLogger = "godoc-mcp"
+
+// sessionLogHook forwards each line logged while serving a session to that
+// session alone, as an MCP log notification filtered by the level the client
+// chose with logging/setLevel. Lines below -log-level are never logged, so the
+// server-wide level stays the floor for every client.
+type sessionLogHook struct{}
+
+func (sessionLogHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (sessionLogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil || server.ClientSessionFromContext(entry.Context) == nil {
+		return nil
+	}
+	mcpServer := server.ServerFromContext(entry.Context)
+	if mcpServer == nil {
+		return nil
+	}
+	data := map[string]any{"message": entry.Message}
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[k] = v
+	}
+	// Delivery is best effort: logging a failure would only be forwarded again
+	mcpServer.SendLogMessageToClient(entry.Context, mcp.NewLoggingMessageNotification(mcpLevel(entry.Level), serverLogger, data))
+	return nil
+}
+
+// onSetLevel records a client's logging/setLevel request. The level, which
+// mcp-go keeps per session, only filters the notifications sent to that client;
+// the server log level stays as -log-level set it.
+func (s *GodocServer) onSetLevel(ctx context.Context, _ any, request *mcp.SetLevelRequest, _ *mcp.EmptyResult) {
+	floor := s.logger.GetLevel()
+	log := ctxLogger(ctx, s.logger).WithFields(logrus.Fields{
+		"session":   sessionID(ctx),
+		"log_level": request.Params.Level,
+	})
+	if logrusLevel(request.Params.Level) > floor {
+		log = log.WithField("server_log_level", floor)
+		log.Info("Session log level changed by client; lines below the server log level are not sent")
+		return
+	}
+	log.Info("Session log level changed by client")
+}
+
+// statusRecorder captures the response status for request logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush keeps event streams working through the recorder
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// withRequestLogging assigns each request a correlation ID, reusing a valid one
+// supplied by the client, and logs the method, path, status, and duration once it completes
+func withRequestLogging(logger *logrus.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(withRequestID(r.Context(), id)))
+
+		logger.WithFields(logrus.Fields{
+			"request_id":  id,
+			"remote_addr": r.RemoteAddr,
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"status":      rec.status,
+			"duration":    time.Since(start),
+		}).Info("HTTP request")
+	})
+}
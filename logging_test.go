@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+// testSession is an initialized client session that records its log level
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	level         atomic.Value
+}
+
+func newTestSession(id string) *testSession {
+	return &testSession{id: id, notifications: make(chan mcp.JSONRPCNotification, 16)}
+}
+
+func (s *testSession) SessionID() string                                   { return s.id }
+func (s *testSession) Initialize()                                         {}
+func (s *testSession) Initialized() bool                                   { return true }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return s.notifications }
+func (s *testSession) SetLogLevel(level mcp.LoggingLevel)                  { s.level.Store(level) }
+
+func (s *testSession) GetLogLevel() mcp.LoggingLevel {
+	if level, ok := s.level.Load().(mcp.LoggingLevel); ok {
+		return level
+	}
+	return mcp.LoggingLevelError
+}
+
+func TestSessionLogLevel(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetLevel(logrus.InfoLevel)
+	logger.AddHook(sessionLogHook{})
+	srv := &GodocServer{logger: logger}
+
+	hooks := &server.Hooks{}
+	hooks.AddAfterSetLevel(srv.onSetLevel)
+	mcpServer := server.NewMCPServer("test", "0", server.WithLogging(), server.WithHooks(hooks))
+	mcpServer.AddTool(mcp.NewTool("log"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		log := ctxLogger(ctx, logger)
+		log.Debug("debug line")
+		log.Info("info line")
+		log.WithError(io.EOF).Warn("warn line")
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	verbose, quiet := newTestSession("verbose"), newTestSession("quiet")
+	call := func(session *testSession, message string) {
+		t.Helper()
+		ctx := mcpServer.WithContext(context.Background(), session)
+		if resp, ok := mcpServer.HandleMessage(ctx, json.RawMessage(message)).(mcp.JSONRPCError); ok {
+			t.Fatalf("%s: %v", message, resp.Error)
+		}
+	}
+	call(verbose, `{"jsonrpc":"2.0","id":1,"method":"logging/setLevel","params":{"level":"debug"}}`)
+	if got := logger.GetLevel(); got != logrus.InfoLevel {
+		t.Fatalf("logging/setLevel changed the server log level to %s", got)
+	}
+	drain(verbose.notifications)
+	call(verbose, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"log"}}`)
+	call(quiet, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"log"}}`)
+
+	tests := []struct {
+		session *testSession
+		want    []string
+	}{
+		// debug is below -log-level, so it is never logged or sent
+		{verbose, []string{"info line", "warn line"}},
+		// a session that kept the default error level receives neither
+		{quiet, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, n := range drain(tt.session.notifications) {
+			data, _ := n.Params.AdditionalFields["data"].(map[string]any)
+			if n.Params.AdditionalFields["logger"] != serverLogger {
+				continue
+			}
+			got = append(got, data["message"].(string))
+			if data["message"] == "warn line" && data["error"] != io.EOF.Error() {
+				t.Errorf("%s: warn line carries error %v, want %q", tt.session.id, data["error"], io.EOF.Error())
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: received %q, want %q", tt.session.id, got, tt.want)
+		}
+	}
+}
+
+// drain returns the notifications queued on ch
+func drain(ch chan mcp.JSONRPCNotification) []mcp.JSONRPCNotification {
+	var out []mcp.JSONRPCNotification
+	for {
+		select {
+		case n := <-ch:
+			out = append(out, n)
+		default:
+			return out
+		}
+	}
+}
+
+func TestWithRequestLogging(t *testing.T) {
+	tests := []struct {
+		header string
+		reused bool
+	}{
+		{"", false},
+		{"abc-123", true},
+		{"trace.ID_9", true},
+		{strings.Repeat("a", 64), true},
+		{strings.Repeat("a", 65), false},
+		{"id with spaces", false},
+		{"id\nlevel=error msg=forged", false},
+		{"id\"quoted", false},
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	for _, tt := range tests {
+		var got string
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = requestIDFromContext(r.Context()) })
+		r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if tt.header != "" {
+			r.Header.Set(requestIDHeader, tt.header)
+		}
+		w := httptest.NewRecorder()
+		withRequestLogging(logger, next).ServeHTTP(w, r)
+
+		if w.Header().Get(requestIDHeader) != got {
+			t.Errorf("%q: response carries ID %q, request %q", tt.header, w.Header().Get(requestIDHeader), got)
+		}
+		if (got == tt.header) != tt.reused || !validRequestID.MatchString(got) {
+			t.Errorf("%q: request ID %q, want reused %v", tt.header, got, tt.reused)
+		}
+	}
+}
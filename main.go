@@ -5,20 +5,23 @@
 // # Overview
 //
 // This server implements the MCP protocol to provide Go documentation access to AI assistants.
-// It uses the standard `go doc` command under the hood but adds caching, error handling,
-// and a clean MCP interface. The server supports:
+// It extracts documentation in process with go/packages and go/doc, falling back to the
+// standard `go doc` command for requests the native backend can't serve, and adds caching,
+// error handling, and a clean MCP interface. The server supports:
 //
 //   - Standard library packages (e.g., "io", "net/http")
 //   - External packages via import paths (e.g., "github.com/user/repo")
 //   - Local packages via relative or absolute paths (e.g., "./pkg", "/path/to/module")
 //   - Specific symbol lookup (functions, types, interfaces, methods)
+//   - Structured JSON output for packages and symbols
 //   - Pagination for large documentation sets
 //   - Intelligent caching with 5-minute TTL
 //
 // # Usage
 //
-// The server is designed to be run as an MCP tool server. When started, it exposes a single
-// tool called "get_doc" that accepts various parameters for documentation retrieval.
+// The server is designed to be run as an MCP tool server. When started, it exposes the
+// "get_doc" tool that accepts various parameters for documentation retrieval, along with
+// "set_session_defaults" for storing per-session defaults such as the working directory.
 //
 // Example tool usage:
 //
@@ -44,6 +47,7 @@
 //
 //   - Package not found: Suggests checking package name, import path, or local path
 //   - Symbol not found: Recommends using -u flag for unexported symbols
+//   - Standard library typos: Suggests close package and symbol names from the index
 //   - Build constraints: Advises on platform-specific issues
 //   - Missing dependencies: Guides users on package installation
 //
@@ -61,19 +65,32 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/jellydator/ttlcache/v3"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 const toolDescription = `Get Go documentation for a package, type, function, or method.
@@ -94,135 +111,306 @@ Common Usage Patterns:
 
 The documentation is cached for 5 minutes to improve performance.`
 
-// Create a shared input schema definition to ensure consistency
-var docInputSchema = mcp.ToolInputSchema{
-	Type: "object",
-	Properties: map[string]any{
-		"path": map[string]any{
-			"type":        "string",
-			"description": "Path to the Go package or file. This can be an import path (e.g., 'io', 'github.com/user/repo') or a local file path.",
-		},
-		"target": map[string]any{
-			"type":        "string",
-			"description": "Optional: Specific symbol to get documentation for (e.g., function name, type name, interface name). Leave empty to get full package documentation.",
-		},
-		"cmd_flags": map[string]any{
-			"type": "array",
-			"items": map[string]any{
-				"type": "string",
+// newDocInputSchema creates the get_doc input schema with the configured page size limits
+func newDocInputSchema(p PaginationConfig) mcp.ToolInputSchema {
+	return mcp.ToolInputSchema{
+		Type: "object",
+		Properties: map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Path to the Go package or file. This can be an import path (e.g., 'io', 'github.com/user/repo') or a local file path. Required unless cursor is set.",
 			},
-			"description": "Optional: Additional go doc command flags. Common flags:\n" +
-				"  -all: Show all documentation for package\n" +
-				"  -src: Show the source code\n" +
-				"  -u: Show unexported symbols as well as exported",
-		},
-		"working_dir": map[string]any{
-			"type":        "string",
-			"description": "Working directory to execute go doc from. Required for relative paths (including '.') to resolve the correct module context. Optional for absolute paths and standard library packages.",
-		},
-		"page": map[string]any{
-			"type":        "integer",
-			"description": "Page number (1-based) for paginated results. Default is 1.",
-			"minimum":     1,
-			"default":     1,
-		},
-		"page_size": map[string]any{
-			"type":        "integer",
-			"description": "Number of lines per page. Default is 1000. Use smaller values for very large documentation.",
-			"minimum":     100,
-			"maximum":     5000,
-			"default":     1000,
+			"target": map[string]any{
+				"type":        []string{"string", "array"},
+				"items":       map[string]any{"type": "string"},
+				"description": "Optional: Specific symbol to get documentation for (e.g., function name, type name, interface name). A glob pattern such as 'Read*' or '*Option' documents every matching symbol; include a dot ('Reader.*') to match methods. A list of symbols or patterns (e.g., ['Client', 'Transport', 'Get']) documents each of them in turn in one response. Leave empty to get full package documentation.",
+			},
+			"cmd_flags": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "string",
+				},
+				"description": "Optional: Additional go doc command flags. Common flags:\n" +
+					"  -all: Show all documentation for package\n" +
+					"  -src: Show the source code\n" +
+					"  -u: Show unexported symbols as well as exported\n" +
+					"Flags go doc rejects are dropped with a warning.",
+			},
+			"working_dir": map[string]any{
+				"type":        "string",
+				"description": "Working directory to execute go doc from. Required for relative paths (including '.') to resolve the correct module context. Optional for absolute paths and standard library packages. Defaults to the session working directory set with set_session_defaults, then to the client's roots: relative paths resolve against the first root that is a Go module, and import paths inside a root's module are documented from that root.",
+			},
+			"signals":     signalsArgument,
+			"doc_score":   docScoreArgument,
+			"source_link": sourceLinkArgument,
+			"oversize":    oversizeArgument,
+			"receiver":    receiverArgument,
+			"stream":      streamArgument,
+			"goos":        platformArguments["goos"],
+			"goarch":      platformArguments["goarch"],
+			"tags":        platformArguments["tags"],
+			"cgo_enabled": platformArguments["cgo_enabled"],
+			"env":         platformArguments["env"],
+			"format": map[string]any{
+				"type":        "string",
+				"description": "Optional: Output format. 'text' (default) returns go doc style text; 'json' returns structured documentation with the package doc and each const, var, func, type, and method as separate entries.",
+				"enum":        []string{formatText, formatJSON},
+				"default":     formatText,
+			},
+			"page": map[string]any{
+				"type":        "integer",
+				"description": "Page number (1-based) for paginated results. Default is 1.",
+				"minimum":     1,
+				"default":     1,
+			},
+			"doc_id": map[string]any{
+				"type":        "string",
+				"description": "Optional: The doc_id reported with an earlier page. When set, the request fails instead of returning a page of different content if the documentation has changed since.",
+			},
+			"page_size":        p.pageSizeSchema(fmt.Sprintf("Number of lines per page. Default is %d, or the session page size set with set_session_defaults. Use smaller values for very large documentation.", p.DefaultPageSize)),
+			"page_size_tokens": p.pageTokensSchema("Optional: Size pages by an estimated token budget instead of a line count, so dense code and prose cost about the same per page. Takes precedence over page_size; pages still hold at most the server's maximum page size in lines."),
+			"cursor": map[string]any{
+				"type":        "string",
+				"description": "Optional: The next_cursor returned with an earlier page. Continues from the end of that page in the same content, even if the documentation has been regenerated since; all other arguments are ignored.",
+			},
+			"start_line": rangeArguments["start_line"],
+			"end_line":   rangeArguments["end_line"],
+			"start_byte": rangeArguments["start_byte"],
+			"end_byte":   rangeArguments["end_byte"],
 		},
-	},
-	Required: []string{"path"},
+	}
 }
 
 type GodocServer struct {
 	cache          *ttlcache.Cache[string, cachedDoc]
 	projectManager *ProjectManager
-	logger         *logrus.Logger
+	sessions       *sessionStore
+	limiter        *subprocessLimiter
+	workers        *subprocessLimiter
+	stdlib         *stdIndex
+	semantic       *semanticIndex
+	symbols        *symbolIndex
+	// pkgSearches caches find_packages results by pkgsite and query
+	pkgSearches *ttlcache.Cache[string, []foundPackage]
+	// signals caches deps.dev signals by module path
+	signals *ttlcache.Cache[string, *packageSignals]
+	// reports caches module_report results by deps.dev endpoint and module version
+	reports *ttlcache.Cache[string, *moduleReport]
+	// usages caches usage_examples code searches by backend and pattern
+	usages *ttlcache.Cache[string, codeSearch]
+	// releases is the catalog of recent module releases, kept while the
+	// module index is polled
+	releases    *releaseCatalog
+	gopls       *goplsPool
+	flights     sharedGroup
+	config      atomic.Pointer[Config]
+	logger      *logrus.Logger
+	closed      atomic.Bool
+	inFlight    atomic.Int64
+	prefetching atomic.Bool
+	metrics     serverMetrics
+	started     time.Time
+	recentLogs  *logRing
+	failures    *ring[failedCall]
+	// workspacePkgs caches the package lists of workspaces for completion
+	workspacePkgs *ttlcache.Cache[string, []string]
+	// cursorDocs holds documents that continuation cursors point into
+	cursorDocs *ttlcache.Cache[string, cursorDoc]
+	// clients holds the IDs of connected sessions
+	clients sync.Map
+	// cancels holds the cancel functions of running tool calls by rpcKey
+	cancels sync.Map
+	// roots holds the root directories listed by each session's client
+	roots sync.Map
+	// subscriptions records the resources each session subscribed to
+	subscriptions subscriptionSet
+	// runtime records what the server's environment provides
+	runtime atomic.Pointer[runtimeCaps]
 }
 
+// cachedDoc is generated documentation split into lines, so every page of a
+// document is served from the same cached copy
 type cachedDoc struct {
-	content   string
+	id        string
+	lines     []string
 	timestamp time.Time
 	byteSize  int
+	// stderr holds what go doc wrote to stderr while generating the document,
+	// such as toolchain download messages
+	stderr string
 }
 
-// runGoDoc executes the go doc command with the given arguments and optional working directory
-func (s *GodocServer) runGoDoc(workingDir string, args ...string) (string, error) {
-	// Create cache key that includes working directory
-	cacheKey := workingDir + "|" + strings.Join(args, "|")
+// newCachedDoc prepares content for pagination. Its id is derived from the
+// content, so regenerating an unchanged document keeps the same identity.
+func newCachedDoc(content string) cachedDoc {
+	sum := sha256.Sum256([]byte(content))
+	return cachedDoc{
+		id:        hex.EncodeToString(sum[:6]),
+		lines:     strings.Split(content, "\n"),
+		timestamp: time.Now(),
+		byteSize:  len(content),
+	}
+}
+
+// runGoDoc returns documentation for the go doc arguments args, formatted as text or
+// json, with an optional working directory. A non-empty scope keeps the cached
+// result private to that session.
+func (s *GodocServer) runGoDoc(ctx context.Context, scope, workingDir, format string, args ...string) (cachedDoc, error) {
+	log := ctxLogger(ctx, s.logger)
+
+	// Create cache key that includes scope, working directory, and format
+	cacheKey := scope + "|" + workingDir + "|" + format + "|" + strings.Join(args, "|")
+	if env := buildEnvFromContext(ctx); len(env) > 0 {
+		cacheKey += "|env:" + strings.Join(env, " ")
+	}
+	ctx, span := startSpan(ctx, "doc.lookup", attribute.String("cache.key", cacheKey))
+	defer span.End()
 
 	// Check cache
 	if item := s.cache.Get(cacheKey); item != nil {
 		doc := item.Value()
-		s.logger.WithFields(logrus.Fields{
+		s.metrics.cacheHits.Add(1)
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		log.WithFields(logrus.Fields{
 			"cache_key": cacheKey,
 			"bytes":     doc.byteSize,
 		}).Debug("Cache hit")
-		return doc.content, nil
+		return doc, nil
+	}
+
+	// Concurrent identical requests share a single extraction, which outlives
+	// the request that started it while any other request still waits for it
+	v, err, shared := s.flights.do(ctx, cacheKey, func(ctx context.Context) (any, error) {
+		content, stderr, err := s.generateDoc(ctx, log, workingDir, format, args)
+		if err != nil {
+			return cachedDoc{}, err
+		}
+		doc := newCachedDoc(content)
+		doc.stderr = stderr
+		s.metrics.cacheMisses.Add(1)
+		s.cache.Set(cacheKey, doc, s.config.Load().CacheTTL)
+
+		log.WithFields(logrus.Fields{
+			"cache_key": cacheKey,
+			"doc_id":    doc.id,
+			"bytes":     doc.byteSize,
+		}).Debug("Cache miss")
+		return doc, nil
+	})
+	span.SetAttributes(attribute.Bool("cache.hit", shared))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return cachedDoc{}, err
+	}
+	if shared {
+		s.metrics.cacheHits.Add(1)
+		log.WithField("cache_key", cacheKey).Debug("Shared in-progress extraction")
+	}
+	return v.(cachedDoc), nil
+}
+
+// generateDoc answers symbol lookups in gopls workspaces through gopls, and otherwise
+// extracts documentation in process with the native backend, falling back to
+// executing go doc for text requests the native backend can't serve. It returns
+// the documentation and any diagnostics go doc wrote to stderr.
+func (s *GodocServer) generateDoc(ctx context.Context, log *logrus.Entry, workingDir, format string, args []string) (string, string, error) {
+	// Symbol lookups in a workspace with a warm gopls skip loading the package
+	if c := s.gopls.forDir(workingDir); c != nil && format == formatText && buildEnvFromContext(ctx) == nil && len(args) == 2 && !strings.HasPrefix(args[0], "-") {
+		content, err := c.symbolDoc(ctx, args[0], args[1])
+		if err == nil {
+			docStreamerFromContext(ctx).Write([]byte(content))
+			return content, "", nil
+		}
+		log.WithError(err).Debug("gopls lookup failed, falling back")
 	}
+	// go doc ignores build tags, so tagged documentation is only extracted in process
+	tagged := hasBuildTags(ctx)
+	if format == formatJSON || tagged || s.config.Load().DocBackend == backendNative {
+		content, err := s.nativeDoc(ctx, workingDir, format, args)
+		if err == nil || format == formatJSON || tagged || errors.Is(err, errServerBusy) {
+			return content, "", err
+		}
+		log.WithError(err).Debug("Native extraction failed, falling back to go doc")
+	}
+	return s.execGoDoc(ctx, log, workingDir, args)
+}
 
-	cmd := exec.Command("go", append([]string{"doc"}, args...)...)
+// execGoDoc runs the go doc command with the given arguments and optional working
+// directory, returning its stdout as the documentation and its stderr separately
+func (s *GodocServer) execGoDoc(ctx context.Context, log *logrus.Entry, workingDir string, args []string) (string, string, error) {
+	progressFromContext(ctx).step("Running go doc " + strings.Join(args, " "))
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	cmd := exec.CommandContext(ctx, "go", append([]string{"doc"}, args...)...)
 	if workingDir != "" {
 		cmd.Dir = workingDir
 	}
-	out, err := cmd.CombinedOutput()
+	cmd.Env = buildEnviron(ctx)
+	// Output is streamed to clients that asked for it as it is produced
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = io.MultiWriter(&stdout, docStreamerFromContext(ctx))
+	cmd.Stderr = &stderr
+	_, span := startSpan(ctx, "go doc", attribute.StringSlice("args", args), attribute.String("working_dir", workingDir))
+	start := time.Now()
+	err = cmd.Run()
+	endSpan(span, err)
+	release()
+	diagnostics := strings.TrimSpace(stderr.String())
+	log.WithFields(logrus.Fields{
+		"args":        args,
+		"working_dir": workingDir,
+		"duration":    time.Since(start),
+		"stderr":      diagnostics,
+	}).Debug("go doc finished")
 	if err != nil {
-		// Enhanced error handling with suggestions
-		errStr := string(out)
-		if strings.Contains(errStr, "no such package") || strings.Contains(errStr, "is not in std") {
-			return "", fmt.Errorf("Package not found. Suggestions:\n"+
+		// Enhanced error handling with suggestions. go doc reports failures on
+		// stderr, but a few end up on stdout.
+		errStr := cmp.Or(diagnostics, strings.TrimSpace(stdout.String()))
+		switch code := classifyOutput(errStr); code {
+		case codePkgNotFound:
+			return "", "", withCode(code, fmt.Errorf("Package not found. Suggestions:\n"+
 				"1. For standard library packages, use just the package name (e.g., 'io', 'net/http')\n"+
 				"2. For external packages, ensure they are imported in the module\n"+
 				"3. For local packages, provide the relative path (e.g., './pkg') or absolute path\n"+
 				"4. Check for typos in the package name\n"+
-				"Error details: %s", errStr)
-		}
-		if strings.Contains(errStr, "no such symbol") {
-			return "", fmt.Errorf("Symbol not found. Suggestions:\n"+
+				"Error details: %s", errStr))
+		case codeSymbolNotFound:
+			return "", "", withCode(code, fmt.Errorf("Symbol not found. Suggestions:\n"+
 				"1. Check if the symbol name is correct (case-sensitive)\n"+
 				"2. Use -u flag to see unexported symbols\n"+
 				"3. Use -all flag to see all package documentation\n"+
-				"Error: %v", err)
-		}
-		if strings.Contains(errStr, "build constraints exclude all Go files") {
-			return "", fmt.Errorf("No Go files found for current platform. Suggestions:\n"+
+				"Error details: %s", errStr))
+		case codeBuildConstraints:
+			return "", "", withCode(code, fmt.Errorf("No Go files found for current platform. Suggestions:\n"+
 				"1. Try using -all flag to see all package files\n"+
 				"2. Check if you need to set GOOS/GOARCH environment variables\n"+
-				"Error: %v", err)
+				"Error: %v", err))
+		case codeInvalidArgument:
+			return "", "", withCode(code, fmt.Errorf("go doc rejected the requested environment: %s", errStr))
 		}
-		return "", fmt.Errorf("go doc error: %v\noutput: %s\nTip: Use -h flag to see all available options", err, errStr)
+		return "", "", fmt.Errorf("go doc error: %v\noutput: %s\nTip: Use -h flag to see all available options", err, errStr)
 	}
 
-	content := string(out)
-	s.cache.Set(cacheKey, cachedDoc{
-		content:   content,
-		timestamp: time.Now(),
-		byteSize:  len(content),
-	}, 5*time.Minute)
-
-	s.logger.WithFields(logrus.Fields{
-		"cache_key": cacheKey,
-		"bytes":     len(content),
-	}).Debug("Cache miss")
-	return content, nil
+	return stdout.String(), diagnostics, nil
 }
 
-// validatePath ensures the path is either a valid local file/directory or appears to be a valid import path
-func (*GodocServer) validatePath(path string, workingDir string) (string, error, []string) {
+// validatePath ensures the path is either a valid local file/directory or appears to be a valid import path.
+// Local directories without Go files fail with the packages found below them.
+func (*GodocServer) validatePath(path string, workingDir string) (string, error, []subpackage) {
 	// For relative paths, working directory is required
 	if strings.HasPrefix(path, ".") {
 		if workingDir == "" {
-			return "", fmt.Errorf("working_dir is required for relative paths (including '.')"), nil
+			return "", withCode(codeInvalidArgument, fmt.Errorf("working_dir is required for relative paths (including '.')")), nil
 		}
 
 		// Read go.mod from working directory only
 		modPath := filepath.Join(workingDir, "go.mod")
 		content, err := os.ReadFile(modPath)
 		if err != nil {
-			return "", fmt.Errorf("failed to read go.mod in working directory: %v", err), nil
+			return "", withCode(codeInvalidWorkingDir, fmt.Errorf("failed to read go.mod in working directory: %v", err)), nil
 		}
 
 		// Parse module name from go.mod
@@ -234,7 +422,13 @@ func (*GodocServer) validatePath(path string, workingDir string) (string, error,
 			}
 		}
 		if moduleName == "" {
-			return "", fmt.Errorf("no module declaration found in go.mod"), nil
+			return "", withCode(codeInvalidWorkingDir, fmt.Errorf("no module declaration found in go.mod")), nil
+		}
+
+		if dir := filepath.Join(workingDir, path); !hasGoFiles(dir) {
+			if subs := findSubpackages(workingDir, dir, moduleName); len(subs) > 0 {
+				return "", withCode(codePkgNotFound, fmt.Errorf("no Go files in %s", path)), subs
+			}
 		}
 
 		// If path is ".", use the module name directly
@@ -250,14 +444,14 @@ func (*GodocServer) validatePath(path string, workingDir string) (string, error,
 	// Handle absolute paths - must match working directory if provided
 	if strings.HasPrefix(path, "/") || filepath.IsAbs(path) {
 		if workingDir != "" && path != workingDir {
-			return "", fmt.Errorf("absolute path must match working directory when provided"), nil
+			return "", withCode(codeInvalidArgument, fmt.Errorf("absolute path must match working directory when provided")), nil
 		}
 
 		// Read go.mod from the absolute path
 		modPath := filepath.Join(path, "go.mod")
 		content, err := os.ReadFile(modPath)
 		if err != nil {
-			return "", fmt.Errorf("failed to read go.mod: %v", err), nil
+			return "", withCode(codePkgNotFound, fmt.Errorf("failed to read go.mod: %v", err)), nil
 		}
 
 		// Parse module name from go.mod
@@ -265,10 +459,15 @@ func (*GodocServer) validatePath(path string, workingDir string) (string, error,
 		for _, line := range strings.Split(string(content), "\n") {
 			if strings.HasPrefix(line, "module ") {
 				moduleName = strings.TrimSpace(strings.TrimPrefix(line, "module "))
+				if !hasGoFiles(path) {
+					if subs := findSubpackages(path, path, moduleName); len(subs) > 0 {
+						return "", withCode(codePkgNotFound, fmt.Errorf("no Go files in %s", path)), subs
+					}
+				}
 				return moduleName, nil, nil
 			}
 		}
-		return "", fmt.Errorf("no module declaration found in go.mod"), nil
+		return "", withCode(codePkgNotFound, fmt.Errorf("no module declaration found in go.mod")), nil
 	}
 
 	// For all other paths, treat as import path
@@ -277,151 +476,667 @@ func (*GodocServer) validatePath(path string, workingDir string) (string, error,
 
 // handleToolCall implements the tools/call endpoint
 func (s *GodocServer) handleToolCall(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.WithField("arguments", request).Debug("handleToolCall called")
+	log := ctxLogger(ctx, s.logger)
+	log.WithField("arguments", request).Debug("handleToolCall called")
+
+	// Continuation cursors carry everything needed to serve the next page
+	if cursor := request.GetString("cursor", ""); cursor != "" {
+		endPagination := timePhase(ctx, "pagination")
+		defer endPagination()
+		return s.continueCursor(ctx, log, cursor), nil
+	}
+	rng, err := requestedRange(request)
+	if err == nil {
+		err = checkStreamRequest(request, rng)
+	}
+	if err != nil {
+		return errorResult(codeInvalidArgument, err.Error()), nil
+	}
+	// Ranges of a document returned earlier in the session need no path
+	if docID := request.GetString("doc_id", ""); rng != nil && docID != "" && request.GetString("path", "") == "" {
+		endPagination := timePhase(ctx, "pagination")
+		defer endPagination()
+		return s.heldRange(ctx, log, docID, *rng), nil
+	}
+
+	env, err := requestBuildEnv(request)
+	if err != nil {
+		return errorResult(codeInvalidArgument, err.Error()), nil
+	}
+	ctx = withBuildEnv(ctx, env)
+
+	req, doc, failed := s.loadDoc(ctx, request)
+	if failed != nil {
+		return failed, nil
+	}
+	path, target := req.path, req.target
+	// Streamed documentation was sent as generated, so it is served unchanged
+	if req.stream == nil {
+		if request.GetBool("receiver", false) {
+			doc = s.withReceiver(ctx, req, doc)
+		}
+		doc, req.aliasOf = s.followAlias(ctx, req, doc)
+	}
+
+	// Package overviews are usually followed by lookups in the packages they import
+	if target == "" && len(req.cmdFlags) == 0 && req.format == formatText && env == nil {
+		go s.prefetchImports(log, req.cacheScope, req.workingDir, path, req.ownModule)
+	}
+
+	// Pages requested against an earlier document must come from the same content
+	if docID := request.GetString("doc_id", ""); docID != "" && docID != doc.id {
+		return errorResult(codeDocChanged, fmt.Sprintf("documentation changed since doc_id %s was returned (now %s); request page 1 again", docID, doc.id)), nil
+	}
+
+	// Get pagination parameters with defaults
+	page := request.GetInt("page", 1)
+	sizing := s.requestSizing(ctx, request)
+	endPagination := timePhase(ctx, "pagination")
+	var result *mcp.CallToolResult
+	switch {
+	case req.stream != nil:
+		result = s.streamedResult(log, req, doc, sizing, req.stream)
+	case rng != nil:
+		result = s.rangeOf(log, doc, *rng)
+	case s.wantsOutline(request, req, doc, sizing):
+		result = s.outline(ctx, log, req, doc, sizing)
+	}
+	if result == nil {
+		result = s.paginate(log, doc, page, sizing)
+	}
+	endPagination()
+	var signals *packageSignals
+	var score *docScore
+	var warning, sourceURL string
+	var hint *importHint
+	src := newDocSource(req)
+	if !result.IsError {
+		var notes string
+		if req.requestedTarget != "" {
+			notes += correctionNote(req.requestedTarget, target)
+		}
+		switch {
+		case req.file != "" && len(req.matched) > 0:
+			notes += fileNote(req.file, req.matched, req.matchedTotal)
+		case len(req.matched) > 0 && slices.ContainsFunc(req.targets, isGlobTarget):
+			notes += globNote(target, req.matched, req.matchedTotal)
+		}
+		if len(env) > 0 {
+			notes += "Generated with " + strings.Join(env, " ") + "\n"
+		}
+		if req.aliasOf != "" {
+			notes += fmt.Sprintf("Note: %s is an alias for %s; its documentation follows the alias's\n", target, req.aliasOf)
+		}
+		if request.GetBool("signals", false) {
+			signals, warning = s.docSignals(ctx, log, req)
+			if warning != "" {
+				req.warnings = append(req.warnings, warning)
+			} else if signals != nil {
+				notes += signalsNote(signals)
+			}
+		}
+		if request.GetBool("doc_score", false) {
+			if score, warning = s.docScoreFor(ctx, log, req); warning != "" {
+				req.warnings = append(req.warnings, warning)
+			} else {
+				notes += "Documentation score: " + score.String() + "\n"
+			}
+		}
+		if request.GetBool("source_link", false) {
+			var err error
+			if sourceURL, err = s.sourceLink(ctx, req); err != nil {
+				log.WithError(err).Debug("No source link")
+				req.warnings = append(req.warnings, "source link unavailable: "+err.Error())
+			} else {
+				notes += "Source: " + sourceURL + "\n"
+			}
+		}
+		for _, w := range req.warnings {
+			notes += "Warning: " + w + "\n"
+		}
+		if notes != "" {
+			result.Content[0] = mcp.NewTextContent(notes + mcp.GetTextFromContent(result.Content[0]))
+		}
+		// Symbol documentation ends with how to import its package
+		if hint = s.importHint(ctx, req, doc, src.version); hint != nil && req.format == formatText {
+			result.Content[0] = mcp.NewTextContent(strings.TrimRight(mcp.GetTextFromContent(result.Content[0]), "\n") + "\n\n" + hint.String() + "\n")
+		}
+		// Clients jump to the documented symbols' declarations from the metadata
+		addDeclLocations(result, s.declLocations(ctx, log, req, src.version))
+		// The version documented, which may have been served from the module cache
+		if src.version != "" {
+			setResultMeta(result, "version", src.version)
+		}
+	}
+
+	// Typed clients get the package, its declarations, and the page as structured content
+	if out, ok := result.StructuredContent.(*docOutput); ok {
+		out.Package, out.Symbol, out.Version = path, target, src.version
+		out.RequestedSymbol, out.Warnings, out.Signals = req.requestedTarget, req.warnings, signals
+		out.MatchedSymbols, out.Source, out.SourceURL = req.matched, req.source, sourceURL
+		out.AliasOf, out.Env, out.Import, out.DocScore = req.aliasOf, env, hint, score
+		switch {
+		case out.Pagination.Page != 1, req.source != "":
+		case len(req.matched) > 0:
+			// The entries of a pattern's symbols are picked from the package's own
+			entries := s.structuredEntries(ctx, req.cacheScope, req.workingDir, formatText, doc, req.cmdArgs)
+			out.Entries = filterEntries(entries, req.matched)
+		default:
+			out.Entries = s.structuredEntries(ctx, req.cacheScope, req.workingDir, req.format, doc, req.cmdArgs)
+		}
+		// Documents are held for their cursors and for later range requests
+		s.keepForCursor(ctx, doc, path, target, src)
+		// The page's metadata and a link to its source follow the documentation
+		addDocLink(result, src, out)
+	}
+	return result, nil
+}
+
+// docRequest is a get_doc request resolved to the go doc invocation serving it
+type docRequest struct {
+	path       string
+	target     string
+	workingDir string
+	cacheScope string
+	format     string
+	cmdFlags   []string
+	cmdArgs    []string
+	ownModule  bool
+	// requestedTarget is set when the requested target named no symbol and was
+	// corrected to target
+	requestedTarget string
+	// file is the Go file requested as the path, whose symbols are the targets
+	file string
+	// targets lists the symbols and patterns of a target list or pattern,
+	// which target joins with commas
+	targets []string
+	// matched lists the symbols documented for targets, the first of
+	// matchedTotal they name or match
+	matched      []string
+	matchedTotal int
+	// aliasOf is the type target aliases, qualified by its import path when
+	// declared in another package, when its documentation follows the alias's
+	aliasOf string
+	// source is sourcePkgsite when the documentation was rendered by pkg.go.dev
+	// because it couldn't be generated locally
+	source string
+	// warnings describe problems worked around to serve the request, such as
+	// dropped cmd_flags
+	warnings []string
+	// stream is set when the documentation was streamed to the client
+	stream *docStreamer
+}
+
+// loadDoc resolves the get_doc arguments of request and generates the
+// documentation they select. A non-nil result reports why that failed.
+func (s *GodocServer) loadDoc(ctx context.Context, request mcp.CallToolRequest) (docRequest, cachedDoc, *mcp.CallToolResult) {
+	log := ctxLogger(ctx, s.logger)
+	if err := s.runtime.Load().toolchainErr; err != nil {
+		return docRequest{}, cachedDoc{}, errorResultFromErr("cannot run go doc", err)
+	}
 
 	// Extract the path from arguments
 	path := request.GetString("path", "")
 	if path == "" {
-		return mcp.NewToolResultError("invalid or missing path parameter"), nil
+		return docRequest{}, cachedDoc{}, errorResult(codeInvalidArgument, "invalid or missing path parameter")
 	}
 
-	// Get working directory
-	workingDir := request.GetString("working_dir", "")
+	// Get working directory, falling back to the session default and then to
+	// the client's roots
+	defaults := s.sessions.get(ctx)
+	workingDir := request.GetString("working_dir", defaults.workingDir)
+	if workingDir == "" {
+		workingDir = s.rootWorkingDir(ctx, path)
+	}
+	// Relative paths need a module to be relative to, so ask the user for one
+	if workingDir == "" && strings.HasPrefix(path, ".") {
+		if dir, ok := s.elicitString(ctx, fmt.Sprintf("Which Go module directory is %q relative to?", path),
+			"working_dir", "Absolute path of the Go module directory", nil); ok {
+			workingDir = dir
+		}
+	}
+	// A Go file documents the symbols it declares, from its package
+	var file string
+	var fileTargets []string
+	if isGoFile(path) {
+		if request.GetString("target", "") != "" || len(request.GetStringSlice("target", nil)) > 0 {
+			return docRequest{}, cachedDoc{}, errorResult(codeInvalidArgument, "target cannot be combined with a Go file path; the file selects the symbols")
+		}
+		flags, _ := dropInvalidFlags(request.GetStringSlice("cmd_flags", nil))
+		unexported := slices.Contains(flags, "-u")
+		var err error
+		file = path
+		if path, workingDir, fileTargets, err = resolveGoFile(path, workingDir, unexported); err != nil {
+			return docRequest{}, cachedDoc{}, errorResultFromErr("invalid file", err)
+		}
+	}
+	// Results from a client's own workspace are cached per session
+	var cacheScope string
 	if workingDir != "" {
-		if info, err := os.Stat(workingDir); err != nil || !info.IsDir() {
-			return mcp.NewToolResultErrorFromErr("invalid working directory", err), nil
+		cacheScope = sessionID(ctx)
+		if err := checkWorkingDir(workingDir); err != nil {
+			return docRequest{}, cachedDoc{}, errorResultFromErr("invalid working directory", err)
 		}
 	}
 
 	// Validate and resolve the path
+	_, span := startSpan(ctx, "validatePath", attribute.String("path", path))
+	endValidation := timePhase(ctx, "validation")
 	resolvedPath, err, subDirs := s.validatePath(path, workingDir)
+	endValidation()
+	endSpan(span, err)
 	if err != nil {
 		if subDirs == nil {
-			return nil, err
+			return docRequest{}, cachedDoc{}, errorResultFromErr("invalid path", err)
 		}
 		// Return a special response indicating available subdirectories
-		return mcp.NewToolResultErrorf("No Go files found in %s, but found Go packages in the following subdirectories:\n%s",
-			path, strings.Join(subDirs, "\n")), nil
+		return docRequest{}, cachedDoc{}, subpackagesResult(path, subDirs)
 	}
 
 	// Use the resolved path for documentation
 	path = resolvedPath
 
+	// Flags go doc would reject are dropped with a warning instead of failing the call
+	cmdFlags, warnings := dropInvalidFlags(request.GetStringSlice("cmd_flags", []string{}))
+	for _, w := range warnings {
+		log.WithField("warning", w).Debug("Dropped cmd_flag")
+	}
+	target := request.GetString("target", "")
+	var requestedTarget string
+	// A list of targets documents each of them in turn, as a pattern does
+	targets := request.GetStringSlice("target", nil)
+	switch {
+	case len(targets) == 1:
+		target, targets = targets[0], nil
+	case len(targets) > maxGlobSymbols:
+		return docRequest{}, cachedDoc{}, errorResult(codeInvalidArgument, fmt.Sprintf("target lists %d symbols; request at most %d at once", len(targets), maxGlobSymbols))
+	}
+	switch {
+	case len(fileTargets) == 1:
+		target = fileTargets[0]
+	case len(fileTargets) > 1:
+		targets = fileTargets
+	}
+
+	// Standard library lookups are checked against the index without a subprocess
+	if isStdLib(path) {
+		// Short names shared by several packages are resolved by the user
+		if _, ok := s.stdlib.lookup(path); !ok && workingDir == "" {
+			if matches := s.stdlib.resolve(path); len(matches) > 1 {
+				if choice, ok := s.elicitString(ctx, fmt.Sprintf("%q matches several standard library packages. Which one did you mean?", path),
+					"package", "Import path of the package", matches); ok {
+					path = choice
+				}
+			}
+		}
+		endValidation := timePhase(ctx, "validation")
+		// Patterns are matched against the package's symbols once it's resolved
+		checkTarget := target
+		if isGlobTarget(target) {
+			checkTarget = ""
+		}
+		resolved, err := s.checkStdLib(path, checkTarget, cmdFlags, workingDir != "")
+		// Misspelled symbols are replaced by the one they most likely meant
+		if errorCode(err) == codeSymbolNotFound {
+			if p, symbol, ok := s.stdlib.correctSymbol(path, target); ok {
+				log.WithFields(logrus.Fields{"target": target, "symbol": symbol}).Debug("Corrected target symbol")
+				requestedTarget, target = target, symbol
+				resolved, err = s.checkStdLib(p, target, cmdFlags, workingDir != "")
+			}
+		}
+		path = resolved
+		endValidation()
+		if err != nil {
+			return docRequest{}, cachedDoc{}, errorResultFromErr("failed to get doc", err)
+		}
+	}
+
+	// Long-running stages report progress to clients that asked for it, and
+	// documentation is streamed to those that asked for that too
+	progress := s.newProgressReporter(ctx, request)
+	ctx = withProgress(ctx, progress)
+	streamer := s.newDocStreamer(ctx, request, progress)
+
 	// Create temporary project if needed
-	if workingDir == "" {
+	ownModule := workingDir != ""
+	if !ownModule {
 		var err error
-		workingDir, err = s.projectManager.GetOrCreateProject(path)
+		endProject := timePhase(ctx, "project_creation")
+		workingDir, err = s.projectManager.GetOrCreateProject(ctx, path)
+		endProject()
+		if errors.Is(err, errServerBusy) {
+			return docRequest{}, cachedDoc{}, errorResult(codeServerBusy, err.Error())
+		}
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("failed to create temporary project", err), nil
+			if doc, ok := s.pkgsiteFallback(ctx, path, target, request.GetString("format", formatText), err); ok {
+				warnings = append(warnings, fallbackWarning(s.config.Load().PkgsiteURL, err))
+				stream, warning := streamer.finish(doc)
+				if warning != "" {
+					warnings = append(warnings, warning)
+				}
+				return docRequest{
+					path:       path,
+					target:     target,
+					cacheScope: cacheScope,
+					format:     formatText,
+					cmdFlags:   cmdFlags,
+					source:     sourcePkgsite,
+					warnings:   warnings,
+					stream:     stream,
+				}, doc, nil
+			}
+			return docRequest{}, cachedDoc{}, errorResultFromErr("failed to create temporary project", err)
 		}
 	}
 
-	// Add any provided command flags
-	cmdArgs := request.GetStringSlice("cmd_flags", []string{})
-	// Add the path
-	cmdArgs = append(cmdArgs, path)
+	// Add any provided command flags and the path
+	cmdArgs := append(cmdFlags, path)
 
-	// Add specific target if provided
-	if target := request.GetString("target", ""); target != "" {
+	// Add specific target if provided; a pattern documents each symbol it
+	// matches in turn
+	if isGlobTarget(target) {
+		targets = []string{target}
+	}
+	multi := len(targets) > 0
+	if target != "" && !multi {
 		cmdArgs = append(cmdArgs, target)
 	}
 
+	format := request.GetString("format", formatText)
+	if format != formatText && format != formatJSON {
+		return docRequest{}, cachedDoc{}, errorResult(codeInvalidArgument, fmt.Sprintf("format must be %q or %q, got %q", formatText, formatJSON, format))
+	}
+
 	// Run go doc command with working directory
-	doc, err := s.runGoDoc(workingDir, cmdArgs...)
+	endGoDoc := timePhase(ctx, "go_doc")
+	var doc cachedDoc
+	var matched, missing []string
+	var matchedTotal int
+	if multi {
+		doc, matched, matchedTotal, missing, err = s.runTargetsDoc(ctx, cacheScope, workingDir, format, cmdFlags, path, targets)
+		for _, t := range missing {
+			warnings = append(warnings, fmt.Sprintf("no symbol in package %s matches target %s", path, t))
+		}
+		if target == "" {
+			target = strings.Join(targets, ",")
+		}
+	} else {
+		// A single document is streamed as it is generated
+		doc, err = s.runGoDoc(withDocStreamer(ctx, streamer), cacheScope, workingDir, format, cmdArgs...)
+	}
+	if errorCode(err) == codeSymbolNotFound && requestedTarget == "" && !multi {
+		if symbol, ok := correctSymbol(target, s.docSymbols(ctx, cacheScope, workingDir, path)); ok {
+			log.WithFields(logrus.Fields{"target": target, "symbol": symbol}).Debug("Corrected target symbol")
+			requestedTarget, target = target, symbol
+			cmdArgs[len(cmdArgs)-1] = target
+			streamer.discard()
+			doc, err = s.runGoDoc(withDocStreamer(ctx, streamer), cacheScope, workingDir, format, cmdArgs...)
+		}
+	}
+	endGoDoc()
+	var source string
+	if err != nil && !ownModule && !multi {
+		if fallback, ok := s.pkgsiteFallback(ctx, path, target, format, err); ok {
+			streamer.discard()
+			warnings = append(warnings, fallbackWarning(s.config.Load().PkgsiteURL, err))
+			doc, source, err = fallback, sourcePkgsite, nil
+		}
+	}
 	if err != nil {
-		s.logger.WithField("error", err).Error("Error running go doc")
-		return mcp.NewToolResultErrorFromErr("failed to get doc", err), nil
+		if errors.Is(err, errServerBusy) {
+			log.Warn("Rejected go doc request, server busy")
+			return docRequest{}, cachedDoc{}, errorResult(codeServerBusy, err.Error())
+		}
+		log.WithField("error", err).Error("Error running go doc")
+		return docRequest{}, cachedDoc{}, errorResultFromErr("failed to get doc", err)
 	}
 
-	// Get pagination parameters with defaults
-	page := request.GetInt("page", 1)
-	pageSize := request.GetInt("page_size", 1000)
-
-	// Split content into lines
-	lines := strings.Split(doc, "\n")
-	totalLines := len(lines)
-	totalPages := (totalLines + pageSize - 1) / pageSize
-
-	// Validate page number
-	if page > totalPages {
-		return mcp.NewToolResultErrorf("page %d exceeds total pages %d", page, totalPages), nil
+	// Documents served from the cache, or generated for another caller, are
+	// streamed once they're complete
+	stream, warning := streamer.finish(doc)
+	if warning != "" {
+		warnings = append(warnings, warning)
 	}
 
-	// Calculate slice bounds
-	start := (page - 1) * pageSize
-	end := min(start+pageSize, totalLines)
-
-	// Join the lines for this page
-	pageContent := strings.Join(lines[start:end], "\n")
-
-	// Create pagination metadata
-	metadata := fmt.Sprintf("Page %d of %d (showing lines %d-%d of %d)",
-		page, totalPages, start+1, end, totalLines)
+	// Internal packages document fine but can't be imported from just anywhere
+	if w := internalWarning(path, workingDir, ownModule); w != "" {
+		warnings = append(warnings, w)
+	}
 
-	// Create the result with documentation and pagination info
-	s.logger.WithFields(logrus.Fields{
-		"page":        page,
-		"total_pages": totalPages,
-		"lines":       end - start,
-	}).Debug("Returning paginated documentation")
-	return mcp.NewToolResultText(metadata + "\n\n" + pageContent), nil
+	return docRequest{
+		path:            path,
+		target:          target,
+		workingDir:      workingDir,
+		cacheScope:      cacheScope,
+		format:          format,
+		cmdFlags:        cmdFlags,
+		cmdArgs:         cmdArgs,
+		ownModule:       ownModule,
+		requestedTarget: requestedTarget,
+		file:            file,
+		targets:         targets,
+		matched:         matched,
+		matchedTotal:    matchedTotal,
+		source:          source,
+		warnings:        warnings,
+		stream:          stream,
+	}, doc, nil
 }
 
 // cleanup removes all temporary directories and stops the cache
 func (s *GodocServer) cleanup() {
+	s.closed.Store(true)
+	s.gopls.close()
 	s.projectManager.cleanup()
+	s.sessions.cleanup()
+	if s.workspacePkgs != nil {
+		s.workspacePkgs.DeleteAll()
+		s.workspacePkgs.Stop()
+	}
+	if s.cursorDocs != nil {
+		s.cursorDocs.DeleteAll()
+		s.cursorDocs.Stop()
+	}
 	if s.cache != nil {
 		s.cache.DeleteAll()
 		s.cache.Stop()
 	}
 }
 
+// newDocCache creates the documentation cache, evicting the least recently used
+// documents once their combined size exceeds the configured byte budget
+func newDocCache(cfg *Config, logger *logrus.Logger) *ttlcache.Cache[string, cachedDoc] {
+	opts := []ttlcache.Option[string, cachedDoc]{ttlcache.WithTTL[string, cachedDoc](cfg.CacheTTL)}
+	if cfg.CacheMaxBytes > 0 {
+		opts = append(opts, ttlcache.WithMaxCost[string, cachedDoc](uint64(cfg.CacheMaxBytes), func(item ttlcache.CostItem[string, cachedDoc]) uint64 {
+			return uint64(item.Value.byteSize)
+		}))
+	}
+	cache := ttlcache.New(opts...)
+	cache.OnEviction(func(_ context.Context, reason ttlcache.EvictionReason, item *ttlcache.Item[string, cachedDoc]) {
+		if reason == ttlcache.EvictionReasonMaxCostExceeded {
+			logger.WithFields(logrus.Fields{
+				"cache_key": item.Key(),
+				"bytes":     item.Value().byteSize,
+			}).Debug("Evicted document over cache byte budget")
+		}
+	})
+	return cache
+}
+
+// newGodocServer creates a GodocServer with its caches started
+func newGodocServer(cfg *Config, logger *logrus.Logger) *GodocServer {
+	limiter := newSubprocessLimiter(cfg.MaxSubprocesses, cfg.MaxQueued, cfg.QueueTimeout)
+	srv := &GodocServer{
+		cache:          newDocCache(cfg, logger),
+		projectManager: NewProjectManager(logger, limiter, cfg.ProjectTTL),
+		sessions:       newSessionStore(),
+		limiter:        limiter,
+		workers:        newSubprocessLimiter(cfg.MaxWorkers, cfg.MaxQueued, cfg.QueueTimeout),
+		stdlib:         &stdIndex{},
+		semantic:       &semanticIndex{},
+		symbols:        &symbolIndex{},
+		pkgSearches:    newFindCache(),
+		signals:        newSignalsCache(),
+		reports:        newReportCache(),
+		usages:         newUsageCache(),
+		releases:       &releaseCatalog{},
+		gopls:          &goplsPool{logger: logger},
+		logger:         logger,
+		started:        time.Now(),
+		recentLogs:     newLogRing(),
+		failures:       newRing[failedCall](bundleFailures),
+		workspacePkgs:  newWorkspacePackagesCache(),
+		cursorDocs:     newCursorCache(cfg),
+	}
+	logger.AddHook(srv.recentLogs)
+	logger.AddHook(sessionLogHook{})
+	srv.config.Store(cfg)
+	srv.setRuntimeCaps(detectRuntimeCaps(context.Background(), cfg))
+	go srv.cache.Start()
+	return srv
+}
+
 func main() {
 	// Set up structured logging to stderr (since stdout is used for MCP communication)
 	logger := logrus.New()
 	logger.SetOutput(os.Stderr)
-	logger.SetLevel(logrus.DebugLevel)
-	logger.Info("Starting godoc-mcp server...")
 
-	srv := &GodocServer{
-		cache:          ttlcache.New(ttlcache.WithTTL[string, cachedDoc](5 * time.Minute)),
-		projectManager: NewProjectManager(logger),
-		logger:         logger,
+	cfg, err := loadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
-	go srv.cache.Start()
+	if err == nil {
+		err = cfg.validate()
+	}
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid configuration")
+	}
+	build := getBuildInfo()
+	if cfg.ShowVersion {
+		fmt.Printf("godoc-mcp %s %s\n", build, build.GoVersion)
+		return
+	}
+	if cfg.Doctor {
+		fmt.Printf("godoc-mcp %s\n\n", build)
+		if !runDoctor(context.Background(), os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+	applyLogConfig(logger, cfg)
+
+	shutdownTracing, err := setupTracing(context.Background(), build.Version, logger)
+	if err != nil {
+		logger.WithError(err).Warn("Tracing disabled")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTracing(ctx)
+	}()
+
+	srv := newGodocServer(cfg, logger)
+	if cfg.Query != nil {
+		ok := srv.runQuery(context.Background(), cfg.Query, os.Stdout)
+		srv.cleanup()
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"version":    build.Version,
+		"commit":     build.Commit,
+		"go_version": build.GoVersion,
+	}).Info("Starting godoc-mcp server...")
+
+	srv.gopls.start(cfg.GoplsPath, cfg.GoplsWorkspaces)
+	go srv.warmSymbolIndex(context.Background())
+	go srv.pollModuleIndex(context.Background())
+
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(srv.onRegisterSession)
+	hooks.AddOnUnregisterSession(srv.onUnregisterSession)
+	hooks.AddAfterSetLevel(srv.onSetLevel)
+	hooks.AddBeforeCallTool(srv.onBeforeCallTool)
+	hooks.AddAfterInitialize(srv.onAfterInitialize)
 
 	// Create new MCP server with tools enabled
 	s := server.NewMCPServer(
 		"godoc-mcp",
-		"0.1.0",
+		build.String(),
 		server.WithToolCapabilities(true), // Enable tools
 		server.WithLogging(),              // Add logging
+		// Subscriptions are answered by the transports, as mcp-go doesn't route them
+		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(false),
+		server.WithToolFilter(srv.filterTools),
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(srv),
+		server.WithResourceCompletionProvider(srv),
+		server.WithToolHandlerMiddleware(srv.withTracing),
+		server.WithToolHandlerMiddleware(srv.withCallID),
+		server.WithToolHandlerMiddleware(srv.withCancellation),
+		server.WithToolHandlerMiddleware(srv.withSlowLog),
+		server.WithToolHandlerMiddleware(srv.trackInFlight),
+		server.WithToolHandlerMiddleware(srv.withWorker),
+		server.WithHooks(hooks),
 	)
 
-	logger.Info("Adding get_doc tool...")
-	s.AddTool(mcp.Tool{
-		Name:        "get_doc",
-		Description: toolDescription,
-		InputSchema: docInputSchema,
-	}, srv.handleToolCall)
+	srv.registerTools(s)
+	srv.registerResources(s)
+	srv.registerPrompts(s)
+	s.AddNotificationHandler("notifications/cancelled", srv.onCancelled)
+	s.AddNotificationHandler(mcp.MethodNotificationRootsListChanged, srv.onRootsChanged)
+
+	// Index the standard library in the background; lookups skip the index until
+	// it is ready, and its packages are listed as resources once it is
+	go func() {
+		srv.stdlib.build(context.Background(), logger)
+		srv.registerStdResources(s)
+		srv.warmSemanticIndex(context.Background())
+	}()
 
 	// Cleanup temporary directories before exit
 	defer srv.cleanup()
 
-	var srvHTTP string
-	flag.StringVar(&srvHTTP, "http", "", "serve as http")
-	flag.Parse()
-	if srvHTTP != "" {
-		logger.Info("Starting http server...")
-		sse := server.NewStreamableHTTPServer(s)
-		if err := sse.Start(srvHTTP); err != nil {
-			logger.WithError(err).Fatal("sse error")
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+	go srv.watchReload(reloadCtx, s, os.Args[1:])
+
+	// Profiling is opt-in and stops with the server
+	if cfg.PprofAddr != "" {
+		go servePprof(reloadCtx, cfg.PprofAddr, logger)
+	}
+	if cfg.ProfileDir != "" {
+		go writeProfiles(reloadCtx, cfg.ProfileDir, cfg.ProfileInterval, logger)
+	}
+	if cfg.MetricsInterval > 0 {
+		go srv.emitMetrics(reloadCtx, s, cfg.MetricsInterval)
+	}
+	go srv.watchSubscriptions(reloadCtx, s)
+
+	if cfg.HTTPAddr != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		logger.WithFields(logrus.Fields{
+			"addr":      cfg.HTTPAddr,
+			"transport": cfg.HTTPTransport,
+			"base_path": cfg.BasePath,
+		}).Info("Starting http server...")
+		if err := srv.serveHTTP(ctx, s, cfg); err != nil {
+			srv.cleanup()
+			logger.WithError(err).Fatal("http server error")
 		}
 		return
 	}
 	// Run server using stdio
 	logger.Info("Starting stdio server...")
-	if err := server.ServeStdio(s); err != nil {
+	// Tool calls run on mcp-go's worker pool, which must be large enough for the
+	// worker limiter to queue and reject calls while cancellations are still read
+	if err := srv.serveStdio(s, server.WithWorkerPoolSize(min(cfg.MaxWorkers+cfg.MaxQueued, 100))); err != nil {
 		logger.WithField("error", err).Fatal("Server error")
 	}
 
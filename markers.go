@@ -0,0 +1,334 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+const sourceMarkersDescription = `List the TODO, FIXME, XXX, HACK, BUG(who), and Deprecated markers in the comments of a package's source.
+Returns each marker with its file, line, author for TODO(who) and BUG(who), the declaration it documents,
+and its text. Use it alongside the documentation when judging a package's health or maturity, before
+relying on a deprecated API, or to find known bugs and unfinished work in code about to be changed.
+path takes the same forms as get_doc's; end it with /... to include the packages below it.`
+
+// Limits on source_markers results
+const (
+	defaultMarkers = 200
+	maxMarkers     = 2000
+	// maxMarkerText caps the text reported for a marker
+	maxMarkerText = 500
+)
+
+// markerKinds are the markers source_markers reports
+var markerKinds = []string{"TODO", "FIXME", "XXX", "HACK", "BUG", "Deprecated"}
+
+// sourceMarker matches a marker at the start of a comment line. BUG needs
+// the BUG(who): form go doc lists in its BUGS section, so prose like "BUG
+// fixes" isn't taken for one.
+var sourceMarker = regexp.MustCompile(`^(?:(TODO|FIXME|XXX|HACK)\b(?:\(([^)]*)\))?:?|BUG\(([^)]*)\):|(Deprecated):)\s*(.*)$`)
+
+// sourceMarkersSchema is the source_markers input schema
+var sourceMarkersSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Package to scan, as for get_doc (e.g., 'net/http', './pkg', or 'github.com/user/repo'). End it with '/...' to include the packages below it.",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory for relative paths and the module's own packages. Defaults to the session working directory.",
+		},
+		"kinds": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string", "enum": markerKinds},
+			"description": "Optional: Only report these markers. Defaults to all of them.",
+		},
+		"include_tests": map[string]any{
+			"type":        "boolean",
+			"description": "Scan _test.go files too.",
+			"default":     false,
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of markers to list.",
+			"minimum":     1,
+			"maximum":     maxMarkers,
+			"default":     defaultMarkers,
+		},
+	},
+	Required: []string{"path"},
+}
+
+// markersOutputSchema is the outputSchema of source_markers, describing markersOutput
+var markersOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path":          map[string]any{"type": "string"},
+		"files_scanned": map[string]any{"type": "integer"},
+		"counts": map[string]any{
+			"type":                 "object",
+			"description":          "Number of markers of each kind found, including any not listed",
+			"additionalProperties": map[string]any{"type": "integer"},
+		},
+		"truncated": map[string]any{"type": "boolean", "description": "Whether markers were left out at limit"},
+		"markers": map[string]any{
+			"type":        "array",
+			"description": "Markers in file order",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"kind":   map[string]any{"type": "string", "enum": markerKinds},
+					"file":   map[string]any{"type": "string", "description": "Absolute path of the file"},
+					"line":   map[string]any{"type": "integer"},
+					"author": map[string]any{"type": "string", "description": "Who the marker names, as in TODO(who) or BUG(who)"},
+					"symbol": map[string]any{"type": "string", "description": "Declaration the comment documents, such as Client.Do or Transport.Dial, or 'package' for package comments"},
+					"text":   map[string]any{"type": "string", "description": "The marker's text, through the end of its paragraph"},
+				},
+				"required": []string{"kind", "file", "line", "text"},
+			},
+		},
+	},
+	Required: []string{"path", "files_scanned", "counts", "truncated", "markers"},
+}
+
+// markersOutput is the structured content of a source_markers result
+type markersOutput struct {
+	Path         string         `json:"path"`
+	FilesScanned int            `json:"files_scanned"`
+	Counts       map[string]int `json:"counts"`
+	Truncated    bool           `json:"truncated"`
+	Markers      []sourceNote   `json:"markers"`
+}
+
+// sourceNote is a marker found in a source comment
+type sourceNote struct {
+	Kind   string `json:"kind"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Author string `json:"author,omitempty"`
+	Symbol string `json:"symbol,omitempty"`
+	Text   string `json:"text"`
+}
+
+// handleSourceMarkers implements the source_markers tool
+func (s *GodocServer) handleSourceMarkers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if err := s.runtime.Load().toolchainErr; err != nil {
+		return errorResultFromErr("cannot load packages", err), nil
+	}
+	path := request.GetString("path", "")
+	if path == "" {
+		return errorResult(codeInvalidArgument, "invalid or missing path parameter"), nil
+	}
+	kinds := request.GetStringSlice("kinds", markerKinds)
+	for _, kind := range kinds {
+		if !slices.Contains(markerKinds, kind) {
+			return errorResult(codeInvalidArgument, fmt.Sprintf("unknown marker kind %q; kinds are %s", kind, strings.Join(markerKinds, ", "))), nil
+		}
+	}
+	limit := request.GetInt("limit", defaultMarkers)
+	if limit < 1 || limit > maxMarkers {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("limit must be between 1 and %d, got %d", maxMarkers, limit)), nil
+	}
+
+	log := ctxLogger(ctx, s.logger).WithField("path", path)
+	progress := s.newProgressReporter(ctx, request)
+	ctx = withProgress(ctx, progress)
+	dirs, err := s.sourceDirs(ctx, request, path)
+	if err != nil {
+		return errorResultFromErr("failed to find the source of "+path, err), nil
+	}
+
+	out := &markersOutput{Path: path, Counts: make(map[string]int), Markers: make([]sourceNote, 0)}
+	includeTests := request.GetBool("include_tests", false)
+	fset := token.NewFileSet()
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || !strings.HasSuffix(name, ".go") || (!includeTests && strings.HasSuffix(name, "_test.go")) {
+				continue
+			}
+			if info, err := e.Info(); err != nil || info.Size() > maxGrepFileBytes {
+				continue
+			}
+			file := filepath.Join(dir, name)
+			f, err := parser.ParseFile(fset, file, nil, parser.ParseComments|parser.SkipObjectResolution)
+			if err != nil {
+				continue
+			}
+			out.FilesScanned++
+			for _, note := range fileMarkers(fset, f) {
+				if !slices.Contains(kinds, note.Kind) {
+					continue
+				}
+				out.Counts[note.Kind]++
+				if len(out.Markers) < limit {
+					note.File = file
+					out.Markers = append(out.Markers, note)
+				} else {
+					out.Truncated = true
+				}
+			}
+		}
+	}
+	log.WithFields(logrus.Fields{"files": out.FilesScanned, "markers": len(out.Markers)}).Debug("Scanned source markers")
+
+	var sb strings.Builder
+	if len(out.Counts) == 0 {
+		fmt.Fprintf(&sb, "No %s markers in the %d files scanned.", strings.Join(kinds, ", "), out.FilesScanned)
+	} else {
+		var counts []string
+		for _, kind := range markerKinds {
+			if n := out.Counts[kind]; n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", n, kind))
+			}
+		}
+		fmt.Fprintf(&sb, "%s in the %d files scanned", strings.Join(counts, ", "), out.FilesScanned)
+		if out.Truncated {
+			fmt.Fprintf(&sb, "; first %d listed, narrow kinds or path, or raise limit, to see more", len(out.Markers))
+		}
+		sb.WriteString(".\n\n")
+	}
+	for _, note := range out.Markers {
+		fmt.Fprintf(&sb, "%s:%d: %s", note.File, note.Line, note.Kind)
+		if note.Author != "" {
+			fmt.Fprintf(&sb, "(%s)", note.Author)
+		}
+		if note.Symbol != "" {
+			fmt.Fprintf(&sb, " [%s]", note.Symbol)
+		}
+		fmt.Fprintf(&sb, ": %s\n", note.Text)
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// fileMarkers returns the markers in the comments of f, without their file
+func fileMarkers(fset *token.FileSet, f *ast.File) []sourceNote {
+	symbols := docSymbols(f)
+	var notes []sourceNote
+	for _, cg := range f.Comments {
+		var lines []string
+		var lineNums []int
+		for _, c := range cg.List {
+			line := fset.Position(c.Pos()).Line
+			text, block := strings.CutPrefix(c.Text, "/*")
+			if block {
+				text = strings.TrimSuffix(text, "*/")
+			} else {
+				text = strings.TrimPrefix(text, "//")
+			}
+			for i, l := range strings.Split(text, "\n") {
+				l = strings.TrimSpace(l)
+				if block {
+					l = strings.TrimSpace(strings.TrimPrefix(l, "*"))
+				}
+				lines = append(lines, l)
+				lineNums = append(lineNums, line+i)
+			}
+		}
+		for i, l := range lines {
+			m := sourceMarker.FindStringSubmatch(l)
+			if m == nil {
+				continue
+			}
+			note := sourceNote{Kind: m[1], Line: lineNums[i], Author: m[2], Symbol: symbols[cg]}
+			switch {
+			case strings.HasPrefix(l, "BUG("):
+				note.Kind, note.Author = "BUG", m[3]
+			case m[4] != "":
+				note.Kind = m[4]
+			}
+			// The marker's paragraph continues to a blank line or the next marker
+			text := []string{m[5]}
+			for _, next := range lines[i+1:] {
+				if next == "" || sourceMarker.MatchString(next) {
+					break
+				}
+				text = append(text, next)
+			}
+			note.Text = strings.TrimSpace(strings.Join(text, " "))
+			if len(note.Text) > maxMarkerText {
+				note.Text = note.Text[:maxMarkerText] + "..."
+			}
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// docSymbols maps the doc comments of f to the declarations they document:
+// functions and methods (Type.Method), types, constants, variables, and the
+// fields and methods of struct and interface types (Type.Field)
+func docSymbols(f *ast.File) map[*ast.CommentGroup]string {
+	symbols := make(map[*ast.CommentGroup]string)
+	add := func(cg *ast.CommentGroup, name string) {
+		if cg != nil {
+			symbols[cg] = name
+		}
+	}
+	add(f.Doc, "package")
+	fields := func(typeName string, list *ast.FieldList) {
+		if list == nil {
+			return
+		}
+		for _, field := range list.List {
+			if len(field.Names) > 0 {
+				add(field.Doc, typeName+"."+field.Names[0].Name)
+				add(field.Comment, typeName+"."+field.Names[0].Name)
+			}
+		}
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil {
+				name = receiverName(d.Recv) + "." + name
+			}
+			add(d.Doc, name)
+		case *ast.GenDecl:
+			var first string
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					add(sp.Doc, sp.Name.Name)
+					add(sp.Comment, sp.Name.Name)
+					first = cmp.Or(first, sp.Name.Name)
+					switch t := sp.Type.(type) {
+					case *ast.StructType:
+						fields(sp.Name.Name, t.Fields)
+					case *ast.InterfaceType:
+						fields(sp.Name.Name, t.Methods)
+					}
+				case *ast.ValueSpec:
+					add(sp.Doc, sp.Names[0].Name)
+					add(sp.Comment, sp.Names[0].Name)
+					first = cmp.Or(first, sp.Names[0].Name)
+				}
+			}
+			// A group's comment documents its only declaration
+			if len(d.Specs) == 1 {
+				add(d.Doc, first)
+			}
+		}
+	}
+	return symbols
+}
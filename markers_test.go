@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSourceMarkers(t *testing.T) {
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/mod\n\ngo 1.21\n",
+		"store/store.go": `// Package store stores values.
+//
+// BUG(ann): Values larger than a page are truncated.
+package store
+
+// Client talks to the store.
+type Client struct {
+	// Timeout bounds each call.
+	// TODO(bob): make it per call.
+	Timeout int
+}
+
+// Get returns the value of key.
+//
+// Deprecated: Use Lookup.
+func (c *Client) Get(key string) string {
+	// FIXME: handle missing keys
+	// instead of returning "".
+	return ""
+}
+
+/* HACK: the BUG fixes in cache are prose */
+var cache = map[string]string{}
+`,
+		"store/store_test.go": "package store\n\n// TODO: test Get\n",
+		"store/sub/sub.go":    "package sub\n\n// XXX: remove\nvar X = 1\n",
+	})
+	all := []string{
+		"store.go:3: BUG(ann) [package]: Values larger than a page are truncated.",
+		"store.go:9: TODO(bob) [Client.Timeout]: make it per call.",
+		"store.go:15: Deprecated [Client.Get]: Use Lookup.",
+		`store.go:17: FIXME: handle missing keys instead of returning "".`,
+		"store.go:22: HACK [cache]: the BUG fixes in cache are prose",
+	}
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		args      map[string]any
+		wantCode  string
+		want      []string
+		count     int
+		files     int
+		truncated bool
+	}{
+		{"no path", map[string]any{"working_dir": dir}, codeInvalidArgument, nil, 0, 0, false},
+		{"unknown kind", map[string]any{"path": "./store", "kinds": []any{"NOTE"}, "working_dir": dir}, codeInvalidArgument, nil, 0, 0, false},
+		{"bad limit", map[string]any{"path": "./store", "limit": maxMarkers + 1, "working_dir": dir}, codeInvalidArgument, nil, 0, 0, false},
+		{"all kinds", map[string]any{"path": "./store", "working_dir": dir}, "", all, 5, 1, false},
+		{"kinds", map[string]any{"path": "./store", "kinds": []any{"TODO", "FIXME"}, "working_dir": dir}, "", []string{all[1], all[3]}, 2, 1, false},
+		{"none of the kinds", map[string]any{"path": "./store", "kinds": []any{"XXX"}, "working_dir": dir}, "", nil, 0, 1, false},
+		{"tests", map[string]any{"path": "./store", "include_tests": true, "working_dir": dir}, "", append(slices.Clone(all), "store_test.go:3: TODO: test Get"), 6, 2, false},
+		{"packages below", map[string]any{"path": "./store/...", "working_dir": dir}, "", append(slices.Clone(all), "sub.go:3: XXX [X]: remove"), 6, 2, false},
+		{"limit", map[string]any{"path": "./store", "limit": 2, "working_dir": dir}, "", all[:2], 5, 1, true},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleSourceMarkers, "source_markers", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*markersOutput)
+		var got []string
+		for _, note := range out.Markers {
+			line := fmt.Sprintf("%s:%d: %s", filepath.Base(note.File), note.Line, note.Kind)
+			if note.Author != "" {
+				line += "(" + note.Author + ")"
+			}
+			if note.Symbol != "" {
+				line += " [" + note.Symbol + "]"
+			}
+			got = append(got, line+": "+note.Text)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: markers\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+		count := 0
+		for _, n := range out.Counts {
+			count += n
+		}
+		if count != tt.count || out.FilesScanned != tt.files || out.Truncated != tt.truncated {
+			t.Errorf("%s: %d markers in %d files, truncated %v; want %d in %d, %v", tt.name, count, out.FilesScanned, out.Truncated, tt.count, tt.files, tt.truncated)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+// metricsLogger names the MCP logger that carries periodic metrics
+const metricsLogger = "godoc-mcp.metrics"
+
+// serverMetrics counts tool calls, their errors, and cache lookups since startup
+type serverMetrics struct {
+	toolCalls   atomic.Int64
+	toolNanos   atomic.Int64
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
+
+	mu     sync.Mutex
+	errors map[string]int64
+}
+
+// metricsSnapshot is the value of every counter at one point in time
+type metricsSnapshot struct {
+	toolCalls, toolNanos, cacheHits, cacheMisses int64
+}
+
+// observeCall records one completed tool call and the category of its error, if any
+func (m *serverMetrics) observeCall(d time.Duration, result *mcp.CallToolResult, err error) {
+	m.toolCalls.Add(1)
+	m.toolNanos.Add(int64(d))
+	if category := errorCategory(result, err); category != "" {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.errors == nil {
+			m.errors = make(map[string]int64)
+		}
+		m.errors[category]++
+	}
+}
+
+// errorCounts returns the number of failed tool calls in each error category
+func (m *serverMetrics) errorCounts() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.errors)
+}
+
+// errorCategory classifies a failed tool call by its error code. Protocol-level
+// failures are internal.
+func errorCategory(result *mcp.CallToolResult, err error) string {
+	switch {
+	case err != nil:
+		return codeInternal
+	case result == nil || !result.IsError:
+		return ""
+	default:
+		return cmp.Or(resultErrorCode(result), codeDocFailed)
+	}
+}
+
+func (m *serverMetrics) snapshot() metricsSnapshot {
+	return metricsSnapshot{
+		toolCalls:   m.toolCalls.Load(),
+		toolNanos:   m.toolNanos.Load(),
+		cacheHits:   m.cacheHits.Load(),
+		cacheMisses: m.cacheMisses.Load(),
+	}
+}
+
+// onRegisterSession remembers a connected session so it can receive metrics
+func (s *GodocServer) onRegisterSession(_ context.Context, session server.ClientSession) {
+	s.clients.Store(session.SessionID(), struct{}{})
+}
+
+// emitMetrics sends a metrics report as an MCP logging notification to every
+// session every interval until ctx is cancelled. Reports are sent at info level,
+// so only clients that lowered their level with logging/setLevel receive them.
+func (s *GodocServer) emitMetrics(ctx context.Context, mcpServer *server.MCPServer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	prev := s.metrics.snapshot()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cur := s.metrics.snapshot()
+		report := s.metricsReport(prev, cur, interval)
+		prev = cur
+
+		notification := mcp.NewLoggingMessageNotification(mcp.LoggingLevelInfo, metricsLogger, report)
+		s.clients.Range(func(id, _ any) bool {
+			err := mcpServer.SendLogMessageToSpecificClient(id.(string), notification)
+			if err != nil && !errors.Is(err, server.ErrSessionNotInitialized) && !errors.Is(err, server.ErrSessionDoesNotSupportLogging) {
+				s.logger.WithError(err).WithField("session", id).Debug("Failed to send metrics")
+			}
+			return true
+		})
+		s.logger.WithFields(logrus.Fields(report)).Trace("Metrics")
+	}
+}
+
+// metricsReport describes the interval between two snapshots along with the
+// current load
+func (s *GodocServer) metricsReport(prev, cur metricsSnapshot, interval time.Duration) map[string]any {
+	calls := cur.toolCalls - prev.toolCalls
+	hits := cur.cacheHits - prev.cacheHits
+	lookups := hits + cur.cacheMisses - prev.cacheMisses
+	var avgLatency, hitRate float64
+	if calls > 0 {
+		avgLatency = float64(cur.toolNanos-prev.toolNanos) / float64(calls) / float64(time.Millisecond)
+	}
+	if lookups > 0 {
+		hitRate = float64(hits) / float64(lookups)
+	}
+	return map[string]any{
+		"interval_seconds":    interval.Seconds(),
+		"tool_calls":          calls,
+		"avg_latency_ms":      avgLatency,
+		"cache_lookups":       lookups,
+		"cache_hit_rate":      hitRate,
+		"cached_documents":    s.cache.Len(),
+		"in_flight":           s.inFlight.Load(),
+		"active_subprocesses": s.limiter.inUse(),
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// modCacheDir returns the module cache directory, or "" if it can't be determined
+var modCacheDir = sync.OnceValue(func() string {
+	env, err := goEnv(context.Background(), "GOMODCACHE")
+	if err != nil {
+		return ""
+	}
+	return env["GOMODCACHE"]
+})
+
+// cachedModule is a module version already extracted in the module cache
+type cachedModule struct {
+	path    string
+	version string
+	dir     string
+}
+
+// findCachedModule finds the module providing pkgPath at version in the module
+// cache, preferring the longest matching module path. An empty version picks
+// the highest cached one, which need not be the latest published.
+// Packages can be documented from the returned directory without go get.
+func findCachedModule(pkgPath, version string) (cachedModule, bool) {
+	root := modCacheDir()
+	if root == "" {
+		return cachedModule{}, false
+	}
+	for modPath := pkgPath; modPath != "." && modPath != "/"; modPath = path.Dir(modPath) {
+		if m, ok := cachedModuleVersion(root, modPath, version); ok {
+			// Nested modules are excluded from their parent's zip, so a
+			// missing directory means the package belongs to another module
+			sub := strings.TrimPrefix(strings.TrimPrefix(pkgPath, modPath), "/")
+			if info, err := os.Stat(filepath.Join(m.dir, filepath.FromSlash(sub))); err == nil && info.IsDir() {
+				return m, true
+			}
+		}
+	}
+	return cachedModule{}, false
+}
+
+// cachedModuleVersion returns the cached version of modPath, or its highest
+// cached version if version is empty, that has a go.mod declaring that module
+// path and can be loaded without downloading
+func cachedModuleVersion(root, modPath, version string) (cachedModule, bool) {
+	escaped, err := module.EscapePath(modPath)
+	if err != nil {
+		return cachedModule{}, false
+	}
+	dirs, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(escaped)+"@*"))
+	if err != nil {
+		return cachedModule{}, false
+	}
+	var best cachedModule
+	for _, dir := range dirs {
+		v, err := module.UnescapeVersion(dir[strings.LastIndex(dir, "@")+1:])
+		if err != nil || !semver.IsValid(v) || version != "" && v != version {
+			continue
+		}
+		if best.version != "" && semver.Compare(v, best.version) <= 0 {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err != nil || modfile.ModulePath(data) != modPath || !requirementsCached(root, data) {
+			continue
+		}
+		best = cachedModule{path: modPath, version: v, dir: dir}
+	}
+	return best, best.version != ""
+}
+
+// latestModuleVersion resolves the version modPath@latest refers to, as go get
+// would, which is a single module proxy request rather than a download
+func latestModuleVersion(ctx context.Context, modPath string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "list", "-m", "-f", "{{.Version}}", modPath+"@latest")
+	// Outside any module, so no go.mod or go.work affects the query
+	cmd.Dir = os.TempDir()
+	env := buildEnviron(ctx)
+	if env == nil {
+		// A nil Env inherits the environment, but appending to it would replace it
+		env = os.Environ()
+	}
+	cmd.Env = append(env, "GOWORK=off")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go list %s@latest: %v: %s", modPath, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// requirementsCached reports whether the go.mod of every module required by the
+// go.mod file data is in the module cache, so loading the module graph stays offline
+func requirementsCached(root string, data []byte) bool {
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return false
+	}
+	for _, req := range f.Require {
+		escaped, err := module.EscapePath(req.Mod.Path)
+		if err != nil {
+			return false
+		}
+		version, err := module.EscapeVersion(req.Mod.Version)
+		if err != nil {
+			return false
+		}
+		if _, err := os.Stat(filepath.Join(root, "cache", "download", filepath.FromSlash(escaped), "@v", version+".mod")); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCachedModuleVersion(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		name = filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("example.com/mod@v1.0.0/go.mod", "module example.com/mod\n")
+	write("example.com/mod@v1.2.0/go.mod", "module example.com/mod\n")
+	// The highest version needs a requirement that was never downloaded
+	write("example.com/mod@v1.3.0/go.mod", "module example.com/mod\n\nrequire example.com/dep v0.1.0\n")
+	write("example.com/mod@v1.4.0-pre/go.mod", "module example.com/other\n")
+	// Upper case letters are escaped in module cache paths
+	write("example.com/!upper@v0.1.0/go.mod", "module example.com/Upper\n\nrequire example.com/dep v0.2.0\n")
+	write("cache/download/example.com/dep/@v/v0.2.0.mod", "module example.com/dep\n")
+
+	tests := []struct {
+		modPath, version string
+		want             string
+	}{
+		{"example.com/mod", "", "v1.2.0"},
+		{"example.com/mod", "v1.0.0", "v1.0.0"},
+		{"example.com/mod", "v1.2.0", "v1.2.0"},
+		{"example.com/mod", "v1.3.0", ""},
+		{"example.com/mod", "v1.4.0-pre", ""},
+		{"example.com/mod", "v1.1.0", ""},
+		{"example.com/Upper", "", "v0.1.0"},
+		{"example.com/missing", "", ""},
+	}
+	for _, tt := range tests {
+		m, ok := cachedModuleVersion(root, tt.modPath, tt.version)
+		if ok != (tt.want != "") || m.version != tt.want {
+			t.Errorf("cachedModuleVersion(%q, %q) = %q, %v; want %q", tt.modPath, tt.version, m.version, ok, tt.want)
+			continue
+		}
+		if ok && m.path != tt.modPath {
+			t.Errorf("cachedModuleVersion(%q, %q) has path %q", tt.modPath, tt.version, m.path)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+const recentReleasesDescription = `List Go module versions published recently, from a catalog of the module index.
+Use it to find what's new, such as fresh releases of the modules under "github.com/aws/", or every
+version of one module published within the catalog's window. By default each module is listed once,
+with its newest release, newest first; pre-releases and pseudo-versions are left out unless asked for.`
+
+// Limits on the release catalog and recent_releases requests
+const (
+	defaultReleaseResults = 50
+	maxReleaseResults     = 500
+	// indexPageSize is the number of versions read from the index per request
+	indexPageSize = 2000
+	// maxIndexPages bounds the requests of one poll, so a long backlog is
+	// caught up over several polls
+	maxIndexPages = 50
+	// maxCatalogReleases bounds the catalog, dropping the oldest releases
+	maxCatalogReleases = 500_000
+	// indexTimeout bounds a request to the module index
+	indexTimeout = 30 * time.Second
+)
+
+// recentReleasesSchema is the recent_releases input schema
+var recentReleasesSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"prefix": map[string]any{
+			"type":        "string",
+			"description": "Optional: Only modules whose path starts with this (e.g., 'github.com/aws/').",
+		},
+		"module": map[string]any{
+			"type":        "string",
+			"description": "Optional: List every version of this module in the catalog instead of the newest of each module.",
+		},
+		"since": map[string]any{
+			"type":        "string",
+			"description": "Optional: Only releases published within this duration (e.g., '6h', '30m'). Defaults to the whole catalog.",
+		},
+		"prerelease": map[string]any{
+			"type":        "boolean",
+			"description": "Include pre-release and pseudo-versions.",
+			"default":     false,
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of releases to return.",
+			"minimum":     1,
+			"maximum":     maxReleaseResults,
+			"default":     defaultReleaseResults,
+		},
+	},
+}
+
+// releasesOutputSchema is the outputSchema of recent_releases, describing releasesOutput
+var releasesOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"catalog_start": map[string]any{"type": "string", "description": "Publication time of the oldest release in the catalog"},
+		"catalog_end":   map[string]any{"type": "string", "description": "Publication time of the newest release in the catalog"},
+		"releases": map[string]any{
+			"type":        "array",
+			"description": "Releases, newest first",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"module":    map[string]any{"type": "string", "description": "Module path"},
+					"version":   map[string]any{"type": "string", "description": "Version published"},
+					"timestamp": map[string]any{"type": "string", "description": "When the module index recorded the version"},
+				},
+				"required": []string{"module", "version", "timestamp"},
+			},
+		},
+	},
+	Required: []string{"releases"},
+}
+
+// releasesOutput is the structured content of a recent_releases result
+type releasesOutput struct {
+	CatalogStart *time.Time      `json:"catalog_start,omitempty"`
+	CatalogEnd   *time.Time      `json:"catalog_end,omitempty"`
+	Releases     []moduleRelease `json:"releases"`
+}
+
+// moduleRelease is a module version recorded by the module index
+type moduleRelease struct {
+	Module    string    `json:"module"`
+	Version   string    `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// releaseCatalog holds the module versions published to the module index
+// within a window, read incrementally by polling it
+type releaseCatalog struct {
+	mu sync.RWMutex
+	// releases are ordered by timestamp, oldest first
+	releases []moduleRelease
+	// versions lists the versions of each module in the catalog, oldest first
+	versions map[string][]string
+	// base is the index the catalog was read from; a new one starts over
+	base string
+	// since is the timestamp the next poll resumes from, and atSince the
+	// releases already read with that timestamp, which the index returns again
+	since   time.Time
+	atSince map[string]bool
+	// loaded is set once a poll has completed
+	loaded bool
+}
+
+// poll reads the releases published to the index at base since the last poll,
+// and drops those that fell out of window
+func (c *releaseCatalog) poll(ctx context.Context, log *logrus.Entry, base string, window time.Duration) error {
+	c.mu.Lock()
+	if base != c.base {
+		c.releases, c.versions = nil, make(map[string][]string)
+		c.base, c.since, c.atSince, c.loaded = base, time.Now().Add(-window), make(map[string]bool), false
+	}
+	since := c.since
+	c.mu.Unlock()
+
+	read := 0
+	for range maxIndexPages {
+		page, err := fetchIndexPage(ctx, base, since)
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		for _, r := range page {
+			c.add(r)
+		}
+		since = c.since
+		c.mu.Unlock()
+		read += len(page)
+		if len(page) < indexPageSize {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	c.prune(time.Now().Add(-window))
+	c.loaded = true
+	total := len(c.releases)
+	c.mu.Unlock()
+	log.WithFields(logrus.Fields{"read": read, "releases": total}).Debug("Polled the module index")
+	return nil
+}
+
+// add records r unless it was already read. c.mu must be held.
+func (c *releaseCatalog) add(r moduleRelease) {
+	key := r.Module + "@" + r.Version
+	switch {
+	case r.Timestamp.Before(c.since), r.Timestamp.Equal(c.since) && c.atSince[key]:
+		return
+	case r.Timestamp.After(c.since):
+		c.since, c.atSince = r.Timestamp, make(map[string]bool)
+	}
+	c.atSince[key] = true
+	c.releases = append(c.releases, r)
+	c.versions[r.Module] = append(c.versions[r.Module], r.Version)
+}
+
+// prune drops the releases published before cutoff, and the oldest beyond
+// maxCatalogReleases. c.mu must be held.
+func (c *releaseCatalog) prune(cutoff time.Time) {
+	n, _ := slices.BinarySearchFunc(c.releases, cutoff, func(r moduleRelease, t time.Time) int {
+		return r.Timestamp.Compare(t)
+	})
+	n = max(n, len(c.releases)-maxCatalogReleases)
+	if n == 0 {
+		return
+	}
+	for _, r := range c.releases[:n] {
+		if versions := c.versions[r.Module][1:]; len(versions) > 0 {
+			c.versions[r.Module] = versions
+		} else {
+			delete(c.versions, r.Module)
+		}
+	}
+	c.releases = slices.Clone(c.releases[n:])
+}
+
+// moduleVersions returns the versions of the module providing pkgPath in the
+// catalog, highest first
+func (c *releaseCatalog) moduleVersions(pkgPath string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for modPath := pkgPath; modPath != ""; {
+		if versions, ok := c.versions[modPath]; ok {
+			versions = slices.Clone(versions)
+			semver.Sort(versions)
+			slices.Reverse(versions)
+			return versions
+		}
+		i := strings.LastIndex(modPath, "/")
+		if i < 0 {
+			break
+		}
+		modPath = modPath[:i]
+	}
+	return nil
+}
+
+// fetchIndexPage reads the releases the index at base recorded since since
+func fetchIndexPage(ctx context.Context, base string, since time.Time) ([]moduleRelease, error) {
+	ctx, cancel := context.WithTimeout(ctx, indexTimeout)
+	defer cancel()
+	u := base + "/index?" + url.Values{"since": {since.UTC().Format(time.RFC3339Nano)}, "limit": {fmt.Sprint(indexPageSize)}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "godoc-mcp/"+getBuildInfo().Version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", u, resp.Status)
+	}
+	var page []moduleRelease
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		var entry struct {
+			Path      string
+			Version   string
+			Timestamp time.Time
+		}
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(sc.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid index entry: %v", err)
+		}
+		page = append(page, moduleRelease{Module: entry.Path, Version: entry.Version, Timestamp: entry.Timestamp})
+	}
+	return page, sc.Err()
+}
+
+// pollModuleIndex keeps the release catalog up to date while polling is
+// configured, until ctx is done. The configuration is read before every
+// poll, so reloads take effect without a restart.
+func (s *GodocServer) pollModuleIndex(ctx context.Context) {
+	log := s.logger.WithField("component", "module-index")
+	for {
+		cfg := s.config.Load().ModuleIndex
+		wait := time.Minute
+		if cfg.Poll > 0 {
+			if err := s.releases.poll(ctx, log.WithField("index", cfg.URL), cfg.URL, cfg.Window); err != nil && ctx.Err() == nil {
+				log.WithError(err).Warn("Failed to poll the module index")
+			}
+			wait = cfg.Poll
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// handleRecentReleases implements the recent_releases tool
+func (s *GodocServer) handleRecentReleases(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	limit := request.GetInt("limit", defaultReleaseResults)
+	if limit < 1 || limit > maxReleaseResults {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("limit must be between 1 and %d, got %d", maxReleaseResults, limit)), nil
+	}
+	var cutoff time.Time
+	if since := request.GetString("since", ""); since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil || d <= 0 {
+			return errorResult(codeInvalidArgument, fmt.Sprintf("since must be a positive duration such as '6h', got %q", since)), nil
+		}
+		cutoff = time.Now().Add(-d)
+	}
+	prefix := request.GetString("prefix", "")
+	modPath := request.GetString("module", "")
+	prerelease := request.GetBool("prerelease", false)
+
+	c := s.releases
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.loaded {
+		return errorResult(codeServerBusy, "the release catalog isn't ready: the module index is still being read; try again shortly"), nil
+	}
+	out := &releasesOutput{Releases: make([]moduleRelease, 0)}
+	if len(c.releases) > 0 {
+		out.CatalogStart, out.CatalogEnd = &c.releases[0].Timestamp, &c.releases[len(c.releases)-1].Timestamp
+	}
+	seen := make(map[string]bool)
+	for _, r := range slices.Backward(c.releases) {
+		if r.Timestamp.Before(cutoff) || len(out.Releases) == limit {
+			break
+		}
+		switch {
+		case modPath != "" && r.Module != modPath,
+			!strings.HasPrefix(r.Module, prefix),
+			!prerelease && (semver.Prerelease(r.Version) != "" || module.IsPseudoVersion(r.Version)),
+			modPath == "" && seen[r.Module]:
+			continue
+		}
+		seen[r.Module] = true
+		out.Releases = append(out.Releases, r)
+	}
+
+	var sb strings.Builder
+	if len(out.Releases) == 0 {
+		sb.WriteString("No matching releases in the catalog.")
+	} else {
+		fmt.Fprintf(&sb, "%d releases, newest first:\n", len(out.Releases))
+		for _, r := range out.Releases {
+			fmt.Fprintf(&sb, "\n%s %s (%s)", r.Module, r.Version, r.Timestamp.UTC().Format(time.DateTime))
+		}
+	}
+	if out.CatalogStart != nil {
+		fmt.Fprintf(&sb, "\n\nThe catalog covers releases from %s to %s UTC.", out.CatalogStart.UTC().Format(time.DateTime), out.CatalogEnd.UTC().Format(time.DateTime))
+	}
+	result := mcp.NewToolResultText(strings.TrimLeft(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecentReleases(t *testing.T) {
+	now := time.Now().UTC()
+	published := []moduleRelease{
+		{"example.com/a", "v1.0.0", now.Add(-5 * time.Hour)},
+		{"example.com/a", "v1.1.0", now.Add(-3 * time.Hour)},
+		{"example.com/b", "v0.1.0", now.Add(-2 * time.Hour)},
+		{"example.com/a", "v1.2.0-rc.1", now.Add(-time.Hour)},
+		{"other.org/c", "v0.0.0-20240101000000-abcdefabcdef", now.Add(-30 * time.Minute)},
+	}
+	var mu sync.Mutex
+	index := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since, err := time.Parse(time.RFC3339Nano, r.URL.Query().Get("since"))
+		if r.URL.Path != "/index" || err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		// The index lists the versions published at or after since
+		enc := json.NewEncoder(w)
+		for _, r := range published {
+			if !r.Timestamp.Before(since) {
+				enc.Encode(map[string]any{"Path": r.Module, "Version": r.Version, "Timestamp": r.Timestamp})
+			}
+		}
+	}))
+	defer index.Close()
+	s := newTestServer(t, "-module-index-url", index.URL)
+	ctx := context.Background()
+	poll := func() {
+		t.Helper()
+		if err := s.releases.poll(ctx, s.logger.WithField("index", index.URL), index.URL, 24*time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := resultErrorCode(callTool(t, ctx, s.handleRecentReleases, "recent_releases", nil)); got != codeServerBusy {
+		t.Errorf("recent_releases before the first poll: error code %q, want %q", got, codeServerBusy)
+	}
+	poll()
+	mu.Lock()
+	published = append(published, moduleRelease{"example.com/b", "v0.2.0", now.Add(-10 * time.Minute)})
+	mu.Unlock()
+	// Polling again reads the new release once, though the index repeats the last
+	poll()
+	poll()
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		// want is the releases listed, as module@version
+		want []string
+	}{
+		{"bad limit", map[string]any{"limit": 0}, codeInvalidArgument, nil},
+		{"bad since", map[string]any{"since": "yesterday"}, codeInvalidArgument, nil},
+		{"negative since", map[string]any{"since": "-1h"}, codeInvalidArgument, nil},
+		{"newest of each module", nil, "", []string{"example.com/b@v0.2.0", "example.com/a@v1.1.0"}},
+		{"prereleases", map[string]any{"prerelease": true}, "", []string{"example.com/b@v0.2.0", "other.org/c@v0.0.0-20240101000000-abcdefabcdef", "example.com/a@v1.2.0-rc.1"}},
+		{"module", map[string]any{"module": "example.com/a"}, "", []string{"example.com/a@v1.1.0", "example.com/a@v1.0.0"}},
+		{"prefix", map[string]any{"prefix": "other.org/", "prerelease": true}, "", []string{"other.org/c@v0.0.0-20240101000000-abcdefabcdef"}},
+		{"since", map[string]any{"since": "4h", "module": "example.com/a"}, "", []string{"example.com/a@v1.1.0"}},
+		{"limit", map[string]any{"limit": 1}, "", []string{"example.com/b@v0.2.0"}},
+		{"none", map[string]any{"prefix": "nowhere.net/"}, "", nil},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleRecentReleases, "recent_releases", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*releasesOutput)
+		var got []string
+		for _, r := range out.Releases {
+			got = append(got, r.Module+"@"+r.Version)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: releases %q, want %q", tt.name, got, tt.want)
+		}
+		if out.CatalogStart == nil || !out.CatalogStart.Equal(published[0].Timestamp) || !out.CatalogEnd.Equal(published[len(published)-1].Timestamp) {
+			t.Errorf("%s: catalog covers %v to %v", tt.name, out.CatalogStart, out.CatalogEnd)
+		}
+	}
+	if got := s.releases.moduleVersions("example.com/a/sub"); !slices.Equal(got, []string{"v1.2.0-rc.1", "v1.1.0", "v1.0.0"}) {
+		t.Errorf("moduleVersions(example.com/a/sub) = %q", got)
+	}
+}
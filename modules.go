@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// maxListedModules caps the modules list_modules reports
+const maxListedModules = 500
+
+const listModulesDescription = `List the Go modules of a repository containing several go.mod files, such as a monorepo, with
+each module's path, directory, latest tagged version, and the repository's other modules it requires.
+Use it to find the module a package belongs to, then pass that module's directory as working_dir to
+get_doc and the other tools.`
+
+// listModulesSchema is the list_modules input schema
+var listModulesSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"root": map[string]any{
+			"type":        "string",
+			"description": "Optional: Repository directory to search for go.mod files. Defaults to the session working directory, then the first client root.",
+		},
+	},
+}
+
+// listModulesOutputSchema is the outputSchema of list_modules, describing listModulesOutput
+var listModulesOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"root":    map[string]any{"type": "string", "description": "The directory searched"},
+		"go_work": map[string]any{"type": "boolean", "description": "Whether root has a go.work file"},
+		"modules": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path":       map[string]any{"type": "string", "description": "Module path"},
+					"dir":        map[string]any{"type": "string", "description": "Module directory relative to root, such as '.' or './tools'; pass root joined with it as working_dir"},
+					"version":    map[string]any{"type": "string", "description": "Highest semantic version tagged for the module in the repository, if any"},
+					"go_version": map[string]any{"type": "string", "description": "The go directive of its go.mod"},
+					"workspace":  map[string]any{"type": "boolean", "description": "Whether root's go.work uses the module"},
+					"requires": map[string]any{
+						"type":        "array",
+						"items":       map[string]any{"type": "string"},
+						"description": "Other modules of the repository it requires, as path@version",
+					},
+				},
+				"required": []string{"path", "dir"},
+			},
+		},
+		"truncated": map[string]any{"type": "boolean", "description": "Whether modules stops at the first " + fmt.Sprint(maxListedModules)},
+	},
+	Required: []string{"root", "go_work", "modules"},
+}
+
+// listModulesOutput is the structured content of a list_modules result
+type listModulesOutput struct {
+	Root      string       `json:"root"`
+	GoWork    bool         `json:"go_work"`
+	Modules   []repoModule `json:"modules"`
+	Truncated bool         `json:"truncated,omitempty"`
+}
+
+// repoModule is a module found in a repository
+type repoModule struct {
+	Path      string   `json:"path"`
+	Dir       string   `json:"dir"`
+	Version   string   `json:"version,omitempty"`
+	GoVersion string   `json:"go_version,omitempty"`
+	Workspace bool     `json:"workspace,omitempty"`
+	Requires  []string `json:"requires,omitempty"`
+
+	// requirements are the module's requirements, with versions
+	requirements []string
+}
+
+// handleListModules implements the list_modules tool
+func (s *GodocServer) handleListModules(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	root := request.GetString("root", s.sessions.get(ctx).workingDir)
+	if root == "" {
+		if roots := s.clientRoots(ctx); len(roots) > 0 {
+			root = roots[0]
+		}
+	}
+	if root == "" {
+		return errorResult(codeInvalidArgument, "no repository to search: pass root, or set working_dir with set_session_defaults"), nil
+	}
+	if !filepath.IsAbs(root) {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("root %q must be an absolute path", root)), nil
+	}
+	if err := checkWorkingDir(root); err != nil {
+		return errorResultFromErr("invalid root", err), nil
+	}
+	root = filepath.Clean(root)
+
+	out := &listModulesOutput{Root: root, Modules: []repoModule{}}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if p != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return ctx.Err()
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+		if len(out.Modules) == maxListedModules {
+			out.Truncated = true
+			return filepath.SkipAll
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		f, err := modfile.ParseLax(p, data, nil)
+		if err != nil || f.Module == nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, filepath.Dir(p))
+		m := repoModule{Path: f.Module.Mod.Path, Dir: "./" + filepath.ToSlash(rel)}
+		if rel == "." {
+			m.Dir = "."
+		}
+		if f.Go != nil {
+			m.GoVersion = f.Go.Version
+		}
+		for _, req := range f.Require {
+			m.requirements = append(m.requirements, req.Mod.Path+"@"+req.Mod.Version)
+		}
+		out.Modules = append(out.Modules, m)
+		return nil
+	})
+	if err != nil {
+		return errorResultFromErr("failed to search "+root, err), nil
+	}
+
+	// Requirements are reported among the repository's own modules
+	for i := range out.Modules {
+		for _, req := range out.Modules[i].requirements {
+			modPath, _, _ := strings.Cut(req, "@")
+			if slices.ContainsFunc(out.Modules, func(m repoModule) bool { return m.Path == modPath }) {
+				out.Modules[i].Requires = append(out.Modules[i].Requires, req)
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "go.work")); err == nil {
+		out.GoWork = true
+		if wf, err := modfile.ParseWork("go.work", data, nil); err == nil {
+			for _, use := range wf.Use {
+				dir := path.Clean(filepath.ToSlash(use.Path))
+				for i := range out.Modules {
+					if path.Clean(out.Modules[i].Dir) == dir {
+						out.Modules[i].Workspace = true
+					}
+				}
+			}
+		}
+	}
+	if top, tags := gitTags(root); top != "" {
+		for i := range out.Modules {
+			dir, err := filepath.Rel(top, filepath.Join(root, out.Modules[i].Dir))
+			if err == nil {
+				out.Modules[i].Version = moduleTag(tags, filepath.ToSlash(dir))
+			}
+		}
+	}
+	slices.SortFunc(out.Modules, func(a, b repoModule) int { return cmp.Compare(a.Dir, b.Dir) })
+
+	var sb strings.Builder
+	switch len(out.Modules) {
+	case 0:
+		fmt.Fprintf(&sb, "No go.mod files found in %s", root)
+	case 1:
+		fmt.Fprintf(&sb, "1 module in %s", root)
+	default:
+		fmt.Fprintf(&sb, "%d modules in %s", len(out.Modules), root)
+	}
+	if out.Truncated {
+		fmt.Fprintf(&sb, " (only the first %d are listed)", maxListedModules)
+	}
+	sb.WriteString("\n")
+	for _, m := range out.Modules {
+		notes := []string{cmp.Or(m.Version, "untagged")}
+		if m.GoVersion != "" {
+			notes = append(notes, "go "+m.GoVersion)
+		}
+		if m.Workspace {
+			notes = append(notes, "in go.work")
+		}
+		fmt.Fprintf(&sb, "\n%s: %s (%s)", m.Dir, m.Path, strings.Join(notes, ", "))
+		if len(m.Requires) > 0 {
+			fmt.Fprintf(&sb, "\n  requires %s", strings.Join(m.Requires, ", "))
+		}
+	}
+	if len(out.Modules) > 0 {
+		sb.WriteString("\n\nPass a module's directory as working_dir to document its packages with relative paths.")
+	}
+	result := mcp.NewToolResultText(sb.String())
+	result.StructuredContent = out
+	return result, nil
+}
+
+// gitTags returns the top-level directory of the git repository containing
+// dir and its tags, read from the repository's refs without running git. It
+// returns an empty directory outside a repository.
+func gitTags(dir string) (string, []string) {
+	top := dir
+	for {
+		if info, err := os.Stat(filepath.Join(top, ".git")); err == nil && info.IsDir() {
+			break
+		}
+		parent := filepath.Dir(top)
+		if parent == top {
+			return "", nil
+		}
+		top = parent
+	}
+	gitDir := filepath.Join(top, ".git")
+	var tags []string
+	refs := filepath.Join(gitDir, "refs", "tags")
+	filepath.WalkDir(refs, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if rel, err := filepath.Rel(refs, p); err == nil {
+				tags = append(tags, filepath.ToSlash(rel))
+			}
+		}
+		return nil
+	})
+	if f, err := os.Open(filepath.Join(gitDir, "packed-refs")); err == nil {
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			_, ref, ok := strings.Cut(sc.Text(), " ")
+			if tag, found := strings.CutPrefix(ref, "refs/tags/"); ok && found {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return top, tags
+}
+
+// moduleTag returns the highest semantic version among tags for the module
+// in dir, relative to the repository root, whose tags are prefixed with dir
+// unless it is the root module
+func moduleTag(tags []string, dir string) string {
+	prefix := dir + "/"
+	if dir == "." {
+		prefix = ""
+	}
+	var best string
+	for _, tag := range tags {
+		v, ok := strings.CutPrefix(tag, prefix)
+		if !ok || !semver.IsValid(v) || semver.Canonical(v) != v {
+			continue
+		}
+		if best == "" || semver.Compare(v, best) > 0 {
+			best = v
+		}
+	}
+	return best
+}
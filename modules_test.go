@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListModules(t *testing.T) {
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod":          "module example.com/repo\n\ngo 1.21\n\nrequire (\n\texample.com/repo/tools v0.2.0\n\tgolang.org/x/mod v0.1.0\n)\n",
+		"tools/go.mod":    "module example.com/repo/tools\n\ngo 1.22\n",
+		"api/go.mod":      "module example.com/repo/api\n\nrequire example.com/repo v1.0.0\n",
+		"broken/go.mod":   "this is not a go.mod file\n",
+		"testdata/go.mod": "module example.com/testdata\n",
+		"vendor/go.mod":   "module example.com/vendored\n",
+		".hidden/go.mod":  "module example.com/hidden\n",
+		"_skip/go.mod":    "module example.com/skip\n",
+		"go.work":         "go 1.22\n\nuse (\n\t.\n\t./tools\n)\n",
+		// Tags of the root module and the tools module, loose and packed;
+		// tags that aren't canonical semantic versions are ignored
+		".git/refs/tags/v1.0.0":       "",
+		".git/refs/tags/v1.2.0":       "",
+		".git/refs/tags/v2":           "",
+		".git/refs/tags/tools/v0.2.0": "",
+		".git/packed-refs":            "# pack-refs with: peeled\nabc123 refs/tags/v1.1.0\nabc123 refs/tags/tools/v0.10.0\n",
+	})
+	empty := t.TempDir()
+	session := sessionContext("a")
+	callTool(t, session, s.handleSetSessionDefaults, "set_session_defaults", map[string]any{"working_dir": dir})
+
+	repo := []repoModule{
+		{Path: "example.com/repo", Dir: ".", Version: "v1.2.0", GoVersion: "1.21", Workspace: true, Requires: []string{"example.com/repo/tools@v0.2.0"}},
+		{Path: "example.com/repo/api", Dir: "./api", Requires: []string{"example.com/repo@v1.0.0"}},
+		{Path: "example.com/repo/tools", Dir: "./tools", Version: "v0.10.0", GoVersion: "1.22", Workspace: true},
+	}
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		args     map[string]any
+		wantCode string
+		root     string
+		goWork   bool
+		want     []repoModule
+	}{
+		{"no root", context.Background(), nil, codeInvalidArgument, "", false, nil},
+		{"relative root", context.Background(), map[string]any{"root": "repo"}, codeInvalidArgument, "", false, nil},
+		{"missing root", context.Background(), map[string]any{"root": filepath.Join(dir, "none")}, codeInvalidWorkingDir, "", false, nil},
+		{"repository", context.Background(), map[string]any{"root": dir}, "", dir, true, repo},
+		{"session working dir", session, nil, "", dir, true, repo},
+		{"module below the repository", context.Background(), map[string]any{"root": filepath.Join(dir, "tools")}, "", filepath.Join(dir, "tools"), false, []repoModule{
+			{Path: "example.com/repo/tools", Dir: ".", Version: "v0.10.0", GoVersion: "1.22"},
+		}},
+		{"no modules", context.Background(), map[string]any{"root": empty}, "", empty, false, []repoModule{}},
+	}
+	for _, tt := range tests {
+		result := callTool(t, tt.ctx, s.handleListModules, "list_modules", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*listModulesOutput)
+		for i := range out.Modules {
+			out.Modules[i].requirements = nil
+		}
+		if out.Root != tt.root || out.GoWork != tt.goWork || !reflect.DeepEqual(out.Modules, tt.want) {
+			t.Errorf("%s: %s (go.work %v) has modules\n%+v\nwant %s (go.work %v) with\n%+v", tt.name, out.Root, out.GoWork, out.Modules, tt.root, tt.goWork, tt.want)
+		}
+	}
+}
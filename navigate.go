@@ -0,0 +1,457 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const findDefinitionDescription = `Find where the identifier at a position in a Go source file is defined.
+Asks gopls, so the answer is exact for anything the file can refer to: local variables, unexported
+helpers, methods of embedded types, and symbols of other packages. Pass the file, and the 1-based
+line and column of the identifier as an editor or compiler error reports them. Returns each
+definition's file:line:column and its source line.`
+
+const hoverSymbolDescription = `Get the documentation and type of the identifier at a position in a Go source file.
+Asks gopls, so it documents what go doc can't, such as unexported helpers, local variables and
+their inferred types, and fields of anonymous structs. Pass the file, and the 1-based line and
+column of the identifier.`
+
+const signatureHelpDescription = `Get the signature and documentation of the function being called at a position in a Go source file.
+Asks gopls for the call enclosing the position, typically just after '(' or a ',' in its
+arguments, and reports which parameter the position is at. Pass the file, and the 1-based line and
+column.`
+
+// goplsQueryTimeout bounds a code navigation query, including the first load
+// of a module by a newly started gopls
+const goplsQueryTimeout = 2 * time.Minute
+
+// newPositionSchema returns the input schema of a code navigation tool
+func newPositionSchema(column string) mcp.ToolInputSchema {
+	return mcp.ToolInputSchema{
+		Type: "object",
+		Properties: map[string]any{
+			"file": map[string]any{
+				"type":        "string",
+				"description": "Go source file, absolute or relative to working_dir. It must belong to a Go module.",
+			},
+			"line": map[string]any{
+				"type":        "integer",
+				"description": "1-based line number.",
+				"minimum":     1,
+			},
+			"column": map[string]any{
+				"type":        "integer",
+				"description": column,
+				"minimum":     1,
+			},
+			"working_dir": map[string]any{
+				"type":        "string",
+				"description": "Optional: Directory relative file paths are resolved against. Defaults to the session working directory.",
+			},
+		},
+		Required: []string{"file", "line", "column"},
+	}
+}
+
+// sourcePosition is a position in a source file as reported to clients
+type sourcePosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// positionSchema describes sourcePosition in output schemas
+var positionSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"file":   map[string]any{"type": "string", "description": "Absolute path of the file"},
+		"line":   map[string]any{"type": "integer", "description": "1-based line"},
+		"column": map[string]any{"type": "integer", "description": "1-based byte column"},
+	},
+	"required": []string{"file", "line", "column"},
+}
+
+// definitionOutputSchema is the outputSchema of find_definition, describing definitionOutput
+var definitionOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"definitions": map[string]any{
+			"type":        "array",
+			"description": "Where the identifier is defined",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"file":   map[string]any{"type": "string", "description": "Absolute path of the file"},
+					"line":   map[string]any{"type": "integer", "description": "1-based line"},
+					"column": map[string]any{"type": "integer", "description": "1-based byte column"},
+					"text":   map[string]any{"type": "string", "description": "The source line of the definition"},
+				},
+				"required": []string{"file", "line", "column"},
+			},
+		},
+	},
+	Required: []string{"definitions"},
+}
+
+// definitionOutput is the structured content of a find_definition result
+type definitionOutput struct {
+	Definitions []definition `json:"definitions"`
+}
+
+// definition is where an identifier is defined
+type definition struct {
+	sourcePosition
+	Text string `json:"text,omitempty"`
+}
+
+// hoverOutputSchema is the outputSchema of hover_symbol, describing hoverOutput
+var hoverOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"contents": map[string]any{"type": "string", "description": "Declaration and documentation of the identifier"},
+		"start":    positionSchema,
+		"end":      positionSchema,
+	},
+	Required: []string{"contents"},
+}
+
+// hoverOutput is the structured content of a hover_symbol result
+type hoverOutput struct {
+	Contents string          `json:"contents"`
+	Start    *sourcePosition `json:"start,omitempty"`
+	End      *sourcePosition `json:"end,omitempty"`
+}
+
+// signatureOutputSchema is the outputSchema of signature_help, describing signatureOutput
+var signatureOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"signatures": map[string]any{
+			"type":        "array",
+			"description": "Signatures of the function called, the active one first",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"label":            map[string]any{"type": "string", "description": "The signature"},
+					"documentation":    map[string]any{"type": "string", "description": "Documentation of the function"},
+					"parameters":       map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Parameters, as written in the signature"},
+					"active_parameter": map[string]any{"type": "integer", "description": "0-based index of the parameter at the position"},
+				},
+				"required": []string{"label"},
+			},
+		},
+	},
+	Required: []string{"signatures"},
+}
+
+// signatureOutput is the structured content of a signature_help result
+type signatureOutput struct {
+	Signatures []signature `json:"signatures"`
+}
+
+// signature is a signature of a called function
+type signature struct {
+	Label           string   `json:"label"`
+	Documentation   string   `json:"documentation,omitempty"`
+	Parameters      []string `json:"parameters,omitempty"`
+	ActiveParameter *int     `json:"active_parameter,omitempty"`
+}
+
+// textPosition is a gopls query resolved from a code navigation request
+type textPosition struct {
+	client *goplsClient
+	file   string
+	params map[string]any
+}
+
+// resolvePosition resolves the file, line, and column of request to an LSP
+// position, and returns the gopls instance of the file's module
+func (s *GodocServer) resolvePosition(ctx context.Context, request mcp.CallToolRequest) (textPosition, error) {
+	file := request.GetString("file", "")
+	if file == "" {
+		return textPosition{}, withCode(codeInvalidArgument, errors.New("invalid or missing file parameter"))
+	}
+	if !filepath.IsAbs(file) {
+		workingDir := request.GetString("working_dir", s.sessions.get(ctx).workingDir)
+		if workingDir == "" {
+			return textPosition{}, withCode(codeInvalidArgument, errors.New("working_dir is required for relative file paths"))
+		}
+		file = filepath.Join(workingDir, file)
+	}
+	if filepath.Ext(file) != ".go" {
+		return textPosition{}, withCode(codeInvalidArgument, fmt.Errorf("%s is not a Go source file", file))
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return textPosition{}, withCode(codeInvalidArgument, err)
+	}
+	lines := strings.Split(string(data), "\n")
+	line, column := request.GetInt("line", 0), request.GetInt("column", 0)
+	if line < 1 || line > len(lines) {
+		return textPosition{}, withCode(codeInvalidArgument, fmt.Errorf("line must be between 1 and %d, got %d", len(lines), line))
+	}
+	text := strings.TrimSuffix(lines[line-1], "\r")
+	if column < 1 || column > len(text)+1 {
+		return textPosition{}, withCode(codeInvalidArgument, fmt.Errorf("column must be between 1 and %d on line %d, got %d", len(text)+1, line, column))
+	}
+	root := moduleRoot(filepath.Dir(file))
+	if root == "" {
+		return textPosition{}, withCode(codeInvalidArgument, fmt.Errorf("%s is not in a Go module", file))
+	}
+	c, err := s.gopls.forWorkspace(s.config.Load().GoplsPath, root)
+	if err != nil {
+		return textPosition{}, fmt.Errorf("failed to start gopls for %s: %w", root, err)
+	}
+	// LSP columns count UTF-16 code units
+	character := len(utf16.Encode([]rune(text[:column-1])))
+	return textPosition{
+		client: c,
+		file:   file,
+		params: map[string]any{
+			"textDocument": map[string]any{"uri": fileURI(file)},
+			"position":     lspPosition{Line: line - 1, Character: character},
+		},
+	}, nil
+}
+
+// handleFindDefinition implements the find_definition tool
+func (s *GodocServer) handleFindDefinition(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pos, err := s.resolvePosition(ctx, request)
+	if err != nil {
+		return errorResultFromErr("invalid position", err), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, goplsQueryTimeout)
+	defer cancel()
+	var raw json.RawMessage
+	if err := pos.client.call(ctx, "textDocument/definition", pos.params, &raw); err != nil {
+		return errorResultFromErr("gopls definition failed", err), nil
+	}
+	// The result is a location, a list of them, or null
+	var locs []lspLocation
+	if err := json.Unmarshal(raw, &locs); err != nil {
+		var loc lspLocation
+		if json.Unmarshal(raw, &loc) == nil && loc.URI != "" {
+			locs = append(locs, loc)
+		}
+	}
+
+	out := &definitionOutput{Definitions: make([]definition, 0, len(locs))}
+	var sb strings.Builder
+	for _, loc := range locs {
+		file := uriPath(loc.URI)
+		def := definition{sourcePosition: toSourcePosition(file, loc.Range.Start)}
+		def.Text = sourceLine(file, loc.Range.Start.Line)
+		out.Definitions = append(out.Definitions, def)
+		fmt.Fprintf(&sb, "%s:%d:%d\n", def.File, def.Line, def.Column)
+		if def.Text != "" {
+			fmt.Fprintf(&sb, "\t%s\n", strings.TrimSpace(def.Text))
+		}
+	}
+	if len(out.Definitions) == 0 {
+		sb.WriteString("No definition found; the position may not be on an identifier.")
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// handleHoverSymbol implements the hover_symbol tool
+func (s *GodocServer) handleHoverSymbol(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pos, err := s.resolvePosition(ctx, request)
+	if err != nil {
+		return errorResultFromErr("invalid position", err), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, goplsQueryTimeout)
+	defer cancel()
+	var hover *struct {
+		Contents json.RawMessage `json:"contents"`
+		Range    *struct {
+			Start lspPosition `json:"start"`
+			End   lspPosition `json:"end"`
+		} `json:"range"`
+	}
+	if err := pos.client.call(ctx, "textDocument/hover", pos.params, &hover); err != nil {
+		return errorResultFromErr("gopls hover failed", err), nil
+	}
+	out := &hoverOutput{}
+	if hover != nil {
+		out.Contents = strings.TrimSpace(markupText(hover.Contents))
+		if hover.Range != nil {
+			start, end := toSourcePosition(pos.file, hover.Range.Start), toSourcePosition(pos.file, hover.Range.End)
+			out.Start, out.End = &start, &end
+		}
+	}
+	text := out.Contents
+	if text == "" {
+		text = "No information; the position may not be on an identifier."
+	}
+	result := mcp.NewToolResultText(text)
+	result.StructuredContent = out
+	return result, nil
+}
+
+// handleSignatureHelp implements the signature_help tool
+func (s *GodocServer) handleSignatureHelp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pos, err := s.resolvePosition(ctx, request)
+	if err != nil {
+		return errorResultFromErr("invalid position", err), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, goplsQueryTimeout)
+	defer cancel()
+	var help *struct {
+		Signatures []struct {
+			Label         string          `json:"label"`
+			Documentation json.RawMessage `json:"documentation"`
+			Parameters    []struct {
+				Label json.RawMessage `json:"label"`
+			} `json:"parameters"`
+			ActiveParameter *int `json:"activeParameter"`
+		} `json:"signatures"`
+		ActiveSignature int  `json:"activeSignature"`
+		ActiveParameter *int `json:"activeParameter"`
+	}
+	if err := pos.client.call(ctx, "textDocument/signatureHelp", pos.params, &help); err != nil {
+		return errorResultFromErr("gopls signature help failed", err), nil
+	}
+
+	out := &signatureOutput{Signatures: make([]signature, 0)}
+	if help != nil {
+		for i, sig := range help.Signatures {
+			sg := signature{Label: sig.Label, Documentation: strings.TrimSpace(markupText(sig.Documentation))}
+			for _, p := range sig.Parameters {
+				sg.Parameters = append(sg.Parameters, parameterLabel(sig.Label, p.Label))
+			}
+			sg.ActiveParameter = sig.ActiveParameter
+			if sg.ActiveParameter == nil && i == help.ActiveSignature {
+				sg.ActiveParameter = help.ActiveParameter
+			}
+			if i == help.ActiveSignature {
+				out.Signatures = append([]signature{sg}, out.Signatures...)
+			} else {
+				out.Signatures = append(out.Signatures, sg)
+			}
+		}
+	}
+
+	var sb strings.Builder
+	for i, sg := range out.Signatures {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(sg.Label)
+		if p := sg.ActiveParameter; p != nil && *p >= 0 && *p < len(sg.Parameters) {
+			fmt.Fprintf(&sb, "\nAt parameter %d: %s", *p+1, sg.Parameters[*p])
+		}
+		if sg.Documentation != "" {
+			sb.WriteString("\n\n" + sg.Documentation)
+		}
+	}
+	if len(out.Signatures) == 0 {
+		sb.WriteString("No signature; the position may not be within the arguments of a call.")
+	}
+	result := mcp.NewToolResultText(sb.String())
+	result.StructuredContent = out
+	return result, nil
+}
+
+// moduleRoot returns the directory of the go.mod governing dir, or "" if there
+// is none
+func moduleRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// toSourcePosition converts an LSP position in file to a 1-based line and
+// byte column
+func toSourcePosition(file string, pos lspPosition) sourcePosition {
+	column := pos.Character + 1
+	if line := sourceLine(file, pos.Line); line != "" {
+		column = byteColumn(line, pos.Character)
+	}
+	return sourcePosition{File: file, Line: pos.Line + 1, Column: column}
+}
+
+// sourceLine returns the 0-based line of file, or "" if it can't be read
+func sourceLine(file string, line int) string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(data), "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return strings.TrimSuffix(lines[line], "\r")
+}
+
+// byteColumn returns the 1-based byte column of the character at UTF-16
+// offset character of line
+func byteColumn(line string, character int) int {
+	units := 0
+	for i, r := range line {
+		if units >= character {
+			return i + 1
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return len(line) + 1
+}
+
+// markupText returns the text of LSP documentation, which is a string, a
+// MarkupContent, or a list of marked strings
+func markupText(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var markup struct {
+		Value string `json:"value"`
+	}
+	if json.Unmarshal(raw, &markup) == nil && markup.Value != "" {
+		return markup.Value
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) == nil {
+		parts := make([]string, 0, len(list))
+		for _, item := range list {
+			parts = append(parts, markupText(item))
+		}
+		return strings.Join(parts, "\n\n")
+	}
+	return ""
+}
+
+// parameterLabel returns the text of a parameter label, which is either a
+// string or the UTF-16 offsets of the parameter within the signature label
+func parameterLabel(label string, raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var offsets [2]int
+	if json.Unmarshal(raw, &offsets) != nil {
+		return ""
+	}
+	units := utf16.Encode([]rune(label))
+	if offsets[0] < 0 || offsets[1] > len(units) || offsets[0] > offsets[1] {
+		return ""
+	}
+	return string(utf16.Decode(units[offsets[0]:offsets[1]]))
+}
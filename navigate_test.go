@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// fakeGoplsEnv makes the test binary serve as a stand-in for gopls, so the
+// code navigation tools are tested without it
+const fakeGoplsEnv = "GODOC_MCP_FAKE_GOPLS"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeGoplsEnv) != "" {
+		fakeGopls(os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeGopls answers LSP requests on r with fixed results about the calc.go of
+// navigationModule: a definition, hover, or signature on any line but the
+// first, where there is no identifier
+func fakeGopls(r io.Reader, w io.Writer) {
+	br := bufio.NewReader(r)
+	tp := textproto.NewReader(br)
+	for {
+		header, err := tp.ReadMIMEHeader()
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, n)
+		if _, err := io.ReadFull(br, body); err != nil {
+			return
+		}
+		var msg struct {
+			ID     *int64 `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				TextDocument struct {
+					URI string `json:"uri"`
+				} `json:"textDocument"`
+				Position lspPosition `json:"position"`
+			} `json:"params"`
+		}
+		json.Unmarshal(body, &msg)
+		if msg.Method == "exit" {
+			return
+		}
+		if msg.ID == nil {
+			continue
+		}
+		var result any
+		onIdentifier := msg.Params.Position.Line > 0
+		switch msg.Method {
+		case "initialize":
+			result = map[string]any{"capabilities": map[string]any{}}
+		case "workspace/symbol":
+			result = []any{}
+		case "textDocument/definition":
+			if onIdentifier {
+				// Add is declared on the third line of calc.go
+				result = []map[string]any{{
+					"uri":   msg.Params.TextDocument.URI,
+					"range": map[string]any{"start": lspPosition{Line: 2, Character: 5}, "end": lspPosition{Line: 2, Character: 8}},
+				}}
+			}
+		case "textDocument/hover":
+			if onIdentifier {
+				result = map[string]any{
+					"contents": map[string]any{"kind": "plaintext", "value": "func Add(a int, b int) int\n\nAdd adds a and b.\n"},
+					"range":    map[string]any{"start": msg.Params.Position, "end": lspPosition{Line: msg.Params.Position.Line, Character: msg.Params.Position.Character + 3}},
+				}
+			}
+		case "textDocument/signatureHelp":
+			if onIdentifier {
+				result = map[string]any{
+					"signatures": []map[string]any{
+						{"label": "Sub(a int, b int) int", "parameters": []any{map[string]any{"label": []int{4, 9}}, map[string]any{"label": []int{11, 16}}}},
+						{"label": "Add(a int, b int) int", "documentation": "Add adds a and b.", "parameters": []any{map[string]any{"label": []int{4, 9}}, map[string]any{"label": "b int"}}},
+					},
+					"activeSignature": 1,
+					"activeParameter": 1,
+				}
+			}
+		}
+		data, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": *msg.ID, "result": result})
+		fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	}
+}
+
+// navigationModule writes a module for the code navigation tools and returns
+// its directory
+func navigationModule(t *testing.T) string {
+	return writeModule(t, map[string]string{
+		"go.mod":    "module example.com/calc\n\ngo 1.21\n",
+		"calc.go":   "package calc\n\nfunc Add(a int, b int) int { return a + b }\n\nvar three = Add(1, 2)\n",
+		"README.md": "calc\n",
+	})
+}
+
+// newNavigationServer returns a server running the fake gopls
+func newNavigationServer(t *testing.T) *GodocServer {
+	t.Setenv(fakeGoplsEnv, "1")
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newTestServer(t, "-gopls", exe)
+}
+
+func TestResolvePosition(t *testing.T) {
+	s := newNavigationServer(t)
+	dir := navigationModule(t)
+	outside := writeModule(t, map[string]string{"main.go": "package main\n"})
+	ctx := context.Background()
+	tools := []struct {
+		name    string
+		handler server.ToolHandlerFunc
+	}{
+		{"find_definition", s.handleFindDefinition},
+		{"hover_symbol", s.handleHoverSymbol},
+		{"signature_help", s.handleSignatureHelp},
+	}
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+	}{
+		{"no file", map[string]any{"line": 1, "column": 1}, codeInvalidArgument},
+		{"relative without working dir", map[string]any{"file": "calc.go", "line": 1, "column": 1}, codeInvalidArgument},
+		{"not go", map[string]any{"file": "README.md", "working_dir": dir, "line": 1, "column": 1}, codeInvalidArgument},
+		{"missing file", map[string]any{"file": "none.go", "working_dir": dir, "line": 1, "column": 1}, codeInvalidArgument},
+		{"line too small", map[string]any{"file": "calc.go", "working_dir": dir, "line": 0, "column": 1}, codeInvalidArgument},
+		{"line too large", map[string]any{"file": "calc.go", "working_dir": dir, "line": 7, "column": 1}, codeInvalidArgument},
+		{"column too large", map[string]any{"file": "calc.go", "working_dir": dir, "line": 1, "column": 14}, codeInvalidArgument},
+		{"outside a module", map[string]any{"file": filepath.Join(outside, "main.go"), "line": 1, "column": 1}, codeInvalidArgument},
+		{"end of line", map[string]any{"file": "calc.go", "working_dir": dir, "line": 1, "column": 13}, ""},
+		{"absolute", map[string]any{"file": filepath.Join(dir, "calc.go"), "line": 3, "column": 6}, ""},
+	}
+	for _, tt := range tests {
+		for _, tool := range tools {
+			result := callTool(t, ctx, tool.handler, tool.name, tt.args)
+			if got := resultErrorCode(result); got != tt.wantCode {
+				t.Errorf("%s %s: error code %q, want %q: %s", tool.name, tt.name, got, tt.wantCode, resultText(result))
+			}
+		}
+	}
+}
+
+func TestFindDefinition(t *testing.T) {
+	s := newNavigationServer(t)
+	dir := navigationModule(t)
+	tests := []struct {
+		line, column int
+		want         []definition
+	}{
+		{1, 1, []definition{}},
+		{5, 13, []definition{{sourcePosition: sourcePosition{File: filepath.Join(dir, "calc.go"), Line: 3, Column: 6}, Text: "func Add(a int, b int) int { return a + b }"}}},
+	}
+	for _, tt := range tests {
+		result := callTool(t, context.Background(), s.handleFindDefinition, "find_definition", map[string]any{"file": "calc.go", "working_dir": dir, "line": tt.line, "column": tt.column})
+		out, ok := result.StructuredContent.(*definitionOutput)
+		if !ok {
+			t.Fatalf("%d:%d: %s", tt.line, tt.column, resultText(result))
+		}
+		if !slices.Equal(out.Definitions, tt.want) {
+			t.Errorf("%d:%d: definitions %+v, want %+v", tt.line, tt.column, out.Definitions, tt.want)
+		}
+	}
+}
+
+func TestHoverSymbol(t *testing.T) {
+	s := newNavigationServer(t)
+	dir := navigationModule(t)
+	tests := []struct {
+		line, column int
+		want         string
+		start, end   int
+	}{
+		{1, 1, "No information", 0, 0},
+		{5, 13, "func Add(a int, b int) int\n\nAdd adds a and b.", 13, 16},
+	}
+	for _, tt := range tests {
+		result := callTool(t, context.Background(), s.handleHoverSymbol, "hover_symbol", map[string]any{"file": "calc.go", "working_dir": dir, "line": tt.line, "column": tt.column})
+		out, ok := result.StructuredContent.(*hoverOutput)
+		if !ok {
+			t.Fatalf("%d:%d: %s", tt.line, tt.column, resultText(result))
+		}
+		if text := resultText(result); !strings.HasPrefix(text, tt.want) {
+			t.Errorf("%d:%d: hover %q, want %q", tt.line, tt.column, text, tt.want)
+		}
+		if tt.start == 0 {
+			if out.Start != nil {
+				t.Errorf("%d:%d: hover has range %+v", tt.line, tt.column, out.Start)
+			}
+		} else if out.Start == nil || out.Start.Column != tt.start || out.End.Column != tt.end {
+			t.Errorf("%d:%d: hover range %+v to %+v, want columns %d to %d", tt.line, tt.column, out.Start, out.End, tt.start, tt.end)
+		}
+	}
+}
+
+func TestSignatureHelp(t *testing.T) {
+	s := newNavigationServer(t)
+	dir := navigationModule(t)
+	tests := []struct {
+		line, column int
+		labels       []string
+		parameters   []string
+		text         string
+	}{
+		{1, 1, nil, nil, "No signature"},
+		// The active signature comes first
+		{5, 20, []string{"Add(a int, b int) int", "Sub(a int, b int) int"}, []string{"a int", "b int"}, "Add(a int, b int) int\nAt parameter 2: b int\n\nAdd adds a and b."},
+	}
+	for _, tt := range tests {
+		result := callTool(t, context.Background(), s.handleSignatureHelp, "signature_help", map[string]any{"file": "calc.go", "working_dir": dir, "line": tt.line, "column": tt.column})
+		out, ok := result.StructuredContent.(*signatureOutput)
+		if !ok {
+			t.Fatalf("%d:%d: %s", tt.line, tt.column, resultText(result))
+		}
+		var labels []string
+		for _, sg := range out.Signatures {
+			labels = append(labels, sg.Label)
+		}
+		if !slices.Equal(labels, tt.labels) {
+			t.Errorf("%d:%d: signatures %q, want %q", tt.line, tt.column, labels, tt.labels)
+		}
+		if len(out.Signatures) > 0 && !slices.Equal(out.Signatures[0].Parameters, tt.parameters) {
+			t.Errorf("%d:%d: parameters %q, want %q", tt.line, tt.column, out.Signatures[0].Parameters, tt.parameters)
+		}
+		if text := resultText(result); !strings.HasPrefix(text, tt.text) {
+			t.Errorf("%d:%d: signature help %q, want %q", tt.line, tt.column, text, tt.text)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// What get_doc returns for documentation that takes more than one page
+const (
+	// oversizeOutline returns the package overview and an outline of its declarations
+	oversizeOutline = "outline"
+	// oversizePage returns the first page
+	oversizePage = "page"
+)
+
+// oversizeArgument is the input schema of the get_doc oversize argument
+var oversizeArgument = map[string]any{
+	"type":        "string",
+	"description": "Optional: What to return when the documentation takes more than one page and no page is requested. 'outline' returns the package overview and an outline of every declaration, with the target and page to fetch each one; 'page' returns page 1. Defaults to the server's setting.",
+	"enum":        []string{oversizeOutline, oversizePage},
+}
+
+// wantsOutline reports whether the get_doc request for doc, to be paged by
+// sizing, should be answered with an outline. Outlines replace the first page
+// of package documentation when no page was asked for.
+func (s *GodocServer) wantsOutline(request mcp.CallToolRequest, req docRequest, doc cachedDoc, sizing pageSizing) bool {
+	args := request.GetArguments()
+	if _, ok := args["page"]; ok {
+		return false
+	}
+	if _, ok := args["doc_id"]; ok {
+		return false
+	}
+	if req.target != "" || req.format != formatText || req.source != "" {
+		return false
+	}
+	if request.GetString("oversize", s.config.Load().Pagination.Oversize) != oversizeOutline {
+		return false
+	}
+	return sizing.validate(s.config.Load().Pagination) == nil && len(sizing.pageStarts(doc.lines)) > 1
+}
+
+// outline returns the overview of the package documented by doc and an outline
+// of its declarations, each with the get_doc target and page that show it. A
+// nil result means the declarations couldn't be extracted.
+func (s *GodocServer) outline(ctx context.Context, log *logrus.Entry, req docRequest, doc cachedDoc, sizing pageSizing) *mcp.CallToolResult {
+	pd := s.structuredDoc(ctx, req.cacheScope, req.workingDir, req.format, doc, req.cmdArgs)
+	if pd == nil {
+		return nil
+	}
+	starts := sizing.pageStarts(doc.lines)
+	lines := declarationLines(doc.lines)
+	entries := docEntries(pd)
+	for i, e := range entries {
+		if line, ok := lines[entryTarget(e)]; ok {
+			entries[i].Page = sort.SearchInts(starts, line+1)
+		}
+	}
+
+	var sb strings.Builder
+	name := req.path
+	if len(req.cmdFlags) > 0 {
+		name += " " + strings.Join(req.cmdFlags, " ")
+	}
+	size := fmt.Sprintf("up to %d lines", sizing.lines)
+	if sizing.tokens > 0 {
+		size = fmt.Sprintf("up to ~%d tokens", sizing.tokens)
+	}
+	fmt.Fprintf(&sb, "Outline of %s (%d lines in %d pages of %s; doc_id %s)\n", name, len(doc.lines), len(starts), size, doc.id)
+	fmt.Fprintf(&sb, "The documentation is too long for one page, so this is its overview and an outline of its declarations. "+
+		"Fetch a declaration with get_doc and the target listed for it, or read the documentation in full with page 1 to %d and doc_id %s.\n\n", len(starts), doc.id)
+
+	fmt.Fprintf(&sb, "package %s // import %q\n\n", pd.Name, pd.ImportPath)
+	if pd.Doc != "" {
+		sb.WriteString(strings.TrimRight(pd.Doc, "\n"))
+		sb.WriteString("\n\n")
+	}
+	writeOutline(&sb, entries)
+
+	log.WithFields(logrus.Fields{
+		"doc_id":  doc.id,
+		"pages":   len(starts),
+		"entries": len(entries),
+	}).Debug("Returning outline of oversized documentation")
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = &docOutput{
+		DocID:   doc.id,
+		Outline: true,
+		Entries: entries,
+		Pagination: pageInfo{
+			PageSize:       sizing.lines,
+			PageSizeTokens: sizing.tokens,
+			TotalPages:     len(starts),
+			TotalLines:     len(doc.lines),
+			HasMore:        true,
+		},
+	}
+	return result
+}
+
+// writeOutline writes entries, in the order docEntries lists them, as an
+// outline grouped like go doc -all output, with each type's declarations
+// indented under it
+func writeOutline(sb *strings.Builder, entries []docEntry) {
+	headings := map[string]string{"const": "CONSTANTS", "var": "VARIABLES", "func": "FUNCTIONS", "type": "TYPES"}
+	var section string
+	var inType bool
+	for _, e := range entries {
+		indent := "  "
+		switch {
+		case e.Kind == "type":
+			inType = true
+		case inType:
+			indent = "    "
+		}
+		if !inType || e.Kind == "type" {
+			if heading := headings[e.Kind]; heading != section {
+				if section != "" {
+					sb.WriteString("\n")
+				}
+				sb.WriteString(heading + "\n")
+				section = heading
+			}
+		}
+		fmt.Fprintf(sb, "%s%s %s", indent, e.Kind, entryTarget(e))
+		if e.Page > 0 {
+			fmt.Fprintf(sb, " (page %d)", e.Page)
+		}
+		if e.Synopsis != "" {
+			sb.WriteString(": " + e.Synopsis)
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// entryTarget returns the get_doc target documenting e
+func entryTarget(e docEntry) string {
+	if e.Kind == "method" {
+		return receiverType(e.Recv) + "." + e.Name
+	}
+	return e.Name
+}
+
+// receiverType returns the name of the type of a method receiver, without
+// pointer or type parameters
+func receiverType(recv string) string {
+	recv = strings.TrimPrefix(recv, "*")
+	if i := strings.IndexByte(recv, '['); i >= 0 {
+		recv = recv[:i]
+	}
+	return recv
+}
+
+// declarationLines returns the line of go doc text output lines on which each
+// declaration starts, by its get_doc target
+func declarationLines(lines []string) map[string]int {
+	found := make(map[string]int)
+	add := func(target string, i int) {
+		if _, ok := found[target]; !ok && target != "" {
+			found[target] = i
+		}
+	}
+	var group bool
+	for i, line := range lines {
+		switch {
+		case group:
+			// Names declared in a const or var group, one per line
+			if line == ")" {
+				group = false
+			} else if strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "\t\t") {
+				add(leadingIdent(strings.TrimPrefix(line, "\t")), i)
+			}
+		case line == "const (" || line == "var (":
+			group = true
+		case strings.HasPrefix(line, "const "), strings.HasPrefix(line, "var "):
+			_, rest, _ := strings.Cut(line, " ")
+			for _, name := range strings.Split(strings.SplitN(rest, " =", 2)[0], ",") {
+				add(leadingIdent(strings.TrimSpace(name)), i)
+			}
+		case strings.HasPrefix(line, "type "):
+			add(leadingIdent(strings.TrimPrefix(line, "type ")), i)
+		case strings.HasPrefix(line, "func ("):
+			recv, rest, ok := strings.Cut(strings.TrimPrefix(line, "func ("), ") ")
+			if ok {
+				fields := strings.Fields(recv)
+				add(receiverType(fields[len(fields)-1])+"."+leadingIdent(rest), i)
+			}
+		case strings.HasPrefix(line, "func "):
+			add(leadingIdent(strings.TrimPrefix(line, "func ")), i)
+		}
+	}
+	return found
+}
+
+// leadingIdent returns the identifier at the start of s
+func leadingIdent(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' })
+	if end < 0 {
+		return s
+	}
+	return s[:end]
+}
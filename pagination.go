@@ -0,0 +1,343 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// minPageSize is the smallest page size clients may request
+const minPageSize = 100
+
+// minPageTokens is the smallest token budget clients may request for a page
+const minPageTokens = 500
+
+// PaginationConfig holds the page size defaults and response limits for get_doc
+type PaginationConfig struct {
+	DefaultPageSize int
+	MaxPageSize     int
+	// MaxResponseBytes caps the size of a single page; zero means unlimited
+	MaxResponseBytes int
+	// Tokenizer names the tokenizers entry estimating page_size_tokens budgets
+	Tokenizer string
+	// Oversize is what get_doc returns by default for documentation longer
+	// than a page: oversizeOutline or oversizePage
+	Oversize string
+}
+
+// tokenizer estimates how many model tokens a line of documentation takes
+type tokenizer func(line string) int
+
+// tokenizers are the token estimates pages can be sized with, by name
+var tokenizers = map[string]tokenizer{
+	"bytes": estimateTokens,
+	"words": estimateWordTokens,
+}
+
+// estimateWordTokens approximates how many model tokens text takes by its
+// words and symbols: a token per short word, more for long identifiers, and
+// one per punctuation mark, which dominates dense code
+func estimateWordTokens(text string) int {
+	tokens, word := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			word++
+			continue
+		case unicode.IsSpace(r):
+		default:
+			tokens++
+		}
+		if word > 0 {
+			tokens += 1 + word/8
+			word = 0
+		}
+	}
+	if word > 0 {
+		tokens += 1 + word/8
+	}
+	return tokens
+}
+
+// validate reports pagination limits that cannot be honored
+func (p PaginationConfig) validate() error {
+	if p.MaxPageSize < minPageSize {
+		return fmt.Errorf("invalid max page size %d: must be at least %d", p.MaxPageSize, minPageSize)
+	}
+	if p.DefaultPageSize < minPageSize || p.DefaultPageSize > p.MaxPageSize {
+		return fmt.Errorf("invalid default page size %d: must be between %d and %d", p.DefaultPageSize, minPageSize, p.MaxPageSize)
+	}
+	if p.MaxResponseBytes < 0 {
+		return fmt.Errorf("invalid max response bytes %d: must not be negative", p.MaxResponseBytes)
+	}
+	if _, ok := tokenizers[p.Tokenizer]; !ok {
+		return fmt.Errorf("invalid tokenizer %q: must be bytes or words", p.Tokenizer)
+	}
+	if p.Oversize != oversizeOutline && p.Oversize != oversizePage {
+		return fmt.Errorf("invalid oversize mode %q: must be %s or %s", p.Oversize, oversizeOutline, oversizePage)
+	}
+	return nil
+}
+
+// pageSizeSchema describes a page_size argument bounded by the configured limits
+func (p PaginationConfig) pageSizeSchema(description string) map[string]any {
+	return map[string]any{
+		"type":        "integer",
+		"description": description,
+		"minimum":     minPageSize,
+		"maximum":     p.MaxPageSize,
+		"default":     p.DefaultPageSize,
+	}
+}
+
+// pageTokensSchema describes a page_size_tokens argument
+func (p PaginationConfig) pageTokensSchema(description string) map[string]any {
+	return map[string]any{
+		"type":        "integer",
+		"description": description,
+		"minimum":     minPageTokens,
+	}
+}
+
+// pageSizing is how the pages of a document are sized: up to lines lines
+// each, or when tokens is set, as many of up to lines lines as fit in an
+// estimated budget of tokens tokens
+type pageSizing struct {
+	lines  int
+	tokens int
+	count  tokenizer
+}
+
+// sizing returns the page sizing for pages of pageSize lines, or of pageTokens
+// tokens when it is set
+func (s *GodocServer) sizing(pageSize, pageTokens int) pageSizing {
+	z := pageSizing{lines: pageSize, tokens: pageTokens}
+	if pageTokens > 0 {
+		limits := s.config.Load().Pagination
+		z.lines, z.count = limits.MaxPageSize, tokenizers[limits.Tokenizer]
+	}
+	return z
+}
+
+// requestSizing returns the page sizing a get_doc request asks for: its
+// page_size_tokens or page_size argument, then the session defaults, then the
+// configured default page size
+func (s *GodocServer) requestSizing(ctx context.Context, request mcp.CallToolRequest) pageSizing {
+	args := request.GetArguments()
+	session := s.sessions.get(ctx)
+	_, hasTokens := args["page_size_tokens"]
+	_, hasSize := args["page_size"]
+	switch {
+	case hasTokens:
+		return s.sizing(0, request.GetInt("page_size_tokens", 0))
+	case hasSize:
+		return s.sizing(request.GetInt("page_size", 0), 0)
+	case session.pageTokens != 0:
+		return s.sizing(0, session.pageTokens)
+	}
+	return s.sizing(cmp.Or(session.pageSize, s.config.Load().Pagination.DefaultPageSize), 0)
+}
+
+// validate reports a page sizing clients may not request
+func (z pageSizing) validate(limits PaginationConfig) error {
+	if z.tokens != 0 {
+		if z.tokens < minPageTokens {
+			return fmt.Errorf("page_size_tokens must be at least %d, got %d", minPageTokens, z.tokens)
+		}
+		return nil
+	}
+	if z.lines < minPageSize || z.lines > limits.MaxPageSize {
+		return fmt.Errorf("page_size must be between %d and %d, got %d", minPageSize, limits.MaxPageSize, z.lines)
+	}
+	return nil
+}
+
+// pageEnd returns the end of the page of lines that starts at line start,
+// moved back to the declaration boundary before it where there is one
+func (z pageSizing) pageEnd(lines []string, start int) int {
+	end := min(start+z.lines, len(lines))
+	if z.tokens > 0 {
+		// Each page holds at least one line, however long
+		tokens := 0
+		for i := start; i < end; i++ {
+			tokens += z.count(lines[i])
+			if tokens > z.tokens && i > start {
+				end = i
+				break
+			}
+		}
+	}
+	return declarationEnd(lines, start, end)
+}
+
+// declarationEnd returns end, the end of a page of lines starting at start,
+// moved back to the last declaration boundary on the page, so no declaration's
+// signature and documentation are split across pages. Pages within a single
+// declaration longer than a page keep their end.
+func declarationEnd(lines []string, start, end int) int {
+	if end >= len(lines) {
+		return end
+	}
+	for b := end; b > start; b-- {
+		if declarationStart(lines, b) {
+			return b
+		}
+	}
+	return end
+}
+
+// declarationStart reports whether a declaration, or a section or paragraph
+// of text, starts at lines[i] of go doc output. In JSON documentation each
+// object, such as a func or type entry, starts one.
+func declarationStart(lines []string, i int) bool {
+	line := lines[i]
+	if strings.TrimSpace(line) == "{" {
+		return true
+	}
+	// Indented lines are documentation or the body of a declaration, and
+	// closing brackets end a declaration's body
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '}' || line[0] == ')' {
+		return false
+	}
+	// Section headings stay with the declarations that follow them
+	if i >= 2 && lines[i-1] == "" && isSectionHeading(lines[i-2]) {
+		return false
+	}
+	for _, keyword := range []string{"func ", "type ", "const ", "var "} {
+		if strings.HasPrefix(line, keyword) {
+			return true
+		}
+	}
+	return lines[i-1] == ""
+}
+
+// isSectionHeading reports whether line is a heading of go doc -all output,
+// such as FUNCTIONS or TYPES
+func isSectionHeading(line string) bool {
+	return line != "" && strings.IndexFunc(line, func(r rune) bool { return !unicode.IsUpper(r) }) < 0
+}
+
+// pageStarts returns the first line of each page of lines
+func (z pageSizing) pageStarts(lines []string) []int {
+	starts := []int{0}
+	for start := z.pageEnd(lines, 0); start < len(lines); start = z.pageEnd(lines, start) {
+		starts = append(starts, start)
+	}
+	return starts
+}
+
+// paginate returns the requested page of doc along with a pagination header,
+// with the page described in its structured content
+func (s *GodocServer) paginate(log *logrus.Entry, doc cachedDoc, page int, sizing pageSizing) *mcp.CallToolResult {
+	if page < 1 {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("page must be at least 1, got %d", page))
+	}
+	if err := sizing.validate(s.config.Load().Pagination); err != nil {
+		return errorResult(codeInvalidArgument, err.Error())
+	}
+
+	starts := sizing.pageStarts(doc.lines)
+	totalPages := len(starts)
+
+	if page <= totalPages {
+		return s.pageAt(log, doc, starts[page-1], sizing)
+	}
+
+	// Pages past the end, such as after the document shrank when its cache entry
+	// expired, fall back to the last page
+	log.WithFields(logrus.Fields{
+		"page":        page,
+		"total_pages": totalPages,
+	}).Debug("Requested page past the end, returning the last page")
+	result := s.pageAt(log, doc, starts[totalPages-1], sizing)
+	notice := fmt.Sprintf("Notice: page %d is past the end of this document (%d pages); showing page %d instead. "+
+		"If earlier pages had a doc_id other than %s, the documentation changed since they were read.\n",
+		page, totalPages, totalPages, doc.id)
+	result.Content[0] = mcp.NewTextContent(notice + mcp.GetTextFromContent(result.Content[0]))
+	result.StructuredContent.(*docOutput).Pagination.RequestedPage = page
+	return result
+}
+
+// pageAt returns the page of doc sized by sizing that starts at line start,
+// with a cursor for the page that follows it
+func (s *GodocServer) pageAt(log *logrus.Entry, doc cachedDoc, start int, sizing pageSizing) *mcp.CallToolResult {
+	limits := s.config.Load().Pagination
+	lines := doc.lines
+	totalLines := len(lines)
+	// Pages are numbered by where they fall among the document's pages from its start
+	starts := sizing.pageStarts(lines)
+	totalPages := len(starts)
+	page := sort.SearchInts(starts, start+1)
+	pageEnd := sizing.pageEnd(lines, start)
+	end := pageEnd
+
+	// Drop trailing lines that would push the page over the response size limit
+	var truncated bool
+	if limit := limits.MaxResponseBytes; limit > 0 {
+		size := 0
+		for i := start; i < end; i++ {
+			size += len(lines[i]) + 1
+			if size > limit && i > start {
+				end, truncated = declarationEnd(lines, start, i), true
+				break
+			}
+		}
+	}
+
+	// Join the lines for this page
+	pageContent := strings.Join(lines[start:end], "\n")
+
+	// Create pagination metadata
+	budget, sizeArg := "", "page_size"
+	if sizing.tokens > 0 {
+		budget = fmt.Sprintf(", up to ~%d tokens", sizing.tokens)
+		sizeArg = "page_size_tokens"
+	}
+	metadata := fmt.Sprintf("Page %d of %d (showing lines %d-%d of %d%s; doc_id %s)",
+		page, totalPages, start+1, end, totalLines, budget, doc.id)
+	if truncated {
+		metadata += fmt.Sprintf("\nPage truncated to the server's %d byte response limit; use a smaller %s to see lines %d-%d",
+			limits.MaxResponseBytes, sizeArg, end+1, pageEnd)
+	}
+	var next string
+	if end < totalLines {
+		next = encodeCursor(docCursor{DocID: doc.id, Line: end, PageSize: sizing.lines, PageTokens: sizing.tokens})
+		metadata += "\nNext page: pass cursor " + next
+	}
+
+	// Create the result with documentation and pagination info
+	log.WithFields(logrus.Fields{
+		"doc_id":      doc.id,
+		"page":        page,
+		"total_pages": totalPages,
+		"lines":       end - start,
+		"truncated":   truncated,
+	}).Debug("Returning paginated documentation")
+	result := mcp.NewToolResultText(metadata + "\n\n" + pageContent)
+	result.StructuredContent = &docOutput{
+		DocID: doc.id,
+		Pagination: pageInfo{
+			Page:           page,
+			PageSize:       sizing.lines,
+			PageSizeTokens: sizing.tokens,
+			TotalPages:     totalPages,
+			TotalLines:     totalLines,
+			FirstLine:      start + 1,
+			LastLine:       end,
+			HasMore:        end < totalLines,
+			Truncated:      truncated,
+			NextCursor:     next,
+		},
+	}
+	// Diagnostics stay out of the documentation, in the result's _meta
+	if doc.stderr != "" {
+		result.Meta = mcp.NewMetaFromMap(map[string]any{"stderr": doc.stderr})
+	}
+	return result
+}
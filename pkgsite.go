@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/mod/semver"
+	"golang.org/x/net/html"
+)
+
+const findPackagesDescription = `Find Go packages on pkg.go.dev for a free-text query.
+Searches the public Go package index, as pkg.go.dev's search box does, for queries such as
+"yaml parsing" or "jwt", and returns candidate packages with their synopsis, how many packages
+import them, and their latest version. Use it when you don't know which third-party package to
+use or document; read a candidate with get_doc and its import path. For packages of the standard
+library or the working module, use search_docs instead.`
+
+// Limits on find_packages requests
+const (
+	defaultFindResults = 10
+	maxFindResults     = 25
+	// findTimeout bounds a search request to pkg.go.dev
+	findTimeout = 15 * time.Second
+	// findCacheTTL is how long search results are reused for the same query
+	findCacheTTL = 10 * time.Minute
+)
+
+// findPackagesSchema is the find_packages input schema
+var findPackagesSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"query": map[string]any{
+			"type":        "string",
+			"description": "What the package should do, or part of its name (e.g., 'yaml parsing', 'jwt').",
+		},
+		"signals": signalsArgument,
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of packages to return.",
+			"minimum":     1,
+			"maximum":     maxFindResults,
+			"default":     defaultFindResults,
+		},
+	},
+	Required: []string{"query"},
+}
+
+// findOutputSchema is the outputSchema of find_packages, describing findOutput
+var findOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"query": map[string]any{"type": "string", "description": "The query searched for"},
+		"packages": map[string]any{
+			"type":        "array",
+			"description": "Matching packages in pkg.go.dev's order",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path":        map[string]any{"type": "string", "description": "Import path; pass it to get_doc as path"},
+					"synopsis":    map[string]any{"type": "string", "description": "First sentence of the package documentation"},
+					"imported_by": map[string]any{"type": "integer", "description": "Number of packages importing it"},
+					"version":     map[string]any{"type": "string", "description": "Latest version"},
+					"published":   map[string]any{"type": "string", "description": "When the latest version was published"},
+					"license":     map[string]any{"type": "string", "description": "License of the module"},
+					"signals":     signalsSchema,
+				},
+				"required": []string{"path"},
+			},
+		},
+	},
+	Required: []string{"query", "packages"},
+}
+
+// findOutput is the structured content of a find_packages result
+type findOutput struct {
+	Query    string         `json:"query"`
+	Packages []foundPackage `json:"packages"`
+}
+
+// foundPackage is a package found on pkg.go.dev
+type foundPackage struct {
+	Path       string `json:"path"`
+	Synopsis   string `json:"synopsis,omitempty"`
+	ImportedBy int    `json:"imported_by,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Published  string `json:"published,omitempty"`
+	License    string `json:"license,omitempty"`
+	// Signals are looked up on deps.dev when requested
+	Signals *packageSignals `json:"signals,omitempty"`
+}
+
+// newFindCache creates the cache of pkg.go.dev search results by query
+func newFindCache() *ttlcache.Cache[string, []foundPackage] {
+	cache := ttlcache.New[string, []foundPackage](
+		ttlcache.WithTTL[string, []foundPackage](findCacheTTL),
+		ttlcache.WithDisableTouchOnHit[string, []foundPackage](),
+	)
+	go cache.Start()
+	return cache
+}
+
+// handleFindPackages implements the find_packages tool
+func (s *GodocServer) handleFindPackages(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := strings.Join(strings.Fields(request.GetString("query", "")), " ")
+	if query == "" {
+		return errorResult(codeInvalidArgument, "query must not be empty"), nil
+	}
+	limit := request.GetInt("limit", defaultFindResults)
+	if limit < 1 || limit > maxFindResults {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("limit must be between 1 and %d, got %d", maxFindResults, limit)), nil
+	}
+	base := s.config.Load().PkgsiteURL
+	log := ctxLogger(ctx, s.logger).WithFields(logrus.Fields{"query": query, "pkgsite": base})
+
+	key := base + "|" + query
+	var pkgs []foundPackage
+	if item := s.pkgSearches.Get(key); item != nil {
+		pkgs = item.Value()
+	} else {
+		var err error
+		pkgs, err = searchPkgsite(ctx, base, query)
+		if err != nil {
+			log.WithError(err).Warn("pkg.go.dev search failed")
+			if ctx.Err() != nil {
+				return errorResultFromErr("failed to search "+base, ctx.Err()), nil
+			}
+			return errorResult(codeNetworkFetch, fmt.Sprintf("failed to search %s: %v", base, err)), nil
+		}
+		s.pkgSearches.Set(key, pkgs, ttlcache.DefaultTTL)
+	}
+	log.WithField("packages", len(pkgs)).Debug("Searched pkg.go.dev")
+
+	out := &findOutput{Query: query, Packages: slices.Clone(pkgs[:min(len(pkgs), limit)])}
+	if request.GetBool("signals", false) {
+		s.addFoundSignals(ctx, log, out.Packages)
+	}
+	var sb strings.Builder
+	if len(out.Packages) == 0 {
+		fmt.Fprintf(&sb, "No packages on %s match %q.", base, query)
+	} else {
+		fmt.Fprintf(&sb, "Packages on %s matching %q. Read one with get_doc, passing its import path as path.\n", base, query)
+		for i, pkg := range out.Packages {
+			var details []string
+			if pkg.ImportedBy > 0 {
+				details = append(details, fmt.Sprintf("imported by %d", pkg.ImportedBy))
+			}
+			if pkg.Version != "" {
+				details = append(details, pkg.Version)
+			}
+			if pkg.License != "" {
+				details = append(details, pkg.License)
+			}
+			fmt.Fprintf(&sb, "\n%d. %s", i+1, pkg.Path)
+			if len(details) > 0 {
+				fmt.Fprintf(&sb, " (%s)", strings.Join(details, ", "))
+			}
+			sb.WriteString("\n")
+			if pkg.Synopsis != "" {
+				fmt.Fprintf(&sb, "   %s\n", pkg.Synopsis)
+			}
+			if pkg.Signals != nil {
+				fmt.Fprintf(&sb, "   Signals (deps.dev): %s\n", pkg.Signals)
+			}
+		}
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// searchPkgsite runs a package search on the pkgsite at base and parses the
+// results page
+func searchPkgsite(ctx context.Context, base, query string) ([]foundPackage, error) {
+	ctx, cancel := context.WithTimeout(ctx, findTimeout)
+	defer cancel()
+	u := base + "/search?" + url.Values{"q": {query}, "m": {"package"}, "limit": {strconv.Itoa(maxFindResults)}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "godoc-mcp/"+getBuildInfo().Version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", u, resp.Status)
+	}
+	doc, err := html.Parse(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return nil, fmt.Errorf("invalid search page: %v", err)
+	}
+	return parseSearchResults(doc), nil
+}
+
+// parseSearchResults extracts the packages listed on a pkgsite search page,
+// one per SearchSnippet element
+func parseSearchResults(doc *html.Node) []foundPackage {
+	pkgs := make([]foundPackage, 0)
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if hasClass(n, "SearchSnippet") {
+			if pkg, ok := parseSnippet(n); ok {
+				pkgs = append(pkgs, pkg)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return pkgs
+}
+
+// parseSnippet extracts a package from a search result snippet
+func parseSnippet(snippet *html.Node) (foundPackage, bool) {
+	var pkg foundPackage
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch testID := attr(n, "data-test-id"); {
+			case testID == "snippet-title" && pkg.Path == "":
+				href, _, _ := strings.Cut(attr(n, "href"), "?")
+				pkg.Path = strings.TrimPrefix(href, "/")
+			case testID == "snippet-synopsis":
+				pkg.Synopsis = nodeText(n)
+			case testID == "snippet-published":
+				pkg.Published = nodeText(n)
+			case testID == "snippet-license":
+				pkg.License = nodeText(n)
+			case attr(n, "aria-label") == "Go to Imported By":
+				text := strings.TrimPrefix(nodeText(n), "Imported by")
+				pkg.ImportedBy, _ = strconv.Atoi(strings.ReplaceAll(strings.TrimSpace(text), ",", ""))
+			case n.Data == "strong" && pkg.Version == "" && semver.IsValid(nodeText(n)):
+				pkg.Version = nodeText(n)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(snippet)
+	return pkg, pkg.Path != ""
+}
+
+// attr returns the value of the named attribute of n
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasClass reports whether n is an element with class among its classes
+func hasClass(n *html.Node, class string) bool {
+	return n.Type == html.ElementNode && strings.Contains(" "+attr(n, "class")+" ", " "+class+" ")
+}
+
+// nodeText returns the text within n with whitespace collapsed
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
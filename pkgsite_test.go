@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// searchPage is a pkgsite search results page listing two YAML packages
+const searchPage = `<html><body><div class="SearchResults">
+<div class="SearchSnippet">
+  <h2><a href="/gopkg.in/yaml.v3?tab=overview" data-test-id="snippet-title">yaml <span>(gopkg.in/yaml.v3)</span></a></h2>
+  <p data-test-id="snippet-synopsis">Package yaml implements YAML support for the Go language.</p>
+  <div class="SearchSnippet-infoLabel">
+    <a aria-label="Go to Imported By"><span>Imported by</span> <strong>23,456</strong></a>
+    <span><strong>v3.0.1</strong></span>
+    <span data-test-id="snippet-published"><strong>May 27, 2022</strong></span>
+    <span data-test-id="snippet-license">Apache-2.0, MIT</span>
+  </div>
+</div>
+<div class="SearchSnippet">
+  <h2><a href="/sigs.k8s.io/yaml" data-test-id="snippet-title">yaml</a></h2>
+  <p data-test-id="snippet-synopsis">Package yaml marshals YAML through JSON.</p>
+</div>
+</div></body></html>`
+
+func TestFindPackages(t *testing.T) {
+	var searches atomic.Int32
+	pkgsite := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches.Add(1)
+		switch q := r.URL.Query().Get("q"); {
+		case r.URL.Path != "/search" || r.URL.Query().Get("m") != "package":
+			http.NotFound(w, r)
+		case q == "broken":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case q == "yaml parsing":
+			fmt.Fprint(w, searchPage)
+		default:
+			fmt.Fprint(w, `<html><body><div class="SearchResults"></div></body></html>`)
+		}
+	}))
+	defer pkgsite.Close()
+	s := newTestServer(t, "-pkgsite-url", pkgsite.URL+"/")
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		want     []foundPackage
+		searches int32
+	}{
+		{"empty query", map[string]any{"query": " "}, codeInvalidArgument, nil, 0},
+		{"bad limit", map[string]any{"query": "yaml", "limit": maxFindResults + 1}, codeInvalidArgument, nil, 0},
+		{"unavailable", map[string]any{"query": "broken"}, codeNetworkFetch, nil, 1},
+		{"found", map[string]any{"query": " yaml \n parsing "}, "", []foundPackage{
+			{Path: "gopkg.in/yaml.v3", Synopsis: "Package yaml implements YAML support for the Go language.", ImportedBy: 23456, Version: "v3.0.1", Published: "May 27, 2022", License: "Apache-2.0, MIT"},
+			{Path: "sigs.k8s.io/yaml", Synopsis: "Package yaml marshals YAML through JSON."},
+		}, 2},
+		{"cached and limited", map[string]any{"query": "yaml parsing", "limit": 1}, "", []foundPackage{
+			{Path: "gopkg.in/yaml.v3", Synopsis: "Package yaml implements YAML support for the Go language.", ImportedBy: 23456, Version: "v3.0.1", Published: "May 27, 2022", License: "Apache-2.0, MIT"},
+		}, 2},
+		{"nothing found", map[string]any{"query": "zyzzyva"}, "", []foundPackage{}, 3},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleFindPackages, "find_packages", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+		} else if tt.wantCode == "" {
+			out := result.StructuredContent.(*findOutput)
+			if !slices.Equal(out.Packages, tt.want) {
+				t.Errorf("%s: found %+v, want %+v", tt.name, out.Packages, tt.want)
+			}
+			if text := resultText(result); len(tt.want) > 0 && !strings.Contains(text, "1. gopkg.in/yaml.v3 (imported by 23456, v3.0.1, Apache-2.0, MIT)") {
+				t.Errorf("%s: result lacks the first package:\n%s", tt.name, text)
+			}
+		}
+		if got := searches.Load(); got != tt.searches {
+			t.Errorf("%s: %d searches made, want %d", tt.name, got, tt.searches)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// sourcePkgsite labels documentation rendered by pkg.go.dev rather than go doc
+const sourcePkgsite = "pkg.go.dev"
+
+// docWrapWidth is the width paragraphs of converted documentation wrap at, as
+// go doc's do
+const docWrapWidth = 80
+
+// fallbackCodes are the errors of local documentation that pkg.go.dev may
+// still answer: a module that couldn't be downloaded or a package that
+// couldn't be loaded
+var fallbackCodes = []string{codeNetworkFetch, codePkgNotFound, codeBuildConstraints, codeDocFailed}
+
+// pkgsiteFallback returns the documentation of pkgPath, or of its target
+// symbol, rendered by the configured pkgsite, when local documentation failed
+// with cause and fallback is enabled. It reports false when pkgsite can't
+// answer either, in which case cause stands.
+func (s *GodocServer) pkgsiteFallback(ctx context.Context, pkgPath, target, format string, cause error) (cachedDoc, bool) {
+	cfg := s.config.Load()
+	if !cfg.PkgsiteFallback || format != formatText || isStdLib(pkgPath) || ctx.Err() != nil {
+		return cachedDoc{}, false
+	}
+	if !slices.Contains(fallbackCodes, errorCode(cause)) {
+		return cachedDoc{}, false
+	}
+	log := ctxLogger(ctx, s.logger).WithField("package", pkgPath)
+
+	key := "pkgsite|" + cfg.PkgsiteURL + "|" + pkgPath + "|" + target
+	if item := s.cache.Get(key); item != nil {
+		return item.Value(), true
+	}
+	content, err := fetchPkgsiteDoc(ctx, cfg.PkgsiteURL, pkgPath, target)
+	if err != nil {
+		log.WithError(err).Debug("pkg.go.dev fallback failed")
+		return cachedDoc{}, false
+	}
+	doc := newCachedDoc(content)
+	s.cache.Set(key, doc, cfg.CacheTTL)
+	log.WithField("bytes", doc.byteSize).Debug("Served documentation from pkg.go.dev")
+	return doc, true
+}
+
+// fallbackWarning labels documentation served by pkgsiteFallback
+func fallbackWarning(base string, cause error) string {
+	msg := strings.Join(strings.Fields(cause.Error()), " ")
+	return fmt.Sprintf("local documentation failed (%s); showing the documentation rendered by %s instead, for its latest version, which may differ from the version your module requires", msg, base)
+}
+
+// fetchPkgsiteDoc fetches the documentation page of pkgPath from the pkgsite
+// at base and converts it to text
+func fetchPkgsiteDoc(ctx context.Context, base, pkgPath, target string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, findTimeout)
+	defer cancel()
+	u := base + "/" + pkgPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "godoc-mcp/"+getBuildInfo().Version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", u, resp.Status)
+	}
+	page, err := html.Parse(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return "", fmt.Errorf("invalid documentation page: %v", err)
+	}
+	content := findNode(page, func(n *html.Node) bool { return hasClass(n, "Documentation-content") })
+	if content == nil {
+		return "", errors.New("no documentation on " + u)
+	}
+	if target != "" {
+		// A symbol's heading is the first child of the section documenting it,
+		// which holds a type's functions and methods as well
+		heading := findNode(content, func(n *html.Node) bool { return n.Type == html.ElementNode && attr(n, "id") == target })
+		if heading == nil || heading.Parent == nil {
+			return "", fmt.Errorf("no symbol %s on %s", target, u)
+		}
+		content = heading.Parent
+	}
+	var w docTextWriter
+	w.render(content)
+	return strings.TrimSpace(w.sb.String()) + "\n", nil
+}
+
+// findNode returns the first node below n, in document order, satisfying match
+func findNode(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findNode(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// docTextWriter converts pkgsite's documentation HTML to text laid out as go
+// doc's: declarations flush left, their documentation indented below them
+type docTextWriter struct {
+	sb strings.Builder
+}
+
+// render writes the text of n and the nodes below it
+func (w *docTextWriter) render(n *html.Node) {
+	if n.Type == html.ElementNode {
+		// The index repeats the declarations, and examples aren't part of go doc's output
+		if hasClass(n, "Documentation-index") || hasClass(n, "Documentation-examples") || n.Data == "details" {
+			return
+		}
+		switch n.Data {
+		case "h2", "h3", "h4":
+			// A symbol's heading only repeats the declaration following it
+			if attr(n, "data-kind") != "" {
+				return
+			}
+			if title := strings.TrimSpace(strings.TrimSuffix(nodeText(n), "¶")); title != "" {
+				w.block(title)
+			}
+			return
+		case "pre":
+			text := strings.TrimRight(preText(n), "\n")
+			if hasClass(n.Parent, "Documentation-declaration") {
+				w.block(text)
+			} else {
+				w.block(indentLines(text, "\t"))
+			}
+			return
+		case "p":
+			w.block(wrapText(nodeText(n), "    ", docWrapWidth))
+			return
+		case "ul", "ol":
+			var items []string
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && c.Data == "li" {
+					items = append(items, wrapText("- "+nodeText(c), "    ", docWrapWidth))
+				}
+			}
+			if len(items) > 0 {
+				w.block(strings.Join(items, "\n"))
+			}
+			return
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.render(c)
+	}
+}
+
+// block writes text as a paragraph, separated from the previous one by a blank line
+func (w *docTextWriter) block(text string) {
+	if w.sb.Len() > 0 {
+		w.sb.WriteString("\n")
+	}
+	w.sb.WriteString(text)
+	w.sb.WriteString("\n")
+}
+
+// preText returns the text within a preformatted element as is
+func preText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// indentLines prefixes each non-empty line of text with prefix
+func indentLines(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapText fills the words of text into lines of at most width bytes, each
+// starting with indent
+func wrapText(text, indent string, width int) string {
+	var lines []string
+	line := indent
+	for _, word := range strings.Fields(text) {
+		if line != indent && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = indent
+		}
+		if line != indent {
+			line += " "
+		}
+		line += word
+	}
+	return strings.Join(append(lines, line), "\n")
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// platformArguments are the input schemas of the get_doc arguments selecting
+// the build configuration documented
+var platformArguments = map[string]any{
+	"goos": map[string]any{
+		"type":        "string",
+		"description": "Optional: Document the package as built for this operating system (GOOS), such as 'windows' or 'darwin', instead of the server's, so platform-specific declarations like syscall's show for that system.",
+	},
+	"goarch": map[string]any{
+		"type":        "string",
+		"description": "Optional: Document the package as built for this architecture (GOARCH), such as 'arm64' or 'wasm', instead of the server's.",
+	},
+	"tags": map[string]any{
+		"type":        "array",
+		"items":       map[string]any{"type": "string"},
+		"description": "Optional: Build tags to document the package with, as for 'go build -tags', such as ['sqlite_omit_load_extension'] or ['netgo'], so files behind build constraints on them are included.",
+	},
+	"cgo_enabled": map[string]any{
+		"type":        "boolean",
+		"description": "Optional: Document the package as built with cgo enabled or disabled (CGO_ENABLED) instead of the server's default, which decides whether files that import \"C\" or are constrained on cgo are included.",
+	},
+	"env": map[string]any{
+		"type":                 "object",
+		"description":          "Optional: For unusual configurations, environment variables to generate the documentation with, such as {\"GOEXPERIMENT\": \"synctest\"}. Only GOOS, GOARCH, GOEXPERIMENT, and GOFLAGS setting build tags (\"-tags=a,b\") are accepted; any other variable fails the request. goos, goarch, and tags may be used instead.",
+		"additionalProperties": map[string]any{"type": "string"},
+		"propertyNames":        map[string]any{"enum": envVars},
+	},
+}
+
+// buildTag matches a valid build tag
+var buildTag = regexp.MustCompile(`^[\w.]+$`)
+
+// supportedPlatforms returns the GOOS/GOARCH pairs the go command supports
+var supportedPlatforms = sync.OnceValue(func() []string {
+	out, err := exec.Command("go", "tool", "dist", "list").Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+})
+
+// buildEnvVars are the variables a get_doc request can set, in the order
+// they are passed on
+var buildEnvVars = []string{"GOOS", "GOARCH", "GOFLAGS", "CGO_ENABLED", "GOEXPERIMENT"}
+
+// envVars are the variables the get_doc env argument accepts
+var envVars = []string{"GOOS", "GOARCH", "GOFLAGS", "GOEXPERIMENT"}
+
+// tagsFlag matches a GOFLAGS flag setting build tags
+var tagsFlag = regexp.MustCompile(`^--?tags=([\w.,]*)$`)
+
+// experiment matches an experiment, or its negation, in GOEXPERIMENT
+var experiment = regexp.MustCompile(`^(no)?\w+$`)
+
+// requestBuildEnv returns the environment variables a get_doc request sets
+// for generating its documentation, as KEY=value. The variables come from
+// the goos, goarch, tags, and cgo_enabled arguments and from env, which may
+// only set envVars, and GOFLAGS only to build tags.
+func requestBuildEnv(request mcp.CallToolRequest) ([]string, error) {
+	vars := make(map[string]string)
+	if raw, ok := request.GetArguments()["env"]; ok && raw != nil {
+		env, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("env must be an object of variable names to values")
+		}
+		for key, v := range env {
+			value, ok := v.(string)
+			switch {
+			case !slices.Contains(envVars, key):
+				return nil, fmt.Errorf("env cannot set %s; only %s can be set", key, strings.Join(envVars, ", "))
+			case !ok:
+				return nil, fmt.Errorf("env %s must be a string", key)
+			}
+			vars[key] = value
+		}
+	}
+	set := func(key, arg, value string) error {
+		if prev, ok := vars[key]; ok && prev != value {
+			return fmt.Errorf("%s %q conflicts with env %s=%s", arg, value, key, prev)
+		}
+		vars[key] = value
+		return nil
+	}
+	if goos := request.GetString("goos", ""); goos != "" {
+		if err := set("GOOS", "goos", goos); err != nil {
+			return nil, err
+		}
+	}
+	if goarch := request.GetString("goarch", ""); goarch != "" {
+		if err := set("GOARCH", "goarch", goarch); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := request.GetArguments()["cgo_enabled"]; ok {
+		cgo := "0"
+		if request.GetBool("cgo_enabled", false) {
+			cgo = "1"
+		}
+		vars["CGO_ENABLED"] = cgo
+	}
+	if err := checkPlatform(vars["GOOS"], vars["GOARCH"]); err != nil {
+		return nil, err
+	}
+
+	// Build tags from env's GOFLAGS and the tags argument are combined
+	var tags []string
+	for _, flag := range strings.Fields(vars["GOFLAGS"]) {
+		m := tagsFlag.FindStringSubmatch(flag)
+		if m == nil {
+			return nil, fmt.Errorf("env GOFLAGS can only set build tags, as -tags=a,b, not %s", flag)
+		}
+		tags = append(tags, strings.Split(m[1], ",")...)
+	}
+	tags = append(tags, request.GetStringSlice("tags", nil)...)
+	tags = slices.DeleteFunc(tags, func(tag string) bool { return tag == "" })
+	for _, tag := range tags {
+		if !buildTag.MatchString(tag) {
+			return nil, fmt.Errorf("invalid build tag %q: tags are letters, digits, underscores, and dots", tag)
+		}
+	}
+	delete(vars, "GOFLAGS")
+	if len(tags) > 0 {
+		vars["GOFLAGS"] = "-tags=" + strings.Join(tags, ",")
+	}
+
+	if experiments, ok := vars["GOEXPERIMENT"]; ok {
+		for _, name := range strings.Split(experiments, ",") {
+			if !experiment.MatchString(name) {
+				return nil, fmt.Errorf("invalid GOEXPERIMENT %q: list experiments, or their negations with a no prefix, separated by commas", experiments)
+			}
+		}
+	}
+
+	var env []string
+	for _, key := range buildEnvVars {
+		if value, ok := vars[key]; ok && value != "" {
+			env = append(env, key+"="+value)
+		}
+	}
+	return env, nil
+}
+
+// checkPlatform returns an error if goos or goarch, either of which may be
+// empty, isn't supported by the go command
+func checkPlatform(goos, goarch string) error {
+	platforms := supportedPlatforms()
+	if platforms == nil || goos == "" && goarch == "" {
+		return nil
+	}
+	supported := func(prefix, suffix string) bool {
+		return slices.ContainsFunc(platforms, func(p string) bool {
+			return strings.HasPrefix(p, prefix) && strings.HasSuffix(p, suffix)
+		})
+	}
+	switch {
+	case goos != "" && !supported(goos+"/", ""):
+		return fmt.Errorf("unknown goos %q; run 'go tool dist list' for the supported platforms", goos)
+	case goarch != "" && !supported("", "/"+goarch):
+		return fmt.Errorf("unknown goarch %q; run 'go tool dist list' for the supported platforms", goarch)
+	case goos != "" && goarch != "" && !slices.Contains(platforms, goos+"/"+goarch):
+		return fmt.Errorf("%s/%s is not a supported platform; run 'go tool dist list' for the supported platforms", goos, goarch)
+	}
+	return nil
+}
+
+// buildEnvKey is the context key of the environment documentation is generated with
+type buildEnvKey struct{}
+
+// withBuildEnv returns a context generating documentation with the
+// environment variables env set
+func withBuildEnv(ctx context.Context, env []string) context.Context {
+	if len(env) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, buildEnvKey{}, env)
+}
+
+// buildEnvFromContext returns the environment variables set in ctx, if any
+func buildEnvFromContext(ctx context.Context) []string {
+	env, _ := ctx.Value(buildEnvKey{}).([]string)
+	return env
+}
+
+// buildEnviron returns the environment of go commands generating
+// documentation in ctx, or nil for the server's own. GOFLAGS set in ctx are
+// added to the server's.
+func buildEnviron(ctx context.Context) []string {
+	env := buildEnvFromContext(ctx)
+	if len(env) == 0 {
+		return nil
+	}
+	environ := os.Environ()
+	for _, kv := range env {
+		if flags, ok := strings.CutPrefix(kv, "GOFLAGS="); ok {
+			kv = "GOFLAGS=" + strings.TrimSpace(os.Getenv("GOFLAGS")+" "+flags)
+		}
+		environ = append(environ, kv)
+	}
+	return environ
+}
+
+// hasBuildTags reports whether documentation in ctx is generated with build
+// tags, which go doc ignores
+func hasBuildTags(ctx context.Context) bool {
+	return slices.ContainsFunc(buildEnvFromContext(ctx), func(kv string) bool {
+		return strings.HasPrefix(kv, "GOFLAGS=")
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRequestBuildEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    []string
+		wantErr bool
+	}{
+		{"none", map[string]any{}, nil, false},
+		{"platform", map[string]any{"goos": "windows", "goarch": "amd64"}, []string{"GOOS=windows", "GOARCH=amd64"}, false},
+		{"cgo off", map[string]any{"cgo_enabled": false}, []string{"CGO_ENABLED=0"}, false},
+		{"cgo on", map[string]any{"cgo_enabled": true}, []string{"CGO_ENABLED=1"}, false},
+		{"tags", map[string]any{"tags": []any{"integration", "", "go1.22"}}, []string{"GOFLAGS=-tags=integration,go1.22"}, false},
+		{"env", map[string]any{"env": map[string]any{"GOARCH": "arm64", "GOEXPERIMENT": "rangefunc,noaliastypeparams"}},
+			[]string{"GOARCH=arm64", "GOEXPERIMENT=rangefunc,noaliastypeparams"}, false},
+		{"env tags combined with tags", map[string]any{"env": map[string]any{"GOFLAGS": "-tags=a,b --tags=c"}, "tags": []any{"d"}},
+			[]string{"GOFLAGS=-tags=a,b,c,d"}, false},
+		{"env agreeing with goos", map[string]any{"env": map[string]any{"GOOS": "linux"}, "goos": "linux"}, []string{"GOOS=linux"}, false},
+		{"env conflicting with goos", map[string]any{"env": map[string]any{"GOOS": "linux"}, "goos": "darwin"}, nil, true},
+		{"env not an object", map[string]any{"env": "GOOS=linux"}, nil, true},
+		{"env value not a string", map[string]any{"env": map[string]any{"GOOS": 1}}, nil, true},
+		{"env variable not allowed", map[string]any{"env": map[string]any{"GOPROXY": "off"}}, nil, true},
+		{"env GOFLAGS beyond tags", map[string]any{"env": map[string]any{"GOFLAGS": "-mod=mod"}}, nil, true},
+		{"invalid tag", map[string]any{"tags": []any{"a b"}}, nil, true},
+		{"invalid experiment", map[string]any{"env": map[string]any{"GOEXPERIMENT": "a;b"}}, nil, true},
+		{"unknown goos", map[string]any{"goos": "plan10"}, nil, true},
+		{"unsupported platform", map[string]any{"goos": "ios", "goarch": "mips"}, nil, true},
+	}
+	for _, tt := range tests {
+		var request mcp.CallToolRequest
+		request.Params.Arguments = tt.args
+		got, err := requestBuildEnv(request)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("%s: requestBuildEnv = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
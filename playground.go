@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+const sharePlaygroundDescription = `Share Go code on the Go Playground and return a runnable link to it.
+Pass code, a complete program in package main, or path and example to share one of a package's
+testable examples (e.g., path "strings" and example "Cut" for ExampleCut). Give the link to a human
+reader so they can run and edit the code in the browser. Examples must be self-contained, as those
+in a package's _test package are.`
+
+// Limits on share_playground requests
+const (
+	// maxPlaygroundBytes is the largest program the playground accepts
+	maxPlaygroundBytes = 64 << 10
+	// playgroundTimeout bounds a share request
+	playgroundTimeout = 15 * time.Second
+)
+
+// sharePlaygroundSchema is the share_playground input schema
+var sharePlaygroundSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"code": map[string]any{
+			"type":        "string",
+			"description": "Go program to share, in package main. Omit to share an example instead.",
+		},
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Package whose example to share, as for get_doc (e.g., 'strings' or 'github.com/user/repo').",
+		},
+		"example": map[string]any{
+			"type":        "string",
+			"description": "Name of the example, with or without its Example prefix (e.g., 'Cut', 'Client_Do', or '' for the package example).",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory for relative paths and the module's own packages. Defaults to the session working directory.",
+		},
+	},
+}
+
+// playgroundOutputSchema is the outputSchema of share_playground, describing playgroundOutput
+var playgroundOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"url":     map[string]any{"type": "string", "description": "Link to the shared program on the playground"},
+		"example": map[string]any{"type": "string", "description": "Name of the example shared, if any"},
+		"code":    map[string]any{"type": "string", "description": "The program shared"},
+	},
+	Required: []string{"url", "code"},
+}
+
+// playgroundOutput is the structured content of a share_playground result
+type playgroundOutput struct {
+	URL     string `json:"url"`
+	Example string `json:"example,omitempty"`
+	Code    string `json:"code"`
+}
+
+// handleSharePlayground implements the share_playground tool
+func (s *GodocServer) handleSharePlayground(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	code := request.GetString("code", "")
+	path := request.GetString("path", "")
+	out := &playgroundOutput{}
+	switch {
+	case code != "" && path != "":
+		return errorResult(codeInvalidArgument, "pass either code or path and example, not both"), nil
+	case code != "":
+	case path != "":
+		if err := s.runtime.Load().toolchainErr; err != nil {
+			return errorResultFromErr("cannot load packages", err), nil
+		}
+		dirs, err := s.sourceDirs(ctx, request, path)
+		if err != nil {
+			return errorResultFromErr("failed to find the source of "+path, err), nil
+		}
+		if len(dirs) > 1 {
+			return errorResult(codeInvalidArgument, fmt.Sprintf("%s names %d packages; examples are shared from one", path, len(dirs))), nil
+		}
+		name := strings.TrimPrefix(request.GetString("example", ""), "Example")
+		if code, err = playableExample(dirs[0], name); err != nil {
+			return errorResultFromErr("failed to share example", err), nil
+		}
+		out.Example = "Example" + name
+	default:
+		return errorResult(codeInvalidArgument, "pass code, or path and example"), nil
+	}
+	if len(code) > maxPlaygroundBytes {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("code is %d bytes; the playground accepts at most %d", len(code), maxPlaygroundBytes)), nil
+	}
+
+	base := s.config.Load().PlaygroundURL
+	log := ctxLogger(ctx, s.logger).WithFields(logrus.Fields{"playground": base, "bytes": len(code)})
+	url, err := sharePlayground(ctx, base, code)
+	if err != nil {
+		log.WithError(err).Warn("Playground share failed")
+		if ctx.Err() != nil {
+			return errorResultFromErr("failed to share on "+base, ctx.Err()), nil
+		}
+		return errorResult(codeNetworkFetch, fmt.Sprintf("failed to share on %s: %v", base, err)), nil
+	}
+	log.WithField("url", url).Debug("Shared on the playground")
+	out.URL, out.Code = url, code
+
+	text := url
+	if out.Example != "" {
+		text = fmt.Sprintf("%s of %s runs at %s\n\n%s", out.Example, path, url, code)
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(text, "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// sharePlayground uploads code to the playground at base and returns the link
+// to it
+func sharePlayground(ctx context.Context, base, code string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, playgroundTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/share", strings.NewReader(code))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", "godoc-mcp/"+getBuildInfo().Version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s/share returned %s: %s", base, resp.Status, strings.TrimSpace(string(body)))
+	}
+	id := strings.TrimSpace(string(body))
+	if id == "" || strings.ContainsAny(id, "/?# \n") {
+		return "", fmt.Errorf("%s/share returned an invalid id %q", base, id)
+	}
+	return base + "/p/" + id, nil
+}
+
+// playableExample returns the example named name of the package in dir as a
+// complete program, as go doc shows runnable examples: name is what follows
+// "Example" in the function's name
+func playableExample(dir, name string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, e.Name()), nil, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		files = append(files, f)
+	}
+	examples := doc.Examples(files...)
+	i := slices.IndexFunc(examples, func(ex *doc.Example) bool { return ex.Name == name })
+	if i < 0 {
+		var names []string
+		for _, ex := range examples {
+			names = append(names, "Example"+ex.Name)
+		}
+		msg := fmt.Sprintf("no example Example%s in %s", name, dir)
+		if len(names) > 0 {
+			msg += "; it has " + strings.Join(names, ", ")
+		}
+		return "", withCode(codeSymbolNotFound, fmt.Errorf("%s", msg))
+	}
+	ex := examples[i]
+	if ex.Play == nil {
+		return "", withCode(codeUnsupported, fmt.Errorf("Example%s is not self-contained; copy it into a main package and pass it as code", name))
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, ex.Play); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSharePlayground(t *testing.T) {
+	var shared string
+	playground := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method != http.MethodPost || r.URL.Path != "/share":
+			http.NotFound(w, r)
+		case strings.Contains(string(body), "rejected"):
+			http.Error(w, "snippet is too large", http.StatusRequestEntityTooLarge)
+		default:
+			shared = string(body)
+			io.WriteString(w, "abc123\n")
+		}
+	}))
+	defer playground.Close()
+	s := newTestServer(t, "-playground-url", playground.URL)
+	dir := writeModule(t, map[string]string{
+		"go.mod":         "module example.com/mod\n\ngo 1.21\n",
+		"greet/greet.go": "package greet\n\nfunc Hello() string { return \"hi\" }\n",
+		"greet/example_test.go": `package greet_test
+
+import (
+	"fmt"
+
+	"example.com/mod/greet"
+)
+
+func ExampleHello() {
+	fmt.Println(greet.Hello())
+	// Output: hi
+}
+`,
+		"inner/inner.go":        "package inner\n\nfunc hello() string { return \"hi\" }\n",
+		"inner/example_test.go": "package inner\n\nimport \"fmt\"\n\nfunc Example() {\n\tfmt.Println(hello())\n}\n",
+	})
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		// want is text of the shared code
+		want string
+	}{
+		{"nothing", nil, codeInvalidArgument, ""},
+		{"both", map[string]any{"code": "package main", "path": "./greet", "working_dir": dir}, codeInvalidArgument, ""},
+		{"too large", map[string]any{"code": strings.Repeat("x", maxPlaygroundBytes+1)}, codeInvalidArgument, ""},
+		{"rejected", map[string]any{"code": "package main // rejected"}, codeNetworkFetch, ""},
+		{"code", map[string]any{"code": "package main\n\nfunc main() {}\n"}, "", "func main() {}"},
+		{"example", map[string]any{"path": "./greet", "example": "ExampleHello", "working_dir": dir}, "", "fmt.Println(greet.Hello())"},
+		{"example suffix", map[string]any{"path": "./greet", "example": "Hello", "working_dir": dir}, "", "fmt.Println(greet.Hello())"},
+		{"missing example", map[string]any{"path": "./greet", "example": "ExampleBye", "working_dir": dir}, codeSymbolNotFound, ""},
+		{"unplayable example", map[string]any{"path": "./inner", "working_dir": dir}, codeUnsupported, ""},
+		{"many packages", map[string]any{"path": "./...", "example": "Hello", "working_dir": dir}, codeInvalidArgument, ""},
+	}
+	for _, tt := range tests {
+		shared = ""
+		result := callTool(t, ctx, s.handleSharePlayground, "share_playground", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*playgroundOutput)
+		if out.URL != playground.URL+"/p/abc123" || !strings.Contains(resultText(result), out.URL) {
+			t.Errorf("%s: shared at %q: %s", tt.name, out.URL, resultText(result))
+		}
+		if shared != out.Code || !strings.Contains(shared, tt.want) {
+			t.Errorf("%s: shared %q, want code containing %q", tt.name, shared, tt.want)
+		}
+	}
+}
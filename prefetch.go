@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/packages"
+)
+
+// prefetchTimeout bounds a single prefetched document
+const prefetchTimeout = time.Minute
+
+// prefetchImports warms the cache with the documentation of up to
+// -prefetch-imports direct imports of pkgPath, as if each were requested next
+// with the same working directory. Prefetching is low priority: only one batch
+// runs at a time and each document needs both a free worker and subprocess slot,
+// so it never queues ahead of client requests. It stops at the first busy slot.
+func (s *GodocServer) prefetchImports(log *logrus.Entry, scope, workingDir, pkgPath string, ownModule bool) {
+	limit := s.config.Load().PrefetchImports
+	if limit <= 0 || !s.prefetching.CompareAndSwap(false, true) {
+		return
+	}
+	defer s.prefetching.Store(false)
+	log = log.WithField("package", pkgPath)
+
+	imports, ok := s.listImports(workingDir, pkgPath)
+	if !ok {
+		log.Debug("Skipped import prefetch, server busy")
+		return
+	}
+	if len(imports) > limit {
+		imports = imports[:limit]
+	}
+
+	start := time.Now()
+	var fetched int
+	for _, imp := range imports {
+		if s.closed.Load() {
+			return
+		}
+		release, ok := s.workers.tryAcquire()
+		if !ok {
+			break
+		}
+		err := s.prefetchDoc(scope, workingDir, imp, ownModule)
+		release()
+		if err != nil {
+			log.WithError(err).WithField("import", imp).Debug("Prefetch failed")
+			continue
+		}
+		fetched++
+	}
+	log.WithFields(logrus.Fields{
+		"prefetched": fetched,
+		"imports":    len(imports),
+		"duration":   time.Since(start),
+	}).Debug("Prefetched imports")
+}
+
+// prefetchDoc caches the default documentation of one import, resolving it the
+// way handleToolCall would for a request without a target
+func (s *GodocServer) prefetchDoc(scope, workingDir, importPath string, ownModule bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), prefetchTimeout)
+	defer cancel()
+	if !ownModule {
+		var err error
+		if workingDir, err = s.projectManager.GetOrCreateProject(ctx, importPath); err != nil {
+			return err
+		}
+	}
+	_, err := s.runGoDoc(ctx, scope, workingDir, formatText, importPath)
+	return err
+}
+
+// listImports returns the sorted direct imports of pkgPath, or false when no
+// subprocess slot is free to resolve them
+func (s *GodocServer) listImports(workingDir, pkgPath string) ([]string, bool) {
+	release, ok := s.limiter.tryAcquire()
+	if !ok {
+		return nil, false
+	}
+	defer release()
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedImports,
+		Dir:  workingDir,
+	}, pkgPath)
+	if err != nil || len(pkgs) != 1 {
+		return nil, true
+	}
+	var imports []string
+	for imp := range pkgs[0].Imports {
+		// cgo pseudo-package and unimportable internal packages have no useful docs
+		if imp == "C" || (isStdLib(imp) && !isPublicStd(imp)) {
+			continue
+		}
+		imports = append(imports, imp)
+	}
+	slices.Sort(imports)
+	return imports, true
+}
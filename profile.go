@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	rpprof "runtime/pprof"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// servePprof serves the net/http/pprof endpoints on addr until ctx is cancelled.
+// It uses its own listener so profiling is available in stdio mode and is never
+// exposed on the MCP address.
+func servePprof(ctx context.Context, addr string, logger *logrus.Logger) {
+	ln, err := listen(addr)
+	if err != nil {
+		logger.WithError(err).Error("Failed to start pprof server")
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	srv := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	logger.WithField("addr", addr).Info("Serving pprof endpoints under /debug/pprof/")
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.WithError(err).Error("pprof server error")
+	}
+}
+
+// writeProfiles continuously records CPU profiles to dir, starting a new file
+// every interval, and writes heap and goroutine snapshots alongside each one
+// until ctx is cancelled
+func writeProfiles(ctx context.Context, dir string, interval time.Duration, logger *logrus.Logger) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		logger.WithError(err).Error("Failed to create profile directory")
+		return
+	}
+	logger.WithFields(logrus.Fields{
+		"dir":      dir,
+		"interval": interval,
+	}).Info("Writing periodic profiles")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		stamp := time.Now().UTC().Format("20060102T150405Z")
+		stopCPU, err := startCPUProfile(filepath.Join(dir, "cpu-"+stamp+".pprof"))
+		if err != nil {
+			logger.WithError(err).Error("Failed to start CPU profile")
+		}
+
+		var done bool
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-ticker.C:
+		}
+		stopCPU()
+		for _, name := range []string{"heap", "goroutine"} {
+			if err := writeProfile(name, filepath.Join(dir, name+"-"+stamp+".pprof")); err != nil {
+				logger.WithError(err).WithField("profile", name).Error("Failed to write profile")
+			}
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// startCPUProfile starts recording a CPU profile to path, returning a function
+// that stops the recording and closes the file
+func startCPUProfile(path string) (func(), error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return func() {}, err
+	}
+	if err := rpprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return func() {}, err
+	}
+	return func() {
+		rpprof.StopCPUProfile()
+		f.Close()
+	}, nil
+}
+
+// writeProfile writes a snapshot of the named runtime profile to path
+func writeProfile(name, path string) error {
+	p := rpprof.Lookup(name)
+	if p == nil {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	if name == "heap" {
+		// Report up-to-date allocation statistics
+		runtime.GC()
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return p.WriteTo(f, 0)
+}
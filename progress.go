@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+type progressKey struct{}
+
+// progressReporter sends notifications/progress for a tool call whose client
+// supplied a progressToken. Long-running stages report a step each, and
+// streamed documentation reports the bytes sent so far.
+type progressReporter struct {
+	ctx    context.Context
+	server *server.MCPServer
+	token  mcp.ProgressToken
+	log    *logrus.Entry
+
+	mu       sync.Mutex
+	progress float64
+	steps    int
+	failed   bool
+}
+
+// newProgressReporter returns a reporter for the tool call in ctx, or nil when
+// the client did not ask for progress notifications
+func (s *GodocServer) newProgressReporter(ctx context.Context, request mcp.CallToolRequest) *progressReporter {
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil || request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	return &progressReporter{
+		ctx:    ctx,
+		server: mcpServer,
+		token:  request.Params.Meta.ProgressToken,
+		log:    ctxLogger(ctx, s.logger),
+	}
+}
+
+// withProgress returns a context carrying p for long-running operations
+func withProgress(ctx context.Context, p *progressReporter) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// progressFromContext returns the reporter stored in ctx, if any
+func progressFromContext(ctx context.Context) *progressReporter {
+	p, _ := ctx.Value(progressKey{}).(*progressReporter)
+	return p
+}
+
+// step reports the start of a stage of a long-running operation
+func (p *progressReporter) step(message string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.steps++
+	progress := float64(p.steps)
+	p.mu.Unlock()
+	p.notify(progress, message)
+}
+
+// notify sends a progress notification, retrying briefly when the client's
+// notification queue is full. Progress must increase with every notification,
+// so a value at or below the last one sent is raised past it. Notifications stop
+// if one cannot be delivered, and notify reports whether it was.
+func (p *progressReporter) notify(progress float64, message string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failed {
+		return false
+	}
+	p.progress = max(progress, p.progress+1)
+	params := map[string]any{
+		"progressToken": p.token,
+		"progress":      p.progress,
+		"message":       message,
+	}
+	for attempt := 1; ; attempt++ {
+		err := p.server.SendNotificationToClient(p.ctx, "notifications/progress", params)
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, server.ErrNotificationChannelBlocked) || attempt == 5 {
+			p.log.WithError(err).Warn("Failed to send progress notification, no further progress will be reported")
+			p.failed = true
+			return false
+		}
+		time.Sleep(time.Duration(attempt) * 10 * time.Millisecond)
+	}
+}
+
+// progressLines reports each line written to it matching prefix as a step, such
+// as the "go: downloading" lines go get writes as it fetches modules
+type progressLines struct {
+	progress *progressReporter
+	prefix   string
+	pending  []byte
+}
+
+func (w *progressLines) Write(p []byte) (int, error) {
+	if w.progress == nil {
+		return len(p), nil
+	}
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := strings.TrimSpace(string(w.pending[:i]))
+		w.pending = w.pending[i+1:]
+		if strings.HasPrefix(line, w.prefix) {
+			w.progress.step(line)
+		}
+	}
+}
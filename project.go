@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"os/exec"
@@ -10,10 +12,21 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jellydator/ttlcache/v3"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"golang.org/x/mod/semver"
+)
+
+// go get is attempted getAttempts times on transient network failures, waiting
+// getBackoff before the first retry and twice as long before each next one
+const (
+	getAttempts = 3
+	getBackoff  = time.Second
 )
 
 // ProjectManager manages temporary Go project directories with caching
@@ -21,52 +34,78 @@ type ProjectManager struct {
 	cache    *ttlcache.Cache[string, string]
 	tempDirs []string
 	mu       sync.Mutex
+	limiter  *subprocessLimiter
+	creating sharedGroup
+	ttl      atomic.Int64
 	logger   *logrus.Logger
 }
 
 // NewProjectManager creates a new ProjectManager with caching
-func NewProjectManager(logger *logrus.Logger) *ProjectManager {
+func NewProjectManager(logger *logrus.Logger, limiter *subprocessLimiter, ttl time.Duration) *ProjectManager {
 	pm := &ProjectManager{
-		cache:    ttlcache.New(ttlcache.WithTTL[string, string](30 * time.Minute)),
+		cache:    ttlcache.New(ttlcache.WithTTL[string, string](ttl)),
 		tempDirs: make([]string, 0),
+		limiter:  limiter,
 		logger:   logger,
 	}
 	pm.cache.OnEviction(func(ctx context.Context, er ttlcache.EvictionReason, i *ttlcache.Item[string, string]) {
 		pm.mu.Lock()
 		defer pm.mu.Unlock()
+		// Module roots and module cache directories are only borrowed
+		if !slices.Contains(pm.tempDirs, i.Value()) {
+			return
+		}
 		os.RemoveAll(i.Value())
 		pm.tempDirs = slices.DeleteFunc(pm.tempDirs, func(s string) bool { return s == i.Value() })
 	})
+	pm.setTTL(ttl)
 	go pm.cache.Start()
 	return pm
 }
 
+// setTTL changes how long newly created projects are kept
+func (pm *ProjectManager) setTTL(ttl time.Duration) {
+	pm.ttl.Store(int64(ttl))
+}
+
 // GetOrCreateProject gets or creates a temporary Go project for the given package path
-func (pm *ProjectManager) GetOrCreateProject(pkgPath string) (string, error) {
+func (pm *ProjectManager) GetOrCreateProject(ctx context.Context, pkgPath string) (string, error) {
+	log := ctxLogger(ctx, pm.logger).WithField("package", pkgPath)
+	ctx, span := startSpan(ctx, "project.get", attribute.String("package", pkgPath))
+	defer span.End()
+
 	// Check cache first
 	if item := pm.cache.Get(pkgPath); item != nil {
 		projectDir := item.Value()
-		pm.logger.WithField("package", pkgPath).WithField("project_dir", projectDir).Debug("Project cache hit")
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		log.WithField("project_dir", projectDir).Debug("Project cache hit")
 		return projectDir, nil
 	}
 
-	pm.logger.WithField("package", pkgPath).Debug("Project cache miss, creating new project")
+	log.Debug("Project cache miss, creating new project")
 
-	// Create new project
-	projectDir, err := pm.createTempProject(pkgPath)
+	// Create new project, once for concurrent requests for the same package
+	v, err, _ := pm.creating.do(ctx, pkgPath, func(ctx context.Context) (any, error) {
+		projectDir, err := pm.createTempProject(ctx, log, pkgPath)
+		if err != nil {
+			return "", err
+		}
+
+		// Cache the project directory
+		pm.cache.Set(pkgPath, projectDir, time.Duration(pm.ttl.Load()))
+		log.WithField("project_dir", projectDir).Debug("Project cached")
+		return projectDir, nil
+	})
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return "", err
 	}
-
-	// Cache the project directory
-	pm.cache.Set(pkgPath, projectDir, 30*time.Minute)
-	pm.logger.WithField("package", pkgPath).WithField("project_dir", projectDir).Debug("Project cached")
-
-	return projectDir, nil
+	return v.(string), nil
 }
 
 // createTempProject creates a temporary Go project with the given package
-func (pm *ProjectManager) createTempProject(pkgPath string) (string, error) {
+func (pm *ProjectManager) createTempProject(ctx context.Context, log *logrus.Entry, pkgPath string) (_ string, err error) {
 	switch {
 	case isStdLib(pkgPath):
 		// Standard library package, create a minimal temp project
@@ -87,6 +126,27 @@ func (pm *ProjectManager) createTempProject(pkgPath string) (string, error) {
 		}
 	}
 
+	// Modules already downloaded are documented in place, without go get
+	if !isStdLib(pkgPath) && !filepath.IsAbs(pkgPath) {
+		if m, ok := pm.cachedProject(ctx, log, pkgPath); ok {
+			log.WithFields(logrus.Fields{
+				"module":  m.path,
+				"version": m.version,
+				"dir":     m.dir,
+			}).Debug("Resolved package from module cache")
+			return m.dir, nil
+		}
+	}
+
+	// Hold a subprocess slot for go mod init and go get
+	progress := progressFromContext(ctx)
+	progress.step("Creating temporary module for " + pkgPath)
+	release, err := pm.limiter.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
 	// Create temp project
 	tempDir, err := os.MkdirTemp("", "godoc-mcp-*")
 	if err != nil {
@@ -97,11 +157,23 @@ func (pm *ProjectManager) createTempProject(pkgPath string) (string, error) {
 	pm.tempDirs = append(pm.tempDirs, tempDir)
 	pm.mu.Unlock()
 
+	// Failed or cancelled projects are removed right away, not left for eviction
+	defer func() {
+		if err != nil {
+			pm.removeTempDir(tempDir)
+		}
+	}()
+
 	// Initialize go.mod
-	cmd := exec.Command("go", "mod", "init", "godoc-temp")
+	cmd := exec.CommandContext(ctx, "go", "mod", "init", "godoc-temp")
 	cmd.Dir = tempDir
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("failed to initialize go.mod: %v\noutput: %s", err, out)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	_, span := startSpan(ctx, "go mod init")
+	err = cmd.Run()
+	endSpan(span, err)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize go.mod: %v\noutput: %s", err, stderr.Bytes())
 	}
 
 	switch {
@@ -111,12 +183,119 @@ func (pm *ProjectManager) createTempProject(pkgPath string) (string, error) {
 		// Remote package, fetch the package
 	}
 
-	cmd = exec.Command("go", "get", pkgPath)
-	cmd.Dir = tempDir
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("failed to get package %s: %v\noutput: %s", pkgPath, err, out)
+	// Downloads need a writable module cache
+	if err := checkModCache(ctx); err != nil {
+		return "", err
 	}
-	return tempDir, nil
+
+	// Modules are reported as go get downloads them
+	progress.step("Fetching " + pkgPath + " with go get")
+	// Transient network failures are retried with exponential backoff; a module
+	// that doesn't exist fails right away
+	for attempt := 1; ; attempt++ {
+		var out []byte
+		out, err = goGet(ctx, log, tempDir, pkgPath, progress)
+		if err == nil {
+			return tempDir, nil
+		}
+		if !transientFetch(string(out)) {
+			// Anything go get can't attribute to a missing package is a fetch failure
+			code := classifyOutput(string(out))
+			if code == "" {
+				code = codeNetworkFetch
+			}
+			if code == codePkgNotFound {
+				return "", withCode(code, fmt.Errorf("package %s not found by go get; this is not a network problem, so retrying will not help: %v\noutput: %s", pkgPath, err, out))
+			}
+			return "", withCode(code, fmt.Errorf("failed to get package %s: %v\noutput: %s", pkgPath, err, out))
+		}
+		if attempt == getAttempts {
+			return "", withCode(codeNetworkFetch, fmt.Errorf("transient network failure getting package %s, still failing after %d attempts; the module proxy or network may be briefly unavailable, so retrying later can succeed: %v\noutput: %s", pkgPath, attempt, err, out))
+		}
+
+		delay := getBackoff << (attempt - 1)
+		log.WithFields(logrus.Fields{
+			"attempt": attempt,
+			"delay":   delay,
+		}).Warn("Transient go get failure, retrying")
+		progress.step(fmt.Sprintf("Retrying go get of %s in %s after a transient failure (attempt %d of %d)", pkgPath, delay, attempt+1, getAttempts))
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// cachedProject finds the module version go get would fetch for pkgPath, an
+// import path with an optional @version, in the module cache. Only canonical
+// versions are looked up directly; latest is resolved through the module proxy
+// first, so a stale cached version is never served in its place.
+func (pm *ProjectManager) cachedProject(ctx context.Context, log *logrus.Entry, pkgPath string) (cachedModule, bool) {
+	pkg, version, _ := strings.Cut(pkgPath, "@")
+	switch {
+	case version == "" || version == "latest":
+	case semver.IsValid(version) && semver.Canonical(version) == version:
+		return findCachedModule(pkg, version)
+	default:
+		// Queries such as branch names or version prefixes are left to go get
+		return cachedModule{}, false
+	}
+	// Latest is resolved for the module a cached version says provides pkg
+	m, ok := findCachedModule(pkg, "")
+	if !ok {
+		return cachedModule{}, false
+	}
+	release, err := pm.limiter.acquire(ctx)
+	if err != nil {
+		return cachedModule{}, false
+	}
+	latest, err := latestModuleVersion(ctx, m.path)
+	release()
+	if err != nil {
+		log.WithError(err).Debug("Cannot resolve latest version, not using the module cache")
+		return cachedModule{}, false
+	}
+	if latest == m.version {
+		return m, true
+	}
+	return findCachedModule(pkg, latest)
+}
+
+// goGet runs go get for pkgPath in the module at dir, returning what it wrote
+// to stderr, where go get reports downloads and failures
+func goGet(ctx context.Context, log *logrus.Entry, dir, pkgPath string, progress *progressReporter) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "get", pkgPath)
+	cmd.Dir = dir
+	cmd.Stderr = io.MultiWriter(&stderr, &progressLines{progress: progress, prefix: "go: downloading "})
+	_, span := startSpan(ctx, "go get", attribute.String("package", pkgPath))
+	start := time.Now()
+	err := cmd.Run()
+	endSpan(span, err)
+	log.WithField("duration", time.Since(start)).Debug("go get finished")
+	return stderr.Bytes(), err
+}
+
+// forget drops the project of pkgPath, so the next request for it creates a
+// new one
+func (pm *ProjectManager) forget(pkgPath string) {
+	pm.cache.Delete(pkgPath)
+}
+
+// removeTempDir deletes a temporary project directory
+func (pm *ProjectManager) removeTempDir(dir string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	os.RemoveAll(dir)
+	pm.tempDirs = slices.DeleteFunc(pm.tempDirs, func(s string) bool { return s == dir })
+}
+
+// liveProjects returns the number of temporary projects on disk
+func (pm *ProjectManager) liveProjects() int {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return len(pm.tempDirs)
 }
 
 // cleanup removes all temporary directories and stops the cache
@@ -126,6 +305,9 @@ func (pm *ProjectManager) cleanup() {
 	}
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
+	for _, dir := range pm.tempDirs {
+		os.RemoveAll(dir)
+	}
 	pm.tempDirs = nil
 	pm.cache.DeleteAll()
 	pm.cache.Stop()
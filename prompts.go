@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// promptDef describes a prompt the server offers
+type promptDef struct {
+	prompt  mcp.Prompt
+	handler server.PromptHandlerFunc
+}
+
+// promptDefs lists the documentation workflows offered as prompts
+func (s *GodocServer) promptDefs() []promptDef {
+	return []promptDef{
+		{
+			prompt: mcp.NewPrompt("explain_package",
+				mcp.WithPromptDescription("Explain what a Go package is for and how to use it, with its documentation attached"),
+				mcp.WithArgument("path", mcp.ArgumentDescription("Import path of the package (e.g., 'net/http', 'github.com/user/repo')"), mcp.RequiredArgument()),
+			),
+			handler: s.handleExplainPackage,
+		},
+		{
+			prompt: mcp.NewPrompt("compare_versions",
+				mcp.WithPromptDescription("Compare the API of two versions of a Go module's package, with the documentation of both attached"),
+				mcp.WithArgument("path", mcp.ArgumentDescription("Import path of the package (e.g., 'github.com/user/repo')"), mcp.RequiredArgument()),
+				mcp.WithArgument("old", mcp.ArgumentDescription("Older module version (e.g., 'v1.2.0')"), mcp.RequiredArgument()),
+				mcp.WithArgument("new", mcp.ArgumentDescription("Newer module version (e.g., 'v1.3.0')"), mcp.RequiredArgument()),
+			),
+			handler: s.handleCompareVersions,
+		},
+		{
+			prompt: mcp.NewPrompt("use_symbol",
+				mcp.WithPromptDescription("Show how to use a Go function, type, or method, with its documentation attached"),
+				mcp.WithArgument("path", mcp.ArgumentDescription("Import path of the package declaring the symbol (e.g., 'net/http')"), mcp.RequiredArgument()),
+				mcp.WithArgument("symbol", mcp.ArgumentDescription("Symbol to explain (e.g., 'Client', 'Client.Do', 'Get')"), mcp.RequiredArgument()),
+			),
+			handler: s.handleUseSymbol,
+		},
+	}
+}
+
+// registerPrompts adds the documentation workflow prompts to mcpServer
+func (s *GodocServer) registerPrompts(mcpServer *server.MCPServer) {
+	var prompts []server.ServerPrompt
+	for _, def := range s.promptDefs() {
+		prompts = append(prompts, server.ServerPrompt{Prompt: def.prompt, Handler: def.handler})
+	}
+	mcpServer.AddPrompts(prompts...)
+}
+
+// promptArgs returns the named prompt arguments, failing if any is missing
+func promptArgs(request mcp.GetPromptRequest, names ...string) ([]string, error) {
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = request.Params.Arguments[name]
+		if values[i] == "" {
+			return nil, fmt.Errorf("prompt %s requires the %s argument", request.Params.Name, name)
+		}
+	}
+	return values, nil
+}
+
+// docPrompt builds a prompt of instructions followed by the documentation of uris
+func (s *GodocServer) docPrompt(ctx context.Context, description, instructions string, uris ...docURI) (*mcp.GetPromptResult, error) {
+	messages := []mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(instructions))}
+	for _, u := range uris {
+		// Arguments are held to the same rules as resource URIs
+		u, err := parseDocURI(u.String())
+		if err != nil {
+			return nil, err
+		}
+		contents, err := s.readDocURI(ctx, u)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get documentation for %s: %w", u, err)
+		}
+		messages = append(messages, mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(contents)))
+	}
+	return mcp.NewGetPromptResult(description, messages), nil
+}
+
+// handleExplainPackage implements the explain_package prompt
+func (s *GodocServer) handleExplainPackage(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args, err := promptArgs(request, "path")
+	if err != nil {
+		return nil, err
+	}
+	path := args[0]
+	return s.docPrompt(ctx, "Explain the Go package "+path, fmt.Sprintf(`Explain the Go package %s using its documentation, attached below.
+
+1. Summarize what the package is for in two or three sentences.
+2. Describe its most important types and functions and how they fit together.
+3. Show a short, idiomatic example of typical use.
+4. Point out common pitfalls or caveats the documentation mentions.
+
+Use the get_doc tool to look up any symbol that needs more detail than the overview gives.`, path),
+		docURI{path: path})
+}
+
+// handleCompareVersions implements the compare_versions prompt
+func (s *GodocServer) handleCompareVersions(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args, err := promptArgs(request, "path", "old", "new")
+	if err != nil {
+		return nil, err
+	}
+	path, oldVersion, newVersion := args[0], args[1], args[2]
+	return s.docPrompt(ctx, fmt.Sprintf("Compare %s@%s with %s", path, oldVersion, newVersion), fmt.Sprintf(`Compare the API of the Go package %s at %s and at %s using their documentation, attached below in that order.
+
+1. List symbols added in %[3]s.
+2. List symbols removed, and signatures changed, since %[2]s; these are breaking changes.
+3. Note behavior changes and deprecations described in the documentation.
+4. Advise what code using %[2]s must change to upgrade.`, path, oldVersion, newVersion),
+		docURI{path: path, version: oldVersion}, docURI{path: path, version: newVersion})
+}
+
+// handleUseSymbol implements the use_symbol prompt
+func (s *GodocServer) handleUseSymbol(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args, err := promptArgs(request, "path", "symbol")
+	if err != nil {
+		return nil, err
+	}
+	path, symbol := args[0], args[1]
+	return s.docPrompt(ctx, fmt.Sprintf("How to use %s.%s", path, symbol), fmt.Sprintf(`Show how to use %s from the Go package %s, using its documentation, attached below.
+
+1. Explain what it does, its parameters, and its results, including errors.
+2. Give a complete, runnable example of typical use.
+3. Mention related functions, types, or methods worth knowing, and any caveats the documentation mentions.
+
+Use the get_doc tool to look up related symbols when needed.`, symbol, path),
+		docURI{path: path, symbol: symbol})
+}
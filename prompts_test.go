@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestPrompts(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		handler server.PromptHandlerFunc
+		args    map[string]string
+		wantErr string
+		// want is text the documentation attached to the prompt contains
+		want []string
+	}{
+		{"explain_package", s.handleExplainPackage, map[string]string{"path": "io"}, "", []string{"package io"}},
+		{"explain_package", s.handleExplainPackage, nil, "requires the path argument", nil},
+		{"explain_package", s.handleExplainPackage, map[string]string{"path": "../io"}, "must name an import path", nil},
+		{"use_symbol", s.handleUseSymbol, map[string]string{"path": "io", "symbol": "Reader"}, "", []string{"type Reader interface"}},
+		{"use_symbol", s.handleUseSymbol, map[string]string{"path": "io"}, "requires the symbol argument", nil},
+		{"use_symbol", s.handleUseSymbol, map[string]string{"path": "io", "symbol": "NoSuchSymbol"}, "failed to get documentation", nil},
+		{"compare_versions", s.handleCompareVersions, map[string]string{"path": "example.com/mod", "old": "v1.0.0"}, "requires the new argument", nil},
+	}
+	for _, tt := range tests {
+		var request mcp.GetPromptRequest
+		request.Params.Name = tt.name
+		request.Params.Arguments = tt.args
+		result, err := tt.handler(ctx, request)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s(%v): error %v, want %q", tt.name, tt.args, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s(%v): %v", tt.name, tt.args, err)
+		}
+		if len(result.Messages) != 1+len(tt.want) {
+			t.Fatalf("%s(%v): %d messages, want instructions and %d documents", tt.name, tt.args, len(result.Messages), len(tt.want))
+		}
+		for i, want := range tt.want {
+			res, ok := result.Messages[i+1].Content.(mcp.EmbeddedResource)
+			text, isText := res.Resource.(mcp.TextResourceContents)
+			if !ok || !isText || !strings.Contains(text.Text, want) {
+				t.Errorf("%s(%v): document %d lacks %q: %+v", tt.name, tt.args, i, want, result.Messages[i+1].Content)
+			}
+		}
+	}
+}
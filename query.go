@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const queryUsage = `Usage: godoc-mcp [flags] query [query flags] <path> [target]
+
+Runs a single get_doc lookup through the server's path resolution and caching
+and prints the result, without attaching an MCP client.
+
+Examples:
+  godoc-mcp query net/http Get
+  godoc-mcp query -working-dir /path/to/module ./pkg Type -json
+  godoc-mcp query -all io
+
+Query flags:
+`
+
+// QueryConfig holds the arguments of the query subcommand
+type QueryConfig struct {
+	Path       string
+	Target     string
+	WorkingDir string
+	CmdFlags   []string
+	Page       int
+	PageSize   int
+	JSON       bool
+}
+
+// queryResult is the -json output of the query subcommand
+type queryResult struct {
+	Path         string `json:"path"`
+	Target       string `json:"target,omitempty"`
+	ResolvedPath string `json:"resolved_path,omitempty"`
+	WorkingDir   string `json:"working_dir,omitempty"`
+	Content      string `json:"content"`
+	Error        bool   `json:"error,omitempty"`
+}
+
+// parseQueryArgs parses the query subcommand arguments. Flags may appear before,
+// between, or after the positional path and target.
+func parseQueryArgs(args []string) (*QueryConfig, error) {
+	q := &QueryConfig{}
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), queryUsage)
+		fs.PrintDefaults()
+	}
+	var all, unexported, src bool
+	fs.StringVar(&q.WorkingDir, "working-dir", "", "working directory to resolve relative paths and module context from")
+	fs.BoolVar(&all, "all", false, "show all documentation for the package (go doc -all)")
+	fs.BoolVar(&unexported, "u", false, "show unexported symbols as well as exported (go doc -u)")
+	fs.BoolVar(&src, "src", false, "show the source code (go doc -src)")
+	fs.IntVar(&q.Page, "page", 1, "page number of the result to print")
+	fs.IntVar(&q.PageSize, "page-size", 0, "number of lines per page (default: the server's default page size)")
+	fs.BoolVar(&q.JSON, "json", false, "print the result as JSON")
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	switch len(positional) {
+	case 1:
+		q.Path = positional[0]
+	case 2:
+		q.Path, q.Target = positional[0], positional[1]
+	default:
+		fs.Usage()
+		return nil, fmt.Errorf("query takes a path and an optional target, got %d arguments", len(positional))
+	}
+
+	if all {
+		q.CmdFlags = append(q.CmdFlags, "-all")
+	}
+	if unexported {
+		q.CmdFlags = append(q.CmdFlags, "-u")
+	}
+	if src {
+		q.CmdFlags = append(q.CmdFlags, "-src")
+	}
+	return q, nil
+}
+
+// runQuery performs the lookup described by q, writes the result to w, and
+// reports whether it succeeded
+func (s *GodocServer) runQuery(ctx context.Context, q *QueryConfig, w io.Writer) bool {
+	args := map[string]any{
+		"path": q.Path,
+		"page": q.Page,
+	}
+	if q.PageSize > 0 {
+		args["page_size"] = q.PageSize
+	}
+	if q.Target != "" {
+		args["target"] = q.Target
+	}
+	if q.WorkingDir != "" {
+		args["working_dir"] = q.WorkingDir
+	}
+	if len(q.CmdFlags) > 0 {
+		args["cmd_flags"] = q.CmdFlags
+	}
+	var request mcp.CallToolRequest
+	request.Params.Name = "get_doc"
+	request.Params.Arguments = args
+
+	res := queryResult{Path: q.Path, Target: q.Target, WorkingDir: q.WorkingDir}
+	if resolved, err, _ := s.validatePath(q.Path, q.WorkingDir); err == nil {
+		res.ResolvedPath = resolved
+	}
+
+	result, err := s.withCallID(s.withSlowLog(s.handleToolCall))(ctx, request)
+	if err != nil {
+		res.Content, res.Error = err.Error(), true
+	} else {
+		var text []string
+		for _, c := range result.Content {
+			if tc, ok := c.(mcp.TextContent); ok {
+				text = append(text, tc.Text)
+			}
+		}
+		res.Content, res.Error = strings.Join(text, "\n"), result.IsError
+	}
+
+	if q.JSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(res)
+	} else {
+		fmt.Fprintln(w, res.Content)
+	}
+	return !res.Error
+}
@@ -0,0 +1,575 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+const quickstartDescription = `Assemble a minimal starting point for using a Go package: a main program
+that constructs each of the package's primary types with its simplest constructor, listing the methods
+to call next, type-checked against the package, followed by the package's best self-contained example.
+Primary types are picked by their constructors, methods, and examples unless types names them. Use it
+before writing code against an unfamiliar package, such as path "net/http".`
+
+// Limits on quickstart requests
+const (
+	// quickstartTypes is the number of primary types picked when types isn't given
+	quickstartTypes = 3
+	// quickstartMethods is the number of methods listed for each type
+	quickstartMethods = 6
+)
+
+// quickstartSchema is the quickstart input schema
+var quickstartSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Package to start using, as for get_doc (e.g., 'net/http' or 'github.com/user/repo').",
+		},
+		"types": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "Optional: The types to construct, in order (e.g., ['Client', 'Request']). Defaults to the package's primary types.",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory for relative paths and the module's own packages. Defaults to the session working directory.",
+		},
+	},
+	Required: []string{"path"},
+}
+
+// quickstartOutputSchema is the outputSchema of quickstart, describing quickstartOutput
+var quickstartOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"package":      map[string]any{"type": "string", "description": "Import path of the package"},
+		"types":        map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "The types the program constructs"},
+		"program":      map[string]any{"type": "string", "description": "The main program constructing the types"},
+		"checked":      map[string]any{"type": "boolean", "description": "Whether the program type-checks against the package"},
+		"check_error":  map[string]any{"type": "string", "description": "Why the program doesn't type-check, when it doesn't"},
+		"example":      map[string]any{"type": "string", "description": "Name of the example chosen, if the package has a self-contained one"},
+		"example_code": map[string]any{"type": "string", "description": "The example as a complete program"},
+	},
+	Required: []string{"package", "types", "program", "checked"},
+}
+
+// quickstartOutput is the structured content of a quickstart result
+type quickstartOutput struct {
+	Package     string   `json:"package"`
+	Types       []string `json:"types"`
+	Program     string   `json:"program"`
+	Checked     bool     `json:"checked"`
+	CheckError  string   `json:"check_error,omitempty"`
+	Example     string   `json:"example,omitempty"`
+	ExampleCode string   `json:"example_code,omitempty"`
+}
+
+// handleQuickstart implements the quickstart tool
+func (s *GodocServer) handleQuickstart(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path := request.GetString("path", "")
+	if path == "" {
+		return errorResult(codeInvalidArgument, "invalid or missing path parameter"), nil
+	}
+	if err := s.runtime.Load().toolchainErr; err != nil {
+		return errorResultFromErr("cannot load packages", err), nil
+	}
+	dir, err := s.loadDir(ctx, request, path)
+	if err != nil {
+		return errorResultFromErr("failed to find the source of "+path, err), nil
+	}
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return errorResultFromErr("cannot load "+path, err), nil
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps | packages.NeedTypes,
+		Dir:     dir,
+	}, path)
+	release()
+	if err != nil {
+		return errorResultFromErr("failed to load "+path, err), nil
+	}
+	if len(pkgs) != 1 {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("%s names %d packages; quickstart takes one", path, len(pkgs))), nil
+	}
+	pkg := pkgs[0]
+	if pkg.Types == nil || len(pkg.Syntax) == 0 {
+		msg := "no Go files in " + path
+		if len(pkg.Errors) > 0 {
+			msg = pkg.Errors[0].Msg
+		}
+		return errorResult(cmp.Or(classifyOutput(msg), codePkgNotFound), msg), nil
+	}
+	if pkg.Name == "main" {
+		return errorResult(codeInvalidArgument, path+" is a command, which can't be imported"), nil
+	}
+
+	files := slices.Clone(pkg.Syntax)
+	files = append(files, parseTestFiles(pkg.Fset, filepath.Dir(pkg.GoFiles[0]))...)
+	dp, err := doc.NewFromFiles(pkg.Fset, files, pkg.PkgPath)
+	if err != nil {
+		return errorResultFromErr("failed to read the documentation of "+path, err), nil
+	}
+
+	var picked []*doc.Type
+	if names := request.GetStringSlice("types", nil); len(names) > 0 {
+		for _, name := range names {
+			i := slices.IndexFunc(dp.Types, func(t *doc.Type) bool { return t.Name == name })
+			if i < 0 {
+				return errorResult(codeSymbolNotFound, fmt.Sprintf("%s has no exported type %s", pkg.PkgPath, name)), nil
+			}
+			picked = append(picked, dp.Types[i])
+		}
+	} else {
+		picked = primaryTypes(dp, pkg.Types)
+	}
+
+	out := &quickstartOutput{Package: pkg.PkgPath, Types: []string{}}
+	for _, t := range picked {
+		out.Types = append(out.Types, t.Name)
+	}
+	out.Program = quickstartProgram(dp, pkg.Types, picked)
+	if err := checkProgram(out.Program, pkg.Types); err != nil {
+		out.CheckError = err.Error()
+	} else {
+		out.Checked = true
+	}
+	if ex := bestExample(dp, picked); ex != nil {
+		var buf bytes.Buffer
+		if err := format.Node(&buf, pkg.Fset, ex.Play); err == nil {
+			out.Example, out.ExampleCode = exampleName(ex), buf.String()
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Quickstart for %s", pkg.PkgPath)
+	if len(out.Types) > 0 {
+		fmt.Fprintf(&sb, ", constructing %s", strings.Join(out.Types, ", "))
+	}
+	if out.Checked {
+		sb.WriteString("; the program type-checks against the package\n\n")
+	} else {
+		fmt.Fprintf(&sb, "; the program does not type-check, so adjust it before use: %s\n\n", out.CheckError)
+	}
+	sb.WriteString(out.Program)
+	if out.Example != "" {
+		fmt.Fprintf(&sb, "\nEXAMPLE %s, from the package's tests\n\n%s", out.Example, out.ExampleCode)
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// parseTestFiles parses the test files in dir into fset, for their examples
+func parseTestFiles(fset *token.FileSet, dir string) []*ast.File {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []*ast.File
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		if f, err := parser.ParseFile(fset, filepath.Join(dir, e.Name()), nil, parser.ParseComments); err == nil {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// primaryTypes returns the types of dp a new user of the package most likely
+// needs: the constructible types with the most constructors, methods, and
+// examples
+func primaryTypes(dp *doc.Package, pkg *types.Package) []*doc.Type {
+	score := func(t *doc.Type) int {
+		n := 3*len(t.Funcs) + len(t.Methods) + 2*len(t.Examples)
+		for _, fn := range slices.Concat(t.Funcs, t.Methods) {
+			n += 2 * len(fn.Examples)
+		}
+		return n
+	}
+	var candidates []*doc.Type
+	for _, t := range dp.Types {
+		named, ok := pkg.Scope().Lookup(t.Name).(*types.TypeName)
+		if !ok || named.IsAlias() {
+			continue
+		}
+		if _, ok := typeArguments(typeParams(named.Type())); !ok {
+			continue
+		}
+		if types.IsInterface(named.Type()) && len(constructors(t, pkg)) == 0 {
+			continue
+		}
+		if score(t) > 0 {
+			candidates = append(candidates, t)
+		}
+	}
+	// Ties keep declaration order
+	slices.SortStableFunc(candidates, func(a, b *doc.Type) int { return score(b) - score(a) })
+	return candidates[:min(len(candidates), quickstartTypes)]
+}
+
+// constructors returns the constructors of t that can be called, most
+// conventional first: New followed by the type's name, then other New
+// functions, each by their number of parameters
+func constructors(t *doc.Type, pkg *types.Package) []*types.Func {
+	var funcs []*types.Func
+	for _, fn := range t.Funcs {
+		f, ok := pkg.Scope().Lookup(fn.Name).(*types.Func)
+		if !ok {
+			continue
+		}
+		if _, ok := typeArguments(f.Signature().TypeParams()); ok {
+			funcs = append(funcs, f)
+		}
+	}
+	rank := func(f *types.Func) int {
+		switch {
+		case f.Name() == "New"+t.Name:
+			return 0
+		case strings.HasPrefix(f.Name(), "New"):
+			return 1
+		}
+		return 2
+	}
+	slices.SortStableFunc(funcs, func(a, b *types.Func) int {
+		return cmp.Or(rank(a)-rank(b), a.Signature().Params().Len()-b.Signature().Params().Len())
+	})
+	return funcs
+}
+
+// quickstartProgram returns a main program constructing each of picked, with
+// its first sentence of documentation and the methods to call next
+func quickstartProgram(dp *doc.Package, pkg *types.Package, picked []*doc.Type) string {
+	imports := map[string]string{pkg.Path(): pkg.Name()}
+	qualify := func(p *types.Package) string {
+		imports[p.Path()] = p.Name()
+		return p.Name()
+	}
+	// Variables mustn't shadow the packages they are constructed with
+	taken := map[string]bool{pkg.Name(): true, "err": true}
+	for _, imp := range pkg.Imports() {
+		taken[imp.Name()] = true
+	}
+
+	var body strings.Builder
+	var vars []string
+	for _, t := range picked {
+		name := varName(t.Name, taken)
+		if line := dp.Synopsis(t.Doc); line != "" {
+			body.WriteString("\t// " + line + "\n")
+		}
+		named := pkg.Scope().Lookup(t.Name).Type()
+		typ := types.TypeString(named, qualify)
+		if inst, ok := instantiate(named, typeParams(named)); ok {
+			typ = types.TypeString(inst, qualify)
+		}
+		if ctors := constructors(t, pkg); len(ctors) > 0 {
+			lhs, call, withErr := constructorCall(ctors[0], named, name, qualify)
+			fmt.Fprintf(&body, "\t%s := %s\n", lhs, call)
+			if withErr {
+				body.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+			}
+		} else if _, ok := named.Underlying().(*types.Struct); ok {
+			ptr := slices.ContainsFunc(t.Methods, func(fn *doc.Func) bool { return strings.HasPrefix(fn.Recv, "*") })
+			if ptr {
+				fmt.Fprintf(&body, "\t%s := &%s{}\n", name, typ)
+			} else {
+				fmt.Fprintf(&body, "\t%s := %s{}\n", name, typ)
+			}
+		} else {
+			fmt.Fprintf(&body, "\tvar %s %s\n", name, typ)
+		}
+		if len(t.Methods) > 0 {
+			var calls []string
+			for _, fn := range t.Methods[:min(len(t.Methods), quickstartMethods)] {
+				calls = append(calls, name+"."+fn.Name)
+			}
+			if more := len(t.Methods) - len(calls); more > 0 {
+				calls = append(calls, fmt.Sprintf("and %d more", more))
+			}
+			body.WriteString("\t// Then: " + strings.Join(calls, ", ") + "\n")
+		}
+		body.WriteString("\n")
+		vars = append(vars, name)
+	}
+	// Packages without types to construct are shown by their functions
+	if len(picked) == 0 && len(dp.Funcs) > 0 {
+		var funcs []string
+		for _, fn := range dp.Funcs[:min(len(dp.Funcs), quickstartMethods)] {
+			funcs = append(funcs, pkg.Name()+"."+fn.Name)
+		}
+		if more := len(dp.Funcs) - len(funcs); more > 0 {
+			funcs = append(funcs, fmt.Sprintf("and %d more", more))
+		}
+		body.WriteString("\t// Functions: " + strings.Join(funcs, ", ") + "\n")
+		fmt.Fprintf(&body, "\t_ = %s.%s\n", pkg.Name(), dp.Funcs[0].Name)
+	}
+	if len(vars) > 0 {
+		blanks := strings.TrimSuffix(strings.Repeat("_, ", len(vars)), ", ")
+		fmt.Fprintf(&body, "\t%s = %s\n", blanks, strings.Join(vars, ", "))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("package main\n\nimport (\n")
+	paths := slices.Sorted(func(yield func(string) bool) {
+		for p := range imports {
+			if !yield(p) {
+				return
+			}
+		}
+	})
+	for _, p := range paths {
+		if imports[p] != assumedPackageName(p) {
+			fmt.Fprintf(&sb, "\t%s %q\n", imports[p], p)
+		} else {
+			fmt.Fprintf(&sb, "\t%q\n", p)
+		}
+	}
+	sb.WriteString(")\n\nfunc main() {\n" + strings.TrimRight(body.String(), "\n") + "\n}\n")
+	if src, err := format.Source([]byte(sb.String())); err == nil {
+		return string(src)
+	}
+	return sb.String()
+}
+
+// constructorCall returns the left-hand side and call of a short variable
+// declaration constructing name, of type typ, with f, passing zero values
+// named after the parameters, and whether it declares err
+func constructorCall(f *types.Func, typ types.Type, name string, qualify types.Qualifier) (string, string, bool) {
+	sig := f.Signature()
+	var typeArgs string
+	if tparams := sig.TypeParams(); tparams.Len() > 0 {
+		if inst, ok := instantiate(sig, tparams); ok {
+			sig = inst.(*types.Signature)
+			typeArgs = "[" + strings.TrimSuffix(strings.Repeat("string, ", tparams.Len()), ", ") + "]"
+		}
+	}
+	var args []string
+	for i := range sig.Params().Len() {
+		p := sig.Params().At(i)
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			break
+		}
+		arg := zeroValue(p.Type(), qualify)
+		if p.Name() != "" && p.Name() != "_" {
+			arg += " /* " + p.Name() + " */"
+		}
+		args = append(args, arg)
+	}
+	var lhs []string
+	constructed, withErr := false, false
+	for i := range sig.Results().Len() {
+		r := sig.Results().At(i).Type()
+		switch {
+		case !constructed && constructs(r, typ):
+			lhs, constructed = append(lhs, name), true
+		case !withErr && types.Identical(r, types.Universe.Lookup("error").Type()):
+			lhs, withErr = append(lhs, "err"), true
+		default:
+			lhs = append(lhs, "_")
+		}
+	}
+	call := qualify(f.Pkg()) + "." + f.Name() + typeArgs + "(" + strings.Join(args, ", ") + ")"
+	return strings.Join(lhs, ", "), call, withErr
+}
+
+// constructs reports whether a result of type r is a value of, or pointer
+// to, typ or an instance of it
+func constructs(r, typ types.Type) bool {
+	if p, ok := r.(*types.Pointer); ok {
+		r = p.Elem()
+	}
+	if types.Identical(r, typ) {
+		return true
+	}
+	n, ok := r.(*types.Named)
+	want, isNamed := typ.(*types.Named)
+	return ok && isNamed && n.Origin() == want.Origin()
+}
+
+// typeParams returns the type parameters of t, if it is a generic named type
+func typeParams(t types.Type) *types.TypeParamList {
+	if n, ok := t.(*types.Named); ok {
+		return n.TypeParams()
+	}
+	return nil
+}
+
+// typeArguments returns string as the argument of each of tparams, which
+// satisfies the usual any and comparable constraints, or false if a
+// constraint rules it out
+func typeArguments(tparams *types.TypeParamList) ([]types.Type, bool) {
+	var args []types.Type
+	for i := range tparams.Len() {
+		iface, ok := tparams.At(i).Constraint().Underlying().(*types.Interface)
+		if !ok || !types.Satisfies(types.Typ[types.String], iface) {
+			return nil, false
+		}
+		args = append(args, types.Typ[types.String])
+	}
+	return args, true
+}
+
+// instantiate returns t, a generic type or signature with the type
+// parameters tparams, instantiated with typeArguments, or false if it can't be
+func instantiate(t types.Type, tparams *types.TypeParamList) (types.Type, bool) {
+	args, ok := typeArguments(tparams)
+	if !ok || len(args) == 0 {
+		return nil, false
+	}
+	inst, err := types.Instantiate(nil, t, args, true)
+	return inst, err == nil
+}
+
+// zeroValue returns an expression of the zero value of t
+func zeroValue(t types.Type, qualify types.Qualifier) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+	case *types.Struct, *types.Array:
+		return types.TypeString(t, qualify) + "{}"
+	}
+	return "nil"
+}
+
+// varName returns a variable name for a value of the type typeName that
+// isn't taken, and takes it
+func varName(typeName string, taken map[string]bool) string {
+	// Leading initialisms are lowered whole: URL becomes url, HTTPClient httpClient
+	runes := []rune(typeName)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	if i > 1 && i < len(runes) {
+		i--
+	}
+	name := strings.ToLower(string(runes[:i])) + string(runes[i:])
+	if token.IsKeyword(name) || types.Universe.Lookup(name) != nil || taken[name] {
+		name = "my" + typeName
+	}
+	for n := 2; taken[name]; n++ {
+		name = "my" + typeName + strconv.Itoa(n)
+	}
+	taken[name] = true
+	return name
+}
+
+// checkProgram type-checks program, importing pkg and the packages it
+// imports from their loaded types
+func checkProgram(program string, pkg *types.Package) error {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", program, 0)
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]*types.Package)
+	var collect func(p *types.Package)
+	collect = func(p *types.Package) {
+		if _, ok := loaded[p.Path()]; ok {
+			return
+		}
+		loaded[p.Path()] = p
+		for _, imp := range p.Imports() {
+			collect(imp)
+		}
+	}
+	collect(pkg)
+	conf := types.Config{Importer: importerFunc(func(path string) (*types.Package, error) {
+		if p, ok := loaded[path]; ok {
+			return p, nil
+		}
+		return nil, fmt.Errorf("package %s is not imported by %s", path, pkg.Path())
+	})}
+	var errs []error
+	conf.Error = func(err error) { errs = append(errs, err) }
+	conf.Check("main", fset, []*ast.File{f}, nil)
+	return errors.Join(errs...)
+}
+
+// importerFunc implements types.Importer with a function
+type importerFunc func(path string) (*types.Package, error)
+
+// Import implements types.Importer
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// bestExample returns the self-contained example of dp most worth starting
+// from: the package's own, then those of the picked types in order, then
+// any other, preferring examples with checked output. It returns nil if
+// none is self-contained.
+func bestExample(dp *doc.Package, picked []*doc.Type) *doc.Example {
+	var ordered [][]*doc.Example
+	ordered = append(ordered, dp.Examples)
+	typeExamples := func(t *doc.Type) []*doc.Example {
+		examples := slices.Clone(t.Examples)
+		for _, fn := range slices.Concat(t.Funcs, t.Methods) {
+			examples = append(examples, fn.Examples...)
+		}
+		return examples
+	}
+	for _, t := range picked {
+		ordered = append(ordered, typeExamples(t))
+	}
+	for _, fn := range dp.Funcs {
+		ordered = append(ordered, fn.Examples)
+	}
+	for _, t := range dp.Types {
+		if !slices.Contains(picked, t) {
+			ordered = append(ordered, typeExamples(t))
+		}
+	}
+	var fallback *doc.Example
+	for _, examples := range ordered {
+		for _, ex := range examples {
+			if ex.Play == nil {
+				continue
+			}
+			if ex.Output != "" || ex.EmptyOutput {
+				return ex
+			}
+			if fallback == nil {
+				fallback = ex
+			}
+		}
+	}
+	return fallback
+}
+
+// exampleName returns the name of the function declaring ex
+func exampleName(ex *doc.Example) string {
+	name := "Example" + ex.Name
+	if ex.Suffix != "" && !strings.HasSuffix(name, "_"+ex.Suffix) {
+		name += "_" + ex.Suffix
+	}
+	return name
+}
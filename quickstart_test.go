@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestQuickstart(t *testing.T) {
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/mod\n\ngo 1.21\n",
+		"store/store.go": `// Package store stores values
+package store
+
+// Store is a persistent key-value store.
+type Store struct{ path string }
+
+// NewStore opens the store at path
+func NewStore(path string, opts ...Option) (*Store, error) { return &Store{path: path}, nil }
+
+func (s *Store) Get(key string) string { return "" }
+
+func (s *Store) Put(key, value string) {}
+
+// Option configures a Store.
+type Option func(*Store)
+
+func WithSize(n int) Option { return nil }
+
+// Cache is an in-memory cache.
+type Cache[K comparable, V any] struct{ m map[K]V }
+
+func NewCache[K comparable, V any]() *Cache[K, V] { return &Cache[K, V]{} }
+
+func (c *Cache[K, V]) Get(key K) V { return c.m[key] }
+`,
+		"store/example_test.go": `package store_test
+
+import (
+	"fmt"
+
+	"example.com/mod/store"
+)
+
+func ExampleStore_Get() {
+	s, _ := store.NewStore("data")
+	fmt.Println(s.Get("key") == "")
+	// Output: true
+}
+`,
+		"util/util.go":     "package util\n\nfunc Add(a, b int) int { return a + b }\n\nfunc Sub(a, b int) int { return a - b }\n",
+		"cmd/tool/main.go": "package main\n\nfunc main() {}\n",
+	})
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		types    []string
+		want     []string
+		example  string
+	}{
+		{"no path", map[string]any{"working_dir": dir}, codeInvalidArgument, nil, nil, ""},
+		{"command", map[string]any{"path": "./cmd/tool", "working_dir": dir}, codeInvalidArgument, nil, nil, ""},
+		{"missing type", map[string]any{"path": "./store", "types": []any{"Missing"}, "working_dir": dir}, codeSymbolNotFound, nil, nil, ""},
+		{"primary types", map[string]any{"path": "./store", "working_dir": dir}, "", []string{"Store", "Cache", "Option"}, []string{
+			"\t// Store is a persistent key-value store.\n\tmyStore, err := store.NewStore(\"\" /* path */)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n\t// Then: myStore.Get, myStore.Put\n",
+			"\tcache := store.NewCache[string, string]()\n",
+			"\toption := store.WithSize(0 /* n */)\n",
+			"\t_, _, _ = myStore, cache, option\n",
+		}, "ExampleStore_Get"},
+		{"named types", map[string]any{"path": "./store", "types": []any{"Option"}, "working_dir": dir}, "", []string{"Option"}, []string{
+			"\toption := store.WithSize(0 /* n */)\n",
+		}, "ExampleStore_Get"},
+		{"functions", map[string]any{"path": "./util", "working_dir": dir}, "", []string{}, []string{
+			"\t// Functions: util.Add, util.Sub\n\t_ = util.Add\n",
+		}, ""},
+		{"standard library", map[string]any{"path": "strings"}, "", nil, []string{"\"strings\""}, ""},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleQuickstart, "quickstart", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*quickstartOutput)
+		if !out.Checked {
+			t.Errorf("%s: program does not type-check: %s\n%s", tt.name, out.CheckError, out.Program)
+		}
+		if tt.types != nil && !slices.Equal(out.Types, tt.types) {
+			t.Errorf("%s: constructs %q, want %q", tt.name, out.Types, tt.types)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out.Program, want) {
+				t.Errorf("%s: program lacks %q:\n%s", tt.name, want, out.Program)
+			}
+		}
+		if tt.example != "" && (out.Example != tt.example || !strings.Contains(out.ExampleCode, "func main() {")) {
+			t.Errorf("%s: example %s, want %s:\n%s", tt.name, out.Example, tt.example, out.ExampleCode)
+		}
+	}
+}
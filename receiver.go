@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"go/doc"
+	"go/format"
+	"slices"
+	"strings"
+)
+
+// receiverArgument is the input schema of the get_doc receiver argument
+var receiverArgument = map[string]any{
+	"type":        "boolean",
+	"description": "Optional: When target is a method such as 'Client.Do', precede its documentation with a brief section on the receiver type: its declaration with fields, the first sentence of its documentation, and its constructors, so the method can be used without a second call.",
+	"default":     false,
+}
+
+// withReceiver returns doc, the text documentation of the method req
+// targets, preceded by a brief section documenting its receiver type. Targets
+// that aren't methods of a type of the package return doc unchanged.
+func (s *GodocServer) withReceiver(ctx context.Context, req docRequest, methodDoc cachedDoc) cachedDoc {
+	typeName, method, ok := strings.Cut(req.target, ".")
+	if !ok || len(req.targets) > 0 || req.format != formatText || req.source != "" {
+		return methodDoc
+	}
+	pd := s.structuredDoc(ctx, req.cacheScope, req.workingDir, formatText, cachedDoc{}, []string{req.path})
+	if pd == nil {
+		return methodDoc
+	}
+	i := slices.IndexFunc(pd.Types, func(t typeDoc) bool { return t.Name == typeName })
+	if i < 0 || !slices.ContainsFunc(pd.Types[i].Methods, func(fn funcDoc) bool { return fn.Name == method }) {
+		return methodDoc
+	}
+	t := pd.Types[i]
+
+	var sb strings.Builder
+	sb.WriteString("RECEIVER TYPE\n\n")
+	sb.WriteString(briefDecl(t.Decl) + "\n")
+	var synopsis doc.Package
+	if line := synopsis.Synopsis(t.Doc); line != "" {
+		sb.WriteString("    " + line + "\n")
+	}
+	if len(t.Funcs) > 0 {
+		sb.WriteString("\n")
+		for _, fn := range t.Funcs {
+			sb.WriteString(fn.Decl + "\n")
+		}
+	}
+	sb.WriteString("\nMETHOD\n\n")
+	sb.WriteString(strings.Join(methodDoc.lines, "\n"))
+
+	combined := newCachedDoc(sb.String())
+	combined.stderr = methodDoc.stderr
+	return combined
+}
+
+// briefDecl returns the type declaration decl without its field comments and
+// the blank lines between fields, keeping go doc's note on unexported fields,
+// with the remaining fields aligned again
+func briefDecl(decl string) string {
+	var kept []string
+	for _, line := range strings.Split(decl, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") && !strings.Contains(trimmed, "unexported") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	brief := strings.Join(kept, "\n")
+	const header = "package p\n\n"
+	if src, err := format.Source([]byte(header + brief)); err == nil {
+		return strings.TrimSpace(strings.TrimPrefix(string(src), header))
+	}
+	return brief
+}
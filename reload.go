@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+// configPollInterval is how often the config file is checked for changes
+const configPollInterval = 5 * time.Second
+
+// watchReload reloads the configuration whenever the process receives SIGHUP or
+// the config file is modified, until ctx is cancelled
+func (s *GodocServer) watchReload(ctx context.Context, mcpServer *server.MCPServer, args []string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	poll := time.NewTicker(configPollInterval)
+	defer poll.Stop()
+	modified := configModTime(s.config.Load().ConfigFile)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case <-poll.C:
+			mtime := configModTime(s.config.Load().ConfigFile)
+			if mtime.Equal(modified) {
+				continue
+			}
+			modified = mtime
+			s.logger.Info("Config file changed, reloading")
+		}
+		if err := s.reload(mcpServer, args); err != nil {
+			s.logger.WithError(err).Error("Config reload failed, keeping current configuration")
+		}
+	}
+}
+
+// configModTime returns when the config file was last modified, or the zero
+// time if there is none
+func configModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// reload re-reads the configuration from args, the environment, and the config
+// file and applies every setting that can change without dropping connections
+func (s *GodocServer) reload(mcpServer *server.MCPServer, args []string) error {
+	cfg, err := loadConfig(args)
+	if err == nil {
+		err = cfg.validate()
+	}
+	if err != nil {
+		return err
+	}
+
+	old := s.config.Load()
+	if changed := startupSettingsChanged(old, cfg); len(changed) > 0 {
+		s.logger.WithField("settings", changed).Warn("Config reload changed settings that take effect after a restart")
+	}
+
+	s.config.Store(cfg)
+	applyLogConfig(s.logger, cfg)
+	s.limiter.resize(cfg.MaxSubprocesses, cfg.MaxQueued, cfg.QueueTimeout)
+	s.workers.resize(cfg.MaxWorkers, cfg.MaxQueued, cfg.QueueTimeout)
+	s.projectManager.setTTL(cfg.ProjectTTL)
+	if cfg.GoplsPath != old.GoplsPath || !slices.Equal(cfg.GoplsWorkspaces, old.GoplsWorkspaces) {
+		s.logger.WithField("workspaces", cfg.GoplsWorkspaces).Info("Restarting gopls for changed workspaces")
+		s.gopls.restart(cfg.GoplsPath, cfg.GoplsWorkspaces)
+	}
+	// The environment may have changed too, such as a go toolchain installed
+	s.setRuntimeCaps(detectRuntimeCaps(context.Background(), cfg))
+	s.registerTools(mcpServer)
+	if !slices.Equal(cfg.IndexWorkspaces, old.IndexWorkspaces) {
+		go s.warmSymbolIndex(context.Background())
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"config_file":      cfg.ConfigFile,
+		"log_level":        cfg.LogLevel,
+		"cache_ttl":        cfg.CacheTTL,
+		"project_ttl":      cfg.ProjectTTL,
+		"max_subprocesses": cfg.MaxSubprocesses,
+	}).Info("Configuration reloaded")
+	return nil
+}
+
+// startupSettingsChanged returns the flag names of the settings that differ
+// between two configurations but are only applied at startup: listeners, the
+// web UI, shutdown draining, profiling, metrics, and the cache size budget
+func startupSettingsChanged(a, b *Config) []string {
+	var changed []string
+	check := func(name string, same bool) {
+		if !same {
+			changed = append(changed, name)
+		}
+	}
+	check("http", a.HTTPAddr == b.HTTPAddr)
+	check("http-transport", a.HTTPTransport == b.HTTPTransport)
+	check("base-path", a.BasePath == b.BasePath)
+	check("advertise-url", a.AdvertiseURL == b.AdvertiseURL)
+	check("trust-forwarded", a.TrustForwarded == b.TrustForwarded)
+	check("cors-origins", slices.Equal(a.CORS.AllowedOrigins, b.CORS.AllowedOrigins))
+	check("cors-methods", slices.Equal(a.CORS.AllowedMethods, b.CORS.AllowedMethods))
+	check("cors-credentials", a.CORS.AllowCredentials == b.CORS.AllowCredentials)
+	check("web-ui", a.WebUI == b.WebUI)
+	check("drain-timeout", a.DrainTimeout == b.DrainTimeout)
+	check("pprof", a.PprofAddr == b.PprofAddr)
+	check("profile-dir", a.ProfileDir == b.ProfileDir)
+	check("profile-interval", a.ProfileInterval == b.ProfileInterval)
+	check("metrics-interval", a.MetricsInterval == b.MetricsInterval)
+	check("cache-max-bytes", a.CacheMaxBytes == b.CacheMaxBytes)
+	return changed
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+// docScheme prefixes the URIs of documentation resources
+const docScheme = "godoc://"
+
+// docURI identifies the documentation of a package, or of one of its symbols,
+// optionally at a specific module version:
+//
+//	godoc://net/http
+//	godoc://net/http#Client.Do
+//	godoc://github.com/user/repo@v1.2.3#TypeName
+type docURI struct {
+	path    string
+	version string
+	symbol  string
+}
+
+// parseDocURI parses a godoc:// resource URI
+func parseDocURI(uri string) (docURI, error) {
+	rest, ok := strings.CutPrefix(uri, docScheme)
+	if !ok {
+		return docURI{}, fmt.Errorf("resource URI %q must start with %s", uri, docScheme)
+	}
+	rest, symbol, _ := strings.Cut(rest, "#")
+	path, version, _ := strings.Cut(rest, "@")
+	var err error
+	if path, err = url.PathUnescape(path); err != nil {
+		return docURI{}, fmt.Errorf("invalid resource URI %q: %v", uri, err)
+	}
+	if symbol, err = url.PathUnescape(symbol); err != nil {
+		return docURI{}, fmt.Errorf("invalid resource URI %q: %v", uri, err)
+	}
+	path = strings.Trim(path, "/")
+	switch {
+	case path == "":
+		return docURI{}, fmt.Errorf("resource URI %q names no package", uri)
+	case strings.HasPrefix(path, "."):
+		return docURI{}, fmt.Errorf("resource URI %q must name an import path, not a relative path", uri)
+	case version != "" && isStdLib(path):
+		return docURI{}, fmt.Errorf("resource URI %q pins a version of a standard library package", uri)
+	}
+	return docURI{path: path, version: version, symbol: symbol}, nil
+}
+
+func (u docURI) String() string {
+	uri := docScheme + u.path
+	if u.version != "" {
+		uri += "@" + u.version
+	}
+	if u.symbol != "" {
+		uri += "#" + u.symbol
+	}
+	return uri
+}
+
+// docTemplates are the resource templates clients can fill in to read
+// documentation. Reads parse the full URI, so any template matching a URI serves
+// it the same way.
+var docTemplates = []mcp.ResourceTemplate{
+	mcp.NewResourceTemplate(docScheme+"{+importPath}", "Go package documentation",
+		mcp.WithTemplateDescription("Documentation of a Go package by import path, such as godoc://net/http or godoc://github.com/user/repo"),
+		mcp.WithTemplateMIMEType("text/plain"),
+	),
+	mcp.NewResourceTemplate(docScheme+"{+importPath}#{symbol}", "Go symbol documentation",
+		mcp.WithTemplateDescription("Documentation of a function, type, method, or field in a Go package, such as godoc://net/http#Client.Do"),
+		mcp.WithTemplateMIMEType("text/plain"),
+	),
+	mcp.NewResourceTemplate(docScheme+"{+importPath}@{version}{#symbol}", "Go documentation at a module version",
+		mcp.WithTemplateDescription("Documentation of a Go package, or one of its symbols, at a specific module version, such as godoc://github.com/user/repo@v1.2.3#TypeName"),
+		mcp.WithTemplateMIMEType("text/plain"),
+	),
+}
+
+// registerResources offers documentation of any package or symbol through the
+// godoc:// templates
+func (s *GodocServer) registerResources(mcpServer *server.MCPServer) {
+	var templates []server.ServerResourceTemplate
+	for _, template := range docTemplates {
+		templates = append(templates, server.ServerResourceTemplate{Template: template, Handler: s.handleReadResource})
+	}
+	mcpServer.AddResourceTemplates(templates...)
+}
+
+// registerStdResources lists every public standard library package as a resource
+// once the index is built, notifying connected clients of the change
+func (s *GodocServer) registerStdResources(mcpServer *server.MCPServer) {
+	if !s.stdlib.ready() {
+		return
+	}
+	var resources []server.ServerResource
+	for _, importPath := range s.stdlib.publicPackages() {
+		sp, _ := s.stdlib.lookup(importPath)
+		resources = append(resources, server.ServerResource{
+			Resource: mcp.NewResource(docURI{path: importPath}.String(), importPath,
+				mcp.WithResourceDescription(sp.synopsis),
+				mcp.WithMIMEType("text/plain"),
+			),
+			Handler: s.handleReadResource,
+		})
+	}
+	mcpServer.AddResources(resources...)
+	s.logger.WithField("resources", len(resources)).Info("Added standard library resources")
+}
+
+// handleReadResource implements resources/read for godoc:// URIs
+func (s *GodocServer) handleReadResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log := ctxLogger(ctx, s.logger).WithField("uri", request.Params.URI)
+	log.Debug("Reading documentation resource")
+
+	u, err := parseDocURI(request.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := s.readDocURI(ctx, u)
+	if err != nil {
+		log.WithError(err).Debug("Failed to read documentation resource")
+		return nil, err
+	}
+	contents.URI = request.Params.URI
+	return []mcp.ResourceContents{contents}, nil
+}
+
+// readDocURI returns the documentation identified by u as text resource contents
+func (s *GodocServer) readDocURI(ctx context.Context, u docURI) (mcp.TextResourceContents, error) {
+	// Resources are backed by get_doc and follow its configuration
+	if !s.toolAllowed("get_doc") {
+		return mcp.TextResourceContents{}, fmt.Errorf("documentation resources are disabled by configuration")
+	}
+
+	release, err := s.workers.acquire(ctx)
+	if err != nil {
+		return mcp.TextResourceContents{}, err
+	}
+	defer release()
+
+	uri := u.String()
+	glob := isGlobTarget(u.symbol)
+	if isStdLib(u.path) {
+		checkTarget := u.symbol
+		if glob {
+			checkTarget = ""
+		}
+		if u.path, err = s.checkStdLib(u.path, checkTarget, nil, false); err != nil {
+			return mcp.TextResourceContents{}, err
+		}
+	}
+	project := u.path
+	if u.version != "" {
+		project += "@" + u.version
+	}
+	workingDir, err := s.projectManager.GetOrCreateProject(ctx, project)
+	if err != nil {
+		return mcp.TextResourceContents{}, err
+	}
+	var doc cachedDoc
+	if glob {
+		doc, _, _, _, err = s.runTargetsDoc(ctx, "", workingDir, formatText, nil, u.path, []string{u.symbol})
+	} else {
+		args := []string{u.path}
+		if u.symbol != "" {
+			args = append(args, u.symbol)
+		}
+		doc, err = s.runGoDoc(ctx, "", workingDir, formatText, args...)
+	}
+	if err != nil {
+		return mcp.TextResourceContents{}, err
+	}
+	ctxLogger(ctx, s.logger).WithFields(logrus.Fields{
+		"uri":    uri,
+		"doc_id": doc.id,
+		"bytes":  doc.byteSize,
+	}).Debug("Read documentation resource")
+	return mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: "text/plain",
+		Text:     strings.Join(doc.lines, "\n"),
+	}, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestParseDocURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		want    docURI
+		wantErr bool
+	}{
+		{uri: "godoc://net/http", want: docURI{path: "net/http"}},
+		{uri: "godoc://net/http#Client.Do", want: docURI{path: "net/http", symbol: "Client.Do"}},
+		{uri: "godoc://github.com/user/repo@v1.2.3#TypeName", want: docURI{path: "github.com/user/repo", version: "v1.2.3", symbol: "TypeName"}},
+		{uri: "godoc://github.com/user/repo@latest", want: docURI{path: "github.com/user/repo", version: "latest"}},
+		{uri: "godoc://net/http/", want: docURI{path: "net/http"}},
+		{uri: "godoc://example.com/with%20space#Sym%2Ebol", want: docURI{path: "example.com/with space", symbol: "Sym.bol"}},
+		{uri: "https://net/http", wantErr: true},
+		{uri: "godoc://", wantErr: true},
+		{uri: "godoc://#Client", wantErr: true},
+		{uri: "godoc://./internal", wantErr: true},
+		{uri: "godoc://net/http@v1.0.0", wantErr: true},
+		{uri: "godoc://example.com/bad%zz", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDocURI(tt.uri)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseDocURI(%q) = %+v, %v; want %+v, error %v", tt.uri, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDocURIString(t *testing.T) {
+	for _, uri := range []string{
+		"godoc://net/http",
+		"godoc://net/http#Client.Do",
+		"godoc://github.com/user/repo@v1.2.3#TypeName",
+	} {
+		u, err := parseDocURI(uri)
+		if err != nil {
+			t.Fatalf("parseDocURI(%q): %v", uri, err)
+		}
+		if got := u.String(); got != uri {
+			t.Errorf("parseDocURI(%q).String() = %q", uri, got)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// restDocResponse is the JSON body returned by the REST facade
+type restDocResponse struct {
+	Content string `json:"content"`
+	Error   bool   `json:"error,omitempty"`
+	// Code is the error code of a failed request
+	Code string `json:"code,omitempty"`
+}
+
+// handleRESTDoc serves GET /doc by translating query parameters into a get_doc
+// call, so REST clients share the cache and project manager with MCP clients.
+//
+// Query parameters mirror the get_doc arguments; cmd_flags may be repeated.
+func (s *GodocServer) handleRESTDoc(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// The REST facade is backed by get_doc and follows its configuration
+	if !s.toolAllowed("get_doc") {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	// Each request has a scope of its own, so nothing is held between them
+	if query.Get("cursor") != "" || query.Get("path") == "" && query.Get("doc_id") != "" {
+		writeRESTDoc(w, r, http.StatusBadRequest, restDocResponse{
+			Content: "cursors and held documents are not available over REST, which keeps no state between requests; pass path with page or a range, and doc_id to detect changes",
+			Error:   true,
+			Code:    codeInvalidArgument,
+		})
+		return
+	}
+	args := make(map[string]any)
+	for _, key := range []string{"path", "target", "working_dir", "doc_id", "page", "page_size", "page_size_tokens", "oversize", "start_line", "end_line", "start_byte", "end_byte", "goos", "goarch", "cgo_enabled"} {
+		if v := query.Get(key); v != "" {
+			args[key] = v
+		}
+	}
+	if targets := query["target"]; len(targets) > 1 {
+		args["target"] = targets
+	}
+	if tags := query["tags"]; len(tags) > 0 {
+		args["tags"] = tags
+	}
+	if vars := query["env"]; len(vars) > 0 {
+		env := make(map[string]any)
+		for _, kv := range vars {
+			key, value, _ := strings.Cut(kv, "=")
+			env[key] = value
+		}
+		args["env"] = env
+	}
+	if flags := query["cmd_flags"]; len(flags) > 0 {
+		args["cmd_flags"] = flags
+	}
+
+	var request mcp.CallToolRequest
+	request.Params.Name = "get_doc"
+	request.Params.Arguments = args
+
+	ctxLogger(r.Context(), s.logger).WithField("query", r.URL.RawQuery).Debug("REST doc request")
+	result, err := s.withTracing(s.withCallID(s.withSlowLog(s.trackInFlight(s.withWorker(s.handleToolCall)))))(withHTTPScope(r.Context()), request)
+	if err != nil {
+		writeRESTDoc(w, r, http.StatusInternalServerError, restDocResponse{Content: err.Error(), Error: true, Code: codeInternal})
+		return
+	}
+
+	status := http.StatusOK
+	if result.IsError {
+		status = restStatus(resultErrorCode(result))
+	}
+	content := resultText(result)
+	if out, ok := result.StructuredContent.(*docOutput); ok && out.Pagination.NextCursor != "" {
+		// The cursor would point into a document no longer held
+		next := fmt.Sprintf("Next page: pass page %d", out.Pagination.Page+1)
+		if out.Pagination.Truncated {
+			next = fmt.Sprintf("Next lines: pass start_line %d", out.Pagination.LastLine+1)
+		}
+		content = strings.Replace(content, "Next page: pass cursor "+out.Pagination.NextCursor, next, 1)
+	}
+	writeRESTDoc(w, r, status, restDocResponse{Content: content, Error: result.IsError, Code: resultErrorCode(result)})
+}
+
+// restStatus maps an error code onto the HTTP status of a failed REST request
+func restStatus(code string) int {
+	switch code {
+	case codePkgNotFound, codeSymbolNotFound:
+		return http.StatusNotFound
+	case codeServerBusy:
+		return http.StatusServiceUnavailable
+	case codeTimeout:
+		return http.StatusGatewayTimeout
+	case codeNetworkFetch:
+		return http.StatusBadGateway
+	case codeDocChanged:
+		return http.StatusConflict
+	case codeGoToolchain:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// writeRESTDoc writes resp as JSON when the client asks for it, and as plain text otherwise
+func writeRESTDoc(w http.ResponseWriter, r *http.Request, status int, resp restDocResponse) {
+	if strings.Contains(r.Header.Get("Accept"), "application/json") || r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(resp.Content))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newTestServer returns a server configured by the command line args, which
+// is cleaned up when the test ends
+func newTestServer(t *testing.T, args ...string) *GodocServer {
+	t.Helper()
+	cfg, err := loadConfig(args)
+	if err != nil {
+		t.Fatalf("loadConfig(%q): %v", args, err)
+	}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate(%q): %v", args, err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	s := newGodocServer(cfg, logger)
+	t.Cleanup(s.cleanup)
+	return s
+}
+
+func TestRESTDocScope(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       string
+	}{
+		{"cursor", "cursor=abc", http.StatusBadRequest, "keeps no state"},
+		{"held range", "doc_id=abc&start_line=1", http.StatusBadRequest, "keeps no state"},
+		{"page", "path=io&cmd_flags=-all&oversize=page&page_size=100", http.StatusOK, "Next page: pass page 2"},
+		{"later page", "path=io&cmd_flags=-all&page=2&page_size=100", http.StatusOK, "Next page: pass page 3"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/doc?format=json&"+tt.query, nil)
+		w := httptest.NewRecorder()
+		s.handleRESTDoc(w, r)
+
+		var resp restDocResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if w.Code != tt.wantStatus || !strings.Contains(resp.Content, tt.want) {
+			t.Errorf("%s: status %d, content %.200q; want %d with %q", tt.name, w.Code, resp.Content, tt.wantStatus, tt.want)
+		}
+		if strings.Contains(resp.Content, "pass cursor") {
+			t.Errorf("%s: response offers a cursor", tt.name)
+		}
+	}
+	if n := s.cursorDocs.Len(); n != 0 {
+		t.Errorf("REST requests left %d documents held", n)
+	}
+}
+
+func TestHTTPScope(t *testing.T) {
+	ctx := context.Background()
+	if sessionID(ctx) != "" || httpScoped(ctx) {
+		t.Fatal("a context without a session has a scope")
+	}
+	a, b := withHTTPScope(ctx), withHTTPScope(ctx)
+	if !httpScoped(a) || sessionID(a) == "" || sessionID(a) == sessionID(b) {
+		t.Errorf("HTTP requests are scoped as %q and %q, want distinct scopes", sessionID(a), sessionID(b))
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/mod/modfile"
+)
+
+// rootsTimeout bounds how long a tool call waits for its client to list roots
+const rootsTimeout = 5 * time.Second
+
+// clientRoots returns the local directories the client in ctx advertised as
+// roots. They are requested once per session, and again after the client
+// reports that they changed.
+func (s *GodocServer) clientRoots(ctx context.Context) []string {
+	id := sessionID(ctx)
+	if roots, ok := s.roots.Load(id); ok {
+		return roots.([]string)
+	}
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	mcpServer := server.ServerFromContext(ctx)
+	if !ok || mcpServer == nil || session.GetClientCapabilities().Roots == nil {
+		return nil
+	}
+
+	log := ctxLogger(ctx, s.logger)
+	ctx, cancel := context.WithTimeout(ctx, rootsTimeout)
+	defer cancel()
+	result, err := mcpServer.RequestRoots(ctx, mcp.ListRootsRequest{})
+	if err != nil {
+		// A client that can't answer is not asked again until its roots change
+		log.WithError(err).Warn("Failed to list client roots")
+		s.roots.Store(id, []string(nil))
+		return nil
+	}
+	var roots []string
+	for _, root := range result.Roots {
+		u, err := url.Parse(root.URI)
+		if err != nil || u.Scheme != "file" {
+			continue
+		}
+		dir := filepath.FromSlash(u.Path)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			roots = append(roots, dir)
+		}
+	}
+	log.WithField("roots", roots).Debug("Listed client roots")
+	s.roots.Store(id, roots)
+	return roots
+}
+
+// onRootsChanged forgets the roots of a session whose client changed them
+func (s *GodocServer) onRootsChanged(ctx context.Context, _ mcp.JSONRPCNotification) {
+	s.roots.Delete(sessionID(ctx))
+}
+
+// rootWorkingDir returns the client root to document path from when no working
+// directory was given: the first Go module root for relative paths, or the root
+// whose module contains an import path. It returns "" when no root applies.
+func (s *GodocServer) rootWorkingDir(ctx context.Context, path string) string {
+	if filepath.IsAbs(path) {
+		return ""
+	}
+	for _, root := range s.clientRoots(ctx) {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(path, ".") {
+			return root
+		}
+		if mod := modfile.ModulePath(data); mod != "" && (path == mod || strings.HasPrefix(path, mod+"/")) {
+			return root
+		}
+	}
+	return ""
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+const searchDocsDescription = `Search Go documentation by keyword.
+Ranks packages and symbols whose names or documentation mention every query word, across the
+standard library, the packages of the working module, and any documentation already fetched with
+get_doc. Returns package and symbol hits with a snippet of the matching text; read a hit in full
+with get_doc and its path and target. Use it when you know what you need but not where it lives,
+such as "multipart boundary" or "retry backoff".`
+
+// Limits on search_docs results and the module packages it reads
+const (
+	defaultSearchResults = 20
+	maxSearchResults     = 100
+	maxSearchPackages    = 200
+)
+
+// Where a search_docs hit was found
+const (
+	searchSourceStd    = "stdlib"
+	searchSourceModule = "module"
+	searchSourceCache  = "cache"
+)
+
+// searchDocsSchema is the search_docs input schema
+var searchDocsSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"query": map[string]any{
+			"type":        "string",
+			"description": "Keywords to search for (e.g., 'multipart boundary'). Every word must match, ignoring case.",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory whose packages are searched too. Defaults to the session working directory, then to the first client root that is a Go module.",
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of hits to return.",
+			"minimum":     1,
+			"maximum":     maxSearchResults,
+			"default":     defaultSearchResults,
+		},
+	},
+	Required: []string{"query"},
+}
+
+// searchOutputSchema is the outputSchema of search_docs, describing searchOutput
+var searchOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"query": map[string]any{"type": "string", "description": "The query searched for"},
+		"total": map[string]any{"type": "integer", "description": "Number of matches before limit was applied"},
+		"hits": map[string]any{
+			"type":        "array",
+			"description": "Matches, best first",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"package": map[string]any{"type": "string", "description": "Import path of the package; pass it to get_doc as path"},
+					"symbol":  map[string]any{"type": "string", "description": "The matching symbol, with methods as Type.Method; pass it to get_doc as target. Omitted for package overviews."},
+					"source":  map[string]any{"type": "string", "enum": []string{searchSourceStd, searchSourceModule, searchSourceCache}, "description": "Where the hit was found"},
+					"score":   map[string]any{"type": "integer", "description": "Relevance; higher is better"},
+					"snippet": map[string]any{"type": "string", "description": "Documentation text around the first match"},
+				},
+				"required": []string{"package", "source", "score"},
+			},
+		},
+	},
+	Required: []string{"query", "total", "hits"},
+}
+
+// searchOutput is the structured content of a search_docs result
+type searchOutput struct {
+	Query string      `json:"query"`
+	Total int         `json:"total"`
+	Hits  []searchHit `json:"hits"`
+}
+
+// searchHit is one search_docs match
+type searchHit struct {
+	Package string `json:"package"`
+	Symbol  string `json:"symbol,omitempty"`
+	Source  string `json:"source"`
+	Score   int    `json:"score"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// searchUnit is a piece of documentation search_docs can match: a package
+// overview or one of its symbols
+type searchUnit struct {
+	pkg    string
+	symbol string
+	doc    string
+	source string
+}
+
+// handleSearchDocs implements the search_docs tool
+func (s *GodocServer) handleSearchDocs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := request.GetString("query", "")
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return errorResult(codeInvalidArgument, "query must contain at least one word"), nil
+	}
+	limit := request.GetInt("limit", defaultSearchResults)
+	if limit < 1 || limit > maxSearchResults {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("limit must be between 1 and %d, got %d", maxSearchResults, limit)), nil
+	}
+	workingDir, err := s.searchWorkingDir(ctx, request)
+	if err != nil {
+		return errorResultFromErr("invalid working directory", err), nil
+	}
+
+	log := ctxLogger(ctx, s.logger).WithField("query", query)
+	progress := s.newProgressReporter(ctx, request)
+	ctx = withProgress(ctx, progress)
+	units := s.collectSearchUnits(ctx, log, workingDir)
+
+	hits := make([]searchHit, 0)
+	for _, u := range units {
+		if score, snippet := scoreUnit(u, terms); score > 0 {
+			hits = append(hits, searchHit{Package: u.pkg, Symbol: u.symbol, Source: u.source, Score: score, Snippet: snippet})
+		}
+	}
+	slices.SortFunc(hits, func(a, b searchHit) int {
+		return cmp.Or(b.Score-a.Score, strings.Compare(a.Package, b.Package), strings.Compare(a.Symbol, b.Symbol))
+	})
+	out := &searchOutput{Query: query, Total: len(hits), Hits: hits[:min(len(hits), limit)]}
+	log.WithFields(logrus.Fields{
+		"units": len(units),
+		"hits":  len(hits),
+	}).Debug("Searched documentation")
+
+	var sb strings.Builder
+	if len(hits) == 0 {
+		fmt.Fprintf(&sb, "No documentation matches %q.", query)
+	} else {
+		fmt.Fprintf(&sb, "%d matches for %q, showing %d. Read one with get_doc, passing its package as path and its symbol as target.\n", len(hits), query, len(out.Hits))
+		for i, hit := range out.Hits {
+			name := hit.Package
+			if hit.Symbol != "" {
+				name += "." + hit.Symbol
+			}
+			fmt.Fprintf(&sb, "\n%d. %s [%s]\n", i+1, name, hit.Source)
+			if hit.Snippet != "" {
+				fmt.Fprintf(&sb, "   %s\n", hit.Snippet)
+			}
+		}
+	}
+	if !s.stdlib.ready() {
+		sb.WriteString("\nThe standard library index is still being built, so standard library packages were not searched.")
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// searchWorkingDir returns the module directory whose packages a search covers:
+// the working_dir argument, the session default, or the first client root that
+// is a Go module. It returns "" when there is none.
+func (s *GodocServer) searchWorkingDir(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+	workingDir := request.GetString("working_dir", s.sessions.get(ctx).workingDir)
+	if workingDir == "" {
+		workingDir = s.rootWorkingDir(ctx, ".")
+	}
+	if workingDir != "" {
+		if err := checkWorkingDir(workingDir); err != nil {
+			return "", err
+		}
+	}
+	return workingDir, nil
+}
+
+// collectSearchUnits returns the documentation searches rank: the standard
+// library index, the packages of the module at workingDir, and the cache
+func (s *GodocServer) collectSearchUnits(ctx context.Context, log *logrus.Entry, workingDir string) []searchUnit {
+	// Packages searched through the index or the module are not searched again
+	// through the cache
+	covered := make(map[string]bool)
+	units := s.stdSearchUnits(covered)
+	if workingDir != "" {
+		progressFromContext(ctx).step("Reading the packages of " + workingDir)
+		units = append(units, s.moduleSearchUnits(ctx, log, workingDir, covered)...)
+	}
+	return append(units, s.cachedSearchUnits(ctx, covered)...)
+}
+
+// stdSearchUnits returns the documentation of the public standard library
+// packages and their symbols, marking the packages covered
+func (s *GodocServer) stdSearchUnits(covered map[string]bool) []searchUnit {
+	var units []searchUnit
+	for _, pkgPath := range s.stdlib.publicPackages() {
+		sp, _ := s.stdlib.lookup(pkgPath)
+		covered[pkgPath] = true
+		units = append(units, searchUnit{pkg: pkgPath, doc: sp.doc, source: searchSourceStd})
+		for symbol, doc := range sp.docs {
+			units = append(units, searchUnit{pkg: pkgPath, symbol: symbol, doc: doc, source: searchSourceStd})
+		}
+	}
+	return units
+}
+
+// moduleSearchUnits returns the documentation of the packages of the module at
+// workingDir and their symbols, marking the packages covered
+func (s *GodocServer) moduleSearchUnits(ctx context.Context, log *logrus.Entry, workingDir string, covered map[string]bool) []searchUnit {
+	pkgs := s.workspacePackages(ctx, workingDir)
+	if len(pkgs) > maxSearchPackages {
+		log.WithField("packages", len(pkgs)).Warn("Module has too many packages, searching only the first ones")
+		pkgs = pkgs[:maxSearchPackages]
+	}
+	var units []searchUnit
+	for _, pkgPath := range pkgs {
+		doc, err := s.runGoDoc(ctx, sessionID(ctx), workingDir, formatJSON, pkgPath)
+		if err != nil {
+			log.WithError(err).WithField("package", pkgPath).Debug("Failed to read package for search")
+			continue
+		}
+		var pd packageDoc
+		if err := json.Unmarshal([]byte(strings.Join(doc.lines, "\n")), &pd); err != nil {
+			continue
+		}
+		covered[pkgPath] = true
+		units = append(units, pd.searchUnits(pkgPath, searchSourceModule)...)
+	}
+	return units
+}
+
+// cachedSearchUnits returns the cached documentation visible to the session in
+// ctx of packages not already covered
+func (s *GodocServer) cachedSearchUnits(ctx context.Context, covered map[string]bool) []searchUnit {
+	session := sessionID(ctx)
+	seen := make(map[string]bool)
+	var units []searchUnit
+	for key, item := range s.cache.Items() {
+		// Keys are scope|workingDir|format|args, see runGoDoc
+		parts := strings.Split(key, "|")
+		if len(parts) < 4 || (parts[0] != "" && parts[0] != session) {
+			continue
+		}
+		args := slices.DeleteFunc(slices.Clone(parts[3:]), func(arg string) bool { return strings.HasPrefix(arg, "-") })
+		if len(args) == 0 || covered[args[0]] {
+			continue
+		}
+		pkgPath, symbol := args[0], ""
+		if len(args) > 1 {
+			symbol = args[1]
+		}
+		content := strings.Join(item.Value().lines, "\n")
+		if parts[2] == formatJSON {
+			var pd packageDoc
+			if json.Unmarshal([]byte(content), &pd) == nil {
+				for _, u := range pd.searchUnits(pkgPath, searchSourceCache) {
+					if id := u.pkg + "#" + u.symbol; !seen[id] {
+						seen[id] = true
+						units = append(units, u)
+					}
+				}
+			}
+			continue
+		}
+		if id := pkgPath + "#" + symbol; !seen[id] {
+			seen[id] = true
+			units = append(units, searchUnit{pkg: pkgPath, symbol: symbol, doc: content, source: searchSourceCache})
+		}
+	}
+	return units
+}
+
+// searchUnits splits pd into the package overview and one unit per symbol
+func (pd *packageDoc) searchUnits(pkgPath, source string) []searchUnit {
+	units := []searchUnit{{pkg: pkgPath, doc: pd.Doc, source: source}}
+	addValues := func(vals []valueDoc) {
+		for _, v := range vals {
+			for _, name := range v.Names {
+				units = append(units, searchUnit{pkg: pkgPath, symbol: name, doc: v.Doc, source: source})
+			}
+		}
+	}
+	addFuncs := func(prefix string, fns []funcDoc) {
+		for _, fn := range fns {
+			units = append(units, searchUnit{pkg: pkgPath, symbol: prefix + fn.Name, doc: fn.Doc, source: source})
+		}
+	}
+	addValues(pd.Consts)
+	addValues(pd.Vars)
+	addFuncs("", pd.Funcs)
+	for _, t := range pd.Types {
+		units = append(units, searchUnit{pkg: pkgPath, symbol: t.Name, doc: t.Doc, source: source})
+		addValues(t.Consts)
+		addValues(t.Vars)
+		addFuncs("", t.Funcs)
+		addFuncs(t.Name+".", t.Methods)
+	}
+	return units
+}
+
+// searchTerms splits a query into lower-case words
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+// scoreUnit ranks u against terms, all of which must appear in its name or
+// documentation, and extracts a snippet around the first match. Name matches
+// weigh more than matches in the text, and repeated mentions count up to a cap.
+// It returns zero when u does not match.
+func scoreUnit(u searchUnit, terms []string) (int, string) {
+	name := strings.ToLower(u.pkg + "." + u.symbol)
+	symbol := strings.ToLower(u.symbol)
+	doc := strings.ToLower(u.doc)
+	score, first := 0, -1
+	for _, term := range terms {
+		inName := strings.Contains(name, term)
+		mentions := strings.Count(doc, term)
+		if !inName && mentions == 0 {
+			return 0, ""
+		}
+		if inName {
+			score += 10
+			if symbol == term || strings.HasSuffix(symbol, "."+term) {
+				score += 10
+			}
+		}
+		score += min(mentions, 5)
+		if i := strings.Index(doc, term); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	// Package overviews rank just below symbols with equally strong matches
+	if u.symbol != "" {
+		score++
+	}
+	return score, snippet(u.doc, first)
+}
+
+// snippet returns the sentence of doc around byte offset at, or the start of
+// doc when at is negative, on a single line of at most about 200 bytes
+func snippet(doc string, at int) string {
+	if doc == "" {
+		return ""
+	}
+	at = min(at, len(doc)-1)
+	start, end := 0, len(doc)
+	if at > 0 {
+		if i := strings.LastIndexAny(doc[:at], ".\n"); i >= 0 && at-i < 120 {
+			start = i + 1
+		} else {
+			start = max(0, at-80)
+		}
+	}
+	if i := strings.IndexAny(doc[max(start, at):], ".\n"); i >= 0 {
+		end = max(start, at) + i + 1
+	}
+	end = min(end, start+200, len(doc))
+	text := strings.Join(strings.Fields(strings.ToValidUTF8(doc[start:end], "")), " ")
+	if start > 0 {
+		text = "..." + text
+	}
+	if end < len(doc) && !strings.HasSuffix(text, ".") {
+		text += "..."
+	}
+	return text
+}
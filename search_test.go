@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSearchDocs(t *testing.T) {
+	s := newTestServer(t)
+	s.stdlib.build(context.Background(), s.logger)
+	dir := writeModule(t, map[string]string{
+		"go.mod":      "module example.com/mod\n\ngo 1.21\n",
+		"greet/hi.go": "// Package greet says hello\npackage greet\n\n// Hello greets a flibbertigibbet\nfunc Hello() string { return \"hi\" }\n",
+	})
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		// first is the top hit as package.symbol, and total the least number of hits
+		first  string
+		source string
+		hits   int
+		total  int
+	}{
+		{"no words", map[string]any{"query": " ,. "}, codeInvalidArgument, "", "", 0, 0},
+		{"limit too small", map[string]any{"query": "reader", "limit": 0}, codeInvalidArgument, "", "", 0, 0},
+		{"limit too large", map[string]any{"query": "reader", "limit": maxSearchResults + 1}, codeInvalidArgument, "", "", 0, 0},
+		{"missing working dir", map[string]any{"query": "reader", "working_dir": dir + "/none"}, codeInvalidWorkingDir, "", "", 0, 0},
+		{"standard library", map[string]any{"query": "multipart boundary", "limit": 5}, "", "mime/multipart", searchSourceStd, 5, 5},
+		{"limited", map[string]any{"query": "reader", "limit": 1}, "", "", searchSourceStd, 1, 2},
+		{"module", map[string]any{"query": "flibbertigibbet", "working_dir": dir}, "", "example.com/mod/greet.Hello", searchSourceModule, 1, 1},
+		// Documentation read for the module is cached, so it is still found
+		{"cached", map[string]any{"query": "flibbertigibbet"}, "", "example.com/mod/greet.Hello", searchSourceCache, 1, 1},
+		{"no matches", map[string]any{"query": "zyzzyva"}, "", "", "", 0, 0},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleSearchDocs, "search_docs", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out, ok := result.StructuredContent.(*searchOutput)
+		if !ok {
+			t.Fatalf("%s: structured content %T", tt.name, result.StructuredContent)
+		}
+		if len(out.Hits) != tt.hits || out.Total < tt.total {
+			t.Errorf("%s: %d hits of %d, want %d of at least %d", tt.name, len(out.Hits), out.Total, tt.hits, tt.total)
+			continue
+		}
+		if tt.hits == 0 {
+			if text := resultText(result); !strings.HasPrefix(text, "No documentation matches") {
+				t.Errorf("%s: result %q", tt.name, text)
+			}
+			continue
+		}
+		hit := out.Hits[0]
+		name := hit.Package
+		if hit.Symbol != "" {
+			name += "." + hit.Symbol
+		}
+		if tt.first != "" && !strings.HasPrefix(name, tt.first) || hit.Source != tt.source {
+			t.Errorf("%s: first hit %s from %s, want %s from %s", tt.name, name, hit.Source, tt.first, tt.source)
+		}
+	}
+}
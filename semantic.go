@@ -0,0 +1,480 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+const semanticSearchDescription = `Search Go documentation by meaning.
+Finds packages and symbols whose documentation is conceptually related to a natural-language
+description, even when it shares no words with it, such as "rate limit outgoing requests" or
+"compare strings ignoring case". Searches the standard library, the packages of the working
+module, and any documentation already fetched with get_doc, ranked by embedding similarity.
+Read a hit in full with get_doc and its path and target. Prefer search_docs when you know the
+words the documentation uses.`
+
+// Embedding backends selected with -embeddings
+const (
+	embeddingsOllama = "ollama"
+	embeddingsOpenAI = "openai"
+)
+
+// Defaults of -embeddings-url and -embeddings-model for each backend
+const (
+	defaultOllamaURL   = "http://localhost:11434"
+	defaultOllamaModel = "nomic-embed-text"
+	defaultOpenAIURL   = "https://api.openai.com/v1"
+	defaultOpenAIModel = "text-embedding-3-small"
+)
+
+// Limits on embedding requests
+const (
+	// embedBatchSize is the number of texts sent to the backend per request
+	embedBatchSize = 64
+	// maxEmbedBytes truncates the documentation embedded for a package or symbol
+	maxEmbedBytes = 2048
+	// embedTimeout bounds a single request to the backend
+	embedTimeout = time.Minute
+)
+
+// semanticSearchSchema is the semantic_search input schema
+var semanticSearchSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"query": map[string]any{
+			"type":        "string",
+			"description": "What you are looking for, in plain language (e.g., 'rate limit outgoing requests').",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory whose packages are searched too. Defaults to the session working directory, then to the first client root that is a Go module.",
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of hits to return.",
+			"minimum":     1,
+			"maximum":     maxSearchResults,
+			"default":     defaultSearchResults,
+		},
+	},
+	Required: []string{"query"},
+}
+
+// semanticOutputSchema is the outputSchema of semantic_search, describing semanticOutput
+var semanticOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"query":    map[string]any{"type": "string", "description": "The query searched for"},
+		"model":    map[string]any{"type": "string", "description": "The embedding model that ranked the hits"},
+		"searched": map[string]any{"type": "integer", "description": "Number of packages and symbols ranked"},
+		"hits": map[string]any{
+			"type":        "array",
+			"description": "The most similar packages and symbols, best first",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"package": map[string]any{"type": "string", "description": "Import path of the package; pass it to get_doc as path"},
+					"symbol":  map[string]any{"type": "string", "description": "The symbol, with methods as Type.Method; pass it to get_doc as target. Omitted for package overviews."},
+					"source":  map[string]any{"type": "string", "enum": []string{searchSourceStd, searchSourceModule, searchSourceCache}, "description": "Where the hit was found"},
+					"score":   map[string]any{"type": "number", "description": "Cosine similarity to the query, from -1 to 1; higher is better"},
+					"snippet": map[string]any{"type": "string", "description": "The start of the documentation"},
+				},
+				"required": []string{"package", "source", "score"},
+			},
+		},
+	},
+	Required: []string{"query", "model", "searched", "hits"},
+}
+
+// semanticOutput is the structured content of a semantic_search result
+type semanticOutput struct {
+	Query    string        `json:"query"`
+	Model    string        `json:"model"`
+	Searched int           `json:"searched"`
+	Hits     []semanticHit `json:"hits"`
+}
+
+// semanticHit is one semantic_search match
+type semanticHit struct {
+	Package string  `json:"package"`
+	Symbol  string  `json:"symbol,omitempty"`
+	Source  string  `json:"source"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+// embedder turns texts into embedding vectors, one per text in order
+type embedder interface {
+	embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// newEmbedder returns the embedder cfg selects and the name of its model, or
+// nil when semantic search is disabled
+func newEmbedder(cfg EmbeddingsConfig) (embedder, string) {
+	client := &http.Client{Timeout: embedTimeout}
+	switch cfg.Backend {
+	case embeddingsOllama:
+		e := &ollamaEmbedder{
+			client: client,
+			url:    strings.TrimSuffix(cmp.Or(cfg.URL, defaultOllamaURL), "/"),
+			model:  cmp.Or(cfg.Model, defaultOllamaModel),
+		}
+		return e, e.model
+	case embeddingsOpenAI:
+		e := &openAIEmbedder{
+			client: client,
+			url:    strings.TrimSuffix(cmp.Or(cfg.URL, defaultOpenAIURL), "/"),
+			model:  cmp.Or(cfg.Model, defaultOpenAIModel),
+			apiKey: os.Getenv(cfg.APIKeyEnv),
+		}
+		return e, e.model
+	}
+	return nil, ""
+}
+
+// ollamaEmbedder embeds texts with the /api/embed endpoint of an Ollama server
+type ollamaEmbedder struct {
+	client *http.Client
+	url    string
+	model  string
+}
+
+func (e *ollamaEmbedder) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var out struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	in := map[string]any{"model": e.model, "input": texts}
+	if err := postJSON(ctx, e.client, e.url+"/api/embed", "", in, &out); err != nil {
+		return nil, err
+	}
+	return out.Embeddings, nil
+}
+
+// openAIEmbedder embeds texts with an OpenAI-compatible /embeddings endpoint
+type openAIEmbedder struct {
+	client *http.Client
+	url    string
+	model  string
+	apiKey string
+}
+
+func (e *openAIEmbedder) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	in := map[string]any{"model": e.model, "input": texts}
+	if err := postJSON(ctx, e.client, e.url+"/embeddings", e.apiKey, in, &out); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding backend returned index %d for %d texts", d.Index, len(texts))
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	if i := slices.IndexFunc(vectors, func(v []float32) bool { return v == nil }); i >= 0 {
+		return nil, fmt.Errorf("embedding backend returned no embedding for text %d", i)
+	}
+	return vectors, nil
+}
+
+// postJSON posts in as JSON to url, authenticated with apiKey when it is set,
+// and decodes the JSON response into out
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := strings.TrimSpace(string(data))
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return fmt.Errorf("%s returned %s: %s", url, resp.Status, msg)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid response from %s: %v", url, err)
+	}
+	return nil
+}
+
+// semanticIndex holds the embeddings of documentation ranked by semantic_search,
+// keyed by a hash of the embedded text, so each text is embedded once per model
+// for as long as the server runs
+type semanticIndex struct {
+	// fill serializes embedding requests, so concurrent searches and warming
+	// don't embed the same texts twice
+	fill sync.Mutex
+
+	mu      sync.Mutex
+	model   string
+	vectors map[[sha256.Size]byte][]float32
+}
+
+// vector returns the normalized embedding of text by model, if it has one
+func (x *semanticIndex) vector(model, text string) ([]float32, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.model != model {
+		return nil, false
+	}
+	v, ok := x.vectors[sha256.Sum256([]byte(text))]
+	return v, ok
+}
+
+// embedMissing embeds the texts without an embedding by model, in batches,
+// reporting the texts left as progress. Switching models discards the
+// embeddings of the previous one.
+func (x *semanticIndex) embedMissing(ctx context.Context, e embedder, model string, texts []string, progress *progressReporter) error {
+	keys := make([][sha256.Size]byte, len(texts))
+	for i, text := range texts {
+		keys[i] = sha256.Sum256([]byte(text))
+	}
+	for {
+		x.fill.Lock()
+		var batch []string
+		var batchKeys [][sha256.Size]byte
+		inBatch := make(map[[sha256.Size]byte]bool)
+		missing := 0
+		x.mu.Lock()
+		if x.model != model {
+			x.model, x.vectors = model, make(map[[sha256.Size]byte][]float32)
+		}
+		for i, key := range keys {
+			if _, ok := x.vectors[key]; ok || inBatch[key] {
+				continue
+			}
+			missing++
+			if len(batch) < embedBatchSize {
+				inBatch[key] = true
+				batch = append(batch, texts[i])
+				batchKeys = append(batchKeys, key)
+			}
+		}
+		x.mu.Unlock()
+		if len(batch) == 0 {
+			x.fill.Unlock()
+			return nil
+		}
+		progress.step(fmt.Sprintf("Embedding documentation, %d of %d texts left", missing, len(texts)))
+		vectors, err := e.embed(ctx, batch)
+		if err == nil && len(vectors) != len(batch) {
+			err = fmt.Errorf("embedding backend returned %d embeddings for %d texts", len(vectors), len(batch))
+		}
+		if err != nil {
+			x.fill.Unlock()
+			return err
+		}
+		x.mu.Lock()
+		if x.model == model {
+			for i, v := range vectors {
+				x.vectors[batchKeys[i]] = normalize(v)
+			}
+		}
+		x.mu.Unlock()
+		x.fill.Unlock()
+	}
+}
+
+// normalize scales v to unit length, so the dot product of two normalized
+// vectors is their cosine similarity
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, f := range v {
+		out[i] = f / norm
+	}
+	return out
+}
+
+// dot returns the dot product of a and b over their common length
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range min(len(a), len(b)) {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// embedText returns the text embedded for u: its qualified name followed by
+// the start of its documentation
+func embedText(u searchUnit) string {
+	name := "package " + u.pkg
+	if u.symbol != "" {
+		name = u.pkg + "." + u.symbol
+	}
+	text := name + "\n" + u.doc
+	if len(text) > maxEmbedBytes {
+		text = strings.ToValidUTF8(text[:maxEmbedBytes], "")
+	}
+	return text
+}
+
+// embeddingError classifies a failure to embed: the request's own timeout or
+// cancellation, or EMBEDDING_FAILED for failures of the backend
+func embeddingError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return withCode(codeEmbeddingFailed, err)
+}
+
+// handleSemanticSearch implements the semantic_search tool
+func (s *GodocServer) handleSemanticSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := strings.TrimSpace(request.GetString("query", ""))
+	if query == "" {
+		return errorResult(codeInvalidArgument, "query must not be empty"), nil
+	}
+	limit := request.GetInt("limit", defaultSearchResults)
+	if limit < 1 || limit > maxSearchResults {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("limit must be between 1 and %d, got %d", maxSearchResults, limit)), nil
+	}
+	e, model := newEmbedder(s.config.Load().Embeddings)
+	if e == nil {
+		return errorResult(codeUnsupported, "semantic search is disabled; start the server with -embeddings to enable it"), nil
+	}
+	workingDir, err := s.searchWorkingDir(ctx, request)
+	if err != nil {
+		return errorResultFromErr("invalid working directory", err), nil
+	}
+
+	log := ctxLogger(ctx, s.logger).WithFields(logrus.Fields{"query": query, "model": model})
+	progress := s.newProgressReporter(ctx, request)
+	ctx = withProgress(ctx, progress)
+	units := slices.DeleteFunc(s.collectSearchUnits(ctx, log, workingDir), func(u searchUnit) bool {
+		return strings.TrimSpace(u.doc) == ""
+	})
+	texts := make([]string, len(units))
+	for i, u := range units {
+		texts[i] = embedText(u)
+	}
+
+	started := time.Now()
+	queryVectors, err := e.embed(ctx, []string{query})
+	if err == nil && len(queryVectors) != 1 {
+		err = fmt.Errorf("embedding backend returned %d embeddings for 1 text", len(queryVectors))
+	}
+	if err != nil {
+		return errorResultFromErr("failed to embed the query", embeddingError(ctx, err)), nil
+	}
+	if err := s.semantic.embedMissing(ctx, e, model, texts, progress); err != nil {
+		return errorResultFromErr("failed to embed documentation", embeddingError(ctx, err)), nil
+	}
+	queryVector := normalize(queryVectors[0])
+
+	hits := make([]semanticHit, 0, len(units))
+	for i, u := range units {
+		v, ok := s.semantic.vector(model, texts[i])
+		if !ok {
+			continue
+		}
+		hits = append(hits, semanticHit{Package: u.pkg, Symbol: u.symbol, Source: u.source, Score: dot(queryVector, v)})
+	}
+	slices.SortFunc(hits, func(a, b semanticHit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.Package, b.Package), strings.Compare(a.Symbol, b.Symbol))
+	})
+	out := &semanticOutput{Query: query, Model: model, Searched: len(hits), Hits: hits[:min(len(hits), limit)]}
+	docs := make(map[string]string, len(units))
+	for _, u := range units {
+		docs[u.pkg+"#"+u.symbol] = u.doc
+	}
+	for i := range out.Hits {
+		hit := &out.Hits[i]
+		hit.Score = math.Round(hit.Score*1000) / 1000
+		hit.Snippet = snippet(docs[hit.Package+"#"+hit.Symbol], -1)
+	}
+	log.WithFields(logrus.Fields{
+		"units":    len(units),
+		"duration": time.Since(started),
+	}).Debug("Ranked documentation by similarity")
+
+	var sb strings.Builder
+	if len(out.Hits) == 0 {
+		fmt.Fprintf(&sb, "No documentation to search for %q.", query)
+	} else {
+		fmt.Fprintf(&sb, "Ranked %d packages and symbols by similarity to %q with %s, showing the top %d. Read one with get_doc, passing its package as path and its symbol as target.\n", len(hits), query, model, len(out.Hits))
+		for i, hit := range out.Hits {
+			name := hit.Package
+			if hit.Symbol != "" {
+				name += "." + hit.Symbol
+			}
+			fmt.Fprintf(&sb, "\n%d. %s [%s, %.3f]\n", i+1, name, hit.Source, hit.Score)
+			if hit.Snippet != "" {
+				fmt.Fprintf(&sb, "   %s\n", hit.Snippet)
+			}
+		}
+	}
+	if !s.stdlib.ready() {
+		sb.WriteString("\nThe standard library index is still being built, so standard library packages were not searched.")
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// warmSemanticIndex embeds the standard library documentation in the
+// background once it is indexed, so the first semantic_search need not wait
+// for it. Searches embed whatever is still missing themselves.
+func (s *GodocServer) warmSemanticIndex(ctx context.Context) {
+	e, model := newEmbedder(s.config.Load().Embeddings)
+	if e == nil || !s.toolAllowed("semantic_search") {
+		return
+	}
+	var texts []string
+	for _, u := range s.stdSearchUnits(make(map[string]bool)) {
+		if strings.TrimSpace(u.doc) != "" {
+			texts = append(texts, embedText(u))
+		}
+	}
+	log := s.logger.WithFields(logrus.Fields{"model": model, "texts": len(texts)})
+	log.Info("Embedding standard library documentation for semantic search...")
+	started := time.Now()
+	if err := s.semantic.embedMissing(ctx, e, model, texts, nil); err != nil {
+		log.WithError(err).Warn("Failed to embed standard library documentation, semantic searches will retry")
+		return
+	}
+	log.WithField("duration", time.Since(started)).Info("Embedded standard library documentation")
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// embeddingVocabulary is the words whose counts make up the embeddings of the
+// test embedding backend
+var embeddingVocabulary = []string{"rate", "limit", "greet", "parse"}
+
+// testEmbedding embeds text as the counts of the vocabulary words in it, plus
+// a constant so no vector is zero
+func testEmbedding(text string) []float32 {
+	v := make([]float32, len(embeddingVocabulary)+1)
+	v[len(embeddingVocabulary)] = 0.1
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		for i, w := range embeddingVocabulary {
+			if strings.HasPrefix(word, w) {
+				v[i]++
+			}
+		}
+	}
+	return v
+}
+
+// newEmbeddingBackend serves the Ollama and OpenAI embedding APIs with
+// testEmbedding, failing requests for texts containing "unembeddable" and
+// OpenAI requests without the API key
+func newEmbeddingBackend(t *testing.T, apiKey string) *httptest.Server {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var vectors [][]float32
+		for _, text := range in.Input {
+			if strings.Contains(text, "unembeddable") {
+				http.Error(w, "model overloaded", http.StatusServiceUnavailable)
+				return
+			}
+			vectors = append(vectors, testEmbedding(text))
+		}
+		switch r.URL.Path {
+		case "/api/embed":
+			json.NewEncoder(w).Encode(map[string]any{"embeddings": vectors})
+		case "/embeddings":
+			if r.Header.Get("Authorization") != "Bearer "+apiKey {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			var data []map[string]any
+			// Entries may come in any order
+			for i := len(vectors) - 1; i >= 0; i-- {
+				data = append(data, map[string]any{"index": i, "embedding": vectors[i]})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"data": data})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(backend.Close)
+	return backend
+}
+
+func TestSemanticSearch(t *testing.T) {
+	t.Setenv("TEST_EMBEDDINGS_KEY", "secret")
+	backend := newEmbeddingBackend(t, "secret")
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/mod\n\ngo 1.21\n",
+		"net/net.go": `// Package net sends requests
+package net
+
+// Throttle limits the rate of outgoing requests to a rate limit
+func Throttle() {}
+
+// Greet greets the server
+func Greet() {}
+`,
+		"text/text.go": "// Package text parses text\npackage text\n\n// Parse parses a line\nfunc Parse() {}\n",
+	})
+	ctx := context.Background()
+
+	disabled := newTestServer(t)
+	result := callTool(t, ctx, disabled.handleSemanticSearch, "semantic_search", map[string]any{"query": "rate limit"})
+	if got := resultErrorCode(result); got != codeUnsupported {
+		t.Errorf("semantic search without -embeddings: error code %q, want %q", got, codeUnsupported)
+	}
+
+	for _, backendName := range []string{embeddingsOllama, embeddingsOpenAI} {
+		s := newTestServer(t, "-embeddings", backendName, "-embeddings-url", backend.URL, "-embeddings-model", "words", "-embeddings-api-key-env", "TEST_EMBEDDINGS_KEY")
+		tests := []struct {
+			name     string
+			args     map[string]any
+			wantCode string
+			// first is the top hit as package.symbol
+			first string
+			hits  int
+		}{
+			{"empty query", map[string]any{"query": "  "}, codeInvalidArgument, "", 0},
+			{"bad limit", map[string]any{"query": "rate limit", "limit": maxSearchResults + 1}, codeInvalidArgument, "", 0},
+			{"backend fails", map[string]any{"query": "unembeddable", "working_dir": dir}, codeEmbeddingFailed, "", 0},
+			{"rate limit", map[string]any{"query": "rate limit", "working_dir": dir}, "", "example.com/mod/net.Throttle", 5},
+			{"parse", map[string]any{"query": "parse a line", "working_dir": dir, "limit": 2}, "", "example.com/mod/text", 2},
+		}
+		for _, tt := range tests {
+			result := callTool(t, ctx, s.handleSemanticSearch, "semantic_search", tt.args)
+			if got := resultErrorCode(result); got != tt.wantCode {
+				t.Errorf("%s %s: error code %q, want %q: %s", backendName, tt.name, got, tt.wantCode, resultText(result))
+				continue
+			}
+			if tt.wantCode != "" {
+				continue
+			}
+			out, ok := result.StructuredContent.(*semanticOutput)
+			if !ok {
+				t.Fatalf("%s %s: structured content %T", backendName, tt.name, result.StructuredContent)
+			}
+			if len(out.Hits) != tt.hits || out.Model != "words" {
+				t.Errorf("%s %s: %d hits by %s, want %d by words", backendName, tt.name, len(out.Hits), out.Model, tt.hits)
+				continue
+			}
+			first := out.Hits[0].Package
+			if out.Hits[0].Symbol != "" {
+				first += "." + out.Hits[0].Symbol
+			}
+			if !strings.HasPrefix(first, tt.first) {
+				t.Errorf("%s %s: first hit %s, want %s", backendName, tt.name, first, tt.first)
+			}
+		}
+	}
+}
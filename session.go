@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sessionIdleTTL is how long an idle session's defaults are kept
+const sessionIdleTTL = time.Hour
+
+const sessionDefaultsDescription = `Set defaults for subsequent tool calls in this session.
+Values set here are used whenever a get_doc call omits the corresponding parameter, so the
+working directory does not need to be repeated on every call. Defaults are scoped to the
+current MCP session and are never shared with other clients. Pass an empty string or 0 to
+clear a value.`
+
+// newSessionDefaultsSchema creates the set_session_defaults input schema with the configured page size limits
+func newSessionDefaultsSchema(p PaginationConfig) mcp.ToolInputSchema {
+	pageSize := p.pageSizeSchema("Default number of lines per page for get_doc calls in this session.")
+	// Zero clears the session default
+	pageSize["minimum"] = 0
+	delete(pageSize, "default")
+	pageTokens := p.pageTokensSchema("Default token budget per page for get_doc calls in this session; takes precedence over page_size.")
+	pageTokens["minimum"] = 0
+	return mcp.ToolInputSchema{
+		Type: "object",
+		Properties: map[string]any{
+			"working_dir": map[string]any{
+				"type":        "string",
+				"description": "Default working directory for get_doc calls in this session.",
+			},
+			"page_size":        pageSize,
+			"page_size_tokens": pageTokens,
+		},
+	}
+}
+
+// sessionDefaults holds per-session values applied when a tool call omits them
+type sessionDefaults struct {
+	workingDir string
+	pageSize   int
+	pageTokens int
+}
+
+// sessionStore tracks defaults for each connected MCP session
+type sessionStore struct {
+	cache *ttlcache.Cache[string, sessionDefaults]
+}
+
+// newSessionStore creates a session store that forgets sessions after they go idle
+func newSessionStore() *sessionStore {
+	ss := &sessionStore{
+		cache: ttlcache.New(ttlcache.WithTTL[string, sessionDefaults](sessionIdleTTL)),
+	}
+	go ss.cache.Start()
+	return ss
+}
+
+// sessionID returns the ID of the MCP session that issued the request in ctx,
+// or the scope of an HTTP request made outside MCP
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	id, _ := ctx.Value(httpScopeKey{}).(string)
+	return id
+}
+
+type httpScopeKey struct{}
+
+// withHTTPScope gives a tool call made for the REST endpoint or the web UI,
+// which have no MCP session, a scope of its own, so HTTP clients never share
+// session defaults, documentation cached for a working directory, or held
+// documents
+func withHTTPScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, httpScopeKey{}, "http-"+newRequestID())
+}
+
+// httpScoped reports whether ctx is scoped to a single HTTP request, whose
+// scope no later request can return to
+func httpScoped(ctx context.Context) bool {
+	return ctx.Value(httpScopeKey{}) != nil
+}
+
+// get returns the defaults for the session in ctx
+func (ss *sessionStore) get(ctx context.Context) sessionDefaults {
+	if item := ss.cache.Get(sessionID(ctx)); item != nil {
+		return item.Value()
+	}
+	return sessionDefaults{}
+}
+
+// set stores the defaults for the session in ctx
+func (ss *sessionStore) set(ctx context.Context, d sessionDefaults) {
+	ss.cache.Set(sessionID(ctx), d, ttlcache.DefaultTTL)
+}
+
+// delete forgets the defaults for a session
+func (ss *sessionStore) delete(id string) {
+	ss.cache.Delete(id)
+}
+
+// cleanup drops all sessions and stops the cache
+func (ss *sessionStore) cleanup() {
+	if ss == nil {
+		return
+	}
+	ss.cache.DeleteAll()
+	ss.cache.Stop()
+}
+
+// onUnregisterSession drops the defaults, roots, and subscriptions of a
+// disconnected session
+func (s *GodocServer) onUnregisterSession(_ context.Context, session server.ClientSession) {
+	s.sessions.delete(session.SessionID())
+	s.clients.Delete(session.SessionID())
+	s.roots.Delete(session.SessionID())
+	s.subscriptions.drop(session.SessionID())
+}
+
+// handleSetSessionDefaults implements the set_session_defaults tool
+func (s *GodocServer) handleSetSessionDefaults(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	d := s.sessions.get(ctx)
+	args := request.GetArguments()
+
+	if _, ok := args["working_dir"]; ok {
+		d.workingDir = request.GetString("working_dir", "")
+		if d.workingDir != "" {
+			if err := checkWorkingDir(d.workingDir); err != nil {
+				return errorResultFromErr("invalid working directory", err), nil
+			}
+		}
+	}
+	if _, ok := args["page_size"]; ok {
+		d.pageSize = request.GetInt("page_size", 0)
+		maxPageSize := s.config.Load().Pagination.MaxPageSize
+		if d.pageSize != 0 && (d.pageSize < minPageSize || d.pageSize > maxPageSize) {
+			return errorResult(codeInvalidArgument, fmt.Sprintf("page_size must be between %d and %d, got %d", minPageSize, maxPageSize, d.pageSize)), nil
+		}
+	}
+	if _, ok := args["page_size_tokens"]; ok {
+		d.pageTokens = request.GetInt("page_size_tokens", 0)
+		if d.pageTokens != 0 && d.pageTokens < minPageTokens {
+			return errorResult(codeInvalidArgument, fmt.Sprintf("page_size_tokens must be at least %d, got %d", minPageTokens, d.pageTokens)), nil
+		}
+	}
+	s.sessions.set(ctx, d)
+	ctxLogger(ctx, s.logger).WithField("session", sessionID(ctx)).Debug("Session defaults updated")
+
+	var sb strings.Builder
+	sb.WriteString("Session defaults:\n")
+	fmt.Fprintf(&sb, "  working_dir: %s\n", valueOrUnset(d.workingDir))
+	pageSize := "unset"
+	if d.pageSize != 0 {
+		pageSize = fmt.Sprint(d.pageSize)
+	}
+	fmt.Fprintf(&sb, "  page_size: %s\n", pageSize)
+	pageTokens := "unset"
+	if d.pageTokens != 0 {
+		pageTokens = fmt.Sprint(d.pageTokens)
+	}
+	fmt.Fprintf(&sb, "  page_size_tokens: %s\n", pageTokens)
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func valueOrUnset(s string) string {
+	if s == "" {
+		return "unset"
+	}
+	return s
+}
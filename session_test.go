@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sessionContext returns a context of a tool call made in the session id
+func sessionContext(id string) context.Context {
+	return server.NewMCPServer("test", "0").WithContext(context.Background(), newTestSession(id))
+}
+
+// callTool calls handler as the named tool with args
+func callTool(t *testing.T, ctx context.Context, handler server.ToolHandlerFunc, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	var request mcp.CallToolRequest
+	request.Params.Name = name
+	request.Params.Arguments = args
+	result, err := handler(ctx, request)
+	if err != nil {
+		t.Fatalf("%s(%v): %v", name, args, err)
+	}
+	return result
+}
+
+func TestSetSessionDefaults(t *testing.T) {
+	s := newTestServer(t)
+	dir := t.TempDir()
+	a, b := sessionContext("a"), sessionContext("b")
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		args     map[string]any
+		wantCode string
+		want     []string
+	}{
+		{"nothing set", a, nil, "", []string{"working_dir: unset", "page_size: unset", "page_size_tokens: unset"}},
+		{"working dir", a, map[string]any{"working_dir": dir}, "", []string{"working_dir: " + dir, "page_size: unset"}},
+		{"page size kept", a, map[string]any{"page_size": 200}, "", []string{"working_dir: " + dir, "page_size: 200"}},
+		{"page tokens", a, map[string]any{"page_size_tokens": 1000}, "", []string{"page_size: 200", "page_size_tokens: 1000"}},
+		{"cleared", a, map[string]any{"working_dir": "", "page_size": 0}, "", []string{"working_dir: unset", "page_size: unset", "page_size_tokens: 1000"}},
+		{"missing dir", a, map[string]any{"working_dir": dir + "/none"}, codeInvalidWorkingDir, nil},
+		{"page size too small", a, map[string]any{"page_size": 1}, codeInvalidArgument, nil},
+		{"page tokens too small", a, map[string]any{"page_size_tokens": 1}, codeInvalidArgument, nil},
+		{"failed calls change nothing", a, nil, "", []string{"working_dir: unset", "page_size_tokens: 1000"}},
+		{"other session", b, nil, "", []string{"working_dir: unset", "page_size_tokens: unset"}},
+	}
+	for _, tt := range tests {
+		result := callTool(t, tt.ctx, s.handleSetSessionDefaults, "set_session_defaults", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		for _, want := range tt.want {
+			if text := resultText(result); !strings.Contains(text, want) {
+				t.Errorf("%s: result %q lacks %q", tt.name, text, want)
+			}
+		}
+	}
+}
+
+func TestSessionDefaultsApplyToGetDoc(t *testing.T) {
+	s := newTestServer(t)
+	a, b := sessionContext("a"), sessionContext("b")
+	callTool(t, a, s.handleSetSessionDefaults, "set_session_defaults", map[string]any{"page_size": 100})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		args     map[string]any
+		pageSize int
+	}{
+		{"session default", a, nil, 100},
+		{"argument wins", a, map[string]any{"page_size": 200}, 200},
+		{"other session", b, nil, s.config.Load().Pagination.DefaultPageSize},
+	}
+	for _, tt := range tests {
+		args := map[string]any{"path": "io", "cmd_flags": []any{"-all"}, "oversize": "page"}
+		for k, v := range tt.args {
+			args[k] = v
+		}
+		result := callTool(t, tt.ctx, s.handleToolCall, "get_doc", args)
+		out, ok := result.StructuredContent.(*docOutput)
+		if result.IsError || !ok {
+			t.Fatalf("%s: %s", tt.name, resultText(result))
+		}
+		if out.Pagination.PageSize != tt.pageSize {
+			t.Errorf("%s: page size %d, want %d", tt.name, out.Pagination.PageSize, tt.pageSize)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// Limits on deps.dev lookups
+const (
+	// signalsTimeout bounds the lookups of one module
+	signalsTimeout = 10 * time.Second
+	// signalsCacheTTL is how long the signals of a module are reused
+	signalsCacheTTL = time.Hour
+	// maxSignalLookups bounds the concurrent module lookups of find_packages
+	maxSignalLookups = 5
+)
+
+// errModuleUnknown reports a module deps.dev has no record of
+var errModuleUnknown = errors.New("module not known to deps.dev")
+
+// signalsSchema describes packageSignals in output schemas
+var signalsSchema = map[string]any{
+	"type":        "object",
+	"description": "Popularity and maintenance signals of the module providing the package, from deps.dev",
+	"properties": map[string]any{
+		"module":            map[string]any{"type": "string", "description": "Module path"},
+		"latest_version":    map[string]any{"type": "string", "description": "The module's default (latest) version"},
+		"published":         map[string]any{"type": "string", "description": "Date the latest version was published (YYYY-MM-DD)"},
+		"deprecated":        map[string]any{"type": "boolean", "description": "Whether the latest version is deprecated"},
+		"licenses":          map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "SPDX licenses of the latest version"},
+		"advisories":        map[string]any{"type": "integer", "description": "Security advisories affecting the latest version"},
+		"direct_dependents": map[string]any{"type": "integer", "description": "Package versions depending directly on the latest version"},
+		"repository":        map[string]any{"type": "string", "description": "Source repository"},
+		"stars":             map[string]any{"type": "integer", "description": "Stars of the source repository"},
+		"open_issues":       map[string]any{"type": "integer", "description": "Open issues of the source repository"},
+		"scorecard":         map[string]any{"type": "number", "description": "OpenSSF Scorecard score of the source repository, from 0 to 10"},
+	},
+	"required": []string{"module"},
+}
+
+// packageSignals summarizes how widely used and well maintained a module is
+type packageSignals struct {
+	Module           string   `json:"module"`
+	LatestVersion    string   `json:"latest_version,omitempty"`
+	Published        string   `json:"published,omitempty"`
+	Deprecated       bool     `json:"deprecated,omitempty"`
+	Licenses         []string `json:"licenses,omitempty"`
+	Advisories       int      `json:"advisories,omitempty"`
+	DirectDependents int      `json:"direct_dependents,omitempty"`
+	Repository       string   `json:"repository,omitempty"`
+	Stars            int      `json:"stars,omitempty"`
+	OpenIssues       int      `json:"open_issues,omitempty"`
+	Scorecard        float64  `json:"scorecard,omitempty"`
+}
+
+// String summarizes the signals on one line
+func (ps *packageSignals) String() string {
+	var parts []string
+	if ps.LatestVersion != "" {
+		latest := "latest " + ps.LatestVersion
+		if ps.Published != "" {
+			latest += " (" + ps.Published + ")"
+		}
+		parts = append(parts, latest)
+	}
+	if ps.Deprecated {
+		parts = append(parts, "DEPRECATED")
+	}
+	if len(ps.Licenses) > 0 {
+		parts = append(parts, strings.Join(ps.Licenses, ", "))
+	}
+	if ps.DirectDependents > 0 {
+		parts = append(parts, fmt.Sprintf("%d direct dependents", ps.DirectDependents))
+	}
+	if ps.Stars > 0 {
+		parts = append(parts, fmt.Sprintf("%d stars", ps.Stars))
+	}
+	if ps.OpenIssues > 0 {
+		parts = append(parts, fmt.Sprintf("%d open issues", ps.OpenIssues))
+	}
+	if ps.Scorecard > 0 {
+		parts = append(parts, fmt.Sprintf("OpenSSF Scorecard %.1f/10", ps.Scorecard))
+	}
+	if ps.Advisories > 0 {
+		parts = append(parts, fmt.Sprintf("%d security advisories", ps.Advisories))
+	}
+	if len(parts) == 0 {
+		return ps.Module
+	}
+	return ps.Module + ": " + strings.Join(parts, ", ")
+}
+
+// newSignalsCache creates the cache of module signals by module path
+func newSignalsCache() *ttlcache.Cache[string, *packageSignals] {
+	cache := ttlcache.New[string, *packageSignals](
+		ttlcache.WithTTL[string, *packageSignals](signalsCacheTTL),
+		ttlcache.WithDisableTouchOnHit[string, *packageSignals](),
+	)
+	go cache.Start()
+	return cache
+}
+
+// moduleSignals returns the signals of the module modPath
+func (s *GodocServer) moduleSignals(ctx context.Context, modPath string) (*packageSignals, error) {
+	if item := s.signals.Get(modPath); item != nil {
+		return item.Value(), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, signalsTimeout)
+	defer cancel()
+	ps, err := fetchSignals(ctx, s.config.Load().DepsDevURL, modPath)
+	if err != nil {
+		return nil, err
+	}
+	s.signals.Set(modPath, ps, ttlcache.DefaultTTL)
+	return ps, nil
+}
+
+// packageSignalsFor returns the signals of the module providing pkgPath, trying
+// each prefix of the path from the longest, as deps.dev knows modules only
+func (s *GodocServer) packageSignalsFor(ctx context.Context, pkgPath string) (*packageSignals, error) {
+	for modPath := pkgPath; modPath != "." && modPath != "/"; modPath = path.Dir(modPath) {
+		ps, err := s.moduleSignals(ctx, modPath)
+		if !errors.Is(err, errModuleUnknown) {
+			return ps, err
+		}
+		if !strings.Contains(modPath, "/") {
+			break
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", pkgPath, errModuleUnknown)
+}
+
+// fetchSignals looks modPath up in the deps.dev API at base: its default
+// version, then that version's licenses, advisories, and dependents and its
+// source repository. Only the first lookup must succeed.
+func fetchSignals(ctx context.Context, base, modPath string) (*packageSignals, error) {
+	pkgURL := base + "/v3/systems/go/packages/" + url.PathEscape(modPath)
+	var pkg struct {
+		Versions []struct {
+			VersionKey   struct{ Version string } `json:"versionKey"`
+			PublishedAt  time.Time                `json:"publishedAt"`
+			IsDefault    bool                     `json:"isDefault"`
+			IsDeprecated bool                     `json:"isDeprecated"`
+		} `json:"versions"`
+	}
+	if err := getJSON(ctx, pkgURL, &pkg); err != nil {
+		return nil, err
+	}
+	ps := &packageSignals{Module: modPath}
+	for _, v := range pkg.Versions {
+		if v.IsDefault {
+			ps.LatestVersion, ps.Deprecated = v.VersionKey.Version, v.IsDeprecated
+			if !v.PublishedAt.IsZero() {
+				ps.Published = v.PublishedAt.Format(time.DateOnly)
+			}
+		}
+	}
+	if ps.LatestVersion == "" {
+		return ps, nil
+	}
+
+	versionURL := pkgURL + "/versions/" + url.PathEscape(ps.LatestVersion)
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.Go(func() error {
+		var version struct {
+			Licenses        []string `json:"licenses"`
+			AdvisoryKeys    []any    `json:"advisoryKeys"`
+			RelatedProjects []struct {
+				ProjectKey   struct{ ID string } `json:"projectKey"`
+				RelationType string              `json:"relationType"`
+			} `json:"relatedProjects"`
+		}
+		if err := getJSON(ctx, versionURL, &version); err != nil {
+			return err
+		}
+		var repo string
+		for _, p := range version.RelatedProjects {
+			if p.RelationType == "SOURCE_REPO" {
+				repo = p.ProjectKey.ID
+			}
+		}
+		mu.Lock()
+		ps.Licenses, ps.Advisories, ps.Repository = version.Licenses, len(version.AdvisoryKeys), repo
+		mu.Unlock()
+		if repo == "" {
+			return nil
+		}
+		var project struct {
+			StarsCount      int `json:"starsCount"`
+			OpenIssuesCount int `json:"openIssuesCount"`
+			Scorecard       struct {
+				OverallScore float64 `json:"overallScore"`
+			} `json:"scorecard"`
+		}
+		if err := getJSON(ctx, base+"/v3/projects/"+url.PathEscape(repo), &project); err != nil {
+			return err
+		}
+		mu.Lock()
+		ps.Stars, ps.OpenIssues, ps.Scorecard = project.StarsCount, project.OpenIssuesCount, project.Scorecard.OverallScore
+		mu.Unlock()
+		return nil
+	})
+	g.Go(func() error {
+		var dependents struct {
+			DirectDependentCount int `json:"directDependentCount"`
+		}
+		if err := getJSON(ctx, base+"/v3alpha/systems/go/packages/"+url.PathEscape(modPath)+"/versions/"+url.PathEscape(ps.LatestVersion)+":dependents", &dependents); err != nil {
+			return err
+		}
+		mu.Lock()
+		ps.DirectDependents = dependents.DirectDependentCount
+		mu.Unlock()
+		return nil
+	})
+	// The details are best effort; the default version alone is worth reporting
+	g.Wait()
+	return ps, nil
+}
+
+// getJSON fetches url and decodes its JSON body into out. A 404 response
+// reports errModuleUnknown.
+func getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return errModuleUnknown
+	default:
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// docSignals returns the signals of the module providing the package req
+// documents, or a warning explaining why there are none. Packages of the
+// standard library and the client's own module have none.
+func (s *GodocServer) docSignals(ctx context.Context, log *logrus.Entry, req docRequest) (*packageSignals, string) {
+	if isStdLib(req.path) || req.ownModule {
+		return nil, ""
+	}
+	var ps *packageSignals
+	var err error
+	if modPath, _ := requiredModule(req.path, req.workingDir); modPath != "" {
+		ps, err = s.moduleSignals(ctx, modPath)
+	} else {
+		ps, err = s.packageSignalsFor(ctx, req.path)
+	}
+	if err != nil {
+		log.WithError(err).Debug("Failed to fetch package signals")
+		return nil, fmt.Sprintf("package signals unavailable: %v", err)
+	}
+	return ps, ""
+}
+
+// addFoundSignals looks up the signals of each found package concurrently.
+// Packages whose module can't be looked up are left without them.
+func (s *GodocServer) addFoundSignals(ctx context.Context, log *logrus.Entry, pkgs []foundPackage) {
+	var g errgroup.Group
+	g.SetLimit(maxSignalLookups)
+	for i := range pkgs {
+		g.Go(func() error {
+			ps, err := s.packageSignalsFor(ctx, pkgs[i].Path)
+			if err != nil {
+				log.WithError(err).WithField("package", pkgs[i].Path).Debug("Failed to fetch package signals")
+				return nil
+			}
+			pkgs[i].Signals = ps
+			return nil
+		})
+	}
+	g.Wait()
+}
+
+// signalsNote formats signals for the start of a tool result
+func signalsNote(ps *packageSignals) string {
+	return "Signals (deps.dev): " + ps.String() + "\n"
+}
+
+// signalsArgument is the input schema of the signals argument
+var signalsArgument = map[string]any{
+	"type":        "boolean",
+	"description": "Optional: Attach popularity and maintenance signals from deps.dev for third-party packages: latest version and release date, licenses, direct dependents, repository stars and open issues, OpenSSF Scorecard, and security advisories.",
+	"default":     false,
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"go/types"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+// Limits on the functions a signature search returns
+const (
+	defaultSignatureResults = 50
+	maxSignatureResults     = 200
+)
+
+const findSignaturesDescription = `Find the functions and methods of a package, or of every package of a pattern such as './...', whose
+signature matches a pattern, using the type checker rather than text search. Parameters and results are
+given as lists of Go types as they are written in code, such as 'context.Context', '*http.Request', or
+'[]byte'; '_' matches any one type and '...' any number of them. For example, params
+['context.Context', '...'] with results ['_', 'error'] finds functions taking a context first and returning
+a value and an error, and kind 'method' with results ['io.Reader'] finds methods returning an io.Reader.`
+
+// findSignaturesSchema is the find_signatures input schema
+var findSignaturesSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Package to search, as for get_doc (e.g., 'net/http', './pkg', or 'github.com/user/repo'). End it with '/...' to include the packages below it, as in './...'.",
+		},
+		"params": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "Optional: Parameter types in order, with '_' for any type and '...' for any number of types. A variadic parameter is written '...T'. Omit to accept any parameters; [] matches none.",
+		},
+		"results": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "Optional: Result types in order, with the same wildcards. Omit to accept any results; [] matches none.",
+		},
+		"kind": map[string]any{
+			"type":        "string",
+			"description": "Optional: Only return functions or methods. Methods include those of interface types.",
+			"enum":        []string{symbolFunc, symbolMethod},
+		},
+		"unexported": map[string]any{
+			"type":        "boolean",
+			"description": "Include unexported functions and methods.",
+			"default":     false,
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of functions to return.",
+			"minimum":     1,
+			"maximum":     maxSignatureResults,
+			"default":     defaultSignatureResults,
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory for relative paths and the module's own packages. Defaults to the session working directory.",
+		},
+	},
+	Required: []string{"path"},
+}
+
+// funcMatchSchema describes funcMatch in output schemas
+var funcMatchSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"name":      map[string]any{"type": "string", "description": "Function name, with methods as Type.Method"},
+		"kind":      map[string]any{"type": "string", "enum": []string{symbolFunc, symbolMethod}},
+		"package":   map[string]any{"type": "string", "description": "Import path of the declaring package"},
+		"signature": map[string]any{"type": "string", "description": "Signature, with types qualified by package name and the package's own unqualified"},
+		"file":      map[string]any{"type": "string"},
+		"line":      map[string]any{"type": "integer"},
+		"matched": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "For find_by_type, the parameters and results holding the type",
+		},
+	},
+	"required": []string{"name", "kind", "package", "signature"},
+}
+
+// findSignaturesOutputSchema is the outputSchema of find_signatures, describing findSignaturesOutput
+var findSignaturesOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"functions": map[string]any{"type": "array", "items": funcMatchSchema},
+		"total":     map[string]any{"type": "integer", "description": "Matching functions before limit"},
+	},
+	Required: []string{"functions", "total"},
+}
+
+// findSignaturesOutput is the structured content of a find_signatures result
+type findSignaturesOutput struct {
+	Functions []funcMatch `json:"functions"`
+	Total     int         `json:"total"`
+}
+
+// funcMatch is a function or method found by its signature
+type funcMatch struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Package   string `json:"package"`
+	Signature string `json:"signature"`
+	File      string `json:"file,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	// Matched lists how a type search matched, such as "param req *http.Request"
+	Matched []string `json:"matched,omitempty"`
+}
+
+// handleFindSignatures implements the find_signatures tool
+func (s *GodocServer) handleFindSignatures(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	var params, results []string
+	var filterParams, filterResults bool
+	if _, ok := args["params"]; ok {
+		params, filterParams = request.GetStringSlice("params", nil), true
+	}
+	if _, ok := args["results"]; ok {
+		results, filterResults = request.GetStringSlice("results", nil), true
+	}
+	if !filterParams && !filterResults {
+		return errorResult(codeInvalidArgument, "give params, results, or both to match signatures against"), nil
+	}
+	params, results = normalizeTypes(params), normalizeTypes(results)
+
+	match := func(pkg *types.Package, fn *types.Func) []string {
+		sig := fn.Type().(*types.Signature)
+		if filterParams && !matchTypeList(params, tupleTypes(pkg, sig.Params(), sig.Variadic())) {
+			return nil
+		}
+		if filterResults && !matchTypeList(results, tupleTypes(pkg, sig.Results(), false)) {
+			return nil
+		}
+		return []string{}
+	}
+	return s.searchFuncs(ctx, request, match)
+}
+
+// searchFuncs lists the functions and methods of the packages request's path
+// names for which match returns a non-nil slice, explaining the match
+func (s *GodocServer) searchFuncs(ctx context.Context, request mcp.CallToolRequest, match func(*types.Package, *types.Func) []string) (*mcp.CallToolResult, error) {
+	path := request.GetString("path", "")
+	if path == "" {
+		return errorResult(codeInvalidArgument, "invalid or missing path parameter"), nil
+	}
+	kind := request.GetString("kind", "")
+	if kind != "" && kind != symbolFunc && kind != symbolMethod {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("invalid kind %q: must be func or method", kind)), nil
+	}
+	limit := request.GetInt("limit", defaultSignatureResults)
+	if limit < 1 || limit > maxSignatureResults {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("limit must be between 1 and %d, got %d", maxSignatureResults, limit)), nil
+	}
+	unexported := request.GetBool("unexported", false)
+	if err := s.runtime.Load().toolchainErr; err != nil {
+		return errorResultFromErr("cannot load packages", err), nil
+	}
+
+	progress := s.newProgressReporter(ctx, request)
+	ctx = withProgress(ctx, progress)
+	dir, err := s.loadDir(ctx, request, path)
+	if err != nil {
+		return errorResultFromErr("failed to find the source of "+path, err), nil
+	}
+	progress.step("Type-checking " + path)
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return errorResultFromErr("cannot load "+path, err), nil
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps | packages.NeedTypes,
+		Dir:     dir,
+	}, path)
+	release()
+	if err != nil {
+		return errorResultFromErr("failed to load "+path, err), nil
+	}
+
+	out := &findSignaturesOutput{Functions: []funcMatch{}}
+	var errs []string
+	loaded := 0
+	for _, pkg := range pkgs {
+		if pkg.Types == nil || len(pkg.Syntax) == 0 {
+			for _, e := range pkg.Errors {
+				errs = append(errs, e.Msg)
+			}
+			continue
+		}
+		loaded++
+		qualifier := nameQualifier(pkg.Types)
+		add := func(fn *types.Func, name, kind string) {
+			if !unexported && !fn.Exported() {
+				return
+			}
+			matched := match(pkg.Types, fn)
+			if matched == nil {
+				return
+			}
+			m := funcMatch{Name: name, Kind: kind, Package: pkg.PkgPath, Signature: types.ObjectString(fn, qualifier), Matched: matched}
+			if pos := pkg.Fset.Position(fn.Pos()); pos.IsValid() {
+				m.File, m.Line = pos.Filename, pos.Line
+			}
+			out.Functions = append(out.Functions, m)
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			switch obj := scope.Lookup(name).(type) {
+			case *types.Func:
+				if kind != symbolMethod {
+					add(obj, name, symbolFunc)
+				}
+			case *types.TypeName:
+				if kind == symbolFunc || obj.IsAlias() || !unexported && !obj.Exported() {
+					continue
+				}
+				named, ok := obj.Type().(*types.Named)
+				if !ok {
+					continue
+				}
+				for i := range named.NumMethods() {
+					m := named.Method(i)
+					add(m, name+"."+m.Name(), symbolMethod)
+				}
+				if iface, ok := named.Underlying().(*types.Interface); ok {
+					for i := range iface.NumExplicitMethods() {
+						m := iface.ExplicitMethod(i)
+						add(m, name+"."+m.Name(), symbolMethod)
+					}
+				}
+			}
+		}
+	}
+	if loaded == 0 {
+		msg := fmt.Sprintf("no Go packages match %s", path)
+		if len(errs) > 0 {
+			msg = strings.Join(errs, "; ")
+		}
+		return errorResultFromErr("failed to load "+path, withCode(codePkgNotFound, errors.New(msg))), nil
+	}
+	slices.SortFunc(out.Functions, func(a, b funcMatch) int {
+		return cmp.Or(cmp.Compare(a.Package, b.Package), cmp.Compare(a.Name, b.Name))
+	})
+	out.Total = len(out.Functions)
+	if len(out.Functions) > limit {
+		out.Functions = out.Functions[:limit]
+	}
+
+	var sb strings.Builder
+	switch {
+	case out.Total == 0:
+		fmt.Fprintf(&sb, "No functions in %s match", path)
+	case out.Total == 1:
+		fmt.Fprintf(&sb, "1 function in %s matches", path)
+	case out.Total > limit:
+		fmt.Fprintf(&sb, "%d functions in %s match; showing the first %d", out.Total, path, limit)
+	default:
+		fmt.Fprintf(&sb, "%d functions in %s match", out.Total, path)
+	}
+	pkgPath := ""
+	for _, m := range out.Functions {
+		if m.Package != pkgPath {
+			pkgPath = m.Package
+			fmt.Fprintf(&sb, "\n\n%s:", pkgPath)
+		}
+		fmt.Fprintf(&sb, "\n  %s", m.Signature)
+		if len(m.Matched) > 0 {
+			fmt.Fprintf(&sb, "\n    %s", strings.Join(m.Matched, "; "))
+		}
+	}
+	result := mcp.NewToolResultText(sb.String())
+	result.StructuredContent = out
+	return result, nil
+}
+
+// normalizeTypes returns the type patterns without spaces, spelling the empty
+// interface as any, as typeForms does
+func normalizeTypes(patterns []string) []string {
+	out := make([]string, len(patterns))
+	for i, p := range patterns {
+		out[i] = normalizeType(p)
+	}
+	return out
+}
+
+// normalizeType removes the spaces of a type as written, spelling the empty
+// interface as any
+func normalizeType(typ string) string {
+	typ = strings.Join(strings.Fields(typ), "")
+	return strings.ReplaceAll(typ, "interface{}", "any")
+}
+
+// nameQualifier qualifies types by package name, leaving those of pkg
+// unqualified
+func nameQualifier(pkg *types.Package) types.Qualifier {
+	return func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return p.Name()
+	}
+}
+
+// typeForms returns the ways code in or outside pkg writes typ, normalized:
+// qualified by package names, and with pkg's own types unqualified
+func typeForms(pkg *types.Package, typ types.Type) []string {
+	qualified := normalizeType(types.TypeString(typ, func(p *types.Package) string { return p.Name() }))
+	relative := normalizeType(types.TypeString(typ, nameQualifier(pkg)))
+	if relative == qualified {
+		return []string{qualified}
+	}
+	return []string{qualified, relative}
+}
+
+// tupleTypes returns the forms of the types of a parameter or result list,
+// writing a variadic last parameter as ...T
+func tupleTypes(pkg *types.Package, tuple *types.Tuple, variadic bool) [][]string {
+	forms := make([][]string, tuple.Len())
+	for i := range tuple.Len() {
+		typ := tuple.At(i).Type()
+		if slice, ok := typ.(*types.Slice); ok && variadic && i == tuple.Len()-1 {
+			for _, form := range typeForms(pkg, slice.Elem()) {
+				forms[i] = append(forms[i], "..."+form)
+			}
+			continue
+		}
+		forms[i] = typeForms(pkg, typ)
+	}
+	return forms
+}
+
+// matchTypeList reports whether the forms of a list of types match patterns,
+// where "_" matches any one type and "..." any number of them. It is the
+// usual wildcard match, which backtracks only to the last "..." seen, so it
+// takes time proportional to the product of the lengths whatever the pattern.
+func matchTypeList(patterns []string, forms [][]string) bool {
+	p, f := 0, 0
+	// star is the index of the last "..." seen, and resume the first form it
+	// has not yet consumed
+	star, resume := -1, 0
+	for f < len(forms) {
+		switch {
+		case p < len(patterns) && patterns[p] == "...":
+			star, resume = p, f
+			p++
+		case p < len(patterns) && (patterns[p] == "_" || slices.Contains(forms[f], patterns[p])):
+			p++
+			f++
+		case star >= 0:
+			// Let the last "..." consume one more form and retry what follows it
+			resume++
+			p, f = star+1, resume
+		default:
+			return false
+		}
+	}
+	for p < len(patterns) && patterns[p] == "..." {
+		p++
+	}
+	return p == len(patterns)
+}
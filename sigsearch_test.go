@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMatchTypeList(t *testing.T) {
+	// func(ctx context.Context, r *Reader, opts ...Option) (int, error) in package
+	// example, which code outside the package writes with example.Reader and example.Option
+	forms := [][]string{
+		{"context.Context"},
+		{"*example.Reader", "*Reader"},
+		{"...example.Option", "...Option"},
+	}
+	results := [][]string{{"int"}, {"error"}}
+
+	tests := []struct {
+		patterns []string
+		forms    [][]string
+		want     bool
+	}{
+		{nil, nil, true},
+		{nil, results, false},
+		{[]string{"int"}, nil, false},
+		{[]string{"int", "error"}, results, true},
+		{[]string{"error", "int"}, results, false},
+		{[]string{"int"}, results, false},
+		{[]string{"_", "error"}, results, true},
+		{[]string{"_", "_", "_"}, results, false},
+		{[]string{"context.Context", "*Reader", "...Option"}, forms, true},
+		{[]string{"context.Context", "*example.Reader", "...example.Option"}, forms, true},
+		{[]string{"context.Context", "*Reader", "Option"}, forms, false},
+		{[]string{"context.Context", "..."}, forms, true},
+		{[]string{"...", "...Option"}, forms, true},
+		{[]string{"...", "*Reader", "..."}, forms, true},
+		{[]string{"...", "context.Context"}, forms, false},
+		{[]string{"..."}, nil, true},
+		{[]string{"...", "_"}, nil, false},
+		{[]string{"...", "...", "error"}, results, true},
+		{[]string{"int", "...", "...", "error"}, results, true},
+		{[]string{"...", "_", "...", "_", "..."}, forms, true},
+		{[]string{"...", "_", "...", "_", "...", "_", "...", "_"}, forms, false},
+	}
+	for _, tt := range tests {
+		if got := matchTypeList(tt.patterns, tt.forms); got != tt.want {
+			t.Errorf("matchTypeList(%q, %q) = %v, want %v", tt.patterns, tt.forms, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTypeListManyWildcards(t *testing.T) {
+	// Patterns alternating "..." with a type no form has would take exponential
+	// time to reject by trying every split of the forms
+	var patterns []string
+	for range 30 {
+		patterns = append(patterns, "...", "string")
+	}
+	patterns = append(patterns, "bool")
+	forms := make([][]string, 60)
+	for i := range forms {
+		forms[i] = []string{"string"}
+	}
+	done := make(chan bool)
+	go func() { done <- matchTypeList(patterns, forms) }()
+	select {
+	case got := <-done:
+		if got {
+			t.Error("matched a pattern ending in a type the list lacks")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("matching a pattern of many ... entries did not finish")
+	}
+}
+
+func TestFindSignatures(t *testing.T) {
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/mod\n\ngo 1.21\n",
+		"svc/svc.go": `package svc
+
+import (
+	"context"
+	"io"
+)
+
+type Service struct{}
+
+func New(ctx context.Context, name string) (*Service, error) { return nil, nil }
+
+func (s *Service) Run(ctx context.Context) error { return nil }
+
+func (s *Service) Output() io.Reader { return nil }
+
+func (s *Service) stop(ctx context.Context) error { return nil }
+
+func Join(sep string, parts ...string) string { return "" }
+
+type Source interface {
+	Open(ctx context.Context) (io.ReadCloser, error)
+}
+
+func helper(ctx context.Context) error { return nil }
+`,
+		"svc/sub/sub.go": "package sub\n\nimport \"context\"\n\nfunc Start(ctx context.Context) error { return nil }\n",
+	})
+	ctx := context.Background()
+	withContext := []any{"context.Context", "..."}
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		want     []string
+		total    int
+	}{
+		{"no pattern", map[string]any{"path": "./svc", "working_dir": dir}, codeInvalidArgument, nil, 0},
+		{"no path", map[string]any{"params": withContext, "working_dir": dir}, codeInvalidArgument, nil, 0},
+		{"bad kind", map[string]any{"path": "./svc", "params": withContext, "kind": "type", "working_dir": dir}, codeInvalidArgument, nil, 0},
+		{"bad limit", map[string]any{"path": "./svc", "params": withContext, "limit": maxSignatureResults + 1, "working_dir": dir}, codeInvalidArgument, nil, 0},
+		{"no package", map[string]any{"path": "./svc/none/...", "params": withContext, "working_dir": dir}, codePkgNotFound, nil, 0},
+		{"params", map[string]any{"path": "./svc", "params": withContext, "working_dir": dir}, "", []string{"New", "Service.Run", "Source.Open"}, 3},
+		{"unexported", map[string]any{"path": "./svc", "params": withContext, "unexported": true, "working_dir": dir}, "", []string{"New", "Service.Run", "Service.stop", "Source.Open", "helper"}, 5},
+		{"functions", map[string]any{"path": "./svc", "params": withContext, "kind": symbolFunc, "working_dir": dir}, "", []string{"New"}, 1},
+		{"methods", map[string]any{"path": "./svc", "results": []any{"io.Reader"}, "kind": symbolMethod, "working_dir": dir}, "", []string{"Service.Output"}, 1},
+		{"no params", map[string]any{"path": "./svc", "params": []any{}, "working_dir": dir}, "", []string{"Service.Output"}, 1},
+		{"any result and error", map[string]any{"path": "./svc", "results": []any{"_", "error"}, "working_dir": dir}, "", []string{"New", "Source.Open"}, 2},
+		{"unqualified", map[string]any{"path": "./svc", "results": []any{"*Service", "error"}, "working_dir": dir}, "", []string{"New"}, 1},
+		{"qualified", map[string]any{"path": "./svc", "results": []any{"*svc.Service", "error"}, "working_dir": dir}, "", []string{"New"}, 1},
+		{"variadic", map[string]any{"path": "./svc", "params": []any{"string", "... string"}, "working_dir": dir}, "", []string{"Join"}, 1},
+		{"limit", map[string]any{"path": "./svc", "params": withContext, "limit": 1, "working_dir": dir}, "", []string{"New"}, 3},
+		{"packages below", map[string]any{"path": "./svc/...", "params": []any{"context.Context"}, "results": []any{"error"}, "working_dir": dir}, "", []string{"Service.Run", "Start"}, 2},
+		{"standard library", map[string]any{"path": "io", "params": []any{"io.Writer", "io.Reader"}, "results": []any{"int64", "error"}}, "", []string{"Copy"}, 1},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleFindSignatures, "find_signatures", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*findSignaturesOutput)
+		var got []string
+		for _, fn := range out.Functions {
+			got = append(got, fn.Name)
+		}
+		if !slices.Equal(got, tt.want) || out.Total != tt.total {
+			t.Errorf("%s: found %q of %d, want %q of %d", tt.name, got, out.Total, tt.want, tt.total)
+		}
+	}
+}
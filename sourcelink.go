@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/go/packages"
+)
+
+// sourceLinkArgument is the input schema of get_doc's source_link argument
+var sourceLinkArgument = map[string]any{
+	"type":        "boolean",
+	"description": "Optional: Include a link to the target's source on its hosting site (a GitHub, GitLab, or Bitbucket permalink to the declaration's line, or the Go source browser for the standard library and golang.org/x), falling back to pkg.go.dev. Without a target, links to the package's directory.",
+	"default":     false,
+}
+
+// sourceLink returns the URL of the declaration of req's target on the site
+// hosting its module's source, at the version documented, or of the package's
+// directory when there is no single target
+func (s *GodocServer) sourceLink(ctx context.Context, req docRequest) (string, error) {
+	if req.ownModule {
+		return "", errors.New("packages of the working module have no published source to link to")
+	}
+	if req.source != "" {
+		return "", errors.New("the documentation was not generated from the module's source")
+	}
+	pkg, err := s.packageFiles(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(pkg.GoFiles[0])
+	file, line := dir, 0
+	if req.target != "" && len(req.matched) == 0 {
+		if file, line = declPosition(pkg.GoFiles, req.target); file == "" {
+			return "", fmt.Errorf("no declaration of %s in %s", req.target, req.path)
+		}
+	}
+
+	if isStdLib(req.path) {
+		rel, err := filepath.Rel(filepath.Join(goRoot(), "src"), file)
+		if err != nil {
+			return "", err
+		}
+		ref := goToolchainVersion()
+		if !strings.HasPrefix(ref, "go1") {
+			ref = "master"
+		} else {
+			ref = "refs/tags/" + ref
+		}
+		return googleSourceURL("go", ref, path.Join("src", filepath.ToSlash(rel)), line), nil
+	}
+	if pkg.Module == nil {
+		return "", fmt.Errorf("no module provides %s", req.path)
+	}
+	// Modules documented in place in the module cache are the main module,
+	// versioned by their directory
+	version := pkg.Module.Version
+	if version == "" {
+		version = resolvedVersion(req.path, req.workingDir)
+	}
+	if version == "" {
+		return "", fmt.Errorf("the version of the module providing %s is unknown", req.path)
+	}
+	rel, err := filepath.Rel(pkg.Module.Dir, file)
+	if err != nil {
+		return "", err
+	}
+	return hostedSourceURL(pkg.Module.Path, version, req.path, req.target, filepath.ToSlash(rel), line), nil
+}
+
+// packageFiles lists the source files of the package req documents, with
+// the module providing it
+func (s *GodocServer) packageFiles(ctx context.Context, req docRequest) (*packages.Package, error) {
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedModule,
+		Dir:     req.workingDir,
+		Env:     buildEnviron(ctx),
+	}, req.path)
+	release()
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 || len(pkgs[0].GoFiles) == 0 {
+		return nil, fmt.Errorf("no source files for %s", req.path)
+	}
+	return pkgs[0], nil
+}
+
+// goRoot returns the GOROOT of the go command
+func goRoot() string {
+	env, err := goEnv(context.Background(), "GOROOT")
+	if err != nil {
+		return ""
+	}
+	return env["GOROOT"]
+}
+
+// hostedSourceURL returns the URL of file, relative to the root of module
+// modPath at version, on the site hosting the module's repository, pointing at
+// line when it is positive. Modules on hosts without a known URL scheme link
+// to the symbol on pkg.go.dev instead.
+func hostedSourceURL(modPath, version, pkgPath, target, file string, line int) string {
+	if repo, ok := strings.CutPrefix(modPath, "golang.org/x/"); ok {
+		repo, _, _ = strings.Cut(repo, "/")
+		sub := strings.TrimPrefix(strings.TrimPrefix(modPath, "golang.org/x/"+repo), "/")
+		ref := strings.TrimSuffix(version, "+incompatible")
+		if sub != "" {
+			ref = sub + "/" + ref
+		}
+		if module.IsPseudoVersion(version) {
+			ref, _ = module.PseudoVersionRev(version)
+		}
+		return googleSourceURL("x/"+repo, ref, path.Join(sub, file), line)
+	}
+
+	parts := strings.Split(modPath, "/")
+	host := parts[0]
+	if len(parts) < 3 || (host != "github.com" && host != "gitlab.com" && host != "bitbucket.org") {
+		u := "https://pkg.go.dev/" + pkgPath + "@" + version
+		if target != "" {
+			u += "#" + target
+		}
+		return u
+	}
+	repo := strings.Join(parts[:3], "/")
+	// A module below the repository root is in that directory and tagged
+	// with its path, unless the directory is only a major version suffix
+	sub := strings.Join(parts[3:], "/")
+	if _, _, ok := module.SplitPathVersion(modPath); ok && len(parts) == 4 && strings.HasPrefix(parts[3], "v") {
+		sub = ""
+	}
+	ref := strings.TrimSuffix(version, "+incompatible")
+	if sub != "" {
+		ref = sub + "/" + ref
+	}
+	if module.IsPseudoVersion(version) {
+		ref, _ = module.PseudoVersionRev(version)
+	}
+	file = path.Join(sub, file)
+
+	switch host {
+	case "gitlab.com":
+		u := fmt.Sprintf("https://%s/-/blob/%s/%s", repo, ref, file)
+		if line > 0 {
+			u += fmt.Sprintf("#L%d", line)
+		}
+		return u
+	case "bitbucket.org":
+		u := fmt.Sprintf("https://%s/src/%s/%s", repo, ref, file)
+		if line > 0 {
+			u += fmt.Sprintf("#lines-%d", line)
+		}
+		return u
+	default:
+		kind := "blob"
+		if line == 0 {
+			kind = "tree"
+		}
+		u := fmt.Sprintf("https://%s/%s/%s/%s", repo, kind, ref, file)
+		if line > 0 {
+			u += fmt.Sprintf("#L%d", line)
+		}
+		return u
+	}
+}
+
+// googleSourceURL returns the URL of file in the Go project repository repo at
+// ref on the Go source browser, pointing at line when it is positive
+func googleSourceURL(repo, ref, file string, line int) string {
+	u := fmt.Sprintf("https://cs.opensource.google/go/%s/+/%s:%s", repo, ref, file)
+	if line > 0 {
+		u += fmt.Sprintf(";l=%d", line)
+	}
+	return u
+}
+
+// declPosition returns the file and line declaring target among files: a
+// package-level name, or a method or field as Type.Name
+func declPosition(files []string, target string) (string, int) {
+	pos := declPositions(files, []string{target})[target]
+	return pos.Filename, pos.Line
+}
+
+// declPositions returns the positions declaring those of targets found among
+// files, as declPosition finds them, parsing each file once
+func declPositions(files, targets []string) map[string]token.Position {
+	positions := make(map[string]token.Position)
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if len(positions) == len(targets) {
+			break
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		found := func(name string, ident *ast.Ident) {
+			if _, ok := positions[name]; !ok && ident != nil && slices.Contains(targets, name) {
+				positions[name] = fset.Position(ident.Pos())
+			}
+		}
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				name := d.Name.Name
+				if d.Recv != nil {
+					name = receiverName(d.Recv) + "." + name
+				}
+				found(name, d.Name)
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch sp := spec.(type) {
+					case *ast.TypeSpec:
+						found(sp.Name.Name, sp.Name)
+						for _, target := range targets {
+							if typ, member, ok := strings.Cut(target, "."); ok && typ == sp.Name.Name {
+								found(target, memberIdent(sp.Type, member))
+							}
+						}
+					case *ast.ValueSpec:
+						for _, name := range sp.Names {
+							found(name.Name, name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return positions
+}
+
+// memberIdent returns the identifier declaring the field or interface method
+// name of a type expression, or nil
+func memberIdent(typ ast.Expr, name string) *ast.Ident {
+	var fields *ast.FieldList
+	switch t := typ.(type) {
+	case *ast.StructType:
+		fields = t.Fields
+	case *ast.InterfaceType:
+		fields = t.Methods
+	default:
+		return nil
+	}
+	for _, field := range fields.List {
+		for _, ident := range field.Names {
+			if ident.Name == name {
+				return ident
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const serverStatsDescription = `Report server health and statistics: uptime, tool calls served, failed calls by
+error category, documentation cache hit rate and size, live temporary projects, and the Go
+toolchain used to generate documentation. Use it to judge how fresh cached documentation is
+or to diagnose a misbehaving server.`
+
+// goToolchainVersion returns the version of the go command used for
+// documentation, which may differ from the toolchain the server was built with
+var goToolchainVersion = sync.OnceValue(func() string {
+	env, err := goEnv(context.Background(), "GOVERSION")
+	if err != nil {
+		return "unknown"
+	}
+	return env["GOVERSION"]
+})
+
+// handleServerStats implements the server_stats tool
+func (s *GodocServer) handleServerStats(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return mcp.NewToolResultText(s.statsReport()), nil
+}
+
+// statsReport describes the server's health and statistics
+func (s *GodocServer) statsReport() string {
+	cfg := s.config.Load()
+	build := getBuildInfo()
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Server: godoc-mcp %s (built with %s)\n", build, build.GoVersion)
+	fmt.Fprintf(&sb, "Go toolchain: %s\n", goToolchainVersion())
+	fmt.Fprintf(&sb, "Uptime: %s (started %s)\n", time.Since(s.started).Round(time.Second), s.started.UTC().Format(time.RFC3339))
+
+	m := s.metrics.snapshot()
+	sb.WriteString("\nRequests:\n")
+	fmt.Fprintf(&sb, "  tool calls: %d\n", m.toolCalls)
+	fmt.Fprintf(&sb, "  in flight: %d\n", s.inFlight.Load())
+	if m.toolCalls > 0 {
+		fmt.Fprintf(&sb, "  average latency: %s\n", (time.Duration(m.toolNanos) / time.Duration(m.toolCalls)).Round(time.Millisecond))
+	}
+	errCounts := s.metrics.errorCounts()
+	if len(errCounts) == 0 {
+		sb.WriteString("  errors: none\n")
+	} else {
+		sb.WriteString("  errors:\n")
+		for _, category := range slices.Sorted(maps.Keys(errCounts)) {
+			fmt.Fprintf(&sb, "    %s: %d\n", category, errCounts[category])
+		}
+	}
+
+	var bytes int
+	items := s.cache.Items()
+	var oldest time.Time
+	for _, item := range items {
+		doc := item.Value()
+		bytes += doc.byteSize
+		if oldest.IsZero() || doc.timestamp.Before(oldest) {
+			oldest = doc.timestamp
+		}
+	}
+	sb.WriteString("\nDocumentation cache:\n")
+	fmt.Fprintf(&sb, "  documents: %d (%d bytes", len(items), bytes)
+	if cfg.CacheMaxBytes > 0 {
+		fmt.Fprintf(&sb, " of %d", cfg.CacheMaxBytes)
+	}
+	sb.WriteString(")\n")
+	fmt.Fprintf(&sb, "  ttl: %s\n", cfg.CacheTTL)
+	if !oldest.IsZero() {
+		fmt.Fprintf(&sb, "  oldest entry: generated %s ago\n", time.Since(oldest).Round(time.Second))
+	}
+	if lookups := m.cacheHits + m.cacheMisses; lookups > 0 {
+		fmt.Fprintf(&sb, "  hit rate: %.1f%% (%d hits, %d misses)\n", 100*float64(m.cacheHits)/float64(lookups), m.cacheHits, m.cacheMisses)
+	}
+
+	sb.WriteString("\nResources:\n")
+	fmt.Fprintf(&sb, "  live temporary projects: %d\n", s.projectManager.liveProjects())
+	fmt.Fprintf(&sb, "  active subprocesses: %d of %d\n", s.limiter.inUse(), cfg.MaxSubprocesses)
+	fmt.Fprintf(&sb, "  standard library index: %s\n", readiness(s.stdlib.ready()))
+	return sb.String()
+}
+
+func readiness(ready bool) string {
+	if ready {
+		return "ready"
+	}
+	return "building"
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestServerStats(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	getDoc := s.trackInFlight(s.handleToolCall)
+	tests := []struct {
+		name string
+		// call is the get_doc call made before reading the statistics, if any
+		call map[string]any
+		want []string
+	}{
+		{"fresh", nil, []string{"Go toolchain: go", "tool calls: 0", "errors: none", "documents: 0 (0 bytes", "standard library index: building"}},
+		{"documented", map[string]any{"path": "io", "target": "Reader"}, []string{"tool calls: 1", "errors: none", "hit rate: "}},
+		{"repeated", map[string]any{"path": "io", "target": "Reader"}, []string{"tool calls: 2", "errors: none"}},
+		{"failed", map[string]any{"path": "io", "target": "NoSuchSymbol"}, []string{"tool calls: 3", "errors:\n    SYMBOL_NOT_FOUND: 1"}},
+	}
+	for _, tt := range tests {
+		if tt.call != nil {
+			callTool(t, ctx, getDoc, "get_doc", tt.call)
+		}
+		result := callTool(t, ctx, s.handleServerStats, "server_stats", nil)
+		text := resultText(result)
+		for _, want := range tt.want {
+			if !strings.Contains(text, want) {
+				t.Errorf("%s: statistics lack %q:\n%s", tt.name, want, text)
+			}
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/packages"
+)
+
+// maxSuggestions caps the alternatives offered for a missing package or symbol
+const maxSuggestions = 5
+
+// stdIndex is an in-memory index of standard library packages and their exported
+// symbols, so existence checks and suggestions never need a subprocess
+type stdIndex struct {
+	packages atomic.Pointer[map[string]*stdPackage]
+}
+
+// stdPackage is the indexed form of one standard library package
+type stdPackage struct {
+	name     string
+	synopsis string
+	// symbols holds top-level names and Type.Method / Type.Field names
+	symbols map[string]bool
+	// methods holds bare method names, which go doc also accepts as a target
+	methods map[string]bool
+	// doc is the package documentation, and docs the documentation of its
+	// symbols, with methods as Type.Method
+	doc  string
+	docs map[string]string
+}
+
+// build indexes the standard library for the current platform. Lookups report
+// the index as unavailable until it completes.
+func (x *stdIndex) build(ctx context.Context, logger *logrus.Logger) {
+	start := time.Now()
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles,
+		Dir:     os.TempDir(),
+	}, "std")
+	if err != nil {
+		logger.WithError(err).Warn("Failed to list standard library packages, index disabled")
+		return
+	}
+
+	index := make(map[string]*stdPackage, len(pkgs))
+	symbols := 0
+	fset := token.NewFileSet()
+	for _, pkg := range pkgs {
+		sp := &stdPackage{name: pkg.Name, symbols: make(map[string]bool), methods: make(map[string]bool)}
+		var files []*ast.File
+		for _, file := range pkg.GoFiles {
+			f, err := parser.ParseFile(fset, file, nil, parser.ParseComments|parser.SkipObjectResolution)
+			if err != nil {
+				continue
+			}
+			files = append(files, f)
+			sp.addDecls(f)
+		}
+		if len(files) > 0 {
+			if dpkg, err := doc.NewFromFiles(fset, files, pkg.PkgPath); err == nil {
+				sp.synopsis = dpkg.Synopsis(dpkg.Doc)
+				sp.addDocs(dpkg)
+			}
+		}
+		index[pkg.PkgPath] = sp
+		symbols += len(sp.symbols)
+	}
+	x.packages.Store(&index)
+	logger.WithFields(logrus.Fields{
+		"packages": len(index),
+		"symbols":  symbols,
+		"duration": time.Since(start),
+	}).Info("Standard library index built")
+}
+
+// addDecls records the exported declarations of f
+func (sp *stdPackage) addDecls(f *ast.File) {
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv == nil {
+				sp.symbols[d.Name.Name] = true
+				continue
+			}
+			if recv := recvTypeName(d.Recv); ast.IsExported(recv) {
+				sp.symbols[recv+"."+d.Name.Name] = true
+				sp.methods[d.Name.Name] = true
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.IsExported() {
+							sp.symbols[n.Name] = true
+						}
+					}
+				case *ast.TypeSpec:
+					if !s.Name.IsExported() {
+						continue
+					}
+					sp.symbols[s.Name.Name] = true
+					var fields *ast.FieldList
+					switch t := s.Type.(type) {
+					case *ast.StructType:
+						fields = t.Fields
+					case *ast.InterfaceType:
+						fields = t.Methods
+					}
+					if fields == nil {
+						continue
+					}
+					for _, field := range fields.List {
+						for _, n := range field.Names {
+							if n.IsExported() {
+								sp.symbols[s.Name.Name+"."+n.Name] = true
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// addDocs records the documentation of the package and its exported symbols
+func (sp *stdPackage) addDocs(dpkg *doc.Package) {
+	sp.doc = dpkg.Doc
+	sp.docs = make(map[string]string)
+	addValues := func(vals []*doc.Value) {
+		for _, v := range vals {
+			for _, name := range v.Names {
+				sp.docs[name] = v.Doc
+			}
+		}
+	}
+	addFuncs := func(prefix string, fns []*doc.Func) {
+		for _, fn := range fns {
+			sp.docs[prefix+fn.Name] = fn.Doc
+		}
+	}
+	addValues(dpkg.Consts)
+	addValues(dpkg.Vars)
+	addFuncs("", dpkg.Funcs)
+	for _, t := range dpkg.Types {
+		sp.docs[t.Name] = t.Doc
+		addValues(t.Consts)
+		addValues(t.Vars)
+		addFuncs("", t.Funcs)
+		addFuncs(t.Name+".", t.Methods)
+	}
+}
+
+// recvTypeName returns the base type name of a method receiver
+func recvTypeName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	expr := recv.List[0].Type
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// ready reports whether the index has been built
+func (x *stdIndex) ready() bool {
+	return x != nil && x.packages.Load() != nil
+}
+
+// lookup returns the indexed package with the given import path
+func (x *stdIndex) lookup(importPath string) (*stdPackage, bool) {
+	if !x.ready() {
+		return nil, false
+	}
+	sp, ok := (*x.packages.Load())[importPath]
+	return sp, ok
+}
+
+// publicPackages returns the sorted import paths of the indexed public packages
+func (x *stdIndex) publicPackages() []string {
+	if !x.ready() {
+		return nil
+	}
+	var out []string
+	for importPath := range *x.packages.Load() {
+		if isPublicStd(importPath) {
+			out = append(out, importPath)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// resolve returns the import paths of public packages whose last path element
+// is name, mirroring how go doc accepts "json" for "encoding/json"
+func (x *stdIndex) resolve(name string) []string {
+	if !x.ready() {
+		return nil
+	}
+	var matches []string
+	for importPath := range *x.packages.Load() {
+		if isPublicStd(importPath) && path.Base(importPath) == name {
+			matches = append(matches, importPath)
+		}
+	}
+	slices.Sort(matches)
+	return matches
+}
+
+// suggestPackages lists public packages with paths close to importPath, with
+// their synopses
+func (x *stdIndex) suggestPackages(importPath string) []string {
+	if !x.ready() {
+		return nil
+	}
+	index := *x.packages.Load()
+	var candidates []string
+	for p := range index {
+		if isPublicStd(p) {
+			candidates = append(candidates, p)
+		}
+	}
+	var out []string
+	for _, p := range closest(importPath, candidates, func(p string) string { return path.Base(p) }) {
+		out = append(out, fmt.Sprintf("%s: %s", p, index[p].synopsis))
+	}
+	return out
+}
+
+// checkStdLib validates a standard library lookup against the index, resolving a
+// short package name like "json" to its import path. Missing packages and symbols
+// are reported with suggestions instead of running go doc. Packages missing from
+// the index are left alone when the caller supplied its own module context.
+func (s *GodocServer) checkStdLib(pkgPath, target string, cmdFlags []string, ownModule bool) (string, error) {
+	if !s.stdlib.ready() {
+		return pkgPath, nil
+	}
+	sp, ok := s.stdlib.lookup(pkgPath)
+	if !ok {
+		if ownModule {
+			return pkgPath, nil
+		}
+		switch matches := s.stdlib.resolve(pkgPath); len(matches) {
+		case 0:
+			msg := "Package not found in the standard library."
+			if suggestions := s.stdlib.suggestPackages(pkgPath); len(suggestions) > 0 {
+				msg += " Did you mean:\n  " + strings.Join(suggestions, "\n  ")
+			}
+			return "", withCode(codePkgNotFound, fmt.Errorf("%s\nFor external packages, use the full import path (e.g., 'github.com/user/repo')", msg))
+		case 1:
+			pkgPath = matches[0]
+			sp, _ = s.stdlib.lookup(pkgPath)
+		default:
+			// Ambiguous short name, let go doc choose
+			return pkgPath, nil
+		}
+	}
+
+	// Unexported symbols are not indexed
+	if target == "" || slices.Contains(cmdFlags, "-u") {
+		return pkgPath, nil
+	}
+	if !sp.hasSymbol(target) {
+		msg := fmt.Sprintf("Symbol %s not found in package %s.", target, pkgPath)
+		if suggestions := sp.suggestSymbols(target); len(suggestions) > 0 {
+			msg += " Did you mean: " + strings.Join(suggestions, ", ") + "?"
+		}
+		return "", withCode(codeSymbolNotFound, fmt.Errorf("%s\nUse -u flag to see unexported symbols, or omit target to list the package", msg))
+	}
+	return pkgPath, nil
+}
+
+// hasSymbol reports whether target names an exported symbol, field, or method
+// of the package. As with go doc, a lower-case target matches either case.
+func (sp *stdPackage) hasSymbol(target string) bool {
+	if sp.symbols[target] || sp.methods[target] {
+		return true
+	}
+	if strings.ToLower(target) != target {
+		return false
+	}
+	for name := range sp.symbols {
+		if strings.EqualFold(name, target) {
+			return true
+		}
+	}
+	for name := range sp.methods {
+		if strings.EqualFold(name, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestSymbols lists the package's symbols closest to target
+func (sp *stdPackage) suggestSymbols(target string) []string {
+	return closest(target, sp.names(), func(s string) string { return s })
+}
+
+// names returns the package's symbols, with fields and methods as Type.Name
+func (sp *stdPackage) names() []string {
+	names := make([]string, 0, len(sp.symbols))
+	for name := range sp.symbols {
+		names = append(names, name)
+	}
+	return names
+}
+
+// isPublicStd reports whether a standard library package may be imported by user code
+func isPublicStd(importPath string) bool {
+	return !strings.HasPrefix(importPath, "vendor/") &&
+		importPath != "internal" && !strings.HasPrefix(importPath, "internal/") &&
+		!strings.Contains(importPath, "/internal/") && !strings.HasSuffix(importPath, "/internal")
+}
+
+// closest returns up to maxSuggestions candidates that contain want or are
+// within a small edit distance of it, compared case-insensitively on key
+func closest(want string, candidates []string, key func(string) string) []string {
+	matches := rankClosest(want, candidates, key)
+	var out []string
+	for _, m := range matches[:min(len(matches), maxSuggestions)] {
+		out = append(out, m.name)
+	}
+	return out
+}
+
+// scored is a candidate ranked by closest, lower scores being closer
+type scored struct {
+	name  string
+	score int
+}
+
+// rankClosest returns every candidate closest would suggest, closest first
+func rankClosest(want string, candidates []string, key func(string) string) []scored {
+	want = strings.ToLower(want)
+	var matches []scored
+	for _, c := range candidates {
+		k := strings.ToLower(key(c))
+		limit := max(1, len(want)/3)
+		switch d := editDistance(want, k); {
+		case d <= limit:
+			matches = append(matches, scored{c, d})
+		case strings.Contains(k, want) || (strings.Contains(want, k) && len(k) > 2):
+			matches = append(matches, scored{c, limit + 1})
+		}
+	}
+	slices.SortFunc(matches, func(a, b scored) int {
+		if a.score != b.score {
+			return a.score - b.score
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	return matches
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
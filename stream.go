@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// streamChunkSize is the largest documentation chunk sent in one notification
+const streamChunkSize = 32 << 10
+
+// streamArgument is the get_doc argument asking for streamed documentation
+var streamArgument = map[string]any{
+	"type":        "boolean",
+	"description": "Optional: Send documentation of at least the server's stream threshold as it is generated, in line-aligned chunks carried in the message of notifications/progress, instead of returning it in the result. Requires a progressToken. The result then reports the doc_id and size of the streamed document, which pages and line ranges can re-read. Cannot be combined with page, cursor, or a range.",
+}
+
+type docStreamerKey struct{}
+
+// docStreamer sends a document to the client in line-aligned chunks carried by
+// notifications/progress, as it is generated. Content is held back until the
+// document reaches the stream threshold, so short documents are returned in the
+// result as usual; past it, each chunk is sent once and dropped.
+type docStreamer struct {
+	ctx context.Context
+	// notify sends a chunk as a progress notification, reporting whether it was delivered
+	notify    func(progress float64, message string) bool
+	log       *logrus.Entry
+	threshold int
+
+	mu        sync.Mutex
+	pending   []byte
+	written   int
+	sent      int
+	chunks    int
+	streaming bool
+	failed    bool
+}
+
+// newDocStreamer returns a streamer for a get_doc call that asked for
+// streaming, or nil when it didn't or streaming is disabled
+func (s *GodocServer) newDocStreamer(ctx context.Context, request mcp.CallToolRequest, progress *progressReporter) *docStreamer {
+	threshold := s.config.Load().StreamThreshold
+	if threshold <= 0 || progress == nil || !request.GetBool("stream", false) {
+		return nil
+	}
+	return &docStreamer{ctx: ctx, notify: progress.notify, log: ctxLogger(ctx, s.logger), threshold: threshold}
+}
+
+// checkStreamRequest reports why a get_doc request can't be streamed, if it asked to be
+func checkStreamRequest(request mcp.CallToolRequest, rng *docRange) error {
+	if !request.GetBool("stream", false) {
+		return nil
+	}
+	args := request.GetArguments()
+	_, page := args["page"]
+	switch {
+	case request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil:
+		return fmt.Errorf("stream requires a progressToken in the request's _meta to carry the documentation")
+	case page, rng != nil:
+		return fmt.Errorf("stream sends the whole document and cannot be combined with page or a range")
+	}
+	return nil
+}
+
+// withDocStreamer returns a context carrying w for documentation generators
+func withDocStreamer(ctx context.Context, w *docStreamer) context.Context {
+	if w == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, docStreamerKey{}, w)
+}
+
+// docStreamerFromContext returns the streamer stored in ctx, if any
+func docStreamerFromContext(ctx context.Context) *docStreamer {
+	w, _ := ctx.Value(docStreamerKey{}).(*docStreamer)
+	return w
+}
+
+// Write holds p until the document reaches the stream threshold, then sends
+// every complete chunk of lines. It never fails, so it can be used as a tee.
+func (w *docStreamer) Write(p []byte) (int, error) {
+	if w == nil {
+		return len(p), nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failed {
+		return len(p), nil
+	}
+	w.written += len(p)
+	w.pending = append(w.pending, p...)
+	if w.written < w.threshold {
+		return len(p), nil
+	}
+	w.streaming = true
+	for len(w.pending) >= streamChunkSize && !w.failed {
+		cut := bytes.LastIndexByte(w.pending[:streamChunkSize], '\n') + 1
+		if cut == 0 {
+			cut = streamChunkSize
+		}
+		w.send(w.pending[:cut])
+		w.pending = w.pending[cut:]
+	}
+	return len(p), nil
+}
+
+// writeDoc streams doc, which was served from the cache or generated for
+// another caller, unless its content was already written while generated
+func (w *docStreamer) writeDoc(doc cachedDoc) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	written := w.written
+	w.mu.Unlock()
+	if written > 0 {
+		return
+	}
+	for i, line := range doc.lines {
+		if i < len(doc.lines)-1 {
+			line += "\n"
+		}
+		w.Write([]byte(line))
+	}
+}
+
+// discard drops content written by a generation that failed, which a retry
+// replaces. Streaming stops if some of it was already sent.
+func (w *docStreamer) discard() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.sent > 0 {
+		w.failed = true
+	}
+	w.pending, w.written, w.streaming = nil, 0, false
+}
+
+// Close sends the rest of a streamed document and reports whether the whole
+// document was streamed
+func (w *docStreamer) Close() bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.streaming || w.failed {
+		return false
+	}
+	if len(w.pending) > 0 {
+		w.send(w.pending)
+		w.pending = nil
+	}
+	if w.failed {
+		return false
+	}
+	w.log.WithFields(logrus.Fields{
+		"bytes":  w.sent,
+		"chunks": w.chunks,
+	}).Debug("Streamed documentation")
+	return true
+}
+
+// send delivers one chunk, whose progress is the number of bytes streamed so
+// far. Streaming stops once the caller is gone or a chunk can't be delivered.
+func (w *docStreamer) send(chunk []byte) {
+	if w.ctx.Err() != nil || !w.notify(float64(w.sent+len(chunk)), string(chunk)) {
+		w.log.Warn("Stopped streaming documentation, returning pages instead")
+		w.failed = true
+		return
+	}
+	w.sent += len(chunk)
+	w.chunks++
+}
+
+// finish streams doc unless its content was written as it was generated, and
+// sends the rest. It returns w when the whole document was streamed, and a
+// warning when streaming stopped partway and the result must hold the
+// documentation instead.
+func (w *docStreamer) finish(doc cachedDoc) (*docStreamer, string) {
+	if w == nil {
+		return nil, ""
+	}
+	w.writeDoc(doc)
+	if w.Close() {
+		return w, ""
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.sent > 0 {
+		return nil, fmt.Sprintf("streaming stopped after %d bytes, so the result holds the documentation instead", w.sent)
+	}
+	return nil, ""
+}
+
+// streamInfo describes a document streamed as progress notifications
+type streamInfo struct {
+	Bytes  int `json:"bytes"`
+	Chunks int `json:"chunks"`
+}
+
+// streamedResult returns the result of a get_doc call whose documentation was
+// streamed: where to find it again rather than the content already sent
+func (s *GodocServer) streamedResult(log *logrus.Entry, req docRequest, doc cachedDoc, sizing pageSizing, w *docStreamer) *mcp.CallToolResult {
+	if err := sizing.validate(s.config.Load().Pagination); err != nil {
+		return errorResult(codeInvalidArgument, err.Error())
+	}
+	starts := sizing.pageStarts(doc.lines)
+	name := req.path
+	if req.target != "" {
+		name += " " + req.target
+	}
+	log.WithFields(logrus.Fields{
+		"doc_id": doc.id,
+		"bytes":  w.sent,
+	}).Debug("Returning summary of streamed documentation")
+	text := fmt.Sprintf("Streamed the documentation of %s (%d lines, %d bytes; doc_id %s) in %d chunks of notifications/progress.\n"+
+		"Read it again with page 1 to %d, or a line range, and doc_id %s.", name, len(doc.lines), w.sent, doc.id, w.chunks, len(starts), doc.id)
+	result := mcp.NewToolResultText(text)
+	result.StructuredContent = &docOutput{
+		DocID:    doc.id,
+		Streamed: &streamInfo{Bytes: w.sent, Chunks: w.chunks},
+		Pagination: pageInfo{
+			PageSize:       sizing.lines,
+			PageSizeTokens: sizing.tokens,
+			TotalPages:     len(starts),
+			TotalLines:     len(doc.lines),
+		},
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// recordStreamer returns a streamer recording the chunks it sends, which fails
+// to deliver the chunk after the first failAfter when failAfter is positive
+func recordStreamer(threshold, failAfter int) (*docStreamer, *[]string) {
+	var chunks []string
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return &docStreamer{
+		ctx: context.Background(),
+		notify: func(_ float64, message string) bool {
+			if failAfter > 0 && len(chunks) == failAfter {
+				return false
+			}
+			chunks = append(chunks, message)
+			return true
+		},
+		log:       logrus.NewEntry(logger),
+		threshold: threshold,
+	}, &chunks
+}
+
+func TestDocStreamer(t *testing.T) {
+	line := strings.Repeat("x", 99) + "\n"
+	long := strings.Repeat(line, 1000) // 100,000 bytes, about three chunks
+	tests := []struct {
+		name      string
+		doc       string
+		writes    int
+		threshold int
+		failAfter int
+		streamed  bool
+		chunks    int
+	}{
+		{"below threshold", strings.Repeat(line, 10), 1, 2000, 0, false, 0},
+		{"one write", long, 1, 2000, 0, true, 4},
+		{"line by line", long, 1000, 2000, 0, true, 4},
+		{"odd writes", long, 7, 64 << 10, 0, true, 4},
+		{"undeliverable", long, 10, 2000, 2, false, 2},
+	}
+	for _, tt := range tests {
+		w, chunks := recordStreamer(tt.threshold, tt.failAfter)
+		size := len(tt.doc) / tt.writes
+		for rest := tt.doc; rest != ""; {
+			n := min(size, len(rest))
+			w.Write([]byte(rest[:n]))
+			rest = rest[n:]
+		}
+		if got := w.Close(); got != tt.streamed {
+			t.Errorf("%s: Close() = %v, want %v", tt.name, got, tt.streamed)
+		}
+		if len(*chunks) != tt.chunks {
+			t.Errorf("%s: sent %d chunks, want %d", tt.name, len(*chunks), tt.chunks)
+		}
+		for i, c := range *chunks {
+			if len(c) > streamChunkSize || i < len(*chunks)-1 && !strings.HasSuffix(c, "\n") {
+				t.Errorf("%s: chunk %d has %d bytes and doesn't end a line", tt.name, i, len(c))
+			}
+		}
+		// Each part of a fully streamed document is sent exactly once
+		if tt.streamed && strings.Join(*chunks, "") != tt.doc {
+			t.Errorf("%s: chunks don't add up to the document", tt.name)
+		}
+	}
+}
+
+func TestDocStreamerFinish(t *testing.T) {
+	doc := newCachedDoc(strings.Repeat("line of documentation\n", 200))
+
+	// Documents from the cache are streamed whole
+	w, chunks := recordStreamer(1000, 0)
+	if stream, warning := w.finish(doc); stream != w || warning != "" {
+		t.Fatalf("finish of a cached document = %v, %q; want the streamer", stream, warning)
+	}
+	if got, want := strings.Join(*chunks, ""), strings.Join(doc.lines, "\n"); got != want {
+		t.Errorf("streamed %d bytes of the cached document, want %d", len(got), len(want))
+	}
+
+	// Documents written as they were generated aren't sent again
+	w, chunks = recordStreamer(1000, 0)
+	w.Write([]byte(strings.Join(doc.lines, "\n")))
+	w.finish(doc)
+	if got := len(strings.Join(*chunks, "")); got != doc.byteSize {
+		t.Errorf("streamed %d bytes of a generated document, want %d", got, doc.byteSize)
+	}
+
+	// A failed generation held below the threshold leaves no trace
+	w, chunks = recordStreamer(1<<20, 0)
+	w.Write([]byte("partial output"))
+	w.discard()
+	if stream, _ := w.finish(newCachedDoc("short")); stream != nil || len(*chunks) != 0 {
+		t.Errorf("short document after a discarded generation was streamed in %d chunks", len(*chunks))
+	}
+
+	// A document only partly delivered is returned in the result with a warning
+	w, _ = recordStreamer(1, 1)
+	long := newCachedDoc(strings.Repeat(strings.Repeat("y", 999)+"\n", 100))
+	if stream, warning := w.finish(long); stream != nil || warning == "" {
+		t.Errorf("finish after a failed chunk = %v, %q; want a warning", stream, warning)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"go/doc"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// docOutput is the structured content of a get_doc result, returned alongside
+// the text page for clients that consume typed results
+type docOutput struct {
+	Package         string          `json:"package"`
+	Symbol          string          `json:"symbol,omitempty"`
+	RequestedSymbol string          `json:"requested_symbol,omitempty"`
+	MatchedSymbols  []string        `json:"matched_symbols,omitempty"`
+	AliasOf         string          `json:"alias_of,omitempty"`
+	Env             []string        `json:"env,omitempty"`
+	Import          *importHint     `json:"import,omitempty"`
+	Source          string          `json:"source,omitempty"`
+	SourceURL       string          `json:"source_url,omitempty"`
+	Warnings        []string        `json:"warnings,omitempty"`
+	Version         string          `json:"version,omitempty"`
+	Signals         *packageSignals `json:"signals,omitempty"`
+	DocScore        *docScore       `json:"doc_score,omitempty"`
+	DocID           string          `json:"doc_id"`
+	Entries         []docEntry      `json:"entries,omitempty"`
+	Pagination      pageInfo        `json:"pagination"`
+	// Outline is set when the result is an outline of documentation too long
+	// for one page rather than a page of it
+	Outline bool `json:"outline,omitempty"`
+	// Range is the explicit range of the document returned instead of a page
+	Range *docRange `json:"range,omitempty"`
+	// Streamed is set when the documentation was streamed as progress
+	// notifications instead of returned
+	Streamed *streamInfo `json:"streamed,omitempty"`
+}
+
+// docEntry summarizes one documented declaration
+type docEntry struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Recv      string `json:"recv,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Synopsis  string `json:"synopsis,omitempty"`
+	// Page is the page showing the declaration, in outlines
+	Page int `json:"page,omitempty"`
+}
+
+// pageInfo describes the page of a document returned by get_doc
+type pageInfo struct {
+	Page           int    `json:"page"`
+	PageSize       int    `json:"page_size"`
+	PageSizeTokens int    `json:"page_size_tokens,omitempty"`
+	TotalPages     int    `json:"total_pages"`
+	TotalLines     int    `json:"total_lines"`
+	FirstLine      int    `json:"first_line"`
+	LastLine       int    `json:"last_line"`
+	HasMore        bool   `json:"has_more"`
+	Truncated      bool   `json:"truncated,omitempty"`
+	NextCursor     string `json:"next_cursor,omitempty"`
+	RequestedPage  int    `json:"requested_page,omitempty"`
+}
+
+// docOutputSchema is the outputSchema of get_doc, describing docOutput
+var docOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"package": map[string]any{
+			"type":        "string",
+			"description": "Import path of the documented package",
+		},
+		"symbol": map[string]any{
+			"type":        "string",
+			"description": "The documented target symbol, if any",
+		},
+		"requested_symbol": map[string]any{
+			"type":        "string",
+			"description": "The target as requested, present only when no symbol had that name and symbol is the closest match documented instead",
+		},
+		"matched_symbols": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "The symbols documented, present only when symbol is a glob pattern",
+		},
+		"alias_of": map[string]any{
+			"type":        "string",
+			"description": "The type symbol aliases, qualified by its import path when declared in another package, when symbol is a type alias and the aliased type's documentation follows",
+		},
+		"env": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "The environment variables the documentation was generated with in place of the server's, as KEY=value, such as GOOS=windows when goos was requested",
+		},
+		"import": importHintSchema,
+		"source": map[string]any{
+			"type":        "string",
+			"description": "Present as \"pkg.go.dev\" when the documentation couldn't be generated locally and was converted from pkg.go.dev's rendering instead",
+		},
+		"source_url": map[string]any{
+			"type":        "string",
+			"description": "Link to the target's declaration, or the package's directory, on the site hosting its source, when source_link was requested",
+		},
+		"warnings": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "Problems worked around to serve the request, such as cmd_flags that go doc rejects and were dropped",
+		},
+		"version": map[string]any{
+			"type":        "string",
+			"description": "Go toolchain version for standard library packages, or the version of the module providing the package. Omitted for packages of the working directory's own module.",
+		},
+		"signals":   signalsSchema,
+		"doc_score": docScoreSchema,
+		"doc_id": map[string]any{
+			"type":        "string",
+			"description": "Identity of the documentation content; pass it back as doc_id when requesting later pages",
+		},
+		"entries": map[string]any{
+			"type":        "array",
+			"description": "The declarations in the documentation, on the first page or in an outline only. Omitted when they can't be extracted, such as for -src output.",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"kind": map[string]any{
+						"type": "string",
+						"enum": []string{"const", "var", "func", "type", "method"},
+					},
+					"name":      map[string]any{"type": "string"},
+					"recv":      map[string]any{"type": "string", "description": "Receiver type of a method"},
+					"signature": map[string]any{"type": "string", "description": "Declaration of a func or method"},
+					"synopsis":  map[string]any{"type": "string", "description": "First sentence of the declaration's documentation"},
+					"page":      map[string]any{"type": "integer", "description": "In outlines, the page of the full documentation showing the declaration"},
+				},
+				"required": []string{"kind", "name"},
+			},
+		},
+		"streamed": map[string]any{
+			"type":        "object",
+			"description": "Set when stream was requested and the documentation was sent as notifications/progress messages instead of returned; pagination.page is then 0",
+			"properties": map[string]any{
+				"bytes":  map[string]any{"type": "integer", "description": "Bytes of documentation streamed"},
+				"chunks": map[string]any{"type": "integer", "description": "Number of notifications carrying them"},
+			},
+			"required": []string{"bytes", "chunks"},
+		},
+		"outline": map[string]any{
+			"type":        "boolean",
+			"description": "Set when the documentation was too long for one page and the result is its overview and an outline of its declarations instead of page 1; pagination.page is then 0",
+		},
+		"range": map[string]any{
+			"type":        "object",
+			"description": "The range of the document returned, when start_line or start_byte was requested instead of a page; pagination.page is then 0",
+			"properties": map[string]any{
+				"unit":  map[string]any{"type": "string", "enum": []string{rangeLines, rangeBytes}},
+				"start": map[string]any{"type": "integer", "description": "First line (1-based) or byte offset (0-based) returned"},
+				"end":   map[string]any{"type": "integer", "description": "Last line returned, or the byte offset the range ends before"},
+				"total": map[string]any{"type": "integer", "description": "Length of the document in the range's unit"},
+			},
+			"required": []string{"unit", "start", "end", "total"},
+		},
+		"pagination": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"page":             map[string]any{"type": "integer"},
+				"page_size":        map[string]any{"type": "integer", "description": "Maximum number of lines per page"},
+				"page_size_tokens": map[string]any{"type": "integer", "description": "Estimated token budget pages were sized by, when page_size_tokens was requested"},
+				"total_pages":      map[string]any{"type": "integer"},
+				"total_lines":      map[string]any{"type": "integer"},
+				"first_line":       map[string]any{"type": "integer", "description": "1-based number of the first line on this page"},
+				"last_line":        map[string]any{"type": "integer", "description": "Number of the last line on this page"},
+				"has_more":         map[string]any{"type": "boolean", "description": "Whether lines follow this page"},
+				"truncated":        map[string]any{"type": "boolean", "description": "Whether the page was cut short by the server's response size limit"},
+				"next_cursor":      map[string]any{"type": "string", "description": "Opaque cursor for the page that follows, when has_more is set; pass it back as cursor"},
+				"requested_page":   map[string]any{"type": "integer", "description": "The page requested, when it was past the end of the document and the last page was returned instead"},
+			},
+			"required": []string{"page", "page_size", "total_pages", "total_lines", "first_line", "last_line", "has_more"},
+		},
+	},
+	Required: []string{"package", "doc_id", "pagination"},
+}
+
+// docEntries lists the declarations documented by pd
+func docEntries(pd *packageDoc) []docEntry {
+	var entries []docEntry
+	var synopsis doc.Package
+	values := func(kind string, vals []valueDoc) {
+		for _, v := range vals {
+			for _, name := range v.Names {
+				entries = append(entries, docEntry{Kind: kind, Name: name, Synopsis: synopsis.Synopsis(v.Doc)})
+			}
+		}
+	}
+	funcs := func(kind string, fns []funcDoc) {
+		for _, fn := range fns {
+			entries = append(entries, docEntry{Kind: kind, Name: fn.Name, Recv: fn.Recv, Signature: fn.Decl, Synopsis: synopsis.Synopsis(fn.Doc)})
+		}
+	}
+	values("const", pd.Consts)
+	values("var", pd.Vars)
+	funcs("func", pd.Funcs)
+	for _, t := range pd.Types {
+		entries = append(entries, docEntry{Kind: "type", Name: t.Name, Synopsis: synopsis.Synopsis(t.Doc)})
+		values("const", t.Consts)
+		values("var", t.Vars)
+		funcs("func", t.Funcs)
+		funcs("method", t.Methods)
+	}
+	return entries
+}
+
+// structuredEntries returns the declarations of the documentation requested by
+// args. JSON documents are parsed directly; text documents are paired with the
+// cached JSON extraction of the same request.
+func (s *GodocServer) structuredEntries(ctx context.Context, scope, workingDir, format string, doc cachedDoc, args []string) []docEntry {
+	pd := s.structuredDoc(ctx, scope, workingDir, format, doc, args)
+	if pd == nil {
+		return nil
+	}
+	return docEntries(pd)
+}
+
+// structuredDoc returns the JSON documentation of the request args that doc,
+// formatted as format, was generated for, or nil if it can't be extracted
+func (s *GodocServer) structuredDoc(ctx context.Context, scope, workingDir, format string, doc cachedDoc, args []string) *packageDoc {
+	if format != formatJSON {
+		var err error
+		if doc, err = s.runGoDoc(ctx, scope, workingDir, formatJSON, args...); err != nil {
+			ctxLogger(ctx, s.logger).WithError(err).Debug("No structured entries for documentation")
+			return nil
+		}
+	}
+	var pd packageDoc
+	if err := json.Unmarshal([]byte(strings.Join(doc.lines, "\n")), &pd); err != nil {
+		return nil
+	}
+	return &pd
+}
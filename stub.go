@@ -0,0 +1,337 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+const generateStubDescription = `Generate a compilable skeleton implementation of a Go interface: a struct type, a compile-time
+assertion that it implements the interface, and a stub for every method (including those of embedded
+interfaces) with the exact signature and a TODO body that panics. Use it instead of writing method
+signatures by hand when implementing interfaces such as io.Reader, http.Handler, or one of a local module's.
+The stub is type-checked against the interface's package before it is returned.`
+
+// generateStubSchema is the generate_stub input schema
+var generateStubSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Package declaring the interface, as for get_doc (e.g., 'io', 'net/http', './pkg', or 'github.com/user/repo').",
+		},
+		"target": map[string]any{
+			"type":        "string",
+			"description": "The interface to implement, such as 'Handler' or 'ReadWriteCloser'.",
+		},
+		"type_name": map[string]any{
+			"type":        "string",
+			"description": "Optional: Name of the implementing type. Defaults to the interface's name with a lowercase first letter, such as 'handler'.",
+		},
+		"package": map[string]any{
+			"type":        "string",
+			"description": "Optional: Package clause of the generated file. Defaults to 'main', or to the interface's package with same_package.",
+		},
+		"same_package": map[string]any{
+			"type":        "boolean",
+			"description": "Generate the stub for the interface's own package, referring to its types unqualified. Required for interfaces with unexported methods.",
+			"default":     false,
+		},
+		"pointer_receiver": map[string]any{
+			"type":        "boolean",
+			"description": "Declare the methods on a pointer receiver.",
+			"default":     true,
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory for relative paths and the module's own packages. Defaults to the session working directory.",
+		},
+	},
+	Required: []string{"path", "target"},
+}
+
+// generateStubOutputSchema is the outputSchema of generate_stub, describing stubOutput
+var generateStubOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"interface":   map[string]any{"type": "string", "description": "The implemented interface, qualified by its import path"},
+		"type_name":   map[string]any{"type": "string"},
+		"methods":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "The stubbed methods"},
+		"code":        map[string]any{"type": "string", "description": "The generated Go file"},
+		"checked":     map[string]any{"type": "boolean", "description": "Whether the file type-checked against the interface's package"},
+		"check_error": map[string]any{"type": "string", "description": "The type-checking errors, when checked is false"},
+	},
+	Required: []string{"interface", "type_name", "methods", "code", "checked"},
+}
+
+// stubOutput is the structured content of a generate_stub result
+type stubOutput struct {
+	Interface  string   `json:"interface"`
+	TypeName   string   `json:"type_name"`
+	Methods    []string `json:"methods"`
+	Code       string   `json:"code"`
+	Checked    bool     `json:"checked"`
+	CheckError string   `json:"check_error,omitempty"`
+}
+
+// handleGenerateStub implements the generate_stub tool
+func (s *GodocServer) handleGenerateStub(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path := request.GetString("path", "")
+	if path == "" {
+		return errorResult(codeInvalidArgument, "invalid or missing path parameter"), nil
+	}
+	target := request.GetString("target", "")
+	if !token.IsIdentifier(target) {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("target %q must name an interface type", target)), nil
+	}
+	typeName := request.GetString("type_name", "")
+	if typeName != "" && (!token.IsIdentifier(typeName) || typeName == "_") {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("type_name %q is not a Go identifier", typeName)), nil
+	}
+	pkgName := request.GetString("package", "")
+	if pkgName != "" && (!token.IsIdentifier(pkgName) || pkgName == "_") {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("package %q is not a Go identifier", pkgName)), nil
+	}
+	samePackage := request.GetBool("same_package", false)
+	if err := s.runtime.Load().toolchainErr; err != nil {
+		return errorResultFromErr("cannot load packages", err), nil
+	}
+	dir, err := s.loadDir(ctx, request, path)
+	if err != nil {
+		return errorResultFromErr("failed to find the source of "+path, err), nil
+	}
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return errorResultFromErr("cannot load "+path, err), nil
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps | packages.NeedTypes,
+		Dir:     dir,
+	}, path)
+	release()
+	if err != nil {
+		return errorResultFromErr("failed to load "+path, err), nil
+	}
+	if len(pkgs) != 1 {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("%s names %d packages; generate_stub takes one", path, len(pkgs))), nil
+	}
+	pkg := pkgs[0]
+	if pkg.Types == nil || len(pkg.Syntax) == 0 {
+		msg := "no Go files in " + path
+		if len(pkg.Errors) > 0 {
+			msg = pkg.Errors[0].Msg
+		}
+		return errorResult(cmp.Or(classifyOutput(msg), codePkgNotFound), msg), nil
+	}
+
+	obj, ok := pkg.Types.Scope().Lookup(target).(*types.TypeName)
+	if !ok {
+		return errorResult(codeSymbolNotFound, fmt.Sprintf("%s has no type %s", pkg.PkgPath, target)), nil
+	}
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	switch {
+	case !ok:
+		return errorResult(codeInvalidArgument, fmt.Sprintf("%s.%s is not an interface", pkg.PkgPath, target)), nil
+	case !iface.IsMethodSet():
+		return errorResult(codeInvalidArgument, fmt.Sprintf("%s.%s is a type constraint, which types can't implement as values", pkg.PkgPath, target)), nil
+	case !samePackage && !obj.Exported():
+		return errorResult(codeInvalidArgument, fmt.Sprintf("%s.%s is unexported; set same_package to implement it in its own package", pkg.PkgPath, target)), nil
+	}
+	if !samePackage {
+		for i := range iface.NumMethods() {
+			if m := iface.Method(i); !m.Exported() {
+				return errorResult(codeInvalidArgument, fmt.Sprintf("%s.%s has the unexported method %s, so only types in %s can implement it; set same_package", pkg.PkgPath, target, m.Name(), pkg.PkgPath)), nil
+			}
+		}
+	}
+	if pkgName == "" {
+		pkgName = "main"
+		if samePackage {
+			pkgName = pkg.Name
+		}
+	}
+
+	code, typeName, methods := stubFile(pkg.Types, obj, pkgName, typeName, samePackage, request.GetBool("pointer_receiver", true))
+	out := &stubOutput{Interface: pkg.PkgPath + "." + target, TypeName: typeName, Methods: methods, Code: code}
+	check := checkProgram(code, pkg.Types)
+	if samePackage {
+		check = checkInPackage(code, pkg)
+	}
+	if check != nil {
+		out.CheckError = check.Error()
+	} else {
+		out.Checked = true
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Stub of %s implementing %s (%s)", out.TypeName, out.Interface, strings.Join(methods, ", "))
+	if out.Checked {
+		sb.WriteString("; it type-checks against the interface's package\n\n")
+	} else {
+		fmt.Fprintf(&sb, "; it does not type-check, so adjust it before use: %s\n\n", out.CheckError)
+	}
+	sb.WriteString(code)
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// stubFile returns a Go file in package pkgName declaring a type implementing
+// the interface obj of pkg, the type's name, typeName or one after the
+// interface, and the names of its methods. With samePackage, the file is for
+// pkg itself.
+func stubFile(pkg *types.Package, obj *types.TypeName, pkgName, typeName string, samePackage, pointer bool) (string, string, []string) {
+	imports := make(map[string]string)
+	qualify := func(p *types.Package) string {
+		if samePackage && p == pkg {
+			return ""
+		}
+		imports[p.Path()] = p.Name()
+		return p.Name()
+	}
+	iface := obj.Type().Underlying().(*types.Interface)
+	var methods, sigs []string
+	used := make(map[string]bool)
+	for i := range iface.NumMethods() {
+		m := iface.Method(i)
+		sig := m.Type().(*types.Signature)
+		methods = append(methods, m.Name())
+		sigs = append(sigs, strings.TrimPrefix(types.TypeString(sig, qualify), "func"))
+		for _, tuple := range []*types.Tuple{sig.Params(), sig.Results()} {
+			for i := range tuple.Len() {
+				used[tuple.At(i).Name()] = true
+			}
+		}
+	}
+	ifaceName := obj.Name()
+	if !samePackage {
+		ifaceName = pkg.Name() + "." + ifaceName
+	}
+	// The type mustn't collide with the package's declarations or the
+	// packages its methods' signatures name
+	taken := make(map[string]bool)
+	if samePackage {
+		for _, name := range pkg.Scope().Names() {
+			taken[name] = true
+		}
+	}
+	for p := range imports {
+		taken[imports[p]] = true
+	}
+	if typeName == "" {
+		typeName = varName(obj.Name(), taken)
+	}
+
+	// Generic interfaces are implemented by a generic type with the same
+	// type parameters, asserted to implement it when they can be
+	// instantiated with string
+	var tparams *types.TypeParamList
+	if named, ok := obj.Type().(*types.Named); ok {
+		tparams = named.TypeParams()
+	}
+	var decl, params, instance string
+	if tparams.Len() > 0 {
+		var list, names []string
+		for i := range tparams.Len() {
+			tp := tparams.At(i)
+			list = append(list, tp.Obj().Name()+" "+types.TypeString(tp.Constraint(), qualify))
+			names = append(names, tp.Obj().Name())
+		}
+		decl, params = "["+strings.Join(list, ", ")+"]", "["+strings.Join(names, ", ")+"]"
+		if args, ok := typeArguments(tparams); ok {
+			var strs []string
+			for _, arg := range args {
+				strs = append(strs, types.TypeString(arg, qualify))
+			}
+			instance = "[" + strings.Join(strs, ", ") + "]"
+		}
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "// %s implements %s.\ntype %s%s struct{}\n\n", typeName, ifaceName, typeName, decl)
+	if tparams.Len() == 0 || instance != "" {
+		qualify(pkg)
+		if pointer {
+			fmt.Fprintf(&body, "var _ %s%s = (*%s%s)(nil)\n", ifaceName, instance, typeName, instance)
+		} else {
+			fmt.Fprintf(&body, "var _ %s%s = %s%s{}\n", ifaceName, instance, typeName, instance)
+		}
+	}
+	recv := receiverFor(typeName, used)
+	recvType := typeName + params
+	if pointer {
+		recvType = "*" + recvType
+	}
+	for i, name := range methods {
+		fmt.Fprintf(&body, "\n// %s implements %s.\nfunc (%s %s) %s%s {\n\t// TODO: implement %s.\n\tpanic(\"unimplemented\")\n}\n",
+			name, ifaceName, recv, recvType, name, sigs[i], name)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "package %s\n\n", pkgName)
+	if len(imports) > 0 {
+		sb.WriteString("import (\n")
+		for _, p := range slices.Sorted(maps.Keys(imports)) {
+			if imports[p] != assumedPackageName(p) {
+				fmt.Fprintf(&sb, "\t%s %q\n", imports[p], p)
+			} else {
+				fmt.Fprintf(&sb, "\t%q\n", p)
+			}
+		}
+		sb.WriteString(")\n\n")
+	}
+	sb.WriteString(body.String())
+	if src, err := format.Source([]byte(sb.String())); err == nil {
+		return string(src), typeName, methods
+	}
+	return sb.String(), typeName, methods
+}
+
+// receiverFor returns a receiver name for the methods of typeName that
+// none of the names their signatures use collide with
+func receiverFor(typeName string, used map[string]bool) string {
+	name := strings.ToLower(typeName[:1])
+	for _, candidate := range []string{name, "x", "impl"} {
+		if !used[candidate] && !token.IsKeyword(candidate) {
+			return candidate
+		}
+	}
+	return "_"
+}
+
+// checkInPackage type-checks the file code as part of pkg, importing the
+// packages pkg imports from their loaded types
+func checkInPackage(code string, pkg *packages.Package) error {
+	f, err := parser.ParseFile(pkg.Fset, "stub.go", code, 0)
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]*types.Package)
+	for path, imp := range pkg.Imports {
+		if imp.Types != nil {
+			loaded[path] = imp.Types
+		}
+	}
+	conf := types.Config{Importer: importerFunc(func(path string) (*types.Package, error) {
+		if p, ok := loaded[path]; ok {
+			return p, nil
+		}
+		return nil, fmt.Errorf("package %s is not imported by %s", path, pkg.PkgPath)
+	})}
+	var errs []error
+	conf.Error = func(err error) { errs = append(errs, err) }
+	conf.Check(pkg.PkgPath, pkg.Fset, append(slices.Clone(pkg.Syntax), f), nil)
+	return errors.Join(errs...)
+}
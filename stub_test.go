@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerateStub(t *testing.T) {
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/mod\n\ngo 1.21\n",
+		"shapes/shapes.go": `package shapes
+
+type Shape interface {
+	Area() float64
+	sealed()
+}
+
+type Getter[T any] interface {
+	Get() T
+}
+
+type Number interface{ ~int | ~float64 }
+
+type closer interface{ Close() error }
+`,
+	})
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		methods  []string
+		want     []string
+	}{
+		{"no path", map[string]any{"target": "Reader"}, codeInvalidArgument, nil, nil},
+		{"bad target", map[string]any{"path": "io", "target": "io.Reader"}, codeInvalidArgument, nil, nil},
+		{"bad type name", map[string]any{"path": "io", "target": "Reader", "type_name": "_"}, codeInvalidArgument, nil, nil},
+		{"bad package", map[string]any{"path": "io", "target": "Reader", "package": "1x"}, codeInvalidArgument, nil, nil},
+		{"no such type", map[string]any{"path": "io", "target": "Missing"}, codeSymbolNotFound, nil, nil},
+		{"not a type", map[string]any{"path": "io", "target": "Copy"}, codeSymbolNotFound, nil, nil},
+		{"not an interface", map[string]any{"path": "io", "target": "SectionReader"}, codeInvalidArgument, nil, nil},
+		{"embedded interfaces", map[string]any{"path": "io", "target": "ReadWriteCloser"}, "", []string{"Close", "Read", "Write"}, []string{
+			"package main\n\nimport (\n\t\"io\"\n)\n",
+			"var _ io.ReadWriteCloser = (*readWriteCloser)(nil)\n",
+			"func (r *readWriteCloser) Read(p []byte) (n int, err error) {\n\t// TODO: implement Read.\n\tpanic(\"unimplemented\")\n}\n",
+		}},
+		{"value receiver", map[string]any{"path": "io", "target": "Reader", "type_name": "myReader", "package": "reader", "pointer_receiver": false}, "", []string{"Read"}, []string{
+			"package reader\n",
+			"var _ io.Reader = myReader{}\n",
+			"func (m myReader) Read(p []byte) (n int, err error) {",
+		}},
+		{"other packages", map[string]any{"path": "net/http", "target": "Handler"}, "", []string{"ServeHTTP"}, []string{
+			"\t\"net/http\"\n",
+			"func (h *handler) ServeHTTP(http.ResponseWriter, *http.Request) {",
+		}},
+		{"unexported interface", map[string]any{"path": "./shapes", "target": "closer", "working_dir": dir}, codeInvalidArgument, nil, nil},
+		{"unexported method", map[string]any{"path": "./shapes", "target": "Shape", "working_dir": dir}, codeInvalidArgument, nil, nil},
+		{"type constraint", map[string]any{"path": "./shapes", "target": "Number", "working_dir": dir}, codeInvalidArgument, nil, nil},
+		{"same package", map[string]any{"path": "./shapes", "target": "Shape", "same_package": true, "working_dir": dir}, "", []string{"Area", "sealed"}, []string{
+			"package shapes\n\n// shape implements Shape.\ntype shape struct{}\n\nvar _ Shape = (*shape)(nil)\n",
+			"func (s *shape) sealed() {",
+		}},
+		{"generic", map[string]any{"path": "./shapes", "target": "Getter", "working_dir": dir}, "", []string{"Get"}, []string{
+			"type getter[T any] struct{}\n\nvar _ shapes.Getter[string] = (*getter[string])(nil)\n",
+			"func (g *getter[T]) Get() T {",
+		}},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleGenerateStub, "generate_stub", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*stubOutput)
+		if !out.Checked {
+			t.Errorf("%s: stub does not type-check: %s\n%s", tt.name, out.CheckError, out.Code)
+		}
+		if !slices.Equal(out.Methods, tt.methods) {
+			t.Errorf("%s: methods %q, want %q", tt.name, out.Methods, tt.methods)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out.Code, want) {
+				t.Errorf("%s: stub lacks %q:\n%s", tt.name, want, out.Code)
+			}
+		}
+	}
+}
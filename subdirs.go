@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxSubpackages caps the packages listed for a directory without Go files
+const maxSubpackages = 100
+
+// subpackage is a package found below a local directory without Go files
+type subpackage struct {
+	// Path is the package directory relative to the module root, as get_doc
+	// accepts it with the module root as working_dir
+	Path       string `json:"path"`
+	ImportPath string `json:"import_path"`
+}
+
+// hasGoFiles reports whether dir directly contains Go files other than tests
+func hasGoFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && isPackageFile(e.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPackageFile reports whether a file name is a non-test Go source file
+func isPackageFile(name string) bool {
+	return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
+}
+
+// findSubpackages returns the packages in dir and below it, within the module
+// modPath rooted at moduleDir, skipping the directories the go command does
+func findSubpackages(moduleDir, dir, modPath string) []subpackage {
+	var subs []subpackage
+	seen := make(map[string]bool)
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if skipPackageDir(moduleDir, dir, p) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		pkgDir := filepath.Dir(p)
+		if seen[pkgDir] || !isPackageFile(d.Name()) {
+			return nil
+		}
+		seen[pkgDir] = true
+		rel, err := filepath.Rel(moduleDir, pkgDir)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		subs = append(subs, subpackage{Path: "./" + rel, ImportPath: path.Join(modPath, rel)})
+		if len(subs) == maxSubpackages {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return subs
+}
+
+// skipPackageDir reports whether the go command skips directory p when
+// matching packages from dir down in the module rooted at moduleDir: p is
+// testdata, vendor, starts with "." or "_", or is a nested module
+func skipPackageDir(moduleDir, dir, p string) bool {
+	name := filepath.Base(p)
+	if p != dir && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(p, "go.mod"))
+	return err == nil && p != moduleDir
+}
+
+// subpackagesResult reports that the local path has no Go files, listing the
+// packages below it both in the message and as subpackages in the result's _meta
+func subpackagesResult(path string, subs []subpackage) *mcp.CallToolResult {
+	var lines []string
+	for _, sub := range subs {
+		lines = append(lines, fmt.Sprintf("  %s (%s)", sub.Path, sub.ImportPath))
+	}
+	msg := fmt.Sprintf("No Go files found in %s, but found Go packages in the following subdirectories:\n%s", path, strings.Join(lines, "\n"))
+	if len(subs) == maxSubpackages {
+		msg += fmt.Sprintf("\nOnly the first %d packages are listed.", maxSubpackages)
+	}
+	msg += "\nPass one of them as path, with the module root as working_dir."
+	result := errorResult(codePkgNotFound, msg)
+	result.Meta.AdditionalFields["subpackages"] = subs
+	return result
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/mod/modfile"
+)
+
+// Resource subscription requests, which mcp-go leaves unrouted, so they are
+// answered before messages reach it
+const (
+	methodSubscribe   = "resources/subscribe"
+	methodUnsubscribe = "resources/unsubscribe"
+)
+
+// stdioSessionID is the ID mcp-go gives its single stdio session
+const stdioSessionID = "stdio"
+
+// subscriptionInterval is how often subscribed resources are checked for a
+// newer module version
+const subscriptionInterval = 5 * time.Minute
+
+// subscriptionSet records the sessions subscribed to each resource
+type subscriptionSet struct {
+	mu sync.Mutex
+	// sessions maps a resource URI to the IDs of its subscribers
+	sessions map[string]map[string]bool
+}
+
+// add subscribes session to uri
+func (ss *subscriptionSet) add(session, uri string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.sessions == nil {
+		ss.sessions = make(map[string]map[string]bool)
+	}
+	if ss.sessions[uri] == nil {
+		ss.sessions[uri] = make(map[string]bool)
+	}
+	ss.sessions[uri][session] = true
+}
+
+// remove unsubscribes session from uri
+func (ss *subscriptionSet) remove(session, uri string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.sessions[uri], session)
+	if len(ss.sessions[uri]) == 0 {
+		delete(ss.sessions, uri)
+	}
+}
+
+// drop unsubscribes session from every resource
+func (ss *subscriptionSet) drop(session string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for uri, sessions := range ss.sessions {
+		delete(sessions, session)
+		if len(sessions) == 0 {
+			delete(ss.sessions, uri)
+		}
+	}
+}
+
+// uris returns the subscribed resources in order
+func (ss *subscriptionSet) uris() []string {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	var uris []string
+	for uri := range ss.sessions {
+		uris = append(uris, uri)
+	}
+	slices.Sort(uris)
+	return uris
+}
+
+// subscribers returns the sessions subscribed to uri in order
+func (ss *subscriptionSet) subscribers(uri string) []string {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	var sessions []string
+	for session := range ss.sessions[uri] {
+		sessions = append(sessions, session)
+	}
+	slices.Sort(sessions)
+	return sessions
+}
+
+// answerSubscription answers message for session when it is a
+// resources/subscribe or resources/unsubscribe request, and reports false for
+// any other message, which is left to mcp-go
+func (s *GodocServer) answerSubscription(session string, message []byte) (mcp.JSONRPCMessage, bool) {
+	if !bytes.Contains(message, []byte(`"resources/`)) {
+		return nil, false
+	}
+	var req struct {
+		ID     mcp.RequestId `json:"id"`
+		Method string        `json:"method"`
+		Params struct {
+			URI string `json:"uri"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(message, &req); err != nil || req.ID.IsNil() || req.Method != methodSubscribe && req.Method != methodUnsubscribe {
+		return nil, false
+	}
+	log := s.logger.WithFields(logrus.Fields{
+		"session": session,
+		"uri":     req.Params.URI,
+	})
+	if _, ok := s.clients.Load(session); !ok {
+		return mcp.NewJSONRPCError(req.ID, mcp.INVALID_REQUEST, "session not initialized", nil), true
+	}
+	u, err := parseDocURI(req.Params.URI)
+	if err != nil {
+		return mcp.NewJSONRPCError(req.ID, mcp.INVALID_PARAMS, err.Error(), nil), true
+	}
+	if req.Method == methodUnsubscribe {
+		s.subscriptions.remove(session, u.String())
+		log.Debug("Unsubscribed from documentation resource")
+		return mcp.NewJSONRPCResultResponse(req.ID, mcp.EmptyResult{}), true
+	}
+	// Resources are backed by get_doc and follow its configuration
+	if !s.toolAllowed("get_doc") {
+		return mcp.NewJSONRPCError(req.ID, mcp.INVALID_PARAMS, "documentation resources are disabled by configuration", nil), true
+	}
+	s.subscriptions.add(session, u.String())
+	log.Debug("Subscribed to documentation resource")
+	return mcp.NewJSONRPCResultResponse(req.ID, mcp.EmptyResult{}), true
+}
+
+// serveStdio serves mcpServer over stdin and stdout like server.ServeStdio,
+// answering resource subscription requests on the way in
+func (s *GodocServer) serveStdio(mcpServer *server.MCPServer, opts ...server.StdioOption) error {
+	stdio := server.NewStdioServer(mcpServer)
+	for _, opt := range opts {
+		opt(stdio)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	return stdio.Listen(ctx, s.stdioSubscriptions(os.Stdin, os.Stdout), os.Stdout)
+}
+
+// stdioSubscriptions returns the messages read from r, less the resource
+// subscription requests, which are answered on w. Each response is written
+// with a single Write, as mcp-go writes its own, so lines never interleave.
+func (s *GodocServer) stdioSubscriptions(r io.Reader, w io.Writer) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if resp, ok := s.answerSubscription(stdioSessionID, line); ok {
+				data, _ := json.Marshal(resp)
+				w.Write(append(data, '\n'))
+			} else if _, werr := pw.Write(line); werr != nil {
+				return
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
+
+// withSubscriptions answers the resource subscription requests posted to an
+// MCP HTTP endpoint and passes every other request on. session returns the ID
+// of the session posting a request, and reply delivers the response.
+func (s *GodocServer) withSubscriptions(next http.Handler, session func(*http.Request) string, reply func(http.ResponseWriter, *http.Request, mcp.JSONRPCMessage)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		if resp, ok := s.answerSubscription(session(r), body); ok {
+			reply(w, r, resp)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// replyJSON writes a response in the body of the request, as the streamable
+// HTTP transport answers a request it doesn't upgrade to an event stream
+func replyJSON(w http.ResponseWriter, _ *http.Request, resp mcp.JSONRPCMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// replySSE returns a reply sending the response on the event stream of the
+// legacy SSE session named by the request, which is accepted with no body
+func replySSE(sse *server.SSEServer) func(http.ResponseWriter, *http.Request, mcp.JSONRPCMessage) {
+	return func(w http.ResponseWriter, r *http.Request, resp mcp.JSONRPCMessage) {
+		if err := sse.SendEventToSession(r.URL.Query().Get("sessionId"), resp); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// watchSubscriptions checks the subscribed resources every subscriptionInterval
+// until ctx is cancelled, notifying the subscribers of each one whose
+// documentation changed
+func (s *GodocServer) watchSubscriptions(ctx context.Context, mcpServer *server.MCPServer) {
+	ticker := time.NewTicker(subscriptionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, uri := range s.subscriptions.uris() {
+			if s.refreshResource(ctx, uri) {
+				s.notifyUpdated(mcpServer, uri)
+			}
+		}
+	}
+}
+
+// refreshResource reports whether the module documented by the resource uri
+// has a newer latest version than the one its project holds, dropping that
+// project so the next read documents the new version. Only resources of a
+// module's latest version change: the standard library follows the server's
+// toolchain, and module versions are immutable.
+func (s *GodocServer) refreshResource(ctx context.Context, uri string) bool {
+	u, err := parseDocURI(uri)
+	if err != nil || isStdLib(u.path) || u.version != "" && u.version != "latest" || !s.runtime.Load().network {
+		return false
+	}
+	log := s.logger.WithField("uri", uri)
+	project := u.path
+	if u.version != "" {
+		project += "@" + u.version
+	}
+	workingDir, err := s.projectManager.GetOrCreateProject(ctx, project)
+	if err != nil {
+		log.WithError(err).Debug("Cannot check subscribed resource")
+		return false
+	}
+	modPath, _ := requiredModule(u.path, workingDir)
+	if modPath == "" {
+		// Modules in the module cache are documented in place
+		data, _ := os.ReadFile(filepath.Join(workingDir, "go.mod"))
+		modPath = modfile.ModulePath(data)
+	}
+	version := resolvedVersion(u.path, workingDir)
+	if modPath == "" || version == "" {
+		return false
+	}
+	release, err := s.projectManager.limiter.acquire(ctx)
+	if err != nil {
+		return false
+	}
+	latest, err := latestModuleVersion(ctx, modPath)
+	release()
+	if err != nil {
+		log.WithError(err).Debug("Cannot resolve latest version of subscribed resource")
+		return false
+	}
+	if latest == version {
+		return false
+	}
+	log.WithFields(logrus.Fields{
+		"module":  modPath,
+		"version": version,
+		"latest":  latest,
+	}).Info("Subscribed resource has a new module version")
+	s.projectManager.forget(project)
+	return true
+}
+
+// notifyUpdated sends notifications/resources/updated for uri to its subscribers
+func (s *GodocServer) notifyUpdated(mcpServer *server.MCPServer, uri string) {
+	for _, session := range s.subscriptions.subscribers(uri) {
+		err := mcpServer.SendNotificationToSpecificClient(session, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+		if err != nil {
+			s.logger.WithError(err).WithField("session", session).Debug("Failed to send resource update")
+		}
+	}
+}
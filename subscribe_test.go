@@ -0,0 +1,199 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestAnswerSubscription(t *testing.T) {
+	s := newTestServer(t)
+	s.clients.Store("a", struct{}{})
+	tests := []struct {
+		name     string
+		session  string
+		message  string
+		answered bool
+		wantCode int
+		want     []string
+	}{
+		{"other method", "a", `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"godoc://io"}}`, false, 0, nil},
+		{"notification", "a", `{"jsonrpc":"2.0","method":"resources/subscribe","params":{"uri":"godoc://io"}}`, false, 0, nil},
+		{"subscribe", "a", `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"godoc://io"}}`, true, 0, []string{"godoc://io"}},
+		{"normalized", "a", `{"jsonrpc":"2.0","id":3,"method":"resources/subscribe","params":{"uri":"godoc://net/http/#Client"}}`, true, 0, []string{"godoc://io", "godoc://net/http#Client"}},
+		{"invalid uri", "a", `{"jsonrpc":"2.0","id":4,"method":"resources/subscribe","params":{"uri":"https://io"}}`, true, mcp.INVALID_PARAMS, []string{"godoc://io", "godoc://net/http#Client"}},
+		{"unknown session", "b", `{"jsonrpc":"2.0","id":5,"method":"resources/subscribe","params":{"uri":"godoc://fmt"}}`, true, mcp.INVALID_REQUEST, []string{"godoc://io", "godoc://net/http#Client"}},
+		{"unsubscribe", "a", `{"jsonrpc":"2.0","id":6,"method":"resources/unsubscribe","params":{"uri":"godoc://io"}}`, true, 0, []string{"godoc://net/http#Client"}},
+	}
+	for _, tt := range tests {
+		resp, ok := s.answerSubscription(tt.session, []byte(tt.message))
+		if ok != tt.answered {
+			t.Fatalf("%s: answered %v, want %v", tt.name, ok, tt.answered)
+		}
+		if errResp, isErr := resp.(mcp.JSONRPCError); isErr != (tt.wantCode != 0) || isErr && errResp.Error.Code != tt.wantCode {
+			t.Errorf("%s: got %+v, want error code %d", tt.name, resp, tt.wantCode)
+		}
+		if got := s.subscriptions.uris(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: subscribed to %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	s.onUnregisterSession(context.Background(), newTestSession("a"))
+	if got := s.subscriptions.uris(); len(got) != 0 {
+		t.Errorf("disconnected session is still subscribed to %q", got)
+	}
+}
+
+func TestStdioSubscriptions(t *testing.T) {
+	s := newTestServer(t)
+	s.clients.Store(stdioSessionID, struct{}{})
+	in := `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"godoc://io"}}` + "\n" +
+		`{"jsonrpc":"2.0","id":3,"method":"tools/list"}`
+	var out bytes.Buffer
+	passed, err := io.ReadAll(s.stdioSubscriptions(strings.NewReader(in), &out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n" + `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`; string(passed) != want {
+		t.Errorf("passed on %q, want %q", passed, want)
+	}
+	if want := `{"jsonrpc":"2.0","id":2,"result":{}}` + "\n"; out.String() != want {
+		t.Errorf("answered %q, want %q", out.String(), want)
+	}
+}
+
+func TestWithSubscriptions(t *testing.T) {
+	s := newTestServer(t)
+	s.clients.Store("a", struct{}{})
+	var passed string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		passed = string(body)
+	})
+	session := func(r *http.Request) string { return r.Header.Get(server.HeaderKeySessionID) }
+	handler := s.withSubscriptions(next, session, replyJSON)
+
+	tests := []struct {
+		body   string
+		passed bool
+		want   string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, true, ""},
+		{`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"godoc://io"}}`, false, `{"jsonrpc":"2.0","id":2,"result":{}}`},
+		{`{"jsonrpc":"2.0","id":3,"method":"resources/unsubscribe","params":{"uri":"godoc://io"}}`, false, `{"jsonrpc":"2.0","id":3,"result":{}}`},
+	}
+	for _, tt := range tests {
+		passed = ""
+		r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(tt.body))
+		r.Header.Set(server.HeaderKeySessionID, "a")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if (passed == tt.body) != tt.passed {
+			t.Errorf("%s: passed on %q", tt.body, passed)
+		}
+		if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+			t.Errorf("%s: answered %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestNotifyUpdated(t *testing.T) {
+	s := newTestServer(t)
+	mcpServer := server.NewMCPServer("test", "0")
+	a, b := newTestSession("a"), newTestSession("b")
+	for _, session := range []*testSession{a, b} {
+		if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.subscriptions.add("a", "godoc://io")
+	s.notifyUpdated(mcpServer, "godoc://io")
+
+	tests := []struct {
+		session *testSession
+		want    int
+	}{
+		{a, 1},
+		{b, 0},
+	}
+	for _, tt := range tests {
+		got := drain(tt.session.notifications)
+		if len(got) != tt.want {
+			t.Fatalf("%s: received %d notifications, want %d", tt.session.id, len(got), tt.want)
+		}
+		for _, n := range got {
+			if n.Method != mcp.MethodNotificationResourceUpdated || n.Params.AdditionalFields["uri"] != "godoc://io" {
+				t.Errorf("%s: received %+v", tt.session.id, n)
+			}
+		}
+	}
+}
+
+func TestRefreshResource(t *testing.T) {
+	// A module proxy serving example.com/sub, to which a release is added
+	proxy := t.TempDir()
+	publish := func(version string) {
+		dir := filepath.Join(proxy, "example.com", "sub", "@v")
+		os.MkdirAll(dir, 0o755)
+		mod := "module example.com/sub\n\ngo 1.21\n"
+		os.WriteFile(filepath.Join(dir, version+".mod"), []byte(mod), 0o644)
+		os.WriteFile(filepath.Join(dir, version+".info"), []byte(`{"Version":"`+version+`","Time":"2024-01-01T00:00:00Z"}`), 0o644)
+		var zipped bytes.Buffer
+		zw := zip.NewWriter(&zipped)
+		for name, content := range map[string]string{
+			"go.mod": mod,
+			"sub.go": "// Package sub is at " + version + "\npackage sub\n",
+		} {
+			f, _ := zw.Create("example.com/sub@" + version + "/" + name)
+			f.Write([]byte(content))
+		}
+		zw.Close()
+		os.WriteFile(filepath.Join(dir, version+".zip"), zipped.Bytes(), 0o644)
+		list, _ := os.ReadFile(filepath.Join(dir, "list"))
+		os.WriteFile(filepath.Join(dir, "list"), append(list, version+"\n"...), 0o644)
+	}
+	publish("v1.0.0")
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-mod=mod -modcacherw")
+	t.Setenv("GOMODCACHE", t.TempDir())
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		uri     string
+		publish string
+		want    bool
+	}{
+		{"godoc://io", "", false},
+		{"godoc://example.com/sub@v1.0.0", "", false},
+		{"godoc://example.com/sub", "", false},
+		{"godoc://example.com/sub", "v1.1.0", true},
+		{"godoc://example.com/sub", "", false},
+	}
+	for _, tt := range tests {
+		if tt.publish != "" {
+			publish(tt.publish)
+		}
+		if got := s.refreshResource(ctx, tt.uri); got != tt.want {
+			t.Fatalf("refreshResource(%s) after publishing %q = %v, want %v", tt.uri, tt.publish, got, tt.want)
+		}
+	}
+	u, _ := parseDocURI("godoc://example.com/sub")
+	contents, err := s.readDocURI(ctx, u)
+	if err != nil || !strings.Contains(contents.Text, "at v1.1.0") {
+		t.Errorf("resource after the update reads %q, %v; want the new version", contents.Text, err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+const summarizeDocsDescription = `Summarize Go documentation for a task, using the client's own model through MCP sampling.
+Fetches the full documentation of a package (as with get_doc and -all) or of a symbol and asks
+the client to summarize what matters for the given task at the requested length. Returns the
+summary with a pointer to the full documentation for drill-down with get_doc. Requires a client
+that supports sampling.`
+
+// samplingTimeout bounds how long a summary may take, including the user's
+// approval of the sampling request
+const samplingTimeout = 2 * time.Minute
+
+// maxSummarizeBytes is the most documentation sent to the client's model
+const maxSummarizeBytes = 256 << 10
+
+// summaryLength is a requested summary length in words and the tokens allowed for it
+type summaryLength struct {
+	words     int
+	maxTokens int
+}
+
+// summaryLengths maps the length argument of summarize_docs to its limits
+var summaryLengths = map[string]summaryLength{
+	"short":  {words: 100, maxTokens: 400},
+	"medium": {words: 300, maxTokens: 1000},
+	"long":   {words: 800, maxTokens: 2500},
+}
+
+// summarizeDocsSchema is the summarize_docs input schema
+var summarizeDocsSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Path to the Go package, as for get_doc.",
+		},
+		"target": map[string]any{
+			"type":        "string",
+			"description": "Optional: Symbol to summarize instead of the whole package.",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Working directory, as for get_doc.",
+		},
+		"task": map[string]any{
+			"type":        "string",
+			"description": "Optional: What the documentation is needed for (e.g., 'stream a large HTTP response body'). The summary focuses on what matters for it.",
+		},
+		"length": map[string]any{
+			"type":        "string",
+			"description": "Summary length: 'short' (about 100 words), 'medium' (about 300), or 'long' (about 800).",
+			"enum":        []string{"short", "medium", "long"},
+			"default":     "medium",
+		},
+	},
+	Required: []string{"path"},
+}
+
+// handleSummarizeDocs implements the summarize_docs tool
+func (s *GodocServer) handleSummarizeDocs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	mcpServer := server.ServerFromContext(ctx)
+	if !ok || mcpServer == nil || session.GetClientCapabilities().Sampling == nil {
+		return errorResult(codeUnsupported, "summarize_docs requires a client that supports sampling; use get_doc instead"), nil
+	}
+	lengthName := request.GetString("length", "medium")
+	length, ok := summaryLengths[lengthName]
+	if !ok {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("length must be short, medium, or long, got %q", lengthName)), nil
+	}
+
+	// Whole packages are summarized from their complete documentation
+	args := map[string]any{
+		"path":        request.GetString("path", ""),
+		"target":      request.GetString("target", ""),
+		"working_dir": request.GetString("working_dir", ""),
+	}
+	if args["target"] == "" {
+		args["cmd_flags"] = []string{"-all"}
+	}
+	if args["working_dir"] == "" {
+		delete(args, "working_dir")
+	}
+	var getDoc mcp.CallToolRequest
+	getDoc.Params.Name = "get_doc"
+	getDoc.Params.Arguments = args
+	getDoc.Params.Meta = request.Params.Meta
+	req, doc, failed := s.loadDoc(ctx, getDoc)
+	if failed != nil {
+		return failed, nil
+	}
+
+	content := strings.Join(doc.lines, "\n")
+	if len(content) > maxSummarizeBytes {
+		content = content[:maxSummarizeBytes] + "\n[documentation truncated]"
+	}
+	subject := req.path
+	if req.target != "" {
+		subject += "." + req.target
+	}
+	instructions := fmt.Sprintf("Summarize the Go documentation of %s below in about %d words.", subject, length.words)
+	if task := request.GetString("task", ""); task != "" {
+		instructions += " Focus on what is needed to " + task + ": the relevant functions and types, how to use them, and pitfalls."
+	} else {
+		instructions += " Cover what it is for, its most important functions and types, and typical use."
+	}
+
+	log := ctxLogger(ctx, s.logger).WithFields(logrus.Fields{
+		"package": req.path,
+		"doc_id":  doc.id,
+		"length":  lengthName,
+	})
+	progressFromContext(ctx).step("Summarizing documentation with the client's model")
+	sampleCtx, cancel := context.WithTimeout(ctx, samplingTimeout)
+	defer cancel()
+	result, err := mcpServer.RequestSampling(sampleCtx, mcp.CreateMessageRequest{
+		CreateMessageParams: mcp.CreateMessageParams{
+			SystemPrompt: "You summarize Go package documentation for programmers. Be accurate and concrete; name the identifiers to use and never invent APIs that are not in the documentation.",
+			Messages: []mcp.SamplingMessage{{
+				Role:    mcp.RoleUser,
+				Content: mcp.NewTextContent(instructions + "\n\n" + content),
+			}},
+			MaxTokens: length.maxTokens,
+		},
+	})
+	if err != nil {
+		log.WithError(err).Warn("Sampling request failed")
+		return errorResultFromErr("failed to summarize documentation", err), nil
+	}
+	summary := mcp.GetTextFromContent(result.Content)
+	log.WithField("model", result.Model).Debug("Summarized documentation")
+
+	// Point back at the full documentation for drill-down
+	pointer := fmt.Sprintf("\n\nFull documentation: get_doc with path %q", req.path)
+	if req.target != "" {
+		pointer += fmt.Sprintf(" and target %q", req.target)
+	} else {
+		pointer += ` and cmd_flags ["-all"]`
+	}
+	pointer += fmt.Sprintf(" (doc_id %s, %d lines)", doc.id, len(doc.lines))
+	contents := []mcp.Content{mcp.NewTextContent(summary + pointer)}
+	if !req.ownModule {
+		uri := docURI{path: req.path, symbol: req.target}.String()
+		contents = append(contents, mcp.NewResourceLink(uri, subject, "Documentation of "+subject, "text/plain"))
+	}
+	return &mcp.CallToolResult{Content: contents}, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// samplingSession is a test session of a client answering sampling requests
+// with summary, or failing them with err
+type samplingSession struct {
+	*testSession
+	capabilities mcp.ClientCapabilities
+	summary      string
+	err          error
+	// request is the last sampling request received
+	request *mcp.CreateMessageRequest
+}
+
+func (s *samplingSession) GetClientInfo() mcp.Implementation              { return mcp.Implementation{} }
+func (s *samplingSession) SetClientInfo(mcp.Implementation)               {}
+func (s *samplingSession) GetClientCapabilities() mcp.ClientCapabilities  { return s.capabilities }
+func (s *samplingSession) SetClientCapabilities(c mcp.ClientCapabilities) { s.capabilities = c }
+
+func (s *samplingSession) RequestSampling(_ context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	s.request = &request
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &mcp.CreateMessageResult{
+		SamplingMessage: mcp.SamplingMessage{Role: mcp.RoleAssistant, Content: mcp.NewTextContent(s.summary)},
+		Model:           "test",
+	}, nil
+}
+
+// callToolInSession calls handler as the named tool with args through an MCP
+// server, as a tool calling back to the client in session is called
+func callToolInSession(t *testing.T, session server.ClientSession, handler server.ToolHandlerFunc, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	mcpServer := server.NewMCPServer("test", "0")
+	mcpServer.AddTool(mcp.NewTool(name), handler)
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(1),
+		Request: mcp.Request{Method: string(mcp.MethodToolsCall)},
+		Params:  map[string]any{"name": name, "arguments": args},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := mcpServer.WithContext(context.Background(), session)
+	resp, ok := mcpServer.HandleMessage(ctx, message).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("%s(%v): %+v", name, args, resp)
+	}
+	result, ok := resp.Result.(*mcp.CallToolResult)
+	if !ok {
+		t.Fatalf("%s(%v): result %T", name, args, resp.Result)
+	}
+	return result
+}
+
+func TestSummarizeDocs(t *testing.T) {
+	s := newTestServer(t)
+	sampling := mcp.ClientCapabilities{Sampling: &struct{}{}}
+	tests := []struct {
+		name     string
+		session  *samplingSession
+		args     map[string]any
+		wantCode string
+		// want is text of the result, and prompt text of the sampling request
+		want       []string
+		prompt     []string
+		maxTokens  int
+		resourceTo string
+	}{
+		{"no sampling", &samplingSession{}, map[string]any{"path": "io"}, codeUnsupported, nil, nil, 0, ""},
+		{"bad length", &samplingSession{capabilities: sampling}, map[string]any{"path": "io", "length": "huge"}, codeInvalidArgument, nil, nil, 0, ""},
+		{"missing symbol", &samplingSession{capabilities: sampling}, map[string]any{"path": "io", "target": "NoSuchSymbol"}, codeSymbolNotFound, nil, nil, 0, ""},
+		{"sampling fails", &samplingSession{capabilities: sampling, err: errors.New("user declined")}, map[string]any{"path": "io"}, codeDocFailed, nil, nil, 0, ""},
+		{"package", &samplingSession{capabilities: sampling, summary: "io moves bytes."},
+			map[string]any{"path": "io"}, "",
+			[]string{"io moves bytes.", `Full documentation: get_doc with path "io" and cmd_flags ["-all"]`},
+			[]string{"Summarize the Go documentation of io below in about 300 words.", "Cover what it is for", "func ReadAll"},
+			1000, "godoc://io"},
+		{"symbol for a task", &samplingSession{capabilities: sampling, summary: "Use ReadAll."},
+			map[string]any{"path": "io", "target": "ReadAll", "task": "read a response body", "length": "short"}, "",
+			[]string{"Use ReadAll.", `and target "ReadAll"`},
+			[]string{"documentation of io.ReadAll below in about 100 words", "Focus on what is needed to read a response body"},
+			400, "godoc://io#ReadAll"},
+	}
+	for _, tt := range tests {
+		tt.session.testSession = newTestSession("a")
+		result := callToolInSession(t, tt.session, s.handleSummarizeDocs, "summarize_docs", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		text := resultText(result)
+		for _, want := range tt.want {
+			if !strings.Contains(text, want) {
+				t.Errorf("%s: result %q lacks %q", tt.name, text, want)
+			}
+		}
+		request := tt.session.request
+		prompt := mcp.GetTextFromContent(request.Messages[0].Content)
+		for _, want := range tt.prompt {
+			if !strings.Contains(prompt, want) {
+				t.Errorf("%s: sampling prompt lacks %q:\n%s", tt.name, want, prompt)
+			}
+		}
+		if request.MaxTokens != tt.maxTokens {
+			t.Errorf("%s: max tokens %d, want %d", tt.name, request.MaxTokens, tt.maxTokens)
+		}
+		if link, ok := result.Content[len(result.Content)-1].(mcp.ResourceLink); !ok || link.URI != tt.resourceTo {
+			t.Errorf("%s: last content %+v, want a link to %s", tt.name, result.Content[len(result.Content)-1], tt.resourceTo)
+		}
+	}
+}
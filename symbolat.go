@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/mod/modfile"
+)
+
+const symbolAtDescription = `Find the declaration enclosing a line of a Go source file and return it with its doc comment:
+the function or method whose body the line is in, the type, field, or interface method it declares, or
+the constant or variable. Lines outside any declaration return the package's documentation. Use it to
+go from where the user's cursor is to the documentation that matters there. It reads the file without
+gopls or the go command.`
+
+// symbolAtSchema is the symbol_at input schema
+var symbolAtSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"file": map[string]any{
+			"type":        "string",
+			"description": "Go source file, absolute or relative to working_dir.",
+		},
+		"line": map[string]any{
+			"type":        "integer",
+			"description": "1-based line number.",
+			"minimum":     1,
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Directory relative file paths are resolved against. Defaults to the session working directory.",
+		},
+	},
+	Required: []string{"file", "line"},
+}
+
+// symbolAtOutputSchema is the outputSchema of symbol_at, describing symbolAtOutput
+var symbolAtOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"file":       map[string]any{"type": "string", "description": "Absolute path of the file"},
+		"line":       map[string]any{"type": "integer"},
+		"package":    map[string]any{"type": "string", "description": "Import path of the file's package, when it is in a module"},
+		"kind":       map[string]any{"type": "string", "enum": []string{"package", "import", "const", "var", "func", "method", "type", "field"}},
+		"symbol":     map[string]any{"type": "string", "description": "The declared name, with methods and fields as Type.Name, the import path for imports, or the package name"},
+		"decl":       map[string]any{"type": "string", "description": "The declaration without its doc comment or function body"},
+		"doc":        map[string]any{"type": "string", "description": "Its doc comment"},
+		"start_line": map[string]any{"type": "integer", "description": "First line of the declaration, including its doc comment"},
+		"end_line":   map[string]any{"type": "integer", "description": "Last line of the declaration"},
+	},
+	Required: []string{"file", "line", "kind", "symbol", "decl"},
+}
+
+// symbolAtOutput is the structured content of a symbol_at result
+type symbolAtOutput struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Package   string `json:"package,omitempty"`
+	Kind      string `json:"kind"`
+	Symbol    string `json:"symbol"`
+	Decl      string `json:"decl"`
+	Doc       string `json:"doc,omitempty"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+}
+
+// handleSymbolAt implements the symbol_at tool
+func (s *GodocServer) handleSymbolAt(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	file := request.GetString("file", "")
+	if file == "" {
+		return errorResult(codeInvalidArgument, "invalid or missing file parameter"), nil
+	}
+	if !filepath.IsAbs(file) {
+		workingDir := request.GetString("working_dir", s.sessions.get(ctx).workingDir)
+		if workingDir == "" {
+			return errorResultFromErr("invalid file", withCode(codeInvalidArgument, errors.New("working_dir is required for relative file paths"))), nil
+		}
+		file = filepath.Join(workingDir, file)
+	}
+	if filepath.Ext(file) != ".go" {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("%s is not a Go source file", file)), nil
+	}
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return errorResultFromErr("invalid file", withCode(codeInvalidArgument, err)), nil
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.ParseComments|parser.SkipObjectResolution)
+	if f == nil {
+		return errorResultFromErr("cannot parse "+file, withCode(codeInvalidArgument, err)), nil
+	}
+	line := request.GetInt("line", 0)
+	if lines := fset.File(f.Pos()).LineCount(); line < 1 || line > lines {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("line must be between 1 and %d, got %d", lines, line)), nil
+	}
+
+	out := enclosingDecl(fset, f, line)
+	if out == nil {
+		out = &symbolAtOutput{Kind: "package", Symbol: f.Name.Name, Decl: "package " + f.Name.Name, Doc: packageComment(f, filepath.Dir(file))}
+	}
+	out.File, out.Line = file, line
+	out.Package = importPathOf(filepath.Dir(file))
+
+	var sb strings.Builder
+	sb.WriteString(out.Kind + " " + out.Symbol)
+	if out.Package != "" && out.Kind != "import" {
+		sb.WriteString(" in " + out.Package)
+	}
+	if out.StartLine > 0 {
+		fmt.Fprintf(&sb, " (%s:%d-%d)", filepath.Base(file), out.StartLine, out.EndLine)
+	}
+	sb.WriteString("\n\n" + out.Decl + "\n\n")
+	if out.Doc != "" {
+		sb.WriteString(out.Doc)
+	} else {
+		sb.WriteString("No doc comment.")
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// enclosingDecl returns the declaration of f spanning line, its doc comment
+// included, or nil if line is outside every declaration. Within a struct or
+// interface type, the field or method spanning line is returned.
+func enclosingDecl(fset *token.FileSet, f *ast.File, line int) *symbolAtOutput {
+	lineOf := func(p token.Pos) int { return fset.Position(p).Line }
+	spans := func(doc *ast.CommentGroup, node ast.Node, comment *ast.CommentGroup) (int, int, bool) {
+		start, end := lineOf(node.Pos()), lineOf(node.End())
+		if doc != nil {
+			start = lineOf(doc.Pos())
+		}
+		if comment != nil {
+			end = max(end, lineOf(comment.End()))
+		}
+		return start, end, start <= line && line <= end
+	}
+
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			start, end, ok := spans(d.Doc, d, nil)
+			if !ok {
+				continue
+			}
+			out := &symbolAtOutput{Kind: symbolFunc, Symbol: d.Name.Name, Decl: printDecl(fset, d), Doc: commentText(d.Doc), StartLine: start, EndLine: end}
+			if d.Recv != nil {
+				out.Kind, out.Symbol = symbolMethod, receiverName(d.Recv)+"."+d.Name.Name
+			}
+			return out
+
+		case *ast.GenDecl:
+			start, end, ok := spans(d.Doc, d, nil)
+			if !ok {
+				continue
+			}
+			// The doc comment of an ungrouped declaration documents its spec
+			var groupDoc *ast.CommentGroup
+			if !d.Lparen.IsValid() {
+				groupDoc = d.Doc
+			}
+			specDoc := func(doc *ast.CommentGroup) *ast.CommentGroup {
+				if doc != nil {
+					return doc
+				}
+				return groupDoc
+			}
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.ImportSpec:
+					if start, end, ok := spans(specDoc(sp.Doc), sp, sp.Comment); ok {
+						importPath, _ := strconv.Unquote(sp.Path.Value)
+						decl := &ast.GenDecl{Tok: token.IMPORT, TokPos: sp.Pos(), Specs: []ast.Spec{sp}}
+						return &symbolAtOutput{Kind: "import", Symbol: importPath, Decl: printDecl(fset, decl), Doc: commentText(sp.Doc, sp.Comment), StartLine: start, EndLine: end}
+					}
+				case *ast.ValueSpec:
+					if start, end, ok := spans(specDoc(sp.Doc), sp, sp.Comment); ok {
+						var names []string
+						for _, n := range sp.Names {
+							names = append(names, n.Name)
+						}
+						decl := &ast.GenDecl{Tok: d.Tok, TokPos: sp.Pos(), Specs: []ast.Spec{sp}}
+						return &symbolAtOutput{Kind: d.Tok.String(), Symbol: strings.Join(names, ", "), Decl: printDecl(fset, decl), Doc: commentText(sp.Doc, d.Doc, sp.Comment), StartLine: start, EndLine: end}
+					}
+				case *ast.TypeSpec:
+					start, end, ok := spans(specDoc(sp.Doc), sp, sp.Comment)
+					if !ok {
+						continue
+					}
+					if member := enclosingMember(fset, sp, line); member != nil {
+						return member
+					}
+					decl := &ast.GenDecl{Tok: token.TYPE, TokPos: sp.Pos(), Specs: []ast.Spec{sp}}
+					return &symbolAtOutput{Kind: symbolType, Symbol: sp.Name.Name, Decl: printDecl(fset, decl), Doc: commentText(specDoc(sp.Doc), sp.Comment), StartLine: start, EndLine: end}
+				}
+			}
+			// Lines of a group outside its specs, such as the opening
+			// "const (", belong to the whole group
+			var names []string
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.ImportSpec:
+					importPath, _ := strconv.Unquote(sp.Path.Value)
+					names = append(names, importPath)
+				case *ast.ValueSpec:
+					for _, n := range sp.Names {
+						names = append(names, n.Name)
+					}
+				case *ast.TypeSpec:
+					names = append(names, sp.Name.Name)
+				}
+			}
+			return &symbolAtOutput{Kind: d.Tok.String(), Symbol: strings.Join(names, ", "), Decl: printDecl(fset, d), Doc: commentText(d.Doc), StartLine: start, EndLine: end}
+		}
+	}
+	return nil
+}
+
+// enclosingMember returns the field of the struct type or the method of the
+// interface type ts spanning line, below the line declaring the type
+func enclosingMember(fset *token.FileSet, ts *ast.TypeSpec, line int) *symbolAtOutput {
+	lineOf := func(p token.Pos) int { return fset.Position(p).Line }
+	var fields *ast.FieldList
+	kind := "field"
+	switch t := ts.Type.(type) {
+	case *ast.StructType:
+		fields = t.Fields
+	case *ast.InterfaceType:
+		fields, kind = t.Methods, symbolMethod
+	default:
+		return nil
+	}
+	if line <= lineOf(ts.Pos()) {
+		return nil
+	}
+	for _, field := range fields.List {
+		start, end := lineOf(field.Pos()), lineOf(field.End())
+		if field.Doc != nil {
+			start = lineOf(field.Doc.Pos())
+		}
+		if field.Comment != nil {
+			end = max(end, lineOf(field.Comment.End()))
+		}
+		if line < start || line > end {
+			continue
+		}
+		out := &symbolAtOutput{Kind: kind, Decl: printDecl(fset, field), Doc: commentText(field.Doc, field.Comment), StartLine: start, EndLine: end}
+		var names []string
+		for _, n := range field.Names {
+			names = append(names, n.Name)
+		}
+		if len(names) == 0 {
+			// Embedded interfaces and constraints are part of the type's
+			// declaration, and embedded fields are named by their type
+			if kind == symbolMethod {
+				return nil
+			}
+			name := strings.TrimPrefix(printDecl(fset, field.Type), "*")
+			if i := strings.IndexByte(name, '['); i >= 0 {
+				name = name[:i]
+			}
+			names = append(names, name[strings.LastIndexByte(name, '.')+1:])
+		}
+		if kind == symbolMethod && len(field.Names) > 0 {
+			out.Decl = field.Names[0].Name + strings.TrimPrefix(printDecl(fset, field.Type), "func")
+		}
+		out.Symbol = ts.Name.Name + "." + strings.Join(names, ", ")
+		return out
+	}
+	return nil
+}
+
+// packageComment returns the package comment of f, or of another file of
+// its package in dir
+func packageComment(f *ast.File, dir string) string {
+	if f.Doc != nil {
+		return f.Doc.Text()
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	fset := token.NewFileSet()
+	for _, e := range entries {
+		if !isPackageFile(e.Name()) {
+			continue
+		}
+		other, err := parser.ParseFile(fset, filepath.Join(dir, e.Name()), nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err == nil && other.Name.Name == f.Name.Name && other.Doc != nil {
+			return other.Doc.Text()
+		}
+	}
+	return ""
+}
+
+// importPathOf returns the import path of the package in dir, or an empty
+// string if dir isn't in a module
+func importPathOf(dir string) string {
+	root := moduleRoot(dir)
+	if root == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	modPath := modfile.ModulePath(data)
+	rel, err := filepath.Rel(root, dir)
+	if modPath == "" || err != nil {
+		return ""
+	}
+	return path.Join(modPath, filepath.ToSlash(rel))
+}
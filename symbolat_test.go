@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestSymbolAt(t *testing.T) {
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod":      "module example.com/mod\n\ngo 1.21\n",
+		"shop/doc.go": "// Package shop sells.\npackage shop\n",
+		"notes.txt":   "not Go\n",
+		"shop/shop.go": `package shop
+
+import (
+	"fmt" // for printing
+)
+
+// Currency of prices
+const Currency = "EUR"
+
+// Limits
+const (
+	// Max is the most.
+	Max = 10
+	Min = 1
+)
+
+// Item is for sale.
+type Item struct {
+	// Name names it.
+	Name string
+	fmt.Stringer
+}
+
+// Pricer prices.
+type Pricer interface {
+	Price(it Item) int
+}
+
+// Print prints it.
+func (it Item) Print() {
+	fmt.Println(it.Name)
+}
+`,
+	})
+	file := filepath.Join(dir, "shop", "shop.go")
+	session := sessionContext("a")
+	callTool(t, session, s.handleSetSessionDefaults, "set_session_defaults", map[string]any{"working_dir": dir})
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		args     map[string]any
+		wantCode string
+		// want is the declaration found, as kind symbol start-end: doc
+		want string
+		decl string
+	}{
+		{"no file", ctx, map[string]any{"line": 1}, codeInvalidArgument, "", ""},
+		{"relative without working dir", ctx, map[string]any{"file": "shop/shop.go", "line": 1}, codeInvalidArgument, "", ""},
+		{"not Go", ctx, map[string]any{"file": "notes.txt", "line": 1, "working_dir": dir}, codeInvalidArgument, "", ""},
+		{"missing file", ctx, map[string]any{"file": "shop/none.go", "line": 1, "working_dir": dir}, codeInvalidArgument, "", ""},
+		{"line before the file", ctx, map[string]any{"file": file, "line": 0}, codeInvalidArgument, "", ""},
+		{"line past the file", ctx, map[string]any{"file": file, "line": 33}, codeInvalidArgument, "", ""},
+		{"package clause", ctx, map[string]any{"file": file, "line": 1}, "", "package shop 0-0: Package shop sells.\n", "package shop"},
+		{"between declarations", ctx, map[string]any{"file": file, "line": 6}, "", "package shop 0-0: Package shop sells.\n", "package shop"},
+		{"import", ctx, map[string]any{"file": file, "line": 4}, "", "import fmt 4-4: for printing\n", `import "fmt"`},
+		{"const", ctx, map[string]any{"file": file, "line": 7}, "", "const Currency 7-8: Currency of prices\n", `const Currency = "EUR"`},
+		{"const group", ctx, map[string]any{"file": file, "line": 11}, "", "const Max, Min 10-15: Limits\n", "const (\n\tMax = 10\n\tMin = 1\n)"},
+		{"documented const in group", ctx, map[string]any{"file": file, "line": 13}, "", "const Max 12-13: Max is the most.\n", "const Max = 10"},
+		{"const in documented group", ctx, map[string]any{"file": file, "line": 14}, "", "const Min 14-14: Limits\n", "const Min = 1"},
+		{"type", ctx, map[string]any{"file": file, "line": 18}, "", "type Item 17-22: Item is for sale.\n", "type Item struct {\n\tName string\n\tfmt.Stringer\n}"},
+		{"field", ctx, map[string]any{"file": file, "line": 20}, "", "field Item.Name 19-20: Name names it.\n", "Name string"},
+		{"embedded field", ctx, map[string]any{"file": file, "line": 21}, "", "field Item.Stringer 21-21: ", "fmt.Stringer"},
+		{"interface method", ctx, map[string]any{"file": file, "line": 26}, "", "method Pricer.Price 26-26: ", "Price(it Item) int"},
+		{"method body", ctx, map[string]any{"file": file, "line": 31}, "", "method Item.Print 29-32: Print prints it.\n", "func (it Item) Print()"},
+		{"session working dir", session, map[string]any{"file": "shop/shop.go", "line": 31}, "", "method Item.Print 29-32: Print prints it.\n", "func (it Item) Print()"},
+	}
+	for _, tt := range tests {
+		result := callTool(t, tt.ctx, s.handleSymbolAt, "symbol_at", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*symbolAtOutput)
+		got := fmt.Sprintf("%s %s %d-%d: %s", out.Kind, out.Symbol, out.StartLine, out.EndLine, out.Doc)
+		if got != tt.want || out.Decl != tt.decl {
+			t.Errorf("%s: found %q, declared %q; want %q, %q", tt.name, got, out.Decl, tt.want, tt.decl)
+		}
+		if out.File != file || out.Package != "example.com/mod/shop" {
+			t.Errorf("%s: file %s in package %q, want %s in example.com/mod/shop", tt.name, out.File, out.Package, file)
+		}
+	}
+}
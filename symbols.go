@@ -0,0 +1,529 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages"
+)
+
+const workspaceSymbolsDescription = `Find declarations in local Go workspaces by name, like an editor's "Go to Symbol".
+Matches the functions, methods, types, constants, and variables of every package of the
+workspace, exported or not, by exact name, prefix, substring, or fuzzy match of the query's
+letters in order (e.g., "NewSrv" finds NewServer). Returns each symbol's kind, package, and
+file:line. The index is kept up to date as files change.`
+
+// Limits on workspace_symbols results and the workspaces indexed on demand
+const (
+	defaultSymbolResults = 50
+	maxSymbolResults     = 200
+	// maxSymbolWorkspaces bounds the workspaces indexed on demand; the least
+	// recently queried one is dropped first
+	maxSymbolWorkspaces = 16
+	// maxRefreshPatterns is the number of changed packages reloaded one by one;
+	// beyond it the whole workspace is reloaded
+	maxRefreshPatterns = 50
+)
+
+// Kinds of workspace symbols
+const (
+	symbolFunc   = "func"
+	symbolMethod = "method"
+	symbolType   = "type"
+	symbolConst  = "const"
+	symbolVar    = "var"
+)
+
+// workspaceSymbolsSchema is the workspace_symbols input schema
+var workspaceSymbolsSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"query": map[string]any{
+			"type":        "string",
+			"description": "Symbol name, prefix, or abbreviation to find, ignoring case (e.g., 'NewServer', 'handle', or 'Client.Do').",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory to search. Defaults to the -index-workspaces and the session working directory, or the first client root that is a Go module.",
+		},
+		"kind": map[string]any{
+			"type":        "string",
+			"description": "Optional: Only return symbols of this kind.",
+			"enum":        []string{symbolFunc, symbolMethod, symbolType, symbolConst, symbolVar},
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of symbols to return.",
+			"minimum":     1,
+			"maximum":     maxSymbolResults,
+			"default":     defaultSymbolResults,
+		},
+	},
+	Required: []string{"query"},
+}
+
+// workspaceSymbolsOutputSchema is the outputSchema of workspace_symbols,
+// describing workspaceSymbolsOutput
+var workspaceSymbolsOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"query":      map[string]any{"type": "string", "description": "The query searched for"},
+		"workspaces": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "The workspaces searched"},
+		"total":      map[string]any{"type": "integer", "description": "Number of matches before limit was applied"},
+		"symbols": map[string]any{
+			"type":        "array",
+			"description": "Matching symbols, best first",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name":     map[string]any{"type": "string", "description": "The symbol, with methods as Type.Method; pass it to get_doc as target"},
+					"kind":     map[string]any{"type": "string", "enum": []string{symbolFunc, symbolMethod, symbolType, symbolConst, symbolVar}},
+					"package":  map[string]any{"type": "string", "description": "Import path of the package; pass it to get_doc as path"},
+					"file":     map[string]any{"type": "string", "description": "Absolute path of the file declaring the symbol"},
+					"line":     map[string]any{"type": "integer", "description": "Line of the declaration"},
+					"exported": map[string]any{"type": "boolean"},
+				},
+				"required": []string{"name", "kind", "package", "file", "line", "exported"},
+			},
+		},
+	},
+	Required: []string{"query", "workspaces", "total", "symbols"},
+}
+
+// workspaceSymbolsOutput is the structured content of a workspace_symbols result
+type workspaceSymbolsOutput struct {
+	Query      string            `json:"query"`
+	Workspaces []string          `json:"workspaces"`
+	Total      int               `json:"total"`
+	Symbols    []workspaceSymbol `json:"symbols"`
+}
+
+// workspaceSymbol is a package-level declaration in a workspace
+type workspaceSymbol struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Package  string `json:"package"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Exported bool   `json:"exported"`
+}
+
+// symbolIndex holds the symbols of the workspaces workspace_symbols searches
+type symbolIndex struct {
+	mu         sync.Mutex
+	workspaces map[string]*workspaceIndex
+}
+
+// workspace returns the index of the module at dir, creating an empty one when
+// it has none. Indexes of workspaces not in pinned are dropped, least recently
+// used first, to keep at most maxSymbolWorkspaces of them.
+func (x *symbolIndex) workspace(dir string, pinned []string) *workspaceIndex {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.workspaces == nil {
+		x.workspaces = make(map[string]*workspaceIndex)
+	}
+	w, ok := x.workspaces[dir]
+	if !ok {
+		w = &workspaceIndex{dir: dir}
+		x.workspaces[dir] = w
+	}
+	w.lastUsed.Store(time.Now().UnixNano())
+	for len(x.workspaces) > maxSymbolWorkspaces+len(pinned) {
+		var oldest *workspaceIndex
+		for d, c := range x.workspaces {
+			if d != dir && !slices.Contains(pinned, d) && (oldest == nil || c.lastUsed.Load() < oldest.lastUsed.Load()) {
+				oldest = c
+			}
+		}
+		if oldest == nil {
+			break
+		}
+		delete(x.workspaces, oldest.dir)
+	}
+	return w
+}
+
+// workspaceIndex is the symbol index of one module, kept per package directory
+// so only the packages whose files changed are reloaded
+type workspaceIndex struct {
+	dir      string
+	lastUsed atomic.Int64
+
+	// mu serializes refreshes and guards the fields below
+	mu      sync.Mutex
+	modPath string
+	stamps  map[string][sha256.Size]byte
+	symbols map[string][]workspaceSymbol
+}
+
+// refresh reloads the packages whose Go files were added, removed, or modified
+// since the last refresh, and returns every symbol of the workspace
+func (w *workspaceIndex) refresh(ctx context.Context, limiter *subprocessLimiter, log *logrus.Entry) ([]workspaceSymbol, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	data, err := os.ReadFile(filepath.Join(w.dir, "go.mod"))
+	if err != nil {
+		return nil, withCode(codeInvalidWorkingDir, fmt.Errorf("%s is not the root of a Go module: %v", w.dir, err))
+	}
+	modPath := modfile.ModulePath(data)
+	if modPath != w.modPath {
+		w.modPath, w.stamps, w.symbols = modPath, nil, make(map[string][]workspaceSymbol)
+	}
+
+	stamps := packageDirStamps(w.dir)
+	var changed []string
+	for dir, stamp := range stamps {
+		if old, ok := w.stamps[dir]; !ok || old != stamp {
+			changed = append(changed, dir)
+		}
+	}
+	for dir := range w.symbols {
+		if _, ok := stamps[dir]; !ok {
+			delete(w.symbols, dir)
+		}
+	}
+	if len(changed) > 0 {
+		started := time.Now()
+		if err := w.load(ctx, limiter, changed); err != nil {
+			return nil, err
+		}
+		log.WithFields(logrus.Fields{
+			"workspace": w.dir,
+			"packages":  len(changed),
+			"duration":  time.Since(started),
+		}).Debug("Indexed workspace symbols")
+	}
+	w.stamps = stamps
+
+	var all []workspaceSymbol
+	for _, symbols := range w.symbols {
+		all = append(all, symbols...)
+	}
+	return all, nil
+}
+
+// load indexes the symbols of the packages in dirs, or of the whole workspace
+// when there are many of them
+func (w *workspaceIndex) load(ctx context.Context, limiter *subprocessLimiter, dirs []string) error {
+	release, err := limiter.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	patterns := []string{"./..."}
+	if len(dirs) <= maxRefreshPatterns && w.stamps != nil {
+		patterns = patterns[:0]
+		for _, dir := range dirs {
+			rel, _ := filepath.Rel(w.dir, dir)
+			patterns = append(patterns, "./"+strings.TrimPrefix(filepath.ToSlash(rel), "."))
+		}
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Dir:     w.dir,
+	}, patterns...)
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		w.symbols[dir] = nil
+	}
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 {
+			continue
+		}
+		dir := filepath.Dir(pkg.GoFiles[0])
+		w.symbols[dir] = packageSymbolsOf(pkg)
+	}
+	return nil
+}
+
+// packageSymbolsOf returns the package-level declarations of pkg
+func packageSymbolsOf(pkg *packages.Package) []workspaceSymbol {
+	var symbols []workspaceSymbol
+	add := func(name, kind string, pos token.Pos, exported bool) {
+		if name == "_" {
+			return
+		}
+		p := pkg.Fset.Position(pos)
+		symbols = append(symbols, workspaceSymbol{Name: name, Kind: kind, Package: pkg.PkgPath, File: p.Filename, Line: p.Line, Exported: exported})
+	}
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					add(decl.Name.Name, symbolFunc, decl.Name.Pos(), decl.Name.IsExported())
+					continue
+				}
+				if recv := receiverName(decl.Recv); recv != "" {
+					add(recv+"."+decl.Name.Name, symbolMethod, decl.Name.Pos(), ast.IsExported(recv) && decl.Name.IsExported())
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						add(spec.Name.Name, symbolType, spec.Name.Pos(), spec.Name.IsExported())
+					case *ast.ValueSpec:
+						kind := symbolVar
+						if decl.Tok == token.CONST {
+							kind = symbolConst
+						}
+						for _, name := range spec.Names {
+							add(name.Name, kind, name.Pos(), name.IsExported())
+						}
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverName returns the name of the type a method is declared on
+func receiverName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	typ := recv.List[0].Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.ParenExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// packageDirStamps returns a fingerprint of the names, sizes, and modification
+// times of the Go files of every package directory in the module at root
+func packageDirStamps(root string) map[string][sha256.Size]byte {
+	files := make(map[string][]string)
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if skipPackageDir(root, root, p) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isPackageFile(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		dir := filepath.Dir(p)
+		files[dir] = append(files[dir], fmt.Sprintf("%s %d %d", d.Name(), info.Size(), info.ModTime().UnixNano()))
+		return nil
+	})
+	stamps := make(map[string][sha256.Size]byte, len(files))
+	for dir, entries := range files {
+		stamps[dir] = sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	}
+	return stamps
+}
+
+// matchSymbol scores how well query matches a symbol name, ignoring case: an
+// exact match beats a prefix, a prefix beats a substring, and a substring beats
+// the query's letters appearing in order. Methods also match by their own name.
+// It returns 0 when query does not match.
+func matchSymbol(query, name string) int {
+	score := matchName(query, name)
+	if _, method, ok := strings.Cut(name, "."); ok && !strings.Contains(query, ".") {
+		score = max(score, matchName(query, method))
+	}
+	return score
+}
+
+// matchName scores query against a single name for matchSymbol. Shorter names
+// rank higher among matches of the same kind.
+func matchName(query, name string) int {
+	q, n := strings.ToLower(query), strings.ToLower(name)
+	penalty := min(len(n), 99)
+	switch {
+	case n == q:
+		if name == query {
+			return 1001
+		}
+		return 1000
+	case strings.HasPrefix(n, q):
+		return 800 - penalty
+	case strings.Contains(n, q):
+		return 600 - penalty
+	}
+	// Fuzzy match: every query letter in order, fewer gaps ranking higher
+	gaps, i := 0, 0
+	for j := 0; j < len(n) && i < len(q); j++ {
+		if n[j] == q[i] {
+			i++
+		} else if i > 0 {
+			gaps++
+		}
+	}
+	if i < len(q) {
+		return 0
+	}
+	return max(1, 400-min(gaps, 200)-penalty)
+}
+
+// indexWorkspaces returns the absolute paths of the -index-workspaces
+func (c *Config) indexWorkspaces() []string {
+	var dirs []string
+	for _, dir := range c.IndexWorkspaces {
+		if abs, err := filepath.Abs(dir); err == nil && !slices.Contains(dirs, abs) {
+			dirs = append(dirs, abs)
+		}
+	}
+	return dirs
+}
+
+// symbolWorkspaces returns the workspaces a workspace_symbols call searches:
+// working_dir when given, else the -index-workspaces and the session working
+// directory or first client root that is a Go module
+func (s *GodocServer) symbolWorkspaces(ctx context.Context, request mcp.CallToolRequest) ([]string, error) {
+	if dir := request.GetString("working_dir", ""); dir != "" {
+		if err := checkWorkingDir(dir); err != nil {
+			return nil, err
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, withCode(codeInvalidWorkingDir, err)
+		}
+		return []string{abs}, nil
+	}
+	dirs := s.config.Load().indexWorkspaces()
+	dir := s.sessions.get(ctx).workingDir
+	if dir == "" {
+		dir = s.rootWorkingDir(ctx, ".")
+	}
+	if dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil && !slices.Contains(dirs, abs) {
+			dirs = append(dirs, abs)
+		}
+	}
+	return dirs, nil
+}
+
+// handleWorkspaceSymbols implements the workspace_symbols tool
+func (s *GodocServer) handleWorkspaceSymbols(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := strings.TrimSpace(request.GetString("query", ""))
+	if query == "" {
+		return errorResult(codeInvalidArgument, "query must not be empty"), nil
+	}
+	kind := request.GetString("kind", "")
+	if kind != "" && !slices.Contains([]string{symbolFunc, symbolMethod, symbolType, symbolConst, symbolVar}, kind) {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("invalid kind %q: must be func, method, type, const, or var", kind)), nil
+	}
+	limit := request.GetInt("limit", defaultSymbolResults)
+	if limit < 1 || limit > maxSymbolResults {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("limit must be between 1 and %d, got %d", maxSymbolResults, limit)), nil
+	}
+	dirs, err := s.symbolWorkspaces(ctx, request)
+	if err != nil {
+		return errorResultFromErr("invalid working directory", err), nil
+	}
+	if len(dirs) == 0 {
+		return errorResult(codeInvalidArgument, "no workspace to search: pass working_dir, set it with set_session_defaults, or start the server with -index-workspaces"), nil
+	}
+
+	log := ctxLogger(ctx, s.logger).WithField("query", query)
+	progress := s.newProgressReporter(ctx, request)
+	type match struct {
+		symbol workspaceSymbol
+		score  int
+	}
+	var matches []match
+	pinned := s.config.Load().indexWorkspaces()
+	for _, dir := range dirs {
+		progress.step("Indexing the symbols of " + dir)
+		symbols, err := s.symbols.workspace(dir, pinned).refresh(ctx, s.limiter, log)
+		if err != nil {
+			return errorResultFromErr("failed to index "+dir, err), nil
+		}
+		for _, sym := range symbols {
+			if kind != "" && sym.Kind != kind {
+				continue
+			}
+			if score := matchSymbol(query, sym.Name); score > 0 {
+				matches = append(matches, match{sym, score})
+			}
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(b.score-a.score, cmp.Compare(boolRank(b.symbol.Exported), boolRank(a.symbol.Exported)),
+			strings.Compare(a.symbol.Package, b.symbol.Package), strings.Compare(a.symbol.Name, b.symbol.Name))
+	})
+	out := &workspaceSymbolsOutput{Query: query, Workspaces: dirs, Total: len(matches), Symbols: make([]workspaceSymbol, 0, min(len(matches), limit))}
+	for _, m := range matches[:min(len(matches), limit)] {
+		out.Symbols = append(out.Symbols, m.symbol)
+	}
+
+	var sb strings.Builder
+	if len(matches) == 0 {
+		fmt.Fprintf(&sb, "No symbols match %q in %s.", query, strings.Join(dirs, ", "))
+	} else {
+		fmt.Fprintf(&sb, "%d symbols match %q, showing %d. Read one with get_doc, passing its package as path and its name as target.\n\n", len(matches), query, len(out.Symbols))
+		for _, sym := range out.Symbols {
+			fmt.Fprintf(&sb, "%s %s.%s\t%s:%d\n", sym.Kind, sym.Package, sym.Name, sym.File, sym.Line)
+		}
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// boolRank orders true before false in descending sorts
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// warmSymbolIndex indexes the -index-workspaces in the background, so their
+// first workspace_symbols query need not wait
+func (s *GodocServer) warmSymbolIndex(ctx context.Context) {
+	if !s.toolAllowed("workspace_symbols") {
+		return
+	}
+	pinned := s.config.Load().indexWorkspaces()
+	for _, dir := range pinned {
+		log := s.logger.WithField("workspace", dir)
+		symbols, err := s.symbols.workspace(dir, pinned).refresh(ctx, s.limiter, log)
+		if err != nil {
+			log.WithError(err).Warn("Failed to index workspace symbols")
+			continue
+		}
+		log.WithField("symbols", len(symbols)).Info("Indexed workspace symbols")
+	}
+}
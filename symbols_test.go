@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkspaceSymbols(t *testing.T) {
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/mod\n\ngo 1.21\n",
+		"server/server.go": `package server
+
+type Server struct{}
+
+func NewServer() *Server { return &Server{} }
+
+func (s *Server) Serve() error { return nil }
+
+func newServerConfig() {}
+
+const DefaultPort = 8080
+
+var started bool
+`,
+	})
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// write is a file added to the module before the call
+		write    string
+		args     map[string]any
+		wantCode string
+		want     []string
+		total    int
+	}{
+		{"empty query", "", map[string]any{"query": " ", "working_dir": dir}, codeInvalidArgument, nil, 0},
+		{"bad kind", "", map[string]any{"query": "Server", "kind": "interface", "working_dir": dir}, codeInvalidArgument, nil, 0},
+		{"bad limit", "", map[string]any{"query": "Server", "limit": maxSymbolResults + 1, "working_dir": dir}, codeInvalidArgument, nil, 0},
+		{"no workspace", "", map[string]any{"query": "Server"}, codeInvalidArgument, nil, 0},
+		{"missing dir", "", map[string]any{"query": "Server", "working_dir": dir + "/none"}, codeInvalidWorkingDir, nil, 0},
+		{"exact first", "", map[string]any{"query": "Server", "working_dir": dir}, "", []string{"type example.com/mod/server.Server\t", "method example.com/mod/server.Server.Serve\t", "func example.com/mod/server.NewServer\t", "func example.com/mod/server.newServerConfig\t"}, 4},
+		{"kind", "", map[string]any{"query": "Server", "kind": symbolMethod, "working_dir": dir}, "", []string{"method example.com/mod/server.Server.Serve\t"}, 1},
+		{"fuzzy", "", map[string]any{"query": "NewSrv", "limit": 1, "working_dir": dir}, "", []string{"func example.com/mod/server.NewServer\tserver/server.go:5"}, 2},
+		{"method name", "", map[string]any{"query": "serve", "limit": 1, "working_dir": dir}, "", []string{"method example.com/mod/server.Server.Serve\t"}, 4},
+		{"constant", "", map[string]any{"query": "defaultport", "working_dir": dir}, "", []string{"const example.com/mod/server.DefaultPort\t"}, 1},
+		{"refreshed", "client/client.go", map[string]any{"query": "Client", "working_dir": dir}, "", []string{"type example.com/mod/client.Client\tclient/client.go:3"}, 1},
+	}
+	for _, tt := range tests {
+		if tt.write != "" {
+			path := filepath.Join(dir, filepath.FromSlash(tt.write))
+			os.MkdirAll(filepath.Dir(path), 0o755)
+			if err := os.WriteFile(path, []byte("package client\n\ntype Client struct{}\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		result := callTool(t, ctx, s.handleWorkspaceSymbols, "workspace_symbols", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*workspaceSymbolsOutput)
+		if out.Total != tt.total || len(out.Symbols) != len(tt.want) {
+			t.Errorf("%s: %d of %d symbols, want %d of %d:\n%s", tt.name, len(out.Symbols), out.Total, len(tt.want), tt.total, resultText(result))
+			continue
+		}
+		// Lines list the symbols in order, with file paths shortened to the module
+		lines := strings.Split(strings.ReplaceAll(resultText(result), dir+string(filepath.Separator), ""), "\n")[2:]
+		for i, want := range tt.want {
+			if !strings.HasPrefix(lines[i], want) {
+				t.Errorf("%s: symbol %d is %q, want %q", tt.name, i, lines[i], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// toolchainError is a problem with the go toolchain or module cache that keeps
+// documentation requests from working, with how to fix it
+type toolchainError struct {
+	err error
+	fix string
+}
+
+func (e *toolchainError) Error() string {
+	return fmt.Sprintf("%v; to fix this, %s", e.err, e.fix)
+}
+
+func (e *toolchainError) Unwrap() error { return e.err }
+
+// newToolchainError wraps err in a toolchainError with the fix suggested by the
+// -doctor check called name
+func newToolchainError(name string, err error) error {
+	for _, c := range doctorChecks {
+		if c.name == name {
+			return withCode(codeGoToolchain, &toolchainError{err: err, fix: c.fix})
+		}
+	}
+	return withCode(codeGoToolchain, err)
+}
+
+// checkToolchain returns why the go command can't serve documentation: it is
+// missing from PATH or older than minGoVersion. It returns nil when it can.
+func checkToolchain(ctx context.Context) error {
+	if err := checkGoToolchain(); err != nil {
+		return newToolchainError("Go toolchain", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	env, err := goEnv(ctx, "GOVERSION", "GOROOT")
+	if err != nil {
+		return newToolchainError("Go toolchain", err)
+	}
+	if _, err := checkGoVersion(ctx, env); err != nil {
+		return newToolchainError("Go toolchain", err)
+	}
+	return nil
+}
+
+// checkModCache returns why modules can't be downloaded into the module cache,
+// or nil when they can
+func checkModCache(ctx context.Context) error {
+	if _, err := checkModCacheWritable(ctx, map[string]string{"GOMODCACHE": modCacheDir()}); err != nil {
+		return newToolchainError("Module cache", err)
+	}
+	return nil
+}
+
+// setRuntimeCaps records the environment the server runs in, reporting toolchain
+// problems that will fail tool calls
+func (s *GodocServer) setRuntimeCaps(caps *runtimeCaps) {
+	s.runtime.Store(caps)
+	if caps.toolchainErr != nil {
+		s.logger.WithError(caps.toolchainErr).Error("Go toolchain unusable, documentation requests will fail")
+	}
+	if caps.modCacheErr != nil {
+		s.logger.WithError(caps.modCacheErr).Warn("Module cache not writable, packages that need downloading will fail")
+	}
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+// Tool tags let operators enable or disable groups of tools by capability
+const (
+	// tagExec marks tools that run go subprocesses
+	tagExec = "exec"
+	// tagNetwork marks tools that may fetch from the network
+	tagNetwork = "network"
+	// tagDebug marks tools that expose server logs and configuration. They are
+	// only offered when enabled by name or by this tag.
+	tagDebug = "debug"
+	// tagTest marks tools that run a module's test code. They are only offered
+	// when enabled by name or by this tag.
+	tagTest = "test"
+)
+
+// optInTags are the tags of tools that must be enabled explicitly
+var optInTags = []string{tagDebug, tagTest}
+
+// toolDef describes a tool the server can offer
+type toolDef struct {
+	tool    mcp.Tool
+	handler server.ToolHandlerFunc
+	tags    []string
+	// requires lists the runtime capabilities the tool cannot work without
+	requires []string
+}
+
+// toolDefs lists every tool the server implements
+func (s *GodocServer) toolDefs() []toolDef {
+	cfg := s.config.Load()
+	pagination := cfg.Pagination
+	defs := []toolDef{
+		{
+			tool: mcp.Tool{
+				Name:         "get_doc",
+				Description:  toolDescription,
+				InputSchema:  newDocInputSchema(pagination),
+				OutputSchema: docOutputSchema,
+			},
+			handler:  s.handleToolCall,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "doc_size",
+				Description:  docSizeDescription,
+				InputSchema:  newDocSizeSchema(pagination),
+				OutputSchema: docSizeOutputSchema,
+			},
+			handler:  s.handleDocSize,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:        "summarize_docs",
+				Description: summarizeDocsDescription,
+				InputSchema: summarizeDocsSchema,
+			},
+			handler:  s.handleSummarizeDocs,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo, needSampling},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "search_docs",
+				Description:  searchDocsDescription,
+				InputSchema:  searchDocsSchema,
+				OutputSchema: searchOutputSchema,
+			},
+			handler:  s.handleSearchDocs,
+			tags:     []string{tagExec},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "workspace_symbols",
+				Description:  workspaceSymbolsDescription,
+				InputSchema:  workspaceSymbolsSchema,
+				OutputSchema: workspaceSymbolsOutputSchema,
+			},
+			handler:  s.handleWorkspaceSymbols,
+			tags:     []string{tagExec},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "list_modules",
+				Description:  listModulesDescription,
+				InputSchema:  listModulesSchema,
+				OutputSchema: listModulesOutputSchema,
+			},
+			handler: s.handleListModules,
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "grep_source",
+				Description:  grepSourceDescription,
+				InputSchema:  grepSourceSchema,
+				OutputSchema: grepOutputSchema,
+			},
+			handler:  s.handleGrepSource,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "source_markers",
+				Description:  sourceMarkersDescription,
+				InputSchema:  sourceMarkersSchema,
+				OutputSchema: markersOutputSchema,
+			},
+			handler:  s.handleSourceMarkers,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "doc_lint",
+				Description:  docLintDescription,
+				InputSchema:  docLintSchema,
+				OutputSchema: docLintOutputSchema,
+			},
+			handler:  s.handleDocLint,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "export_api",
+				Description:  exportAPIDescription,
+				InputSchema:  exportAPISchema,
+				OutputSchema: exportAPIOutputSchema,
+			},
+			handler:  s.handleExportAPI,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "find_signatures",
+				Description:  findSignaturesDescription,
+				InputSchema:  findSignaturesSchema,
+				OutputSchema: findSignaturesOutputSchema,
+			},
+			handler:  s.handleFindSignatures,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "find_by_type",
+				Description:  findByTypeDescription,
+				InputSchema:  findByTypeSchema,
+				OutputSchema: findSignaturesOutputSchema,
+			},
+			handler:  s.handleFindByType,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "whats_new",
+				Description:  whatsNewDescription,
+				InputSchema:  whatsNewSchema,
+				OutputSchema: whatsNewOutputSchema,
+			},
+			handler:  s.handleWhatsNew,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "find_packages",
+				Description:  findPackagesDescription,
+				InputSchema:  findPackagesSchema,
+				OutputSchema: findOutputSchema,
+			},
+			handler: s.handleFindPackages,
+			tags:    []string{tagNetwork},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "module_report",
+				Description:  moduleReportDescription,
+				InputSchema:  moduleReportSchema,
+				OutputSchema: reportOutputSchema,
+			},
+			handler: s.handleModuleReport,
+			tags:    []string{tagNetwork},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "find_definition",
+				Description:  findDefinitionDescription,
+				InputSchema:  newPositionSchema("1-based byte column of the identifier."),
+				OutputSchema: definitionOutputSchema,
+			},
+			handler:  s.handleFindDefinition,
+			tags:     []string{tagExec},
+			requires: []string{needGo, needGopls},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "hover_symbol",
+				Description:  hoverSymbolDescription,
+				InputSchema:  newPositionSchema("1-based byte column of the identifier."),
+				OutputSchema: hoverOutputSchema,
+			},
+			handler:  s.handleHoverSymbol,
+			tags:     []string{tagExec},
+			requires: []string{needGo, needGopls},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "signature_help",
+				Description:  signatureHelpDescription,
+				InputSchema:  newPositionSchema("1-based byte column within the call's arguments."),
+				OutputSchema: signatureOutputSchema,
+			},
+			handler:  s.handleSignatureHelp,
+			tags:     []string{tagExec},
+			requires: []string{needGo, needGopls},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "symbol_at",
+				Description:  symbolAtDescription,
+				InputSchema:  symbolAtSchema,
+				OutputSchema: symbolAtOutputSchema,
+			},
+			handler: s.handleSymbolAt,
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "quickstart",
+				Description:  quickstartDescription,
+				InputSchema:  quickstartSchema,
+				OutputSchema: quickstartOutputSchema,
+			},
+			handler:  s.handleQuickstart,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "generate_stub",
+				Description:  generateStubDescription,
+				InputSchema:  generateStubSchema,
+				OutputSchema: generateStubOutputSchema,
+			},
+			handler:  s.handleGenerateStub,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "check_code",
+				Description:  checkCodeDescription,
+				InputSchema:  checkCodeSchema,
+				OutputSchema: checkCodeOutputSchema,
+			},
+			handler:  s.handleCheckCode,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "test_coverage",
+				Description:  testCoverageDescription,
+				InputSchema:  testCoverageSchema,
+				OutputSchema: coverageOutputSchema,
+			},
+			handler:  s.handleTestCoverage,
+			tags:     []string{tagExec, tagTest},
+			requires: []string{needGo},
+		},
+		{
+			tool: mcp.Tool{
+				Name:         "share_playground",
+				Description:  sharePlaygroundDescription,
+				InputSchema:  sharePlaygroundSchema,
+				OutputSchema: playgroundOutputSchema,
+			},
+			handler: s.handleSharePlayground,
+			tags:    []string{tagExec, tagNetwork},
+		},
+		{
+			tool: mcp.Tool{
+				Name:        "set_session_defaults",
+				Description: sessionDefaultsDescription,
+				InputSchema: newSessionDefaultsSchema(pagination),
+			},
+			handler: s.handleSetSessionDefaults,
+		},
+		{
+			tool: mcp.Tool{
+				Name:        "server_stats",
+				Description: serverStatsDescription,
+				InputSchema: mcp.ToolInputSchema{Type: "object", Properties: map[string]any{}},
+			},
+			handler: s.handleServerStats,
+		},
+		{
+			tool: mcp.Tool{
+				Name:        "debug_bundle",
+				Description: debugBundleDescription,
+				InputSchema: mcp.ToolInputSchema{Type: "object", Properties: map[string]any{}},
+			},
+			handler: s.handleDebugBundle,
+			tags:    []string{tagExec, tagDebug},
+		},
+	}
+	// Semantic search is opt-in, offered only with an embedding backend configured
+	if cfg.Embeddings.Backend != "" {
+		defs = append(defs, toolDef{
+			tool: mcp.Tool{
+				Name:         "semantic_search",
+				Description:  semanticSearchDescription,
+				InputSchema:  semanticSearchSchema,
+				OutputSchema: semanticOutputSchema,
+			},
+			handler:  s.handleSemanticSearch,
+			tags:     []string{tagExec, tagNetwork},
+			requires: []string{needGo},
+		})
+	}
+	// Usage examples are opt-in, offered only with a code search backend configured
+	if cfg.CodeSearch.Backend != "" {
+		defs = append(defs, toolDef{
+			tool: mcp.Tool{
+				Name:         "usage_examples",
+				Description:  usageExamplesDescription,
+				InputSchema:  usageExamplesSchema,
+				OutputSchema: usageOutputSchema,
+			},
+			handler: s.handleUsageExamples,
+			tags:    []string{tagNetwork},
+		})
+	}
+	// Recent releases are listed from the catalog kept only while polling is configured
+	if cfg.ModuleIndex.Poll > 0 {
+		defs = append(defs, toolDef{
+			tool: mcp.Tool{
+				Name:         "recent_releases",
+				Description:  recentReleasesDescription,
+				InputSchema:  recentReleasesSchema,
+				OutputSchema: releasesOutputSchema,
+			},
+			handler: s.handleRecentReleases,
+			tags:    []string{tagNetwork},
+		})
+	}
+	return defs
+}
+
+// registerTools updates the tools offered by mcpServer to every tool enabled by
+// the current configuration and supported by the environment. Only tools that
+// were added, removed, or changed are updated, so connected clients are sent
+// tools/list_changed only when their tool list actually changes.
+func (s *GodocServer) registerTools(mcpServer *server.MCPServer) {
+	cfg := s.config.Load()
+	current := mcpServer.ListTools()
+	var changed []server.ServerTool
+	offered := make(map[string]bool)
+	for _, def := range s.toolDefs() {
+		if !cfg.toolEnabled(def.tool.Name, def.tags) {
+			s.logger.WithField("tool", def.tool.Name).Info("Tool disabled by configuration")
+			continue
+		}
+		if need := s.unmet(def); need != "" {
+			s.logger.WithFields(logrus.Fields{
+				"tool":     def.tool.Name,
+				"requires": need,
+			}).Warn("Tool unavailable in this environment")
+			continue
+		}
+		offered[def.tool.Name] = true
+		if cur, ok := current[def.tool.Name]; ok && reflect.DeepEqual(cur.Tool, def.tool) {
+			continue
+		}
+		s.logger.WithField("tool", def.tool.Name).Info("Adding tool...")
+		changed = append(changed, server.ServerTool{Tool: def.tool, Handler: def.handler})
+	}
+	var removed []string
+	for name := range current {
+		if !offered[name] {
+			s.logger.WithField("tool", name).Info("Removing tool...")
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) > 0 {
+		mcpServer.DeleteTools(removed...)
+	}
+	if len(changed) > 0 {
+		mcpServer.AddTools(changed...)
+	}
+}
+
+// toolAllowed reports whether the named tool is enabled by the current configuration
+// and supported by the environment
+func (s *GodocServer) toolAllowed(name string) bool {
+	cfg := s.config.Load()
+	for _, def := range s.toolDefs() {
+		if def.tool.Name == name {
+			return cfg.toolEnabled(name, def.tags) && s.unmet(def) == ""
+		}
+	}
+	return false
+}
+
+// unmet returns the first runtime requirement of def the server's environment
+// does not meet, or "" if it meets them all
+func (s *GodocServer) unmet(def toolDef) string {
+	for _, need := range def.requires {
+		if !s.serverHas(need) {
+			return need
+		}
+	}
+	return ""
+}
+
+// toolEnabled reports whether a tool with the given name and tags may be offered.
+// Entries in the enable and disable lists match either a tool name or a tag.
+// Debug and test tools must be enabled explicitly, by name or with their tag,
+// which opts in to them without restricting the other tools.
+func (c *Config) toolEnabled(name string, tags []string) bool {
+	matches := func(list []string) bool {
+		return slices.Contains(list, name) || slices.ContainsFunc(tags, func(tag string) bool {
+			return slices.Contains(list, tag)
+		})
+	}
+	isOptIn := func(tag string) bool { return slices.Contains(optInTags, tag) }
+	if i := slices.IndexFunc(tags, isOptIn); i >= 0 {
+		if !slices.Contains(c.EnableTools, name) && !slices.Contains(c.EnableTools, tags[i]) {
+			return false
+		}
+		return !matches(c.DisableTools)
+	}
+	enabled := slices.DeleteFunc(slices.Clone(c.EnableTools), isOptIn)
+	if len(enabled) > 0 && !matches(enabled) {
+		return false
+	}
+	return !matches(c.DisableTools)
+}
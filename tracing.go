@@ -0,0 +1,134 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the server's spans. It records nothing until setupTracing
+// installs an exporting provider.
+var tracer = otel.Tracer("github.com/mrjoshuak/godoc-mcp")
+
+// setupTracing exports spans over OTLP when the standard OpenTelemetry
+// environment variables configure an endpoint. The exporter, its protocol
+// (http/protobuf or grpc), headers, sampler, and resource attributes all come
+// from OTEL_* variables. The returned function flushes pending spans.
+func setupTracing(ctx context.Context, version string, logger *logrus.Logger) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") || !tracingConfigured() {
+		return noop, nil
+	}
+
+	protocol := firstEnv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL")
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch protocol {
+	case "", "http/protobuf":
+		exporter, err = otlptracehttp.New(ctx)
+	case "grpc":
+		exporter, err = otlptracegrpc.New(ctx)
+	default:
+		return noop, fmt.Errorf("unsupported OTLP protocol %q: must be http/protobuf or grpc", protocol)
+	}
+	if err != nil {
+		return noop, fmt.Errorf("failed to create OTLP exporter: %v", err)
+	}
+
+	// Environment attributes, including OTEL_SERVICE_NAME, override the defaults
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName("godoc-mcp"), semconv.ServiceVersion(version)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return noop, fmt.Errorf("failed to build trace resource: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.WithError(err).Debug("OpenTelemetry error")
+	}))
+	logger.WithField("protocol", cmp.Or(protocol, "http/protobuf")).Info("Exporting traces over OTLP")
+	return provider.Shutdown, nil
+}
+
+// tracingConfigured reports whether the environment names an OTLP trace endpoint
+// or explicitly selects the otlp exporter
+func tracingConfigured() bool {
+	switch os.Getenv("OTEL_TRACES_EXPORTER") {
+	case "otlp":
+		return true
+	case "none":
+		return false
+	}
+	return firstEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT") != ""
+}
+
+// firstEnv returns the first non-empty environment variable of keys
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// startSpan starts a span named name as a child of the span in ctx
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err, if any, on span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// withTracing wraps each tool call in a span, marking tool errors as failures
+func (s *GodocServer) withTracing(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, span := startSpan(ctx, "tools/call "+request.Params.Name,
+			attribute.String("mcp.tool.name", request.Params.Name),
+			attribute.String("mcp.session.id", sessionID(ctx)),
+		)
+		result, err := next(ctx, request)
+		if err == nil && result != nil && result.IsError {
+			span.SetStatus(codes.Error, "tool returned an error")
+		}
+		endSpan(span, err)
+		return result, err
+	}
+}
+
+// withTraceContext continues traces propagated by HTTP clients in traceparent headers
+func withTraceContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
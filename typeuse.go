@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Where find_by_type looks for a type
+const (
+	positionParam  = "param"
+	positionResult = "result"
+	positionAny    = "any"
+)
+
+const findByTypeDescription = `List the functions and methods of a package, or of every package of a pattern such as './...' for the
+working module, that take a type as a parameter or return it, such as every function accepting an
+*http.Request. The type is written as in code, qualified by package name; unless exact is set, it also
+matches pointers, slices, arrays, maps, channels, and variadic parameters of it, so 'http.Request' finds
+*http.Request and []*http.Request too. Receivers don't count.`
+
+// findByTypeSchema is the find_by_type input schema
+var findByTypeSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Package to search, as for get_doc (e.g., 'net/http', './pkg', or 'github.com/user/repo'). End it with '/...' to include the packages below it, as in './...'.",
+		},
+		"type": map[string]any{
+			"type":        "string",
+			"description": "Type to look for, as written in code (e.g., '*http.Request', 'io.Reader', or 'Config' for the searched package's own types).",
+		},
+		"position": map[string]any{
+			"type":        "string",
+			"description": "Where the type must appear.",
+			"enum":        []string{positionParam, positionResult, positionAny},
+			"default":     positionAny,
+		},
+		"exact": map[string]any{
+			"type":        "boolean",
+			"description": "Only match the type itself, not pointers, slices, maps, or channels of it.",
+			"default":     false,
+		},
+		"kind": map[string]any{
+			"type":        "string",
+			"description": "Optional: Only return functions or methods. Methods include those of interface types.",
+			"enum":        []string{symbolFunc, symbolMethod},
+		},
+		"unexported": map[string]any{
+			"type":        "boolean",
+			"description": "Include unexported functions and methods.",
+			"default":     false,
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of functions to return.",
+			"minimum":     1,
+			"maximum":     maxSignatureResults,
+			"default":     defaultSignatureResults,
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory for relative paths and the module's own packages. Defaults to the session working directory.",
+		},
+	},
+	Required: []string{"path", "type"},
+}
+
+// handleFindByType implements the find_by_type tool
+func (s *GodocServer) handleFindByType(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	typ := normalizeType(request.GetString("type", ""))
+	if typ == "" {
+		return errorResult(codeInvalidArgument, "invalid or missing type parameter"), nil
+	}
+	position := request.GetString("position", positionAny)
+	if position != positionParam && position != positionResult && position != positionAny {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("invalid position %q: must be param, result, or any", position)), nil
+	}
+	exact := request.GetBool("exact", false)
+
+	match := func(pkg *types.Package, fn *types.Func) []string {
+		sig := fn.Type().(*types.Signature)
+		var matched []string
+		uses := func(role string, tuple *types.Tuple, variadic bool) {
+			for i := range tuple.Len() {
+				v := tuple.At(i)
+				if typeUses(pkg, v.Type(), typ, exact) {
+					matched = append(matched, paramString(pkg, role, v, variadic && i == tuple.Len()-1))
+				}
+			}
+		}
+		if position != positionResult {
+			uses(positionParam, sig.Params(), sig.Variadic())
+		}
+		if position != positionParam {
+			uses(positionResult, sig.Results(), false)
+		}
+		return matched
+	}
+	return s.searchFuncs(ctx, request, match)
+}
+
+// typeUses reports whether typ, as code in pkg sees it, is the normalized
+// type want or, unless exact, is built from it by pointers, slices, arrays,
+// maps, or channels
+func typeUses(pkg *types.Package, typ types.Type, want string, exact bool) bool {
+	if slices.Contains(typeForms(pkg, typ), want) {
+		return true
+	}
+	if exact {
+		return false
+	}
+	switch t := typ.(type) {
+	case *types.Pointer:
+		return typeUses(pkg, t.Elem(), want, false)
+	case *types.Slice:
+		return typeUses(pkg, t.Elem(), want, false)
+	case *types.Array:
+		return typeUses(pkg, t.Elem(), want, false)
+	case *types.Chan:
+		return typeUses(pkg, t.Elem(), want, false)
+	case *types.Map:
+		return typeUses(pkg, t.Key(), want, false) || typeUses(pkg, t.Elem(), want, false)
+	}
+	return false
+}
+
+// paramString describes the parameter or result v of a function in pkg, as
+// in "param req *http.Request"
+func paramString(pkg *types.Package, role string, v *types.Var, variadic bool) string {
+	typ := v.Type()
+	prefix := ""
+	if slice, ok := typ.(*types.Slice); ok && variadic {
+		typ, prefix = slice.Elem(), "..."
+	}
+	desc := role
+	if v.Name() != "" && v.Name() != "_" {
+		desc += " " + v.Name()
+	}
+	return desc + " " + prefix + types.TypeString(typ, nameQualifier(pkg))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindByType(t *testing.T) {
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/mod\n\ngo 1.21\n",
+		"api/api.go": `package api
+
+import "net/http"
+
+type Config struct{}
+
+func Load(path string) (*Config, error) { return nil, nil }
+
+func Merge(cfgs ...Config) Config { return Config{} }
+
+func Index(m map[string][]*Config) {}
+
+func Serve(w http.ResponseWriter, r *http.Request) {}
+
+func Batch(reqs []*http.Request) <-chan *http.Request { return nil }
+
+func (c *Config) Clone() *Config { return c }
+
+func apply(c Config) {}
+
+type Store interface {
+	Get(key string) (Config, bool)
+}
+`,
+	})
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		// want lists the functions found, each with how it matched
+		want []string
+	}{
+		{"no type", map[string]any{"path": "./api", "working_dir": dir}, codeInvalidArgument, nil},
+		{"bad position", map[string]any{"path": "./api", "type": "Config", "position": "receiver", "working_dir": dir}, codeInvalidArgument, nil},
+		{"anywhere", map[string]any{"path": "./api", "type": "Config", "working_dir": dir}, "", []string{
+			"Config.Clone: result *Config",
+			"Index: param m map[string][]*Config",
+			"Load: result *Config",
+			"Merge: param cfgs ...Config; result Config",
+			"Store.Get: result Config",
+		}},
+		{"qualified", map[string]any{"path": "./api", "type": "*api.Config", "exact": true, "working_dir": dir}, "", []string{
+			"Config.Clone: result *Config",
+			"Load: result *Config",
+		}},
+		{"exact", map[string]any{"path": "./api", "type": "Config", "exact": true, "working_dir": dir}, "", []string{
+			"Merge: result Config",
+			"Store.Get: result Config",
+		}},
+		{"params", map[string]any{"path": "./api", "type": "Config", "position": positionParam, "working_dir": dir}, "", []string{
+			"Index: param m map[string][]*Config",
+			"Merge: param cfgs ...Config",
+		}},
+		{"unexported", map[string]any{"path": "./api", "type": "Config", "position": positionParam, "unexported": true, "working_dir": dir}, "", []string{
+			"Index: param m map[string][]*Config",
+			"Merge: param cfgs ...Config",
+			"apply: param c Config",
+		}},
+		{"methods", map[string]any{"path": "./api", "type": "Config", "kind": symbolMethod, "working_dir": dir}, "", []string{
+			"Config.Clone: result *Config",
+			"Store.Get: result Config",
+		}},
+		{"other package", map[string]any{"path": "./api", "type": "*http.Request", "working_dir": dir}, "", []string{
+			"Batch: param reqs []*http.Request; result <-chan *http.Request",
+			"Serve: param r *http.Request",
+		}},
+		{"results", map[string]any{"path": "./api", "type": "* http.Request", "position": positionResult, "working_dir": dir}, "", []string{
+			"Batch: result <-chan *http.Request",
+		}},
+		{"not used", map[string]any{"path": "./api", "type": "io.Reader", "working_dir": dir}, "", nil},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleFindByType, "find_by_type", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*findSignaturesOutput)
+		var got []string
+		for _, fn := range out.Functions {
+			got = append(got, fn.Name+": "+strings.Join(fn.Matched, "; "))
+		}
+		if !slices.Equal(got, tt.want) || out.Total != len(tt.want) {
+			t.Errorf("%s: found\n%q of %d\nwant\n%q", tt.name, got, out.Total, tt.want)
+		}
+	}
+}
@@ -0,0 +1,573 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/html"
+	"golang.org/x/sync/errgroup"
+)
+
+const usageExamplesDescription = `Find real-world usage of an exported Go symbol in public code.
+Searches a code search backend for call sites of the symbol in files importing its package and
+returns a handful of deduplicated snippets, each attributed to its repository and file with a link.
+Use it when documentation alone doesn't show how a function or type is used in practice, such as
+path "golang.org/x/sync/errgroup" and symbol "WithContext". Methods are given as Type.Method.`
+
+// Code search backends selected with -code-search
+const (
+	codeSearchSourcegraph = "sourcegraph"
+	codeSearchGrepApp     = "grepapp"
+)
+
+// Defaults of -code-search-url for each backend
+const (
+	defaultSourcegraphURL = "https://sourcegraph.com"
+	defaultGrepAppURL     = "https://grep.app"
+)
+
+// Limits on usage_examples requests
+const (
+	defaultUsageExamples = 5
+	maxUsageExamples     = 20
+	// usageSearchResults is the number of matches requested from the backend,
+	// from which examples are picked
+	usageSearchResults = 100
+	// usageContextLines is the number of lines shown around a call site
+	usageContextLines = 3
+	// usageTimeout bounds the requests of one search
+	usageTimeout = 20 * time.Second
+	// usageCacheTTL is how long the matches of a search are reused
+	usageCacheTTL = 10 * time.Minute
+)
+
+// usageExamplesSchema is the usage_examples input schema
+var usageExamplesSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Import path of the package declaring the symbol (e.g., 'golang.org/x/sync/errgroup').",
+		},
+		"symbol": map[string]any{
+			"type":        "string",
+			"description": "Exported symbol to find usage of: a function, type, constant, or variable, or a method as Type.Method (e.g., 'WithContext' or 'Group.Go').",
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of examples to return.",
+			"minimum":     1,
+			"maximum":     maxUsageExamples,
+			"default":     defaultUsageExamples,
+		},
+	},
+	Required: []string{"path", "symbol"},
+}
+
+// usageOutputSchema is the outputSchema of usage_examples, describing usageOutput
+var usageOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"query":   map[string]any{"type": "string", "description": "The query sent to the code search backend"},
+		"backend": map[string]any{"type": "string", "description": "The code search backend searched"},
+		"examples": map[string]any{
+			"type":        "array",
+			"description": "Usage snippets, at most one per repository",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"repository": map[string]any{"type": "string", "description": "Repository the snippet is from"},
+					"file":       map[string]any{"type": "string", "description": "File within the repository"},
+					"line":       map[string]any{"type": "integer", "description": "1-based line of the usage"},
+					"url":        map[string]any{"type": "string", "description": "Link to the usage"},
+					"code":       map[string]any{"type": "string", "description": "The usage with the lines around it"},
+				},
+				"required": []string{"repository", "file", "line", "code"},
+			},
+		},
+	},
+	Required: []string{"query", "backend", "examples"},
+}
+
+// usageOutput is the structured content of a usage_examples result
+type usageOutput struct {
+	Query    string         `json:"query"`
+	Backend  string         `json:"backend"`
+	Examples []usageExample `json:"examples"`
+}
+
+// usageExample is a usage of a symbol found by code search
+type usageExample struct {
+	Repository string `json:"repository"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	URL        string `json:"url,omitempty"`
+	Code       string `json:"code"`
+	// rev is the revision the match was found at, for fetching its context
+	rev string
+}
+
+// codeSearcher finds Go code matching a regular expression in files containing
+// importPath
+type codeSearcher interface {
+	search(ctx context.Context, pattern, importPath string) (query string, matches []usageExample, err error)
+	// context fills in the lines around each example's match
+	context(ctx context.Context, examples []usageExample) error
+}
+
+// newCodeSearcher returns the code searcher cfg selects and its base URL, or nil
+// when usage_examples is disabled
+func newCodeSearcher(cfg CodeSearchConfig) (codeSearcher, string) {
+	client := &http.Client{Timeout: usageTimeout}
+	switch cfg.Backend {
+	case codeSearchSourcegraph:
+		// Sourcegraph takes access tokens in its own scheme rather than as
+		// bearer tokens
+		if token := os.Getenv(cfg.TokenEnv); token != "" {
+			client.Transport = tokenTransport{token}
+		}
+		c := &sourcegraphSearcher{client: client, url: strings.TrimSuffix(cmp.Or(cfg.URL, defaultSourcegraphURL), "/")}
+		return c, c.url
+	case codeSearchGrepApp:
+		c := &grepAppSearcher{client: client, url: strings.TrimSuffix(cmp.Or(cfg.URL, defaultGrepAppURL), "/")}
+		return c, c.url
+	}
+	return nil, ""
+}
+
+// codeSearch is the outcome of a code search: the query the backend ran and
+// its matches
+type codeSearch struct {
+	query   string
+	matches []usageExample
+}
+
+// newUsageCache creates the cache of code searches by backend and pattern
+func newUsageCache() *ttlcache.Cache[string, codeSearch] {
+	cache := ttlcache.New[string, codeSearch](
+		ttlcache.WithTTL[string, codeSearch](usageCacheTTL),
+		ttlcache.WithDisableTouchOnHit[string, codeSearch](),
+	)
+	go cache.Start()
+	return cache
+}
+
+// handleUsageExamples implements the usage_examples tool
+func (s *GodocServer) handleUsageExamples(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pkgPath := strings.TrimSpace(request.GetString("path", ""))
+	symbol := strings.TrimSpace(request.GetString("symbol", ""))
+	if pkgPath == "" || symbol == "" {
+		return errorResult(codeInvalidArgument, "path and symbol must not be empty"), nil
+	}
+	typ, member, isMethod := strings.Cut(symbol, ".")
+	if !token.IsExported(typ) || (isMethod && !token.IsExported(member)) {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("symbol must be exported, as Name or Type.Method, got %q", symbol)), nil
+	}
+	limit := request.GetInt("limit", defaultUsageExamples)
+	if limit < 1 || limit > maxUsageExamples {
+		return errorResult(codeInvalidArgument, fmt.Sprintf("limit must be between 1 and %d, got %d", maxUsageExamples, limit)), nil
+	}
+	searcher, base := newCodeSearcher(s.config.Load().CodeSearch)
+	if searcher == nil {
+		return errorResult(codeUnsupported, "no code search backend is configured"), nil
+	}
+	log := ctxLogger(ctx, s.logger).WithFields(logrus.Fields{"package": pkgPath, "symbol": symbol, "code_search": base})
+
+	// A method is called on values of any name; other symbols are qualified
+	// by the package's name
+	pattern := `\b` + regexp.QuoteMeta(assumedPackageName(pkgPath)+"."+symbol) + `\b`
+	if isMethod {
+		pattern = `\.` + regexp.QuoteMeta(member) + `\(`
+	}
+	ctx, cancel := context.WithTimeout(ctx, usageTimeout)
+	defer cancel()
+
+	key := base + "|" + pattern + "|" + pkgPath
+	var found codeSearch
+	if item := s.usages.Get(key); item != nil {
+		found = item.Value()
+	} else {
+		var err error
+		progressFromContext(ctx).step("Searching " + base)
+		if found.query, found.matches, err = searcher.search(ctx, pattern, pkgPath); err != nil {
+			log.WithError(err).Warn("Code search failed")
+			if ctx.Err() != nil {
+				return errorResultFromErr("failed to search "+base, ctx.Err()), nil
+			}
+			return errorResult(codeNetworkFetch, fmt.Sprintf("failed to search %s: %v", base, err)), nil
+		}
+		s.usages.Set(key, found, ttlcache.DefaultTTL)
+	}
+
+	examples := pickUsageExamples(found.matches, pkgPath, limit)
+	if err := searcher.context(ctx, examples); err != nil {
+		log.WithError(err).Debug("Failed to fetch the context of usage examples")
+	}
+	log.WithFields(logrus.Fields{"matches": len(found.matches), "examples": len(examples)}).Debug("Searched for usage examples")
+
+	out := &usageOutput{Query: found.query, Backend: base, Examples: examples}
+	var sb strings.Builder
+	if len(examples) == 0 {
+		fmt.Fprintf(&sb, "No usage of %s.%s found on %s.", pkgPath, symbol, base)
+	} else {
+		fmt.Fprintf(&sb, "Usage of %s.%s found on %s, one example per repository:\n", pkgPath, symbol, base)
+		for i, ex := range examples {
+			fmt.Fprintf(&sb, "\n%d. %s: %s:%d\n", i+1, ex.Repository, ex.File, ex.Line)
+			if ex.URL != "" {
+				fmt.Fprintf(&sb, "   %s\n", ex.URL)
+			}
+			fmt.Fprintf(&sb, "\n%s\n", indentLines(ex.Code, "\t"))
+		}
+	}
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	result.StructuredContent = out
+	return result, nil
+}
+
+// pickUsageExamples returns up to limit of matches, at most one per repository
+// and none with the same code, skipping the repository declaring pkgPath, whose
+// uses are its implementation rather than examples
+func pickUsageExamples(matches []usageExample, pkgPath string, limit int) []usageExample {
+	home := repositoryOf(pkgPath)
+	repos := make(map[string]bool)
+	codes := make(map[string]bool)
+	examples := make([]usageExample, 0, limit)
+	for _, m := range matches {
+		if len(examples) == limit {
+			break
+		}
+		code := strings.Join(strings.Fields(m.Code), " ")
+		if repos[m.Repository] || codes[code] || m.Repository == home || inModule(pkgPath, m.Repository) {
+			continue
+		}
+		repos[m.Repository], codes[code] = true, true
+		examples = append(examples, m)
+	}
+	return examples
+}
+
+// repositoryOf returns the repository likely hosting pkgPath, such as
+// github.com/golang/go for the standard library
+func repositoryOf(pkgPath string) string {
+	if isStdLib(pkgPath) {
+		return "github.com/golang/go"
+	}
+	if repo, ok := strings.CutPrefix(pkgPath, "golang.org/x/"); ok {
+		repo, _, _ = strings.Cut(repo, "/")
+		return "github.com/golang/" + repo
+	}
+	parts := strings.Split(pkgPath, "/")
+	if len(parts) < 3 {
+		return pkgPath
+	}
+	return strings.Join(parts[:3], "/")
+}
+
+// assumedPackageName returns the name a package is most likely declared with,
+// from its import path, as goimports assumes it: the last element without a
+// major version suffix, a go- prefix, or anything after the first character
+// not allowed in an identifier
+func assumedPackageName(importPath string) string {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil && path.Dir(importPath) != "." {
+			base = path.Base(path.Dir(importPath))
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_')
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// snippetAround returns the lines of content within usageContextLines of the
+// 1-based line
+func snippetAround(content string, line int) string {
+	lines := strings.Split(content, "\n")
+	lo := max(line-1-usageContextLines, 0)
+	hi := min(line+usageContextLines, len(lines))
+	if lo >= hi {
+		return ""
+	}
+	return trimIndent(lines[lo:hi])
+}
+
+// trimIndent joins lines, dropping the indentation they share
+func trimIndent(lines []string) string {
+	prefix := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimPrefix(line, prefix)
+	}
+	return strings.Trim(strings.Join(out, "\n"), "\n")
+}
+
+// sourcegraphSearcher searches a Sourcegraph instance through its GraphQL API
+type sourcegraphSearcher struct {
+	client *http.Client
+	url    string
+}
+
+// sourcegraphQuery finds the files matching a search, with the line of each match
+const sourcegraphQuery = `query Usage($query: String!) {
+  search(query: $query, version: V3) {
+    results {
+      results {
+        ... on FileMatch {
+          file { path url }
+          repository { name }
+          commit { oid }
+          lineMatches { preview lineNumber }
+        }
+      }
+    }
+  }
+}`
+
+func (c *sourcegraphSearcher) search(ctx context.Context, pattern, importPath string) (string, []usageExample, error) {
+	query := fmt.Sprintf(`lang:go patterntype:regexp -file:_test\.go$ file:has.content(%s) count:%d %s`,
+		strconv.Quote(regexp.QuoteMeta(strconv.Quote(importPath))), usageSearchResults, pattern)
+	var out struct {
+		Data struct {
+			Search struct {
+				Results struct {
+					Results []struct {
+						File struct {
+							Path string `json:"path"`
+							URL  string `json:"url"`
+						} `json:"file"`
+						Repository struct {
+							Name string `json:"name"`
+						} `json:"repository"`
+						Commit struct {
+							OID string `json:"oid"`
+						} `json:"commit"`
+						LineMatches []struct {
+							Preview    string `json:"preview"`
+							LineNumber int    `json:"lineNumber"`
+						} `json:"lineMatches"`
+					} `json:"results"`
+				} `json:"results"`
+			} `json:"search"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	in := map[string]any{"query": sourcegraphQuery, "variables": map[string]any{"query": query}}
+	if err := postJSON(ctx, c.client, c.url+"/.api/graphql", "", in, &out); err != nil {
+		return query, nil, err
+	}
+	if len(out.Errors) > 0 {
+		return query, nil, fmt.Errorf("%s", out.Errors[0].Message)
+	}
+	var matches []usageExample
+	for _, r := range out.Data.Search.Results.Results {
+		if r.File.Path == "" || len(r.LineMatches) == 0 {
+			continue
+		}
+		// Sourcegraph numbers lines from 0
+		lm := r.LineMatches[0]
+		ex := usageExample{
+			Repository: r.Repository.Name,
+			File:       r.File.Path,
+			Line:       lm.LineNumber + 1,
+			Code:       strings.TrimSpace(lm.Preview),
+			rev:        r.Commit.OID,
+		}
+		if r.File.URL != "" {
+			ex.URL = c.url + r.File.URL + "?L" + strconv.Itoa(ex.Line)
+		}
+		matches = append(matches, ex)
+	}
+	return query, matches, nil
+}
+
+// context replaces each example's matching line with the lines around it,
+// read from the file at the revision it was found at
+func (c *sourcegraphSearcher) context(ctx context.Context, examples []usageExample) error {
+	var g errgroup.Group
+	for i := range examples {
+		g.Go(func() error {
+			ex := &examples[i]
+			repo := ex.Repository
+			if ex.rev != "" {
+				repo += "@" + ex.rev
+			}
+			u := c.url + "/" + repo + "/-/raw/" + ex.File
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := c.client.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("%s returned %s", u, resp.Status)
+			}
+			data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+			if err != nil {
+				return err
+			}
+			if code := snippetAround(string(data), ex.Line); code != "" {
+				ex.Code = code
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// tokenTransport authenticates requests with a Sourcegraph access token
+type tokenTransport struct{ token string }
+
+func (t tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+t.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// grepAppSearcher searches grep.app, which indexes public GitHub repositories
+type grepAppSearcher struct {
+	client *http.Client
+	url    string
+}
+
+func (c *grepAppSearcher) search(ctx context.Context, pattern, importPath string) (string, []usageExample, error) {
+	// grep.app can't require the import as well, so matches in files using
+	// another package of the same name remain
+	u := c.url + "/api/search?" + url.Values{"q": {pattern}, "regexp": {"true"}, "f.lang": {"Go"}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return pattern, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "godoc-mcp/"+getBuildInfo().Version)
+	var out struct {
+		Hits struct {
+			Hits []struct {
+				Repo    struct{ Raw string } `json:"repo"`
+				Path    struct{ Raw string } `json:"path"`
+				Branch  struct{ Raw string } `json:"branch"`
+				Content struct {
+					Snippet string `json:"snippet"`
+				} `json:"content"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return pattern, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return pattern, nil, fmt.Errorf("%s returned %s", u, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(&out); err != nil {
+		return pattern, nil, fmt.Errorf("invalid response from %s: %v", u, err)
+	}
+	re := regexp.MustCompile(pattern)
+	var matches []usageExample
+	for _, h := range out.Hits.Hits {
+		if strings.HasSuffix(h.Path.Raw, "_test.go") {
+			continue
+		}
+		line, code := grepAppSnippet(h.Content.Snippet, re)
+		if line == 0 {
+			continue
+		}
+		ex := usageExample{Repository: "github.com/" + h.Repo.Raw, File: h.Path.Raw, Line: line, Code: code}
+		if h.Branch.Raw != "" {
+			ex.URL = fmt.Sprintf("https://github.com/%s/blob/%s/%s#L%d", h.Repo.Raw, h.Branch.Raw, h.Path.Raw, line)
+		}
+		matches = append(matches, ex)
+	}
+	return pattern, matches, nil
+}
+
+// context does nothing: grep.app's snippets already hold the lines around
+// each match
+func (c *grepAppSearcher) context(context.Context, []usageExample) error {
+	return nil
+}
+
+// grepAppSnippet returns the first line of a grep.app snippet matching re and
+// the snippet's lines around it. Snippets are HTML tables with a row per line,
+// holding its number and its code.
+func grepAppSnippet(snippet string, re *regexp.Regexp) (int, string) {
+	doc, err := html.Parse(strings.NewReader(snippet))
+	if err != nil {
+		return 0, ""
+	}
+	type row struct {
+		n    int
+		code string
+	}
+	var rows []row
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "tr" {
+			var cells []*html.Node
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && c.Data == "td" {
+					cells = append(cells, c)
+				}
+			}
+			if len(cells) == 2 {
+				if num, err := strconv.Atoi(strings.TrimSpace(nodeText(cells[0]))); err == nil {
+					rows = append(rows, row{num, strings.TrimRight(preText(cells[1]), "\n")})
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	for i, r := range rows {
+		if !re.MatchString(r.code) {
+			continue
+		}
+		var lines []string
+		for _, r := range rows[max(i-usageContextLines, 0):min(i+usageContextLines+1, len(rows))] {
+			lines = append(lines, r.code)
+		}
+		return r.n, trimIndent(lines)
+	}
+	return 0, ""
+}
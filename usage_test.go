@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// appSource is the file of github.com/a/app in which the test code search
+// backends find yaml.Unmarshal, on line 5
+const appSource = `package main
+
+func load(data []byte) (cfg Config) {
+	// Parse the configuration
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		panic(err)
+	}
+	return cfg
+}
+`
+
+func TestUsageExamples(t *testing.T) {
+	t.Setenv("TEST_SOURCEGRAPH_TOKEN", "secret")
+	var searches atomic.Int32
+	sourcegraph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/.api/graphql":
+			searches.Add(1)
+			var in struct {
+				Variables struct{ Query string } `json:"variables"`
+			}
+			json.NewDecoder(r.Body).Decode(&in)
+			if strings.Contains(in.Variables.Query, "Broken") {
+				fmt.Fprint(w, `{"errors":[{"message":"search timed out"}]}`)
+				return
+			}
+			match := func(repo, file, commit, preview string, line int) map[string]any {
+				return map[string]any{
+					"file":        map[string]any{"path": file, "url": "/" + repo + "/-/blob/" + file},
+					"repository":  map[string]any{"name": repo},
+					"commit":      map[string]any{"oid": commit},
+					"lineMatches": []map[string]any{{"preview": preview, "lineNumber": line}},
+				}
+			}
+			results := []any{
+				match("gopkg.in/yaml.v3", "decode.go", "c0", "yaml.Unmarshal(in, out)", 10),
+				match("github.com/a/app", "main.go", "c1", "\tif err := yaml.Unmarshal(data, &cfg); err != nil {", 4),
+				match("github.com/a/app", "other.go", "c1", "yaml.Unmarshal(other, &v)", 7),
+				match("github.com/b/copy", "main.go", "c2", "if err := yaml.Unmarshal(data, &cfg); err != nil {", 4),
+				match("github.com/c/tool", "run.go", "c3", "yaml.Unmarshal(b, &v)", 0),
+				map[string]any{"file": map[string]any{"path": "empty.go"}, "repository": map[string]any{"name": "github.com/d/none"}, "lineMatches": []any{}},
+			}
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"search": map[string]any{"results": map[string]any{"results": results}}}})
+		case "/github.com/a/app@c1/-/raw/main.go":
+			fmt.Fprint(w, appSource)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer sourcegraph.Close()
+	s := newTestServer(t, "-code-search", codeSearchSourcegraph, "-code-search-url", sourcegraph.URL, "-code-search-token-env", "TEST_SOURCEGRAPH_TOKEN")
+	ctx := context.Background()
+
+	app := usageExample{
+		Repository: "github.com/a/app",
+		File:       "main.go",
+		Line:       5,
+		URL:        sourcegraph.URL + "/github.com/a/app/-/blob/main.go?L5",
+		Code:       "func load(data []byte) (cfg Config) {\n\t// Parse the configuration\n\tif err := yaml.Unmarshal(data, &cfg); err != nil {\n\t\tpanic(err)\n\t}\n\treturn cfg",
+		rev:        "c1",
+	}
+	tool := usageExample{
+		Repository: "github.com/c/tool",
+		File:       "run.go",
+		Line:       1,
+		URL:        sourcegraph.URL + "/github.com/c/tool/-/blob/run.go?L1",
+		Code:       "yaml.Unmarshal(b, &v)",
+		rev:        "c3",
+	}
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantCode string
+		want     []usageExample
+		searches int32
+	}{
+		{"no symbol", map[string]any{"path": "gopkg.in/yaml.v3"}, codeInvalidArgument, nil, 0},
+		{"unexported", map[string]any{"path": "gopkg.in/yaml.v3", "symbol": "unmarshal"}, codeInvalidArgument, nil, 0},
+		{"unexported method", map[string]any{"path": "gopkg.in/yaml.v3", "symbol": "Decoder.decode"}, codeInvalidArgument, nil, 0},
+		{"bad limit", map[string]any{"path": "gopkg.in/yaml.v3", "symbol": "Unmarshal", "limit": maxUsageExamples + 1}, codeInvalidArgument, nil, 0},
+		{"search fails", map[string]any{"path": "gopkg.in/yaml.v3", "symbol": "Broken"}, codeNetworkFetch, nil, 1},
+		// Matches in the package's own repository, repeated repositories, and
+		// repeated code are left out
+		{"examples", map[string]any{"path": "gopkg.in/yaml.v3", "symbol": "Unmarshal"}, "", []usageExample{app, tool}, 2},
+		{"cached and limited", map[string]any{"path": "gopkg.in/yaml.v3", "symbol": "Unmarshal", "limit": 1}, "", []usageExample{app}, 2},
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleUsageExamples, "usage_examples", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+		} else if tt.wantCode == "" {
+			out := result.StructuredContent.(*usageOutput)
+			if !reflect.DeepEqual(out.Examples, tt.want) {
+				t.Errorf("%s: examples\n%+v\nwant\n%+v", tt.name, out.Examples, tt.want)
+			}
+			if !strings.Contains(out.Query, `file:has.content("\"gopkg\\.in/yaml\\.v3\"")`) || !strings.HasSuffix(out.Query, `\byaml\.Unmarshal\b`) {
+				t.Errorf("%s: query %q", tt.name, out.Query)
+			}
+		}
+		if got := searches.Load(); got != tt.searches {
+			t.Errorf("%s: %d searches made, want %d", tt.name, got, tt.searches)
+		}
+	}
+}
+
+func TestUsageExamplesGrepApp(t *testing.T) {
+	var query string
+	grepApp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/search" || r.URL.Query().Get("regexp") != "true" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query().Get("q")
+		var rows strings.Builder
+		for i, line := range strings.Split(appSource, "\n") {
+			fmt.Fprintf(&rows, "<tr><td><div>%d</div></td><td><pre>%s</pre></td></tr>", i+1, line)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"hits": map[string]any{"hits": []any{
+			map[string]any{"repo": map[string]any{"raw": "a/app"}, "path": map[string]any{"raw": "main_test.go"}, "branch": map[string]any{"raw": "main"}, "content": map[string]any{"snippet": "<table>" + rows.String() + "</table>"}},
+			map[string]any{"repo": map[string]any{"raw": "a/app"}, "path": map[string]any{"raw": "main.go"}, "branch": map[string]any{"raw": "main"}, "content": map[string]any{"snippet": "<table>" + rows.String() + "</table>"}},
+			map[string]any{"repo": map[string]any{"raw": "b/other"}, "path": map[string]any{"raw": "x.go"}, "content": map[string]any{"snippet": "<table><tr><td>1</td><td><pre>other.Unmarshal()</pre></td></tr></table>"}},
+		}}})
+	}))
+	defer grepApp.Close()
+	s := newTestServer(t, "-code-search", codeSearchGrepApp, "-code-search-url", grepApp.URL)
+
+	tests := []struct {
+		symbol string
+		query  string
+		want   []usageExample
+	}{
+		{"Unmarshal", `\byaml\.Unmarshal\b`, []usageExample{{
+			Repository: "github.com/a/app",
+			File:       "main.go",
+			Line:       5,
+			URL:        "https://github.com/a/app/blob/main/main.go#L5",
+			Code:       "func load(data []byte) (cfg Config) {\n\t// Parse the configuration\n\tif err := yaml.Unmarshal(data, &cfg); err != nil {\n\t\tpanic(err)\n\t}\n\treturn cfg",
+		}}},
+		{"Decoder.Decode", `\.Decode\(`, []usageExample{}},
+	}
+	for _, tt := range tests {
+		result := callTool(t, context.Background(), s.handleUsageExamples, "usage_examples", map[string]any{"path": "gopkg.in/yaml.v3", "symbol": tt.symbol})
+		out, ok := result.StructuredContent.(*usageOutput)
+		if !ok {
+			t.Fatalf("%s: %s", tt.symbol, resultText(result))
+		}
+		if query != tt.query || out.Query != tt.query {
+			t.Errorf("%s: searched for %q, reported %q, want %q", tt.symbol, query, out.Query, tt.query)
+		}
+		if !reflect.DeepEqual(out.Examples, tt.want) {
+			t.Errorf("%s: examples\n%+v\nwant\n%+v", tt.symbol, out.Examples, tt.want)
+		}
+	}
+}
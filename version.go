@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, set at link time:
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.commit=abc1234 -X main.date=2025-01-01T00:00:00Z"
+//
+// Values left unset are filled from the module build info when available.
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// buildInfo describes the running binary
+type buildInfo struct {
+	Version   string
+	Commit    string
+	Date      string
+	GoVersion string
+}
+
+// getBuildInfo returns the build metadata, preferring ldflags values over the
+// module and VCS information embedded by the go command
+func getBuildInfo() buildInfo {
+	bi := buildInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if bi.Version == "" && info.Main.Version != "(devel)" {
+			bi.Version = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && bi.Commit == "":
+				bi.Commit = setting.Value
+			case setting.Key == "vcs.time" && bi.Date == "":
+				bi.Date = setting.Value
+			}
+		}
+	}
+	if bi.Version == "" {
+		bi.Version = "devel"
+	}
+	if len(bi.Commit) > 12 {
+		bi.Commit = bi.Commit[:12]
+	}
+	return bi
+}
+
+// String formats the build metadata for --version output and the MCP server info
+func (bi buildInfo) String() string {
+	s := bi.Version
+	switch {
+	case bi.Commit != "" && bi.Date != "":
+		s += fmt.Sprintf(" (commit %s, built %s)", bi.Commit, bi.Date)
+	case bi.Commit != "":
+		s += fmt.Sprintf(" (commit %s)", bi.Commit)
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// internalParent returns the root of the tree of packages allowed to import
+// pkgPath, the parent of its last internal element, and whether pkgPath is an
+// internal package at all
+func internalParent(pkgPath string) (string, bool) {
+	elems := strings.Split(pkgPath, "/")
+	for i := len(elems) - 1; i >= 0; i-- {
+		if elems[i] == "internal" {
+			return strings.Join(elems[:i], "/"), true
+		}
+	}
+	return "", false
+}
+
+// internalWarning warns that pkgPath is an internal package, which code outside
+// the tree it belongs to cannot import. Packages the module in workingDir may
+// import throughout, when it is the client's own module, need no warning.
+func internalWarning(pkgPath, workingDir string, ownModule bool) string {
+	parent, ok := internalParent(pkgPath)
+	if !ok {
+		return ""
+	}
+	if parent == "" {
+		return fmt.Sprintf("%s is internal to the standard library and cannot be imported by other code; do not suggest importing it", pkgPath)
+	}
+	if ownModule {
+		if data, err := os.ReadFile(filepath.Join(workingDir, "go.mod")); err == nil {
+			if mod := modfile.ModulePath(data); mod != "" && inModule(mod, parent) {
+				return ""
+			}
+		}
+	}
+	return fmt.Sprintf("%s is an internal package: only packages in %s/... can import it, so code outside that tree cannot; do not suggest importing it elsewhere", pkgPath, parent)
+}
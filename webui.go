@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"go/doc/comment"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// webUI serves a browsable documentation site under base+"/ui/", generated
+// through get_doc and search_docs, so browsers share the cache and limits
+// with MCP clients
+type webUI struct {
+	s    *GodocServer
+	base string
+	page *template.Template
+}
+
+// Views of the web UI, selecting what a page shows
+const (
+	uiViewPackages = "packages"
+	uiViewPackage  = "package"
+	uiViewSearch   = "search"
+)
+
+// uiPage is the data the page template renders
+type uiPage struct {
+	Base   string
+	View   string
+	Title  string
+	Query  string
+	Search bool
+	// Error is shown instead of the page's content
+	Error string
+
+	// Std and Other list the packages of the index page
+	Std   []uiPackage
+	Other []uiPackage
+
+	// Doc is the package documented, Target the symbol it is filtered to,
+	// and Version the version documented
+	Doc     *packageDoc
+	Target  string
+	Version string
+
+	Hits []searchHit
+}
+
+// uiPackage is an entry of the package list
+type uiPackage struct {
+	Path     string
+	Synopsis string
+}
+
+// ServeHTTP routes the pages of the site: the package list, package and
+// symbol pages under pkg/, and search
+func (ui *webUI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// The site is backed by get_doc and follows its configuration
+	if !ui.s.toolAllowed("get_doc") {
+		http.NotFound(w, r)
+		return
+	}
+	page := &uiPage{Base: ui.base, Title: "Go documentation", Search: ui.s.toolAllowed("search_docs")}
+	status := http.StatusOK
+	rest := strings.TrimPrefix(r.URL.Path, ui.base+"/ui/")
+	switch {
+	case rest == "":
+		page.View = uiViewPackages
+		ui.packageList(r.Context(), page)
+	case rest == "search":
+		page.View = uiViewSearch
+		status = ui.search(r, page)
+	case strings.HasPrefix(rest, "pkg/") && len(rest) > len("pkg/"):
+		page.View = uiViewPackage
+		status = ui.packagePage(r, page, strings.TrimSuffix(strings.TrimPrefix(rest, "pkg/"), "/"))
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := ui.page.Execute(w, page); err != nil {
+		ctxLogger(r.Context(), ui.s.logger).WithError(err).Debug("Failed to render web UI page")
+	}
+}
+
+// packageList fills page with the public standard library packages and the
+// other packages whose documentation is known: those of the -gopls-workspaces
+// and any fetched into the shared cache
+func (ui *webUI) packageList(ctx context.Context, page *uiPage) {
+	known := ui.s.knownPackages(ctx)
+	for key := range ui.s.cache.Items() {
+		// Keys are scope|workingDir|format|args, see runGoDoc; session
+		// scoped documents are private to their session
+		parts := strings.Split(key, "|")
+		if len(parts) < 4 || parts[0] != "" {
+			continue
+		}
+		args := slices.DeleteFunc(slices.Clone(parts[3:]), func(arg string) bool { return strings.HasPrefix(arg, "-") })
+		if len(args) > 0 && !slices.Contains(known, args[0]) {
+			known = append(known, args[0])
+		}
+	}
+	slices.Sort(known)
+	for _, pkgPath := range known {
+		if isStdLib(pkgPath) {
+			var synopsis string
+			if sp, ok := ui.s.stdlib.lookup(pkgPath); ok {
+				synopsis = sp.synopsis
+			}
+			page.Std = append(page.Std, uiPackage{Path: pkgPath, Synopsis: synopsis})
+		} else {
+			page.Other = append(page.Other, uiPackage{Path: pkgPath})
+		}
+	}
+}
+
+// packagePage fills page with the documentation of pkgPath, or of its symbol
+// given as the target query parameter, and returns the response status
+func (ui *webUI) packagePage(r *http.Request, page *uiPage, pkgPath string) int {
+	target := r.URL.Query().Get("target")
+	page.Title, page.Target = pkgPath, target
+	if target != "" {
+		page.Title = pkgPath + "." + target
+	}
+	args := map[string]any{"path": pkgPath, "format": formatJSON}
+	if target != "" {
+		args["target"] = target
+	}
+	var req docRequest
+	var doc cachedDoc
+	result := ui.call(r.Context(), "get_doc", args, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var errResult *mcp.CallToolResult
+		req, doc, errResult = ui.s.loadDoc(ctx, request)
+		if errResult != nil {
+			return errResult, nil
+		}
+		return &mcp.CallToolResult{}, nil
+	})
+	if result.IsError {
+		page.Error = resultText(result)
+		return restStatus(resultErrorCode(result))
+	}
+	var pd packageDoc
+	if err := json.Unmarshal([]byte(strings.Join(doc.lines, "\n")), &pd); err != nil {
+		// Documentation rendered by pkg.go.dev is only available as text
+		page.Error = "no structured documentation for " + pkgPath + ": " + err.Error()
+		return http.StatusBadGateway
+	}
+	page.Doc, page.Version = &pd, resolvedVersion(req.path, req.workingDir)
+	return http.StatusOK
+}
+
+// search fills page with the search_docs hits of the q query parameter and
+// returns the response status
+func (ui *webUI) search(r *http.Request, page *uiPage) int {
+	page.Query = strings.TrimSpace(r.URL.Query().Get("q"))
+	page.Title = "Search"
+	if !page.Search {
+		page.Error = "search is disabled on this server"
+		return http.StatusNotFound
+	}
+	if page.Query == "" {
+		return http.StatusOK
+	}
+	page.Title = "Search: " + page.Query
+	result := ui.call(r.Context(), "search_docs", map[string]any{"query": page.Query, "limit": maxSearchResults}, ui.s.handleSearchDocs)
+	if result.IsError {
+		page.Error = resultText(result)
+		return restStatus(resultErrorCode(result))
+	}
+	if out, ok := result.StructuredContent.(*searchOutput); ok {
+		page.Hits = out.Hits
+	}
+	return http.StatusOK
+}
+
+// call runs handler as the named tool with args through the middleware tool
+// calls go through, and returns its result
+func (ui *webUI) call(ctx context.Context, name string, args map[string]any, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) *mcp.CallToolResult {
+	s := ui.s
+	var request mcp.CallToolRequest
+	request.Params.Name = name
+	request.Params.Arguments = args
+	result, err := s.withTracing(s.withCallID(s.withSlowLog(s.trackInFlight(s.withWorker(handler)))))(withHTTPScope(ctx), request)
+	if err != nil {
+		return errorResult(codeInternal, err.Error())
+	}
+	return result
+}
+
+// resultText joins the text content of a tool result
+func resultText(result *mcp.CallToolResult) string {
+	var text []string
+	for _, c := range result.Content {
+		if tc, ok := c.(mcp.TextContent); ok {
+			text = append(text, tc.Text)
+		}
+	}
+	return strings.Join(text, "\n")
+}
+
+// symbolURL returns the page of symbol of pkgPath, or of the package itself
+// when symbol is empty
+func (ui *webUI) symbolURL(pkgPath, symbol string) string {
+	u := ui.base + "/ui/pkg/" + pkgPath
+	if symbol != "" {
+		u += "?target=" + url.QueryEscape(symbol)
+	}
+	return u
+}
+
+// docHTML renders a doc comment of pkgPath as HTML, linking its doc links to
+// the pages of their packages and symbols
+func (ui *webUI) docHTML(pkgPath, text string) template.HTML {
+	p := comment.Parser{
+		// Every [Name] is taken to be a symbol of the package; a page for
+		// one that doesn't exist explains so
+		LookupSym: func(recv, name string) bool { return true },
+	}
+	printer := comment.Printer{
+		HeadingLevel: 4,
+		DocLinkURL: func(link *comment.DocLink) string {
+			importPath := link.ImportPath
+			if importPath == "" {
+				importPath = pkgPath
+			}
+			symbol := link.Name
+			if link.Recv != "" {
+				symbol = link.Recv + "." + symbol
+			}
+			return ui.symbolURL(importPath, symbol)
+		},
+	}
+	return template.HTML(printer.HTML(p.Parse(text)))
+}
+
+// funcs returns the functions the page template calls
+func (ui *webUI) funcs() template.FuncMap {
+	return template.FuncMap{
+		"doc":       ui.docHTML,
+		"symbolURL": ui.symbolURL,
+		"valueArgs": func(pkg string, v valueDoc) map[string]any { return map[string]any{"Pkg": pkg, "Value": v} },
+		"funcArgs":  func(pkg string, f funcDoc) map[string]any { return map[string]any{"Pkg": pkg, "Func": f} },
+		// anchor returns the fragment of a function or method, as Type.Name
+		"anchor": func(recv, name string) string {
+			recv = strings.TrimPrefix(recv, "*")
+			if i := strings.IndexByte(recv, '['); i >= 0 {
+				recv = recv[:i]
+			}
+			if recv == "" {
+				return name
+			}
+			return recv + "." + name
+		},
+	}
+}
+
+// newWebUI returns the web UI served under base
+func (s *GodocServer) newWebUI(base string) http.Handler {
+	ui := &webUI{s: s, base: base}
+	ui.page = template.Must(template.New("page").Funcs(ui.funcs()).Parse(uiPageTemplate))
+	return ui
+}
+
+// uiPageTemplate lays out the pages of the web UI
+const uiPageTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - godoc-mcp</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 0 auto; padding: 0 1rem 3rem; line-height: 1.5; color: #202224; }
+header { display: flex; gap: 1rem; align-items: center; padding: 1rem 0; border-bottom: 1px solid #ddd; margin-bottom: 1rem; }
+header a { font-weight: bold; color: inherit; text-decoration: none; }
+header form { flex: 1; display: flex; gap: .5rem; }
+header input[type=search] { flex: 1; padding: .3rem .5rem; }
+a { color: #007d9c; }
+pre { background: #f6f8fa; padding: .75rem; overflow-x: auto; border-radius: 4px; }
+code, pre { font-family: ui-monospace, monospace; font-size: .9rem; }
+h2 { border-bottom: 1px solid #eee; margin-top: 2rem; }
+h3 a.permalink, h4 a.permalink { visibility: hidden; text-decoration: none; margin-left: .3rem; }
+h3:hover a.permalink, h4:hover a.permalink { visibility: visible; }
+table { border-collapse: collapse; width: 100%; }
+td { padding: .2rem .75rem .2rem 0; vertical-align: top; }
+.error { background: #fff0f0; border: 1px solid #e0b4b4; padding: .75rem; white-space: pre-wrap; }
+.muted { color: #666; }
+ul.index { list-style: none; padding-left: 0; }
+ul.index ul { list-style: none; padding-left: 1.5rem; }
+</style>
+</head>
+<body>
+<header>
+<a href="{{.Base}}/ui/">godoc-mcp</a>
+{{if .Search}}<form action="{{.Base}}/ui/search" method="get"><input type="search" name="q" value="{{.Query}}" placeholder="Search documentation" aria-label="Search documentation"><button type="submit">Search</button></form>{{end}}
+</header>
+<main>
+{{if .Error}}
+<h1>{{.Title}}</h1>
+<p class="error">{{.Error}}</p>
+{{else if eq .View "package"}}{{template "package" .}}
+{{else if eq .View "search"}}{{template "search" .}}
+{{else}}{{template "packages" .}}
+{{end}}
+</main>
+</body>
+</html>
+
+{{define "packages"}}
+<h1>Packages</h1>
+{{if not (or .Std .Other)}}<p class="muted">No packages are known yet; the standard library is still being indexed.</p>{{end}}
+{{if .Other}}
+<h2>Other packages</h2>
+<table>{{range .Other}}<tr><td><a href="{{symbolURL .Path ""}}">{{.Path}}</a></td></tr>{{end}}</table>
+{{end}}
+{{if .Std}}
+<h2>Standard library</h2>
+<table>{{range .Std}}<tr><td><a href="{{symbolURL .Path ""}}">{{.Path}}</a></td><td class="muted">{{.Synopsis}}</td></tr>{{end}}</table>
+{{end}}
+{{end}}
+
+{{define "search"}}
+{{if not .Query}}<h1>Search</h1><p class="muted">Search the names and documentation of packages and their symbols.</p>
+{{else if .Hits}}<h1>Results for “{{.Query}}”</h1>
+<table>{{range .Hits}}<tr><td><a href="{{symbolURL .Package .Symbol}}">{{.Package}}{{if .Symbol}}.{{.Symbol}}{{end}}</a></td><td class="muted">{{.Snippet}}</td></tr>{{end}}</table>
+{{else}}<h1>Results for “{{.Query}}”</h1><p class="muted">No documentation matches.</p>{{end}}
+{{end}}
+
+{{define "package"}}{{$pkg := .Doc.ImportPath}}
+{{if .Target}}
+<h1>{{.Doc.Name}}.{{.Target}}</h1>
+<p class="muted"><a href="{{symbolURL $pkg ""}}">package {{.Doc.Name}}</a> · import "{{$pkg}}"{{if .Version}} · {{.Version}}{{end}}</p>
+{{else}}
+<h1>package {{.Doc.Name}}</h1>
+<p class="muted">import "{{$pkg}}"{{if .Version}} · {{.Version}}{{end}}</p>
+{{doc $pkg .Doc.Doc}}
+<h2 id="pkg-index">Index</h2>
+<ul class="index">
+{{if .Doc.Consts}}<li><a href="#pkg-constants">Constants</a></li>{{end}}
+{{if .Doc.Vars}}<li><a href="#pkg-variables">Variables</a></li>{{end}}
+{{range .Doc.Funcs}}<li><a href="#{{.Name}}">{{.Decl}}</a></li>{{end}}
+{{range .Doc.Types}}{{$type := .Name}}<li><a href="#{{.Name}}">type {{.Name}}</a>
+{{if or .Funcs .Methods}}<ul>{{range .Funcs}}<li><a href="#{{.Name}}">{{.Decl}}</a></li>{{end}}{{range .Methods}}<li><a href="#{{$type}}.{{.Name}}">{{.Decl}}</a></li>{{end}}</ul>{{end}}
+</li>{{end}}
+</ul>
+{{end}}
+{{if .Doc.Consts}}<h2 id="pkg-constants">Constants</h2>{{range .Doc.Consts}}{{template "value" (valueArgs $pkg .)}}{{end}}{{end}}
+{{if .Doc.Vars}}<h2 id="pkg-variables">Variables</h2>{{range .Doc.Vars}}{{template "value" (valueArgs $pkg .)}}{{end}}{{end}}
+{{if .Doc.Funcs}}<h2 id="pkg-functions">Functions</h2>{{range .Doc.Funcs}}{{template "func" (funcArgs $pkg .)}}{{end}}{{end}}
+{{if .Doc.Types}}<h2 id="pkg-types">Types</h2>
+{{range .Doc.Types}}
+<h3 id="{{.Name}}">type {{.Name}}<a class="permalink" href="{{symbolURL $pkg .Name}}">¶</a></h3>
+<pre>{{.Decl}}</pre>
+{{doc $pkg .Doc}}
+{{range .Consts}}{{template "value" (valueArgs $pkg .)}}{{end}}
+{{range .Vars}}{{template "value" (valueArgs $pkg .)}}{{end}}
+{{range .Funcs}}{{template "func" (funcArgs $pkg .)}}{{end}}
+{{range .Methods}}{{template "func" (funcArgs $pkg .)}}{{end}}
+{{end}}
+{{end}}
+{{end}}
+
+{{define "value"}}<pre>{{.Value.Decl}}</pre>
+{{doc .Pkg .Value.Doc}}{{end}}
+
+{{define "func"}}{{$id := anchor .Func.Recv .Func.Name}}
+<h4 id="{{$id}}">{{if .Func.Recv}}func ({{.Func.Recv}}) {{.Func.Name}}{{else}}func {{.Func.Name}}{{end}}<a class="permalink" href="{{symbolURL .Pkg $id}}">¶</a></h4>
+<pre>{{.Func.Decl}}</pre>
+{{doc .Pkg .Func.Doc}}{{end}}
+`
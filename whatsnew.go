@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	goversion "go/version"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+	"golang.org/x/tools/go/packages"
+)
+
+const whatsNewDescription = `List the exported API a package gained between two versions: for the standard library, the symbols
+added in each Go release after since, from the Go distribution's API files; for other modules, the symbols
+added, removed, or changed between the two module versions, by comparing their declarations. Use it to
+answer whether a symbol such as sync.OnceFunc can be used with the versions a project is pinned to.
+Without since, it compares against the working module's go directive for the standard library, or the
+version of the module it requires, so the report lists what the project can't use yet.`
+
+// whatsNewSchema is the whats_new input schema
+var whatsNewSchema = mcp.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"path": map[string]any{
+			"type":        "string",
+			"description": "Import path of the package (e.g., 'sync', 'net/http', or 'github.com/google/uuid').",
+		},
+		"since": map[string]any{
+			"type":        "string",
+			"description": "Optional: Version to compare from: a Go release such as 'go1.21' for the standard library, or a module version such as 'v1.3.0'. Defaults to the working module's go directive, or to the version of the module it requires.",
+		},
+		"until": map[string]any{
+			"type":        "string",
+			"description": "Optional: Version to compare to. Defaults to the Go toolchain's release for the standard library, and to the latest version of other modules.",
+		},
+		"target": map[string]any{
+			"type":        "string",
+			"description": "Optional: Only report this symbol, with its methods and fields, such as 'OnceFunc' or 'Builder'.",
+		},
+		"working_dir": map[string]any{
+			"type":        "string",
+			"description": "Optional: Go module directory whose go directive or requirement is the default since. Defaults to the session working directory.",
+		},
+	},
+	Required: []string{"path"},
+}
+
+// apiChangeSchema describes apiChange in output schemas
+var apiChangeSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"kind":     map[string]any{"type": "string", "enum": apiSymbolKinds},
+		"name":     map[string]any{"type": "string", "description": "Symbol name, with methods and fields as Type.Name"},
+		"decl":     map[string]any{"type": "string", "description": "Its declaration"},
+		"version":  map[string]any{"type": "string", "description": "The Go release that added it, for the standard library"},
+		"previous": map[string]any{"type": "string", "description": "Its declaration at since, for changed symbols"},
+	},
+	"required": []string{"kind", "name", "decl"},
+}
+
+// whatsNewOutputSchema is the outputSchema of whats_new, describing whatsNewOutput
+var whatsNewOutputSchema = mcp.ToolOutputSchema{
+	Type: "object",
+	Properties: map[string]any{
+		"package": map[string]any{"type": "string"},
+		"since":   map[string]any{"type": "string"},
+		"until":   map[string]any{"type": "string"},
+		"added":   map[string]any{"type": "array", "items": apiChangeSchema},
+		"removed": map[string]any{"type": "array", "items": apiChangeSchema, "description": "Symbols of since missing from until, for modules"},
+		"changed": map[string]any{"type": "array", "items": apiChangeSchema, "description": "Symbols whose declaration changed, for modules"},
+	},
+	Required: []string{"package", "since", "until", "added"},
+}
+
+// whatsNewOutput is the structured content of a whats_new result
+type whatsNewOutput struct {
+	Package string      `json:"package"`
+	Since   string      `json:"since"`
+	Until   string      `json:"until"`
+	Added   []apiChange `json:"added"`
+	Removed []apiChange `json:"removed,omitempty"`
+	Changed []apiChange `json:"changed,omitempty"`
+}
+
+// apiChange is an exported symbol added, removed, or changed between versions
+type apiChange struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Decl     string `json:"decl"`
+	Version  string `json:"version,omitempty"`
+	Previous string `json:"previous,omitempty"`
+}
+
+// handleWhatsNew implements the whats_new tool
+func (s *GodocServer) handleWhatsNew(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path := request.GetString("path", "")
+	if path == "" || strings.HasPrefix(path, ".") || filepath.IsAbs(path) || strings.Contains(path, "@") {
+		return errorResult(codeInvalidArgument, "path must be an import path, without a version"), nil
+	}
+	since, until := request.GetString("since", ""), request.GetString("until", "")
+	target := request.GetString("target", "")
+	workingDir := request.GetString("working_dir", s.sessions.get(ctx).workingDir)
+	if workingDir == "" {
+		workingDir = s.rootWorkingDir(ctx, path)
+	}
+	if err := s.runtime.Load().toolchainErr; err != nil {
+		return errorResultFromErr("cannot load packages", err), nil
+	}
+
+	var out *whatsNewOutput
+	var err error
+	if isStdLib(path) {
+		out, err = stdAPIChanges(path, since, until, workingDir)
+	} else {
+		progress := s.newProgressReporter(ctx, request)
+		ctx = withProgress(ctx, progress)
+		out, err = s.moduleAPIChanges(ctx, path, since, until, workingDir)
+	}
+	if err != nil {
+		return errorResultFromErr("failed to compare the API of "+path, err), nil
+	}
+	if target != "" {
+		other := func(c apiChange) bool { return c.Name != target && !strings.HasPrefix(c.Name, target+".") }
+		out.Added = slices.DeleteFunc(out.Added, other)
+		out.Removed = slices.DeleteFunc(out.Removed, other)
+		out.Changed = slices.DeleteFunc(out.Changed, other)
+	}
+
+	var sb strings.Builder
+	what := "the exported API of " + path
+	if target != "" {
+		what = path + "." + target
+	}
+	if len(out.Added)+len(out.Removed)+len(out.Changed) == 0 {
+		fmt.Fprintf(&sb, "No changes to %s between %s and %s", what, out.Since, out.Until)
+	} else {
+		fmt.Fprintf(&sb, "Changes to %s between %s and %s", what, out.Since, out.Until)
+	}
+	for _, section := range []struct {
+		name    string
+		changes []apiChange
+	}{{"Added", out.Added}, {"Removed", out.Removed}, {"Changed", out.Changed}} {
+		if len(section.changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n\n%s:", section.name)
+		for _, c := range section.changes {
+			// Fields and interface methods are declared without their type
+			decl := c.Decl
+			if typ, _, ok := strings.Cut(c.Name, "."); ok && !strings.Contains(decl, typ) {
+				decl = typ + "." + decl
+			}
+			sb.WriteString("\n  " + strings.ReplaceAll(decl, "\n", "\n  "))
+			if c.Version != "" {
+				sb.WriteString(" (" + c.Version + ")")
+			}
+			if c.Previous != "" {
+				sb.WriteString("\n    was " + strings.ReplaceAll(c.Previous, "\n", "\n    "))
+			}
+		}
+	}
+	result := mcp.NewToolResultText(sb.String())
+	result.StructuredContent = out
+	return result, nil
+}
+
+// goRelease returns the Go release of a version such as "1.21", "go1.21", or
+// "go1.21.3", as its minor number
+func goRelease(v string) (int, bool) {
+	if !strings.HasPrefix(v, "go") {
+		v = "go" + v
+	}
+	if !goversion.IsValid(v) {
+		return 0, false
+	}
+	minor, err := strconv.Atoi(strings.TrimPrefix(goversion.Lang(v), "go1."))
+	return minor, err == nil
+}
+
+// stdAPIChanges lists the API added to the standard library package path
+// after the Go release since up to until, from the API files of GOROOT
+func stdAPIChanges(path, since, until, workingDir string) (*whatsNewOutput, error) {
+	if since == "" && workingDir != "" {
+		data, err := os.ReadFile(filepath.Join(workingDir, "go.mod"))
+		if f, _ := modfile.ParseLax("go.mod", data, nil); err == nil && f != nil && f.Go != nil {
+			since = f.Go.Version
+		}
+	}
+	if since == "" {
+		return nil, withCode(codeInvalidArgument, fmt.Errorf("since is required without a working module with a go directive"))
+	}
+	from, ok := goRelease(since)
+	if !ok {
+		return nil, withCode(codeInvalidArgument, fmt.Errorf("since %q is not a Go release such as go1.21", since))
+	}
+	to, ok := goRelease(cmp.Or(until, goToolchainVersion()))
+	if !ok {
+		return nil, withCode(codeInvalidArgument, fmt.Errorf("until %q is not a Go release such as go1.23", until))
+	}
+	out := &whatsNewOutput{Package: path, Since: fmt.Sprintf("go1.%d", from), Until: fmt.Sprintf("go1.%d", to), Added: []apiChange{}}
+
+	apiDir := filepath.Join(goRoot(), "api")
+	if _, err := os.Stat(apiDir); err != nil {
+		return nil, withCode(codeUnsupported, fmt.Errorf("the Go distribution has no API files: %v", err))
+	}
+	seen := make(map[string]bool)
+	for minor := from + 1; minor <= to; minor++ {
+		release := fmt.Sprintf("go1.%d", minor)
+		f, err := os.Open(filepath.Join(apiDir, release+".txt"))
+		if err != nil {
+			continue
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			c, ok := parseAPILine(sc.Text(), path)
+			if !ok || seen[c.Kind+" "+c.Decl] {
+				continue
+			}
+			seen[c.Kind+" "+c.Decl] = true
+			c.Version = release
+			out.Added = append(out.Added, c)
+		}
+		f.Close()
+	}
+	return out, nil
+}
+
+// parseAPILine parses a line of a Go API file, such as
+// "pkg sync, func OnceFunc(func()) func() #56102", declaring a symbol of the
+// package path
+func parseAPILine(line, path string) (apiChange, bool) {
+	rest, ok := strings.CutPrefix(line, "pkg "+path)
+	if !ok {
+		return apiChange{}, false
+	}
+	// Platform-specific API names its platforms after the package
+	if strings.HasPrefix(rest, " (") {
+		_, rest, _ = strings.Cut(rest, ")")
+	}
+	decl, ok := strings.CutPrefix(rest, ", ")
+	// Lines such as "pkg strings, func Title //deprecated" deprecate API
+	// added by an earlier release
+	if !ok || strings.HasSuffix(decl, "//deprecated") {
+		return apiChange{}, false
+	}
+	if i := strings.LastIndex(decl, " #"); i >= 0 {
+		decl = decl[:i]
+	}
+	name := func(s string) string {
+		if i := strings.IndexAny(s, " ([,"); i >= 0 {
+			return s[:i]
+		}
+		return s
+	}
+	kind, spec, _ := strings.Cut(decl, " ")
+	c := apiChange{Kind: kind, Decl: decl}
+	switch kind {
+	case "func", "const", "var":
+		c.Name = name(spec)
+	case "method":
+		recv, method, _ := strings.Cut(spec, ") ")
+		recv = strings.TrimLeft(recv, "(*")
+		c.Name = name(recv) + "." + name(method)
+	case "type":
+		c.Name = name(spec)
+		// Fields and interface methods follow their type's kind
+		if _, member, ok := strings.Cut(spec, ", "); ok {
+			c.Kind = "field"
+			if strings.Contains(spec, " interface, ") {
+				c.Kind = "method"
+			}
+			c.Name += "." + name(strings.TrimPrefix(member, "embedded "))
+			c.Decl = member
+		}
+	default:
+		return apiChange{}, false
+	}
+	return c, true
+}
+
+// moduleAPIChanges compares the exported API of the module package path at
+// since with its API at until
+func (s *GodocServer) moduleAPIChanges(ctx context.Context, path, since, until, workingDir string) (*whatsNewOutput, error) {
+	if since == "" && workingDir != "" {
+		since = resolvedVersion(path, workingDir)
+	}
+	if since == "" {
+		return nil, withCode(codeInvalidArgument, fmt.Errorf("since is required when the working module doesn't require %s", path))
+	}
+	for _, v := range []string{since, until} {
+		if v != "" && !semver.IsValid(v) {
+			return nil, withCode(codeInvalidArgument, fmt.Errorf("%q is not a module version such as v1.2.3", v))
+		}
+	}
+	load := func(v string) (*apiPackage, string, error) {
+		dir, err := s.projectManager.GetOrCreateProject(ctx, path+"@"+v)
+		if err != nil {
+			return nil, "", err
+		}
+		progressFromContext(ctx).step("Loading " + path + "@" + v)
+		release, err := s.limiter.acquire(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		pkgs, err := packages.Load(&packages.Config{
+			Context: ctx,
+			Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+			Dir:     dir,
+		}, path)
+		release()
+		if err == nil && (len(pkgs) != 1 || len(pkgs[0].Syntax) == 0) {
+			err = withCode(codePkgNotFound, fmt.Errorf("no Go files in %s@%s", path, v))
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		api, err := exportedAPI(pkgs[0], false)
+		return api, resolvedVersion(path, dir), err
+	}
+	old, since, err := load(since)
+	if err != nil {
+		return nil, err
+	}
+	cur, until, err := load(cmp.Or(until, "latest"))
+	if err != nil {
+		return nil, err
+	}
+
+	key := func(sym apiSymbol) string { return sym.Kind + " " + apiName(sym) }
+	before := make(map[string]apiSymbol, len(old.Symbols))
+	for _, sym := range old.Symbols {
+		before[key(sym)] = sym
+	}
+	out := &whatsNewOutput{Package: path, Since: since, Until: until, Added: []apiChange{}}
+	for _, sym := range cur.Symbols {
+		prev, ok := before[key(sym)]
+		delete(before, key(sym))
+		switch {
+		case !ok:
+			out.Added = append(out.Added, apiChange{Kind: sym.Kind, Name: apiName(sym), Decl: sym.Decl})
+		case prev.Decl != sym.Decl:
+			out.Changed = append(out.Changed, apiChange{Kind: sym.Kind, Name: apiName(sym), Decl: sym.Decl, Previous: prev.Decl})
+		}
+	}
+	for _, sym := range old.Symbols {
+		if _, ok := before[key(sym)]; ok {
+			out.Removed = append(out.Removed, apiChange{Kind: sym.Kind, Name: apiName(sym), Decl: sym.Decl})
+		}
+	}
+	return out, nil
+}
+
+// apiName returns the name of sym, with methods and fields as Type.Name
+func apiName(sym apiSymbol) string {
+	if sym.Recv != "" {
+		return sym.Recv + "." + sym.Name
+	}
+	return sym.Name
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWhatsNew(t *testing.T) {
+	// A module proxy serving two versions of example.com/lib
+	proxy := t.TempDir()
+	publish := func(version, src string) {
+		dir := filepath.Join(proxy, "example.com", "lib", "@v")
+		os.MkdirAll(dir, 0o755)
+		mod := "module example.com/lib\n\ngo 1.21\n"
+		os.WriteFile(filepath.Join(dir, version+".mod"), []byte(mod), 0o644)
+		os.WriteFile(filepath.Join(dir, version+".info"), []byte(`{"Version":"`+version+`","Time":"2024-01-01T00:00:00Z"}`), 0o644)
+		var zipped bytes.Buffer
+		zw := zip.NewWriter(&zipped)
+		for name, content := range map[string]string{"go.mod": mod, "lib.go": src} {
+			f, _ := zw.Create("example.com/lib@" + version + "/" + name)
+			f.Write([]byte(content))
+		}
+		zw.Close()
+		os.WriteFile(filepath.Join(dir, version+".zip"), zipped.Bytes(), 0o644)
+		list, _ := os.ReadFile(filepath.Join(dir, "list"))
+		os.WriteFile(filepath.Join(dir, "list"), append(list, version+"\n"...), 0o644)
+	}
+	publish("v1.0.0", "package lib\n\nfunc Old() {}\n\nfunc Parse(s string) int { return 0 }\n\ntype Config struct{ Name string }\n")
+	publish("v1.1.0", "package lib\n\nfunc Parse(s string, strict bool) int { return 0 }\n\ntype Config struct {\n\tName    string\n\tTimeout int\n}\n\nfunc New() *Config { return nil }\n")
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-mod=mod -modcacherw")
+	t.Setenv("GOMODCACHE", t.TempDir())
+	s := newTestServer(t)
+	dir := writeModule(t, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.20\n\nrequire example.com/lib v1.0.0\n",
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		wantCode     string
+		since, until string
+		// added, removed, and changed are the changes listed, as kind name
+		added, removed, changed []string
+	}{
+		{"local path", map[string]any{"path": "./lib"}, codeInvalidArgument, "", "", nil, nil, nil},
+		{"versioned path", map[string]any{"path": "example.com/lib@v1.0.0"}, codeInvalidArgument, "", "", nil, nil, nil},
+		{"no standard library release", map[string]any{"path": "strings"}, codeInvalidArgument, "", "", nil, nil, nil},
+		{"bad release", map[string]any{"path": "strings", "since": "one"}, codeInvalidArgument, "", "", nil, nil, nil},
+		{"bad module version", map[string]any{"path": "example.com/lib", "since": "1.0"}, codeInvalidArgument, "", "", nil, nil, nil},
+		{"no module version", map[string]any{"path": "example.com/lib"}, codeInvalidArgument, "", "", nil, nil, nil},
+		{"standard library", map[string]any{"path": "strings", "since": "go1.17", "until": "1.20"}, "", "go1.17", "go1.20",
+			[]string{"func Clone go1.18", "func Cut go1.18", "func CutPrefix go1.20", "func CutSuffix go1.20"}, nil, nil},
+		{"go directive", map[string]any{"path": "sync", "until": "go1.21", "target": "OnceFunc", "working_dir": dir}, "", "go1.20", "go1.21",
+			[]string{"func OnceFunc go1.21"}, nil, nil},
+		{"module", map[string]any{"path": "example.com/lib", "since": "v1.0.0"}, "", "v1.0.0", "v1.1.0",
+			[]string{"field Config.Timeout", "func New"}, []string{"func Old"}, []string{"func Parse", "type Config"}},
+		{"required version", map[string]any{"path": "example.com/lib", "target": "Config", "working_dir": dir}, "", "v1.0.0", "v1.1.0",
+			[]string{"field Config.Timeout"}, nil, []string{"type Config"}},
+		{"same version", map[string]any{"path": "example.com/lib", "since": "v1.1.0", "until": "v1.1.0"}, "", "v1.1.0", "v1.1.0", nil, nil, nil},
+	}
+	// changeNames returns changes as kind name, followed by the release
+	// adding them for the standard library
+	changeNames := func(changes []apiChange) []string {
+		var names []string
+		for _, c := range changes {
+			name := c.Kind + " " + c.Name
+			if c.Version != "" {
+				name += " " + c.Version
+			}
+			names = append(names, name)
+		}
+		return names
+	}
+	for _, tt := range tests {
+		result := callTool(t, ctx, s.handleWhatsNew, "whats_new", tt.args)
+		if got := resultErrorCode(result); got != tt.wantCode {
+			t.Errorf("%s: error code %q, want %q: %s", tt.name, got, tt.wantCode, resultText(result))
+			continue
+		}
+		if tt.wantCode != "" {
+			continue
+		}
+		out := result.StructuredContent.(*whatsNewOutput)
+		if out.Since != tt.since || out.Until != tt.until {
+			t.Errorf("%s: compared %s to %s, want %s to %s", tt.name, out.Since, out.Until, tt.since, tt.until)
+		}
+		for _, list := range []struct {
+			name    string
+			changes []apiChange
+			want    []string
+		}{{"added", out.Added, tt.added}, {"removed", out.Removed, tt.removed}, {"changed", out.Changed, tt.changed}} {
+			if got := changeNames(list.changes); !slices.Equal(got, list.want) {
+				t.Errorf("%s: %s %q, want %q", tt.name, list.name, got, list.want)
+			}
+		}
+	}
+}